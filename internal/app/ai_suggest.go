@@ -0,0 +1,45 @@
+package app
+
+import (
+	"ai-course/internal/logger"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultAISuggestInterval 未配置时后台生成 AI 批改建议的扫描间隔
+const defaultAISuggestInterval = 30 * time.Second
+
+// startAISuggestionWorker 启动为已提交的主观题答案生成 AI 批改建议的后台任务
+// 模型调用不在学生提交、限时交卷和作业关闭的流程中进行，模型响应慢或不可用时只会推迟建议的生成
+// 多个实例同时运行时与作业调度一样通过数据库中的领导锁保证只有一个实例调用模型
+func (app *Application) startAISuggestionWorker(ctx context.Context) {
+	interval := defaultAISuggestInterval
+	if app.Config.Grading.AISuggestSeconds > 0 {
+		interval = time.Duration(app.Config.Grading.AISuggestSeconds) * time.Second
+	}
+	lease := 3 * interval
+	owner := schedulerOwnerID()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				count, err := app.GradingService.SuggestPendingAnswers(ctx, owner, lease, now)
+				if err != nil {
+					logger.Logger.Error("Failed to generate pending AI suggestions", zap.Error(err), zap.String("owner", owner))
+					continue
+				}
+				if count > 0 {
+					logger.Logger.Info("AI suggestions generated", zap.Int("count", count))
+				}
+			}
+		}
+	}()
+}
@@ -5,6 +5,7 @@ import (
 	"ai-course/internal/controller"
 	"ai-course/internal/repository"
 	"ai-course/internal/service"
+	"context"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
@@ -12,16 +13,28 @@ import (
 
 // Application 应用程序结构
 type Application struct {
-	Engine            *gin.Engine
-	Config            *config.Config
-	DB                repository.DB
-	UserService       service.UserService
-	ClassService      service.ClassService
-	AssignmentService service.AssignmentService
-	QuestionService   service.QuestionService
-	SubmissionService service.SubmissionService
-	GradingService    service.GradingService
-	AttachmentService service.AttachmentService
+	Engine              *gin.Engine
+	Config              *config.Config
+	DB                  repository.DB
+	UserService         service.UserService
+	ClassService        service.ClassService
+	AssignmentService   service.AssignmentService
+	QuestionService     service.QuestionService
+	SubmissionService   service.SubmissionService
+	GradingService      service.GradingService
+	AttachmentService   service.AttachmentService
+	EnrollmentService   service.EnrollmentService
+	RoleService         service.RoleService
+	AccessService       service.AccessService
+	LessonPlanService   service.LessonPlanService
+	QuestionBankService service.QuestionBankService
+	RegradeService      service.RegradeService
+	ExtensionService    service.ExtensionService
+	TemplateService     service.TemplateService
+	GradebookService    service.GradebookService
+	ExportService       service.ExportService
+	RosterService       service.RosterService
+	SchedulerService    service.SchedulerService
 }
 
 // NewApplication 创建应用程序实例
@@ -36,18 +49,42 @@ func NewApplication(
 	submissionService service.SubmissionService,
 	gradingService service.GradingService,
 	attachmentService service.AttachmentService,
+	enrollmentService service.EnrollmentService,
+	roleService service.RoleService,
+	accessService service.AccessService,
+	lessonPlanService service.LessonPlanService,
+	questionBankService service.QuestionBankService,
+	regradeService service.RegradeService,
+	extensionService service.ExtensionService,
+	templateService service.TemplateService,
+	gradebookService service.GradebookService,
+	exportService service.ExportService,
+	rosterService service.RosterService,
+	schedulerService service.SchedulerService,
 ) *Application {
 	return &Application{
-		Engine:            engine,
-		Config:            cfg,
-		DB:                db,
-		UserService:       userService,
-		ClassService:      classService,
-		AssignmentService: assignmentService,
-		QuestionService:   questionService,
-		SubmissionService: submissionService,
-		GradingService:    gradingService,
-		AttachmentService: attachmentService,
+		Engine:              engine,
+		Config:              cfg,
+		DB:                  db,
+		UserService:         userService,
+		ClassService:        classService,
+		AssignmentService:   assignmentService,
+		QuestionService:     questionService,
+		SubmissionService:   submissionService,
+		GradingService:      gradingService,
+		AttachmentService:   attachmentService,
+		EnrollmentService:   enrollmentService,
+		RoleService:         roleService,
+		AccessService:       accessService,
+		LessonPlanService:   lessonPlanService,
+		QuestionBankService: questionBankService,
+		RegradeService:      regradeService,
+		ExtensionService:    extensionService,
+		TemplateService:     templateService,
+		GradebookService:    gradebookService,
+		ExportService:       exportService,
+		RosterService:       rosterService,
+		SchedulerService:    schedulerService,
 	}
 }
 
@@ -63,7 +100,7 @@ func NewGinEngine(cfg *config.Config) *gin.Engine {
 
 // RegisterRoutes 注册路由
 func (app *Application) RegisterRoutes() {
-	router := controller.NewRouter(app.Engine, app.UserService, app.ClassService, app.AssignmentService, app.QuestionService, app.SubmissionService, app.GradingService, app.AttachmentService)
+	router := controller.NewRouter(app.Engine, app.Config, app.UserService, app.ClassService, app.AssignmentService, app.QuestionService, app.SubmissionService, app.GradingService, app.AttachmentService, app.EnrollmentService, app.RoleService, app.AccessService, app.LessonPlanService, app.QuestionBankService, app.RegradeService, app.ExtensionService, app.TemplateService, app.GradebookService, app.ExportService, app.RosterService)
 	router.RegisterRoutes()
 }
 
@@ -77,6 +114,11 @@ func (app *Application) Run() error {
 	// 注册路由
 	app.RegisterRoutes()
 
+	// 启动后台任务
+	app.startTimedSubmissionSweeper(context.Background())
+	app.startAssignmentScheduler(context.Background())
+	app.startAISuggestionWorker(context.Background())
+
 	// 启动服务器
 	addr := fmt.Sprintf(":%d", app.Config.Server.Port)
 	return app.Engine.Run(addr)
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"ai-course/internal/logger"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultScheduleInterval 未配置时作业定时发布和关闭的调度间隔
+const defaultScheduleInterval = 30 * time.Second
+
+// startAssignmentScheduler 启动作业定时发布和关闭的后台任务
+// 多个实例同时运行时通过数据库中的领导锁保证只有一个实例执行调度
+func (app *Application) startAssignmentScheduler(ctx context.Context) {
+	cfg := app.Config.Scheduler
+	if cfg.Disabled {
+		logger.Logger.Info("Assignment scheduler disabled")
+		return
+	}
+
+	interval := defaultScheduleInterval
+	if cfg.IntervalSeconds > 0 {
+		interval = time.Duration(cfg.IntervalSeconds) * time.Second
+	}
+	lease := 3 * interval
+	if cfg.LeaseSeconds > 0 {
+		lease = time.Duration(cfg.LeaseSeconds) * time.Second
+	}
+	owner := schedulerOwnerID()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				result, err := app.SchedulerService.RunDue(ctx, owner, lease, now)
+				if err != nil {
+					logger.Logger.Error("Failed to run assignment scheduler", zap.Error(err), zap.String("owner", owner))
+					continue
+				}
+				if result.Published > 0 || result.Closed > 0 {
+					logger.Logger.Info("Assignment scheduler finished",
+						zap.Int("published", result.Published),
+						zap.Int("closed", result.Closed),
+						zap.Int("auto_submitted", result.AutoSubmitted),
+					)
+				}
+			}
+		}
+	}()
+}
+
+// schedulerOwnerID 生成本实例的唯一标识，用作领导锁的持有者
+func schedulerOwnerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), rand.Int63())
+}
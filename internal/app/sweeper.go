@@ -0,0 +1,42 @@
+package app
+
+import (
+	"ai-course/internal/logger"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultTimedSweepInterval 未配置时限时作答自动交卷的扫描间隔
+const defaultTimedSweepInterval = 30 * time.Second
+
+// startTimedSubmissionSweeper 启动限时作答自动交卷的后台任务
+// 多个实例同时运行时由条件更新保证每份草稿只交卷一次
+func (app *Application) startTimedSubmissionSweeper(ctx context.Context) {
+	interval := defaultTimedSweepInterval
+	if app.Config.Grading.TimedSweepSeconds > 0 {
+		interval = time.Duration(app.Config.Grading.TimedSweepSeconds) * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				count, err := app.SubmissionService.SubmitExpiredAttempts(ctx, now)
+				if err != nil {
+					logger.Logger.Error("Failed to submit expired attempts", zap.Error(err))
+					continue
+				}
+				if count > 0 {
+					logger.Logger.Info("Expired attempts auto submitted", zap.Int("count", count))
+				}
+			}
+		}
+	}()
+}
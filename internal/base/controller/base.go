@@ -31,6 +31,11 @@ func (b *BaseController) Fail(code int, message string) {
 	b.handler.Fail(code, message)
 }
 
+// FailWithData 带数据的失败响应
+func (b *BaseController) FailWithData(code int, message string, data interface{}) {
+	b.handler.FailWithData(code, message, data)
+}
+
 // ParamError 参数错误响应
 func (b *BaseController) ParamError(message string) {
 	b.handler.ParamError(message)
@@ -41,6 +46,11 @@ func (b *BaseController) Unauthorized(message string) {
 	b.handler.Unauthorized(message)
 }
 
+// Conflict 资源状态冲突响应
+func (b *BaseController) Conflict(message string) {
+	b.handler.Conflict(message)
+}
+
 // ServerError 服务器错误响应
 func (b *BaseController) ServerError(message string) {
 	b.handler.ServerError(message)
@@ -20,6 +20,7 @@ const (
 	CodeUnauthorized = 401 // 未授权
 	CodeForbidden    = 403 // 禁止访问
 	CodeNotFound     = 404 // 资源不存在
+	CodeConflict     = 409 // 资源状态冲突
 	CodeServerError  = 500 // 服务器内部错误
 )
 
@@ -30,6 +31,7 @@ const (
 	MsgUnauthorized = "未授权访问"
 	MsgForbidden    = "禁止访问"
 	MsgNotFound     = "资源不存在"
+	MsgConflict     = "资源已被修改"
 	MsgServerError  = "服务器内部错误"
 )
 
@@ -135,6 +137,19 @@ func (r *Handler) NotFound(message string) {
 	r.C.JSON(http.StatusNotFound, resp)
 }
 
+// Conflict 资源状态冲突响应
+func (r *Handler) Conflict(message string) {
+	if message == "" {
+		message = MsgConflict
+	}
+	resp := Response{
+		Code:    CodeConflict,
+		Message: message,
+		Data:    nil,
+	}
+	r.C.JSON(http.StatusConflict, resp)
+}
+
 // ServerError 服务器错误响应
 func (r *Handler) ServerError(message string) {
 	if message == "" {
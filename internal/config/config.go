@@ -10,9 +10,14 @@ import (
 
 // Config 应用配置
 type Config struct {
-	Server ServerConfig `mapstructure:"server"`
-	Logger LoggerConfig `mapstructure:"logger"`
-	MySQL  MySQLConfig  `mapstructure:"mysql"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Logger    LoggerConfig    `mapstructure:"logger"`
+	MySQL     MySQLConfig     `mapstructure:"mysql"`
+	RBAC      RBACConfig      `mapstructure:"rbac"`
+	AI        AIConfig        `mapstructure:"ai"`
+	Sandbox   SandboxConfig   `mapstructure:"sandbox"`
+	Grading   GradingConfig   `mapstructure:"grading"`
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
 }
 
 // ServerConfig 服务器配置
@@ -46,6 +51,46 @@ type MySQLConfig struct {
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"`
 }
 
+// RBACConfig 权限配置
+type RBACConfig struct {
+	// Permissions 角色到允许操作的映射，例如 teacher: ["assignment:publish", "grading:*"]
+	// 按角色覆盖内置的默认权限矩阵，未列出的角色仍使用默认权限
+	Permissions map[string][]string `mapstructure:"permissions"`
+}
+
+// AIConfig 大模型配置
+type AIConfig struct {
+	Provider    string  `mapstructure:"provider"`    // 提供方：openai（OpenAI 兼容接口）或 local（本地规则，默认）
+	BaseURL     string  `mapstructure:"base_url"`    // 接口地址，例如 https://api.openai.com/v1
+	APIKey      string  `mapstructure:"api_key"`     // 接口密钥
+	Model       string  `mapstructure:"model"`       // 模型名称
+	Timeout     int     `mapstructure:"timeout"`     // 请求超时时间（秒）
+	Temperature float64 `mapstructure:"temperature"` // 采样温度
+}
+
+// SandboxConfig 编程题沙箱配置
+type SandboxConfig struct {
+	Disabled       bool   `mapstructure:"disabled"`        // 关闭后编程题不自动运行，需教师人工批改
+	WorkDir        string `mapstructure:"work_dir"`        // 临时工作目录，默认系统临时目录
+	CompileTimeout int    `mapstructure:"compile_timeout"` // 编译超时时间（秒）
+	MaxOutputKB    int    `mapstructure:"max_output_kb"`   // 每次运行捕获的输出上限（KB）
+	MaxConcurrent  int    `mapstructure:"max_concurrent"`  // 同时运行的程序数量上限
+}
+
+// GradingConfig 批改配置
+type GradingConfig struct {
+	RegradeWindowDays int `mapstructure:"regrade_window_days"` // 成绩发布后允许申请复核的天数，默认 7 天
+	TimedSweepSeconds int `mapstructure:"timed_sweep_seconds"` // 限时作答超时自动交卷的扫描间隔（秒），默认 30 秒
+	AISuggestSeconds  int `mapstructure:"ai_suggest_seconds"`  // 后台生成主观题 AI 批改建议的扫描间隔（秒），默认 30 秒
+}
+
+// SchedulerConfig 作业定时发布和关闭的调度配置
+type SchedulerConfig struct {
+	Disabled        bool `mapstructure:"disabled"`         // 关闭后本实例不执行调度，多实例部署时可只在部分实例上开启
+	IntervalSeconds int  `mapstructure:"interval_seconds"` // 调度间隔（秒），默认 30 秒
+	LeaseSeconds    int  `mapstructure:"lease_seconds"`    // 领导锁的有效期（秒），持有锁的实例停止后其他实例在过期后接管，默认为调度间隔的 3 倍
+}
+
 var GlobalConfig *Config
 
 // LoadConfig 加载配置
@@ -5,6 +5,7 @@ import (
 	"ai-course/internal/logger"
 	"ai-course/internal/model"
 	"ai-course/internal/service"
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -68,6 +69,18 @@ func (c *AssignmentController) Create(ctx *gin.Context) {
 			zap.Error(err),
 			zap.Uint("teacher_id", teacherID),
 		)
+		if errors.Is(err, service.ErrLatePolicyInvalid) {
+			c.ParamError("迟交策略无效：最晚提交时间必须晚于截止时间")
+			return
+		}
+		if errors.Is(err, service.ErrRandomizationInvalid) {
+			c.ParamError("随机组卷设置无效：题目池不能重复设置")
+			return
+		}
+		if errors.Is(err, service.ErrScheduleInvalid) {
+			c.ParamError("定时设置无效：关闭时间不能早于截止时间，且必须晚于定时发布时间")
+			return
+		}
 		c.ServerError(err.Error())
 		return
 	}
@@ -136,11 +149,17 @@ func (c *AssignmentController) Update(ctx *gin.Context) {
 			zap.Uint("teacher_id", teacherID),
 		)
 		
-		switch err.Error() {
-		case "assignment not found":
+		switch msg := err.Error(); {
+		case msg == "assignment not found":
 			c.Fail(404, "作业不存在")
-		case "teacher has no permission to update this assignment":
+		case msg == "teacher has no permission to update this assignment":
 			c.Fail(403, "无权限操作此作业")
+		case errors.Is(err, service.ErrLatePolicyInvalid):
+			c.ParamError("迟交策略无效：最晚提交时间必须晚于截止时间")
+		case errors.Is(err, service.ErrRandomizationInvalid):
+			c.ParamError("随机组卷设置无效：题目池不能重复设置")
+		case errors.Is(err, service.ErrScheduleInvalid):
+			c.ParamError("定时设置无效：关闭时间不能早于截止时间，且必须晚于定时发布时间")
 		default:
 			c.ServerError(err.Error())
 		}
@@ -223,7 +242,7 @@ func (c *AssignmentController) Delete(ctx *gin.Context) {
 
 // Detail godoc
 // @Summary 获取作业详情
-// @Description 获取作业详细信息
+// @Description 获取作业详细信息，学生看到的题目不包含答案和解析
 // @Tags 作业管理
 // @Produce json
 // @Param id path int true "作业ID"
@@ -241,7 +260,22 @@ func (c *AssignmentController) Detail(ctx *gin.Context) {
 		return
 	}
 
-	assignment, err := c.assignmentService.GetAssignmentDetail(ctx.Request.Context(), uint(id))
+	// 获取当前用户ID，用于区分教师和学生视图
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		logger.Logger.Warn("User not authenticated for get assignment detail")
+		c.Unauthorized("用户未认证")
+		return
+	}
+
+	currentUserID, ok := userID.(uint)
+	if !ok {
+		logger.Logger.Warn("Invalid user ID format for get assignment detail")
+		c.Unauthorized("用户ID格式无效")
+		return
+	}
+
+	assignment, err := c.assignmentService.GetAssignmentDetail(ctx.Request.Context(), uint(id), currentUserID)
 	if err != nil {
 		logger.Logger.Error("Failed to get assignment detail",
 			zap.Error(err),
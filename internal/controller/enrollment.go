@@ -0,0 +1,448 @@
+package controller
+
+import (
+	"ai-course/internal/base/controller"
+	"ai-course/internal/logger"
+	"ai-course/internal/service"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EnrollmentController 班级成员控制器
+type EnrollmentController struct {
+	controller.BaseController
+	enrollmentService service.EnrollmentService
+}
+
+// NewEnrollmentController 创建班级成员控制器
+func NewEnrollmentController(enrollmentService service.EnrollmentService) *EnrollmentController {
+	return &EnrollmentController{
+		enrollmentService: enrollmentService,
+	}
+}
+
+// AddStudents godoc
+// @Summary 添加班级学生
+// @Description 教师按学号批量将学生加入班级，学号对应的用户不是学生时不加入
+// @Tags 班级成员
+// @Accept json
+// @Produce json
+// @Param id path int true "班级ID"
+// @Param request body service.AddStudentsDTO true "学号列表"
+// @Success 200 {object} response.Response "添加成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/class/{id}/students [post]
+func (c *EnrollmentController) AddStudents(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	var req service.AddStudentsDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid add students request",
+			zap.Error(err),
+		)
+		c.ParamError("添加学生参数无效")
+		return
+	}
+
+	operatorID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := c.enrollmentService.AddStudents(ctx.Request.Context(), uint(classID), operatorID, &req)
+	if err != nil {
+		logger.Logger.Error("Failed to add students",
+			zap.Error(err),
+			zap.Uint("class_id", uint(classID)),
+			zap.Uint("operator_id", operatorID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("添加学生成功", result)
+}
+
+// RemoveStudent godoc
+// @Summary 移除班级学生
+// @Description 教师将学生移出班级（保留成员记录和退出时间）
+// @Tags 班级成员
+// @Produce json
+// @Param id path int true "班级ID"
+// @Param student_id path int true "学生用户ID"
+// @Success 200 {object} response.Response "移除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级或学生不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/class/{id}/students/{student_id} [delete]
+func (c *EnrollmentController) RemoveStudent(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	studentID, err := strconv.ParseUint(ctx.Param("student_id"), 10, 32)
+	if err != nil {
+		c.ParamError("学生ID格式无效")
+		return
+	}
+
+	operatorID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.enrollmentService.RemoveStudent(ctx.Request.Context(), uint(classID), uint(studentID), operatorID); err != nil {
+		logger.Logger.Error("Failed to remove student",
+			zap.Error(err),
+			zap.Uint("class_id", uint(classID)),
+			zap.Uint("student_id", uint(studentID)),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("移除学生成功", nil)
+}
+
+// ListStudents godoc
+// @Summary 获取班级学生列表
+// @Description 分页获取班级学生名单
+// @Tags 班级成员
+// @Produce json
+// @Param id path int true "班级ID"
+// @Param page query int true "页码"
+// @Param page_size query int false "每页数量(默认20)"
+// @Param status query string false "成员状态过滤" Enums(active,left)
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/class/{id}/students [get]
+func (c *EnrollmentController) ListStudents(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	var req service.ClassStudentListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Logger.Warn("Invalid list students request",
+			zap.Error(err),
+		)
+		c.ParamError("获取学生列表参数无效")
+		return
+	}
+
+	operatorID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	response, err := c.enrollmentService.ListStudents(ctx.Request.Context(), uint(classID), operatorID, &req)
+	if err != nil {
+		logger.Logger.Error("Failed to list students",
+			zap.Error(err),
+			zap.Uint("class_id", uint(classID)),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(response)
+}
+
+// Join godoc
+// @Summary 通过邀请码加入班级
+// @Description 学生输入班级邀请码加入班级
+// @Tags 班级成员
+// @Accept json
+// @Produce json
+// @Param request body service.JoinClassDTO true "邀请码"
+// @Success 200 {object} response.Response "加入成功"
+// @Failure 400 {object} response.Response "邀请码无效或已加入"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/class/join [post]
+func (c *EnrollmentController) Join(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	var req service.JoinClassDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid join class request",
+			zap.Error(err),
+		)
+		c.ParamError("邀请码不能为空")
+		return
+	}
+
+	studentID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	class, err := c.enrollmentService.JoinByCode(ctx.Request.Context(), studentID, &req)
+	if err != nil {
+		logger.Logger.Warn("Failed to join class",
+			zap.Error(err),
+			zap.Uint("student_id", studentID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	logger.Logger.Info("Student joined class",
+		zap.Uint("student_id", studentID),
+		zap.Uint("class_id", class.ID),
+	)
+
+	c.SuccessWithMessage("加入班级成功", class)
+}
+
+// Leave godoc
+// @Summary 退出班级
+// @Description 学生退出自己所在的班级
+// @Tags 班级成员
+// @Produce json
+// @Param id path int true "班级ID"
+// @Success 200 {object} response.Response "退出成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "不在该班级中"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/class/{id}/leave [post]
+func (c *EnrollmentController) Leave(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	studentID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.enrollmentService.Leave(ctx.Request.Context(), uint(classID), studentID); err != nil {
+		logger.Logger.Warn("Failed to leave class",
+			zap.Error(err),
+			zap.Uint("class_id", uint(classID)),
+			zap.Uint("student_id", studentID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("退出班级成功", nil)
+}
+
+// MyClasses godoc
+// @Summary 获取我的班级
+// @Description 学生获取自己当前所在的班级列表
+// @Tags 班级成员
+// @Produce json
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/class/mine [get]
+func (c *EnrollmentController) MyClasses(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	studentID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	classes, err := c.enrollmentService.GetStudentClasses(ctx.Request.Context(), studentID)
+	if err != nil {
+		logger.Logger.Error("Failed to get student classes",
+			zap.Error(err),
+			zap.Uint("student_id", studentID),
+		)
+		c.ServerError(err.Error())
+		return
+	}
+
+	c.Success(classes)
+}
+
+// AddTeacher godoc
+// @Summary 添加协同授课教师
+// @Description 班级创建者按工号添加协同授课教师，协同授课教师可管理班级作业和批改
+// @Tags 班级成员
+// @Accept json
+// @Produce json
+// @Param id path int true "班级ID"
+// @Param request body service.AddTeacherDTO true "教师工号"
+// @Success 200 {object} response.Response "添加成功"
+// @Failure 400 {object} response.Response "请求参数错误或该用户不是教师"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级或教师不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/class/{id}/teachers [post]
+func (c *EnrollmentController) AddTeacher(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	var req service.AddTeacherDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid add teacher request",
+			zap.Error(err),
+		)
+		c.ParamError("教师工号不能为空")
+		return
+	}
+
+	operatorID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	teacher, err := c.enrollmentService.AddTeacher(ctx.Request.Context(), uint(classID), operatorID, &req)
+	if err != nil {
+		logger.Logger.Warn("Failed to add co-teacher",
+			zap.Error(err),
+			zap.Uint("class_id", uint(classID)),
+			zap.Uint("operator_id", operatorID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("添加协同授课教师成功", teacher)
+}
+
+// RemoveTeacher godoc
+// @Summary 移除协同授课教师
+// @Description 班级创建者移除协同授课教师
+// @Tags 班级成员
+// @Produce json
+// @Param id path int true "班级ID"
+// @Param teacher_id path int true "教师用户ID"
+// @Success 200 {object} response.Response "移除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级或教师不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/class/{id}/teachers/{teacher_id} [delete]
+func (c *EnrollmentController) RemoveTeacher(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	teacherID, err := strconv.ParseUint(ctx.Param("teacher_id"), 10, 32)
+	if err != nil {
+		c.ParamError("教师ID格式无效")
+		return
+	}
+
+	operatorID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.enrollmentService.RemoveTeacher(ctx.Request.Context(), uint(classID), uint(teacherID), operatorID); err != nil {
+		logger.Logger.Warn("Failed to remove co-teacher",
+			zap.Error(err),
+			zap.Uint("class_id", uint(classID)),
+			zap.Uint("teacher_id", uint(teacherID)),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("移除协同授课教师成功", nil)
+}
+
+// ListTeachers godoc
+// @Summary 获取协同授课教师列表
+// @Description 获取班级的协同授课教师（不含班级创建者）
+// @Tags 班级成员
+// @Produce json
+// @Param id path int true "班级ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/class/{id}/teachers [get]
+func (c *EnrollmentController) ListTeachers(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	operatorID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	teachers, err := c.enrollmentService.ListTeachers(ctx.Request.Context(), uint(classID), operatorID)
+	if err != nil {
+		logger.Logger.Error("Failed to list co-teachers",
+			zap.Error(err),
+			zap.Uint("class_id", uint(classID)),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(teachers)
+}
+
+// currentUserID 获取当前登录用户ID，失败时直接写入响应
+func (c *EnrollmentController) currentUserID(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return 0, false
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return 0, false
+	}
+
+	return uid, true
+}
+
+// handleError 将服务层错误映射为响应
+func (c *EnrollmentController) handleError(err error) {
+	switch {
+	case errors.Is(err, service.ErrClassNotFound):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrNoClassPermission):
+		c.Fail(403, err.Error())
+	case errors.Is(err, service.ErrStudentNotEnrolled), errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrCoTeacherNotFound):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrAlreadyEnrolled), errors.Is(err, service.ErrInvalidClassCode),
+		errors.Is(err, service.ErrNotTeacher), errors.Is(err, service.ErrAlreadyCoTeacher):
+		c.Fail(400, err.Error())
+	default:
+		c.ServerError(err.Error())
+	}
+}
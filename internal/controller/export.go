@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"ai-course/internal/base/controller"
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/pkg/export"
+	"ai-course/internal/service"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ExportController 成绩导出控制器
+type ExportController struct {
+	controller.BaseController
+	exportService service.ExportService
+}
+
+// NewExportController 创建成绩导出控制器
+func NewExportController(exportService service.ExportService) *ExportController {
+	return &ExportController{
+		exportService: exportService,
+	}
+}
+
+// AssignmentGrades godoc
+// @Summary 导出作业成绩
+// @Description 导出作业中每个在读学生的学号、姓名、各题得分、总分、状态、作答次数、迟交、迟交扣分、评语和提交时间。
+// @Description 多次作答时总分按作业的计分方式计算，其余各列取计分的那次作答；学生分批查询并以流的方式写出。
+// @Description columns 为逗号分隔的列名，可选 code,name,questions,total,status,attempts,late,late_penalty,feedback,submitted_at，按给定顺序导出
+// @Tags 成绩导出
+// @Produce application/octet-stream
+// @Param assignment_id path int true "作业ID"
+// @Param format query string false "导出格式 csv 或 xlsx" default(csv)
+// @Param columns query string false "导出的列，默认全部"
+// @Param bom query bool false "CSV 是否写入 UTF-8 BOM（Excel 打开中文需要）" default(true)
+// @Success 200 {file} file "成绩文件"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/grading/assignment/{assignment_id}/export [get]
+func (c *ExportController) AssignmentGrades(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	opened := false
+	err = c.exportService.ExportAssignmentGrades(ctx.Request.Context(), uint(assignmentID), c.exportRequest(ctx), teacherID, c.opener(ctx, &opened))
+	if err != nil {
+		logger.Logger.Error("Failed to export assignment grades",
+			zap.Error(err),
+			zap.Uint64("assignment_id", assignmentID),
+			zap.Uint("teacher_id", teacherID),
+			zap.Bool("streaming", opened),
+		)
+		c.handleError(ctx, err, opened)
+		return
+	}
+
+	logger.Logger.Info("Assignment grades exported",
+		zap.Uint64("assignment_id", assignmentID),
+		zap.Uint("teacher_id", teacherID),
+	)
+}
+
+// ClassGradebook godoc
+// @Summary 导出班级成绩册
+// @Description 导出班级每个在读学生的学号、姓名、各作业得分、各分类得分率、课程总评和等级，计算方式与成绩册一致；
+// @Description 免做和待批改等没有得分的作业导出状态名称。学生分批查询并以流的方式写出。
+// @Description columns 为逗号分隔的列名，可选 code,name,assignments,categories,percent,level，按给定顺序导出
+// @Tags 成绩导出
+// @Produce application/octet-stream
+// @Param class_id path int true "班级ID"
+// @Param format query string false "导出格式 csv 或 xlsx" default(csv)
+// @Param columns query string false "导出的列，默认全部"
+// @Param bom query bool false "CSV 是否写入 UTF-8 BOM（Excel 打开中文需要）" default(true)
+// @Success 200 {file} file "成绩册文件"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/gradebook/class/{class_id}/export [get]
+func (c *ExportController) ClassGradebook(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("class_id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	opened := false
+	err = c.exportService.ExportClassGradebook(ctx.Request.Context(), uint(classID), c.exportRequest(ctx), teacherID, c.opener(ctx, &opened))
+	if err != nil {
+		logger.Logger.Error("Failed to export class gradebook",
+			zap.Error(err),
+			zap.Uint64("class_id", classID),
+			zap.Uint("teacher_id", teacherID),
+			zap.Bool("streaming", opened),
+		)
+		c.handleError(ctx, err, opened)
+		return
+	}
+
+	logger.Logger.Info("Class gradebook exported",
+		zap.Uint64("class_id", classID),
+		zap.Uint("teacher_id", teacherID),
+	)
+}
+
+// exportRequest 解析导出参数
+func (c *ExportController) exportRequest(ctx *gin.Context) *model.GradeExportRequest {
+	req := &model.GradeExportRequest{
+		Format: ctx.Query("format"),
+		BOM:    ctx.DefaultQuery("bom", "true") != "false",
+	}
+	for _, column := range strings.Split(ctx.Query("columns"), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			req.Columns = append(req.Columns, model.GradeExportColumn(column))
+		}
+	}
+	return req
+}
+
+// opener 返回设置下载响应头并输出到响应体的 ExportOpener
+func (c *ExportController) opener(ctx *gin.Context, opened *bool) service.ExportOpener {
+	return attachmentOpener(ctx, "grades", opened)
+}
+
+// attachmentOpener 返回以附件形式输出到响应体的 ExportOpener，fallback 为不含扩展名的 ASCII 后备文件名
+func attachmentOpener(ctx *gin.Context, fallback string, opened *bool) service.ExportOpener {
+	return func(filename string, format export.Format) (io.Writer, error) {
+		*opened = true
+		setAttachmentHeaders(ctx, fallback+format.Extension(), filename, format.ContentType())
+		ctx.Status(http.StatusOK)
+		return ctx.Writer, nil
+	}
+}
+
+// setAttachmentHeaders 设置下载文件的响应头
+func setAttachmentHeaders(ctx *gin.Context, fallback string, filename string, contentType string) {
+	// 文件名含中文，filename 提供 ASCII 的后备名称，filename* 按 RFC 5987 编码
+	disposition := "attachment; filename=\"" + fallback + "\"; filename*=UTF-8''" + url.PathEscape(filename)
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", disposition)
+	ctx.Header("Cache-Control", "no-store")
+}
+
+// currentUserID 获取当前登录用户ID
+func (c *ExportController) currentUserID(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return 0, false
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return 0, false
+	}
+	return uid, true
+}
+
+// handleError 将服务层错误映射为响应，已开始输出文件时只能中断连接
+func (c *ExportController) handleError(ctx *gin.Context, err error, opened bool) {
+	if opened {
+		ctx.Abort()
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrExportFormatInvalid), errors.Is(err, service.ErrExportColumnInvalid):
+		c.ParamError(err.Error())
+	case errors.Is(err, service.ErrAssignmentNotFound), errors.Is(err, service.ErrClassNotFound):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrAccessDenied):
+		c.Fail(403, err.Error())
+	default:
+		c.ServerError(err.Error())
+	}
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"ai-course/internal/base/controller"
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/service"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ExtensionController 作业延期控制器
+type ExtensionController struct {
+	controller.BaseController
+	extensionService service.ExtensionService
+}
+
+// NewExtensionController 创建作业延期控制器
+func NewExtensionController(extensionService service.ExtensionService) *ExtensionController {
+	return &ExtensionController{
+		extensionService: extensionService,
+	}
+}
+
+// Grant godoc
+// @Summary 设置学生延期
+// @Description 教师为班级中的学生单独设置作业截止时间，已有延期时替换；学生在延期内提交不算迟交
+// @Tags 作业管理
+// @Accept json
+// @Produce json
+// @Param id path int true "作业ID"
+// @Param request body model.GrantExtensionRequest true "延期信息"
+// @Success 200 {object} response.Response{data=model.DeadlineExtension} "设置成功"
+// @Failure 400 {object} response.Response "请求参数错误或学生不在班级中"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/assignment/{id}/extensions [post]
+func (c *ExtensionController) Grant(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	var req model.GrantExtensionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid grant extension request",
+			zap.Error(err),
+		)
+		c.ParamError("延期参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	extension, err := c.extensionService.GrantExtension(ctx.Request.Context(), uint(id), &req, teacherID)
+	if err != nil {
+		logger.Logger.Warn("Failed to grant deadline extension",
+			zap.Error(err),
+			zap.Uint("assignment_id", uint(id)),
+			zap.Uint("student_id", req.StudentID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	logger.Logger.Info("Deadline extension granted",
+		zap.Uint("assignment_id", uint(id)),
+		zap.Uint("student_id", req.StudentID),
+		zap.Uint("teacher_id", teacherID),
+	)
+
+	c.SuccessWithMessage("设置延期成功", extension)
+}
+
+// List godoc
+// @Summary 获取作业延期列表
+// @Description 教师查看作业中所有学生的延期
+// @Tags 作业管理
+// @Produce json
+// @Param id path int true "作业ID"
+// @Success 200 {object} response.Response{data=[]model.DeadlineExtension} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/assignment/{id}/extensions [get]
+func (c *ExtensionController) List(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	extensions, err := c.extensionService.GetExtensions(ctx.Request.Context(), uint(id), teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to get deadline extensions",
+			zap.Error(err),
+			zap.Uint("assignment_id", uint(id)),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(extensions)
+}
+
+// Revoke godoc
+// @Summary 取消学生延期
+// @Description 教师取消学生的延期，已提交的作业不受影响
+// @Tags 作业管理
+// @Produce json
+// @Param id path int true "作业ID"
+// @Param student_id path int true "学生ID"
+// @Success 200 {object} response.Response "取消成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "延期记录不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/assignment/{id}/extensions/{student_id} [delete]
+func (c *ExtensionController) Revoke(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+	studentID, err := strconv.ParseUint(ctx.Param("student_id"), 10, 32)
+	if err != nil {
+		c.ParamError("学生ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.extensionService.RevokeExtension(ctx.Request.Context(), uint(id), uint(studentID), teacherID); err != nil {
+		logger.Logger.Warn("Failed to revoke deadline extension",
+			zap.Error(err),
+			zap.Uint("assignment_id", uint(id)),
+			zap.Uint("student_id", uint(studentID)),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("取消延期成功", nil)
+}
+
+// currentUserID 获取当前登录用户ID
+func (c *ExtensionController) currentUserID(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return 0, false
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return 0, false
+	}
+	return uid, true
+}
+
+// handleError 将服务层错误映射为响应
+func (c *ExtensionController) handleError(err error) {
+	switch {
+	case errors.Is(err, service.ErrAssignmentNotFound), errors.Is(err, service.ErrExtensionNotFound):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrAccessDenied):
+		c.Fail(403, err.Error())
+	case errors.Is(err, service.ErrExtensionStudentNotFound), errors.Is(err, service.ErrExtensionDeadlineInvalid):
+		c.Fail(400, err.Error())
+	default:
+		c.ServerError(err.Error())
+	}
+}
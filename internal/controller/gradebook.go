@@ -0,0 +1,378 @@
+package controller
+
+import (
+	"ai-course/internal/base/controller"
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/service"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GradebookController 成绩册控制器
+type GradebookController struct {
+	controller.BaseController
+	gradebookService service.GradebookService
+}
+
+// NewGradebookController 创建成绩册控制器
+func NewGradebookController(gradebookService service.GradebookService) *GradebookController {
+	return &GradebookController{
+		gradebookService: gradebookService,
+	}
+}
+
+// ClassGradebook godoc
+// @Summary 获取班级成绩册
+// @Description 任课教师获取班级在读学生×已发布作业的成绩矩阵（包含成绩尚未发布的作业），以及各分类成绩、课程总评和等级。
+// @Description 多次作答按作业的计分方式计算得分；免做的作业不计入总评，缺交（教师标记或截止后未提交）按零分计；
+// @Description 设置成绩分类后按分类权重加权，未分类的作业不计入总评，各分类可去掉最低的若干次作业
+// @Tags 成绩册
+// @Produce json
+// @Param class_id path int true "班级ID"
+// @Success 200 {object} response.Response{data=model.GradebookResponse} "获取成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/gradebook/class/{class_id} [get]
+func (c *GradebookController) ClassGradebook(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("class_id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	gradebook, err := c.gradebookService.GetClassGradebook(ctx.Request.Context(), uint(classID), teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to get class gradebook",
+			zap.Error(err),
+			zap.Uint64("class_id", classID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(gradebook)
+}
+
+// MySummary godoc
+// @Summary 获取个人成绩汇总
+// @Description 学生获取自己在班级中的成绩、分类成绩、课程总评和等级，只包含已发布成绩的作业
+// @Tags 成绩册
+// @Produce json
+// @Param class_id path int true "班级ID"
+// @Success 200 {object} response.Response{data=model.GradeSummaryResponse} "获取成功"
+// @Failure 403 {object} response.Response "不是班级成员"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/gradebook/class/{class_id}/me [get]
+func (c *GradebookController) MySummary(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("class_id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	studentID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	summary, err := c.gradebookService.GetStudentSummary(ctx.Request.Context(), uint(classID), studentID)
+	if err != nil {
+		logger.Logger.Warn("Failed to get student grade summary",
+			zap.Error(err),
+			zap.Uint64("class_id", classID),
+			zap.Uint("student_id", studentID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(summary)
+}
+
+// Settings godoc
+// @Summary 获取成绩册设置
+// @Description 获取班级的成绩分类（权重、去掉最低分次数）和等级分数线，未设置等级时返回默认五级制
+// @Tags 成绩册
+// @Produce json
+// @Param class_id path int true "班级ID"
+// @Success 200 {object} response.Response{data=model.GradebookSettingsResponse} "获取成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/gradebook/class/{class_id}/settings [get]
+func (c *GradebookController) Settings(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("class_id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	settings, err := c.gradebookService.GetSettings(ctx.Request.Context(), uint(classID), teacherID)
+	if err != nil {
+		logger.Logger.Warn("Failed to get gradebook settings",
+			zap.Error(err),
+			zap.Uint64("class_id", classID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(settings)
+}
+
+// UpdateSettings godoc
+// @Summary 更新成绩册设置
+// @Description 整体替换班级的成绩分类和等级分数线：带 ID 的分类更新，未列出的分类删除（其中的作业变为未分类）；等级为空时使用默认五级制
+// @Tags 成绩册
+// @Accept json
+// @Produce json
+// @Param class_id path int true "班级ID"
+// @Param request body model.UpdateGradebookSettingsRequest true "成绩册设置"
+// @Success 200 {object} response.Response{data=model.GradebookSettingsResponse} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/gradebook/class/{class_id}/settings [put]
+func (c *GradebookController) UpdateSettings(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("class_id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	var req model.UpdateGradebookSettingsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid update gradebook settings request",
+			zap.Error(err),
+		)
+		c.ParamError("成绩册设置参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	settings, err := c.gradebookService.UpdateSettings(ctx.Request.Context(), uint(classID), &req, teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to update gradebook settings",
+			zap.Error(err),
+			zap.Uint64("class_id", classID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	logger.Logger.Info("Gradebook settings updated",
+		zap.Uint64("class_id", classID),
+		zap.Uint("teacher_id", teacherID),
+	)
+
+	c.SuccessWithMessage("更新成绩册设置成功", settings)
+}
+
+// SetCategory godoc
+// @Summary 设置作业成绩分类
+// @Description 设置作业所属的成绩分类，category_id 为空时取消分类
+// @Tags 成绩册
+// @Accept json
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Param request body model.SetAssignmentCategoryRequest true "成绩分类"
+// @Success 200 {object} response.Response "设置成功"
+// @Failure 400 {object} response.Response "分类不属于作业所在班级"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/gradebook/assignment/{assignment_id}/category [put]
+func (c *GradebookController) SetCategory(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	var req model.SetAssignmentCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid set assignment category request",
+			zap.Error(err),
+		)
+		c.ParamError("成绩分类参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.gradebookService.SetAssignmentCategory(ctx.Request.Context(), uint(assignmentID), &req, teacherID); err != nil {
+		logger.Logger.Warn("Failed to set assignment category",
+			zap.Error(err),
+			zap.Uint64("assignment_id", assignmentID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("设置成绩分类成功", nil)
+}
+
+// Mark godoc
+// @Summary 标记免做或缺交
+// @Description 标记学生的作业免做（不计入总评）或缺交（按零分计），已有标记时替换；学生有成绩后缺交标记不再生效
+// @Tags 成绩册
+// @Accept json
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Param student_id path int true "学生ID"
+// @Param request body model.MarkGradeRequest true "标记"
+// @Success 200 {object} response.Response{data=model.GradeMark} "标记成功"
+// @Failure 400 {object} response.Response "请求参数错误或学生不在班级中"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/gradebook/assignment/{assignment_id}/marks/{student_id} [put]
+func (c *GradebookController) Mark(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+	studentID, err := strconv.ParseUint(ctx.Param("student_id"), 10, 32)
+	if err != nil {
+		c.ParamError("学生ID格式无效")
+		return
+	}
+
+	var req model.MarkGradeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid mark grade request",
+			zap.Error(err),
+		)
+		c.ParamError("标记参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	mark, err := c.gradebookService.MarkGrade(ctx.Request.Context(), uint(assignmentID), uint(studentID), &req, teacherID)
+	if err != nil {
+		logger.Logger.Warn("Failed to mark grade",
+			zap.Error(err),
+			zap.Uint64("assignment_id", assignmentID),
+			zap.Uint64("student_id", studentID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("标记成功", mark)
+}
+
+// ClearMark godoc
+// @Summary 清除免做或缺交标记
+// @Description 清除学生作业的标记，按提交情况重新计算
+// @Tags 成绩册
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Param student_id path int true "学生ID"
+// @Success 200 {object} response.Response "清除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "标记不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/gradebook/assignment/{assignment_id}/marks/{student_id} [delete]
+func (c *GradebookController) ClearMark(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+	studentID, err := strconv.ParseUint(ctx.Param("student_id"), 10, 32)
+	if err != nil {
+		c.ParamError("学生ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.gradebookService.ClearMark(ctx.Request.Context(), uint(assignmentID), uint(studentID), teacherID); err != nil {
+		logger.Logger.Warn("Failed to clear grade mark",
+			zap.Error(err),
+			zap.Uint64("assignment_id", assignmentID),
+			zap.Uint64("student_id", studentID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("清除标记成功", nil)
+}
+
+// currentUserID 获取当前登录用户ID
+func (c *GradebookController) currentUserID(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return 0, false
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return 0, false
+	}
+	return uid, true
+}
+
+// handleError 将服务层错误映射为响应
+func (c *GradebookController) handleError(err error) {
+	switch {
+	case errors.Is(err, service.ErrClassNotFound), errors.Is(err, service.ErrAssignmentNotFound),
+		errors.Is(err, service.ErrGradeMarkNotFound):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrAccessDenied):
+		c.Fail(403, err.Error())
+	case errors.Is(err, service.ErrGradeCategoryInvalid), errors.Is(err, service.ErrGradebookSettingsInvalid),
+		errors.Is(err, service.ErrStudentNotEnrolled):
+		c.Fail(400, err.Error())
+	default:
+		c.ServerError(err.Error())
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"ai-course/internal/base/controller"
 	"ai-course/internal/logger"
 	"ai-course/internal/model"
+	"ai-course/internal/pkg/export"
 	"ai-course/internal/service"
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -179,6 +181,7 @@ func (c *GradingController) GetGradingDetail(ctx *gin.Context) {
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 403 {object} response.Response "无权限"
 // @Failure 404 {object} response.Response "提交不存在"
+// @Failure 409 {object} response.Response "提交已被修改或被其他教师领取"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /api/grading/submission/{submission_id} [post]
 func (c *GradingController) GradeSubmission(ctx *gin.Context) {
@@ -222,13 +225,19 @@ func (c *GradingController) GradeSubmission(ctx *gin.Context) {
 			zap.Uint("teacher_id", teacherID),
 		)
 		
-		switch err.Error() {
-		case "submission not found":
+		switch msg := err.Error(); {
+		case errors.Is(err, service.ErrGradeConflict), errors.Is(err, service.ErrGradingLocked):
+			c.Conflict(err.Error())
+		case msg == "submission not found":
 			c.Fail(404, "提交不存在")
-		case "teacher has no permission to grade this submission":
+		case msg == "teacher has no permission to grade this submission":
 			c.Fail(403, "无权限批改此提交")
-		case "submission is not submitted yet":
+		case msg == "submission is not submitted yet":
 			c.Fail(400, "作业尚未提交")
+		case msg == "no ai suggestion to accept for question":
+			c.Fail(400, "该题暂无AI批改建议，无法采纳")
+		case errors.Is(err, service.ErrGradeScoreExceeded):
+			c.Fail(400, "得分不能超过题目分值")
 		default:
 			c.ServerError(err.Error())
 		}
@@ -425,4 +434,341 @@ func (c *GradingController) GetGradingProgress(ctx *gin.Context) {
 	}
 
 	c.Success(progress)
-}
\ No newline at end of file
+}
+
+// SuggestGrades godoc
+// @Summary 生成AI批改建议
+// @Description 为提交中的主观题重新生成AI建议得分和评语，建议仅作为草稿，不计入成绩
+// @Tags 作业批改
+// @Produce json
+// @Param submission_id path int true "提交ID"
+// @Success 200 {object} response.Response "生成成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "提交不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/grading/submission/{submission_id}/ai-suggest [post]
+func (c *GradingController) SuggestGrades(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	submissionID, err := strconv.ParseUint(ctx.Param("submission_id"), 10, 32)
+	if err != nil {
+		c.ParamError("提交ID格式无效")
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return
+	}
+
+	teacherID, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return
+	}
+
+	results, err := c.gradingService.SuggestGrades(ctx.Request.Context(), uint(submissionID), teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to generate ai suggestions",
+			zap.Error(err),
+			zap.Uint("submission_id", uint(submissionID)),
+			zap.Uint("teacher_id", teacherID),
+		)
+
+		switch err.Error() {
+		case "submission not found":
+			c.Fail(404, "提交不存在")
+		case "teacher has no permission to grade this submission":
+			c.Fail(403, "无权限批改此提交")
+		default:
+			c.ServerError(err.Error())
+		}
+		return
+	}
+
+	c.SuccessWithMessage("生成AI批改建议成功", results)
+}
+
+// ClaimSubmission godoc
+// @Summary 领取批改
+// @Description 教师领取提交的批改锁，有效期内其他教师不能批改该提交，重复领取会延长有效期
+// @Tags 作业批改
+// @Produce json
+// @Param submission_id path int true "提交ID"
+// @Success 200 {object} response.Response{data=model.GradingLock} "领取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "提交不存在"
+// @Failure 409 {object} response.Response "已被其他教师领取"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/grading/submission/{submission_id}/claim [post]
+func (c *GradingController) ClaimSubmission(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	submissionID, err := strconv.ParseUint(ctx.Param("submission_id"), 10, 32)
+	if err != nil {
+		c.ParamError("提交ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	lock, err := c.gradingService.ClaimSubmission(ctx.Request.Context(), uint(submissionID), teacherID)
+	if err != nil {
+		logger.Logger.Warn("Failed to claim submission",
+			zap.Error(err),
+			zap.Uint("submission_id", uint(submissionID)),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("领取成功", lock)
+}
+
+// ReleaseSubmission godoc
+// @Summary 释放批改
+// @Description 教师放弃已领取的提交，其他教师可以立即领取
+// @Tags 作业批改
+// @Produce json
+// @Param submission_id path int true "提交ID"
+// @Success 200 {object} response.Response "释放成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "提交不存在"
+// @Failure 409 {object} response.Response "由其他教师领取"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/grading/submission/{submission_id}/claim [delete]
+func (c *GradingController) ReleaseSubmission(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	submissionID, err := strconv.ParseUint(ctx.Param("submission_id"), 10, 32)
+	if err != nil {
+		c.ParamError("提交ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.gradingService.ReleaseSubmission(ctx.Request.Context(), uint(submissionID), teacherID); err != nil {
+		logger.Logger.Warn("Failed to release submission",
+			zap.Error(err),
+			zap.Uint("submission_id", uint(submissionID)),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("释放成功", nil)
+}
+
+// ClaimNextSubmission godoc
+// @Summary 领取下一份待批改提交
+// @Description 按提交时间领取作业中下一份未被其他教师领取的提交，返回提交详情和批改锁，多名教师可同时从队列中领取
+// @Tags 作业批改
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Success 200 {object} response.Response "领取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在或没有待批改提交"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/grading/assignment/{assignment_id}/next [post]
+func (c *GradingController) ClaimNextSubmission(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	submission, lock, err := c.gradingService.ClaimNextSubmission(ctx.Request.Context(), uint(assignmentID), teacherID)
+	if err != nil {
+		logger.Logger.Warn("Failed to claim next submission",
+			zap.Error(err),
+			zap.Uint("assignment_id", uint(assignmentID)),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("领取成功", gin.H{
+		"submission": submission,
+		"lock":       lock,
+	})
+}
+
+// GetGradeTimeline godoc
+// @Summary 获取成绩变更记录
+// @Description 按时间顺序获取提交的成绩变更记录（操作人、来源、变更前后的得分和评语），任课教师可随时查看，学生本人在成绩发布后查看
+// @Tags 作业批改
+// @Produce json
+// @Param submission_id path int true "提交ID"
+// @Success 200 {object} response.Response{data=[]model.GradeEvent} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误或成绩尚未发布"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "提交不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/grading/submission/{submission_id}/timeline [get]
+func (c *GradingController) GetGradeTimeline(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	submissionID, err := strconv.ParseUint(ctx.Param("submission_id"), 10, 32)
+	if err != nil {
+		c.ParamError("提交ID格式无效")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	events, err := c.gradingService.GetGradeTimeline(ctx.Request.Context(), uint(submissionID), userID)
+	if err != nil {
+		logger.Logger.Warn("Failed to get grade timeline",
+			zap.Error(err),
+			zap.Uint("submission_id", uint(submissionID)),
+			zap.Uint("user_id", userID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(events)
+}
+
+// ImportGrades godoc
+// @Summary 导入作业成绩
+// @Description 上传 CSV 或 XLSX 成绩表，按学号（User.Code）匹配班级在读学生、按题号匹配作业题目，表头识别“学号”“姓名”“评语”和“第N题”（或 QN）列，其他列忽略，
+// @Description 可以直接使用成绩导出的文件。空白单元格表示不修改；得分须为不超过题目分值的非负整数。
+// @Description dry_run 为 true（默认）时只返回比对报告（有变化、无变化、未知学生、未提交、超出分值、格式错误）；
+// @Description 为 false 时所有行都有效才会在同一事务中保存，否则返回报告且不保存任何成绩
+// @Tags 作业批改
+// @Accept multipart/form-data
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Param file formData file true "成绩表（.csv 或 .xlsx）"
+// @Param dry_run formData bool false "只比对不保存" default(true)
+// @Success 200 {object} response.Response{data=model.GradeImportReport} "比对或导入成功"
+// @Failure 400 {object} response.Response{data=model.GradeImportReport} "文件无效或存在无法导入的行"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 409 {object} response.Response "提交已被修改或被其他教师领取"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/grading/assignment/{assignment_id}/import [post]
+func (c *GradingController) ImportGrades(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		c.ParamError("请上传成绩表文件")
+		return
+	}
+	dryRun := ctx.DefaultPostForm("dry_run", "true") != "false"
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	report, err := c.gradingService.ImportGrades(ctx.Request.Context(), uint(assignmentID), file, dryRun, teacherID)
+	if err != nil {
+		logger.Logger.Warn("Failed to import grades",
+			zap.Error(err),
+			zap.Uint64("assignment_id", assignmentID),
+			zap.Uint("teacher_id", teacherID),
+			zap.String("filename", file.Filename),
+		)
+		switch {
+		case errors.Is(err, service.ErrGradeImportRejected):
+			c.FailWithData(400, "成绩表中存在无法导入的行，未保存任何成绩", report)
+		case errors.Is(err, service.ErrGradeImportEmpty):
+			c.ParamError("成绩表为空")
+		case errors.Is(err, service.ErrGradeImportNoCodeColumn):
+			c.ParamError("成绩表缺少学号列")
+		case errors.Is(err, service.ErrGradeImportNoScoreColumn):
+			c.ParamError("成绩表缺少题目得分列或评语列")
+		case errors.Is(err, service.ErrGradeImportQuestionColumn):
+			c.ParamError("成绩表的题目列重复或超出作业的题目数量")
+		case errors.Is(err, service.ErrGradeImportHeader):
+			c.ParamError("成绩表表头无效")
+		case errors.Is(err, export.ErrUnsupportedFormat):
+			c.ParamError("仅支持 CSV 和 XLSX 文件")
+		case errors.Is(err, export.ErrTooLarge):
+			c.ParamError("文件过大")
+		case errors.Is(err, export.ErrInvalidFile):
+			c.ParamError("无法解析成绩表文件")
+		default:
+			c.handleError(err)
+		}
+		return
+	}
+
+	if report.Applied {
+		logger.Logger.Info("Grade import applied",
+			zap.Uint64("assignment_id", assignmentID),
+			zap.Int("changed", report.Summary.Changed),
+		)
+		c.SuccessWithMessage("导入成绩成功", report)
+		return
+	}
+	c.Success(report)
+}
+
+// currentUserID 获取当前登录用户ID
+func (c *GradingController) currentUserID(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return 0, false
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return 0, false
+	}
+	return uid, true
+}
+
+// handleError 将服务层错误映射为响应
+func (c *GradingController) handleError(err error) {
+	switch {
+	case errors.Is(err, service.ErrSubmissionNotFound), errors.Is(err, service.ErrAssignmentNotFound),
+		errors.Is(err, service.ErrGradingQueueEmpty):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrAccessDenied):
+		c.Fail(403, err.Error())
+	case errors.Is(err, service.ErrGradeConflict), errors.Is(err, service.ErrGradingLocked):
+		c.Conflict(err.Error())
+	case errors.Is(err, service.ErrGradesNotPublished):
+		c.Fail(400, "成绩尚未发布")
+	case err.Error() == "submission is not submitted yet":
+		c.Fail(400, "作业尚未提交")
+	default:
+		c.ServerError(err.Error())
+	}
+}
@@ -0,0 +1,434 @@
+package controller
+
+import (
+	"ai-course/internal/base/controller"
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/service"
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LessonPlanController 教案控制器
+type LessonPlanController struct {
+	controller.BaseController
+	lessonPlanService service.LessonPlanService
+}
+
+// NewLessonPlanController 创建教案控制器
+func NewLessonPlanController(lessonPlanService service.LessonPlanService) *LessonPlanController {
+	return &LessonPlanController{
+		lessonPlanService: lessonPlanService,
+	}
+}
+
+// Create godoc
+// @Summary 创建教案
+// @Description 教师为任课班级创建教案，可同时提交教学环节、关联作业和附件，新教案为草稿状态
+// @Tags 教案管理
+// @Accept json
+// @Produce json
+// @Param request body model.CreateLessonPlanRequest true "教案信息"
+// @Success 200 {object} response.Response "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/lesson-plan [post]
+func (c *LessonPlanController) Create(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	var req model.CreateLessonPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid create lesson plan request",
+			zap.Error(err),
+		)
+		c.ParamError("创建教案参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	plan, err := c.lessonPlanService.CreateLessonPlan(ctx.Request.Context(), &req, teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to create lesson plan",
+			zap.Error(err),
+			zap.Uint("class_id", req.ClassID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	logger.Logger.Info("Lesson plan created successfully",
+		zap.Uint("lesson_plan_id", plan.ID),
+		zap.Uint("teacher_id", teacherID),
+	)
+
+	c.SuccessWithMessage("创建教案成功", plan)
+}
+
+// Update godoc
+// @Summary 更新教案
+// @Description 教师更新教案，教学环节、关联作业和附件提供时整体替换
+// @Tags 教案管理
+// @Accept json
+// @Produce json
+// @Param id path int true "教案ID"
+// @Param request body model.UpdateLessonPlanRequest true "教案信息"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "教案不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/lesson-plan/{id} [put]
+func (c *LessonPlanController) Update(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("教案ID格式无效")
+		return
+	}
+
+	var req model.UpdateLessonPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid update lesson plan request",
+			zap.Error(err),
+		)
+		c.ParamError("更新教案参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	plan, err := c.lessonPlanService.UpdateLessonPlan(ctx.Request.Context(), uint(id), &req, teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to update lesson plan",
+			zap.Error(err),
+			zap.Uint64("lesson_plan_id", id),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("更新教案成功", plan)
+}
+
+// Delete godoc
+// @Summary 删除教案
+// @Description 教师删除教案
+// @Tags 教案管理
+// @Produce json
+// @Param id path int true "教案ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "教案不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/lesson-plan/{id} [delete]
+func (c *LessonPlanController) Delete(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("教案ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.lessonPlanService.DeleteLessonPlan(ctx.Request.Context(), uint(id), teacherID); err != nil {
+		logger.Logger.Error("Failed to delete lesson plan",
+			zap.Error(err),
+			zap.Uint64("lesson_plan_id", id),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("删除教案成功", nil)
+}
+
+// Detail godoc
+// @Summary 获取教案详情
+// @Description 获取教案详情，包含教学环节、关联作业和附件；学生只能查看所在班级已发布的教案
+// @Tags 教案管理
+// @Produce json
+// @Param id path int true "教案ID"
+// @Success 200 {object} response.Response{data=model.LessonPlanDetailResponse} "获取成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "教案不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/lesson-plan/{id} [get]
+func (c *LessonPlanController) Detail(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("教案ID格式无效")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	detail, err := c.lessonPlanService.GetLessonPlanDetail(ctx.Request.Context(), uint(id), userID)
+	if err != nil {
+		logger.Logger.Error("Failed to get lesson plan detail",
+			zap.Error(err),
+			zap.Uint64("lesson_plan_id", id),
+			zap.Uint("user_id", userID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(detail)
+}
+
+// List godoc
+// @Summary 获取教师教案列表
+// @Description 获取当前教师创建或任课班级中的教案
+// @Tags 教案管理
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/lesson-plan/list [get]
+func (c *LessonPlanController) List(ctx *gin.Context) {
+	c.InitHandler(ctx)
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	plans, total, err := c.lessonPlanService.GetTeacherLessonPlans(ctx.Request.Context(), teacherID, page, pageSize)
+	if err != nil {
+		logger.Logger.Error("Failed to get teacher lesson plans",
+			zap.Error(err),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.ServerError(err.Error())
+		return
+	}
+
+	c.Success(gin.H{
+		"list":  plans,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
+
+// ClassList godoc
+// @Summary 获取班级教案列表
+// @Description 任课教师获取班级全部教案，学生获取所在班级已发布的教案
+// @Tags 教案管理
+// @Produce json
+// @Param class_id path int true "班级ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/lesson-plan/class/{class_id} [get]
+func (c *LessonPlanController) ClassList(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	classID, err := strconv.ParseUint(ctx.Param("class_id"), 10, 32)
+	if err != nil {
+		c.ParamError("班级ID格式无效")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	plans, err := c.lessonPlanService.GetClassLessonPlans(ctx.Request.Context(), uint(classID), userID)
+	if err != nil {
+		logger.Logger.Error("Failed to get class lesson plans",
+			zap.Error(err),
+			zap.Uint64("class_id", classID),
+			zap.Uint("user_id", userID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(gin.H{
+		"class_id": classID,
+		"list":     plans,
+		"total":    len(plans),
+	})
+}
+
+// Publish godoc
+// @Summary 发布教案
+// @Description 发布后班级学生可以查看教案
+// @Tags 教案管理
+// @Produce json
+// @Param id path int true "教案ID"
+// @Success 200 {object} response.Response "发布成功"
+// @Failure 400 {object} response.Response "教案已发布"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "教案不存在"
+// @Router /api/lesson-plan/{id}/publish [post]
+func (c *LessonPlanController) Publish(ctx *gin.Context) {
+	c.changeStatus(ctx, "发布教案成功", c.lessonPlanService.PublishLessonPlan)
+}
+
+// Unpublish godoc
+// @Summary 取消发布教案
+// @Description 教案恢复为草稿状态，学生不再可见
+// @Tags 教案管理
+// @Produce json
+// @Param id path int true "教案ID"
+// @Success 200 {object} response.Response "取消发布成功"
+// @Failure 400 {object} response.Response "教案尚未发布"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "教案不存在"
+// @Router /api/lesson-plan/{id}/unpublish [post]
+func (c *LessonPlanController) Unpublish(ctx *gin.Context) {
+	c.changeStatus(ctx, "取消发布教案成功", c.lessonPlanService.UnpublishLessonPlan)
+}
+
+// Clone godoc
+// @Summary 复制教案
+// @Description 将教案复制到教师任课的其他班级，新教案为草稿状态；复制到其他班级时不保留关联的作业和附件
+// @Tags 教案管理
+// @Accept json
+// @Produce json
+// @Param id path int true "教案ID"
+// @Param request body model.CloneLessonPlanRequest true "目标班级"
+// @Success 200 {object} response.Response "复制成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "教案或班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/lesson-plan/{id}/clone [post]
+func (c *LessonPlanController) Clone(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("教案ID格式无效")
+		return
+	}
+
+	var req model.CloneLessonPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid clone lesson plan request",
+			zap.Error(err),
+		)
+		c.ParamError("复制教案参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	plan, err := c.lessonPlanService.CloneLessonPlan(ctx.Request.Context(), uint(id), &req, teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to clone lesson plan",
+			zap.Error(err),
+			zap.Uint64("lesson_plan_id", id),
+			zap.Uint("class_id", req.ClassID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	logger.Logger.Info("Lesson plan cloned successfully",
+		zap.Uint64("source_id", id),
+		zap.Uint("lesson_plan_id", plan.ID),
+		zap.Uint("class_id", req.ClassID),
+	)
+
+	c.SuccessWithMessage("复制教案成功", plan)
+}
+
+// changeStatus 执行教案状态变更
+func (c *LessonPlanController) changeStatus(ctx *gin.Context, message string, change func(ctx context.Context, id uint, teacherID uint) error) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("教案ID格式无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := change(ctx.Request.Context(), uint(id), teacherID); err != nil {
+		logger.Logger.Error("Failed to change lesson plan status",
+			zap.Error(err),
+			zap.Uint64("lesson_plan_id", id),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage(message, nil)
+}
+
+// currentUserID 获取当前登录用户ID，失败时写入未授权响应
+func (c *LessonPlanController) currentUserID(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return 0, false
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return 0, false
+	}
+	return uid, true
+}
+
+// handleError 将服务层错误映射为响应
+func (c *LessonPlanController) handleError(err error) {
+	switch {
+	case errors.Is(err, service.ErrLessonPlanNotFound), errors.Is(err, service.ErrClassNotFound):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrAccessDenied):
+		c.Fail(403, err.Error())
+	case errors.Is(err, service.ErrLessonPlanPublished), errors.Is(err, service.ErrLessonPlanNotPublished),
+		errors.Is(err, service.ErrInvalidPlanAssignment), errors.Is(err, service.ErrInvalidPlanAttachment):
+		c.Fail(400, err.Error())
+	default:
+		c.ServerError(err.Error())
+	}
+}
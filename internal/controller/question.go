@@ -4,8 +4,14 @@ import (
 	"ai-course/internal/base/controller"
 	"ai-course/internal/logger"
 	"ai-course/internal/model"
+	"ai-course/internal/pkg/document"
+	"ai-course/internal/pkg/questionfmt"
 	"ai-course/internal/service"
+	"errors"
+	"net/http"
+	"path"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -83,15 +89,17 @@ func (c *QuestionController) Create(ctx *gin.Context) {
 			zap.Uint("teacher_id", teacherID),
 		)
 		
-		switch err.Error() {
-		case "assignment not found":
+		switch msg := err.Error(); {
+		case strings.HasPrefix(msg, "assignment not found"):
 			c.Fail(404, "作业不存在")
-		case "teacher has no permission to add question to this assignment":
+		case msg == "teacher has no permission to add question to this assignment":
 			c.Fail(403, "无权限为此作业添加题目")
-		case "cannot add question to published assignment":
+		case msg == "cannot add question to published assignment":
 			c.Fail(400, "已发布的作业不能添加题目")
+		case strings.HasPrefix(msg, "question invalid"):
+			c.ParamError(msg)
 		default:
-			c.ServerError(err.Error())
+			c.ServerError(msg)
 		}
 		return
 	}
@@ -171,17 +179,19 @@ func (c *QuestionController) Update(ctx *gin.Context) {
 			zap.Uint("teacher_id", teacherID),
 		)
 		
-		switch err.Error() {
-		case "question not found":
+		switch msg := err.Error(); {
+		case msg == "question not found":
 			c.Fail(404, "题目不存在")
-		case "assignment not found":
+		case msg == "assignment not found":
 			c.Fail(404, "作业不存在")
-		case "teacher has no permission to update this question":
+		case msg == "teacher has no permission to update this question":
 			c.Fail(403, "无权限修改此题目")
-		case "cannot update question in published assignment":
+		case msg == "cannot update question in published assignment":
 			c.Fail(400, "已发布的作业不能修改题目")
+		case strings.HasPrefix(msg, "question invalid"):
+			c.ParamError(msg)
 		default:
-			c.ServerError(err.Error())
+			c.ServerError(msg)
 		}
 		return
 	}
@@ -309,4 +319,369 @@ func (c *QuestionController) List(ctx *gin.Context) {
 		"questions":     questions,
 		"total":         len(questions),
 	})
-}
\ No newline at end of file
+}
+
+// BatchCreate godoc
+// @Summary 批量添加题目
+// @Description 教师为指定作业批量添加题目，可直接提交 AI 生成的题目草稿；任一题目校验失败则全部不保存
+// @Tags 题目管理
+// @Accept json
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Param request body model.BatchCreateQuestionsRequest true "题目列表"
+// @Success 200 {object} response.Response "添加成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/question/assignment/{assignment_id}/batch [post]
+func (c *QuestionController) BatchCreate(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	var req model.BatchCreateQuestionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid batch create question request",
+			zap.Error(err),
+		)
+		c.ParamError("批量创建题目参数无效")
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return
+	}
+
+	teacherID, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return
+	}
+
+	questions, err := c.questionService.CreateQuestions(ctx.Request.Context(), req.Questions, uint(assignmentID), teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to batch create questions",
+			zap.Error(err),
+			zap.Uint("assignment_id", uint(assignmentID)),
+			zap.Uint("teacher_id", teacherID),
+		)
+
+		switch msg := err.Error(); {
+		case strings.HasPrefix(msg, "assignment not found"):
+			c.Fail(404, "作业不存在")
+		case msg == "teacher has no permission to add question to this assignment":
+			c.Fail(403, "无权限为此作业添加题目")
+		case msg == "cannot add question to published assignment":
+			c.Fail(400, "已发布的作业不能添加题目")
+		case strings.HasPrefix(msg, "question "):
+			c.ParamError(msg)
+		default:
+			c.ServerError(msg)
+		}
+		return
+	}
+
+	logger.Logger.Info("Questions created successfully",
+		zap.Uint("assignment_id", uint(assignmentID)),
+		zap.Int("count", len(questions)),
+		zap.Uint("teacher_id", teacherID),
+	)
+
+	c.SuccessWithMessage("批量创建题目成功", questions)
+}
+
+// Generate godoc
+// @Summary AI 生成题目
+// @Description 根据粘贴的文本、作业附件或上传的 PDF/Markdown/TXT 文件生成题目草稿，草稿不会保存，确认后通过批量添加接口入库
+// @Tags 题目管理
+// @Accept json,mpfd
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Param request body model.GenerateQuestionsRequest false "生成参数（JSON 方式）"
+// @Param file formData file false "出题材料文件（multipart 方式）"
+// @Success 200 {object} response.Response{data=model.GenerateQuestionsResponse} "生成成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业或附件不存在"
+// @Failure 502 {object} response.Response "题目生成服务调用失败"
+// @Router /api/question/assignment/{assignment_id}/generate [post]
+func (c *QuestionController) Generate(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	var req model.GenerateQuestionsRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		logger.Logger.Warn("Invalid generate question request",
+			zap.Error(err),
+		)
+		c.ParamError("生成题目参数无效")
+		return
+	}
+
+	// 上传文件为可选项
+	file, err := ctx.FormFile("file")
+	if err != nil && !errors.Is(err, http.ErrMissingFile) && !errors.Is(err, http.ErrNotMultipart) {
+		logger.Logger.Warn("Invalid generate question file",
+			zap.Error(err),
+		)
+		c.ParamError("上传文件无效")
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return
+	}
+
+	teacherID, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return
+	}
+
+	result, err := c.questionService.GenerateQuestions(ctx.Request.Context(), uint(assignmentID), teacherID, &req, file)
+	if err != nil {
+		logger.Logger.Error("Failed to generate questions",
+			zap.Error(err),
+			zap.Uint("assignment_id", uint(assignmentID)),
+			zap.Uint("teacher_id", teacherID),
+		)
+
+		switch {
+		case errors.Is(err, service.ErrAssignmentNotFound), errors.Is(err, service.ErrAttachmentNotFound):
+			c.Fail(404, err.Error())
+		case errors.Is(err, service.ErrAccessDenied):
+			c.Fail(403, err.Error())
+		case errors.Is(err, service.ErrMaterialRequired):
+			c.ParamError("请提供出题材料（文本、附件或上传文件）")
+		case errors.Is(err, service.ErrMaterialTooShort):
+			c.ParamError("出题材料内容过少")
+		case errors.Is(err, document.ErrUnsupportedFormat):
+			c.ParamError("仅支持 PDF、Markdown 和 TXT 文件")
+		case errors.Is(err, document.ErrNoText):
+			c.ParamError("未能从文件中提取到文本")
+		case errors.Is(err, document.ErrTooLarge):
+			c.ParamError("文件过大")
+		case errors.Is(err, service.ErrGenerateQuestions):
+			c.Fail(502, "题目生成服务调用失败")
+		default:
+			c.ServerError(err.Error())
+		}
+		return
+	}
+
+	logger.Logger.Info("Questions generated",
+		zap.Uint("assignment_id", uint(assignmentID)),
+		zap.Uint("teacher_id", teacherID),
+		zap.String("model", result.Model),
+		zap.Int("accepted", len(result.Questions)),
+		zap.Int("rejected", len(result.Rejected)),
+	)
+
+	c.SuccessWithMessage("生成题目成功", result)
+}
+
+// Import godoc
+// @Summary 导入题目
+// @Description 上传 QTI 2.1（zip 内容包或单个题目 XML）、Moodle XML、GIFT 或 Markdown 题目文件，转换为作业题目并排在已有题目之后，Markdown 格式说明见 questionfmt 包文档。
+// @Description 每道题目按创建题目的规则校验并在报告中给出结果和原因；dry_run 为 true（默认）时只校验不保存，
+// @Description 为 false 时所有题目都有效才会保存，skip_invalid 为 true 时跳过无效题目保存其余题目；文件中没有分值的题目使用 score 或题型默认分值
+// @Tags 题目管理
+// @Accept multipart/form-data
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Param file formData file true "题目文件（.zip、.xml、.gift、.txt 或 .md）"
+// @Param format formData string false "文件格式：qti、moodle、gift 或 markdown，不填时按扩展名和内容识别"
+// @Param dry_run formData bool false "只校验不保存" default(true)
+// @Param skip_invalid formData bool false "跳过无效题目" default(false)
+// @Param score formData int false "文件中没有分值的题目使用的分值"
+// @Success 200 {object} response.Response{data=model.QuestionImportReport} "校验或导入成功"
+// @Failure 400 {object} response.Response{data=model.QuestionImportReport} "文件无效或存在无效题目"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/question/assignment/{assignment_id}/import [post]
+func (c *QuestionController) Import(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		c.ParamError("请上传题目文件")
+		return
+	}
+	opts, ok := questionImportOptions(ctx)
+	if !ok {
+		c.ParamError("分值必须为正整数")
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return
+	}
+
+	teacherID, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return
+	}
+
+	report, err := c.questionService.ImportQuestions(ctx.Request.Context(), uint(assignmentID), teacherID, file, opts)
+	if err != nil {
+		logger.Logger.Warn("Failed to import questions",
+			zap.Error(err),
+			zap.Uint64("assignment_id", assignmentID),
+			zap.Uint("teacher_id", teacherID),
+			zap.String("filename", file.Filename),
+		)
+
+		if msg := questionFileErrorMessage(err); msg != "" {
+			c.ParamError(msg)
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrQuestionImportRejected):
+			c.FailWithData(400, err.Error(), report)
+		case errors.Is(err, service.ErrAssignmentNotFound):
+			c.Fail(404, err.Error())
+		case errors.Is(err, service.ErrAccessDenied):
+			c.Fail(403, err.Error())
+		case errors.Is(err, service.ErrAssignmentPublished):
+			c.Fail(400, err.Error())
+		default:
+			c.ServerError(err.Error())
+		}
+		return
+	}
+
+	if report.Applied {
+		logger.Logger.Info("Questions imported",
+			zap.Uint64("assignment_id", assignmentID),
+			zap.Uint("teacher_id", teacherID),
+			zap.String("format", report.Format),
+			zap.Int("imported", report.Summary.Imported),
+			zap.Int("skipped", report.Summary.Invalid),
+		)
+		c.SuccessWithMessage("导入题目成功", report)
+		return
+	}
+	c.Success(report)
+}
+
+// Export godoc
+// @Summary 导出题目
+// @Description 将作业的题目按顺序导出为 QTI 2.1 内容包（zip）、Moodle XML、GIFT 或 Markdown 文件。
+// @Description 格式无法表示的题目（如编程题）不导出，其题号以逗号分隔列在 X-Skipped-Questions 响应头中
+// @Tags 题目管理
+// @Produce application/zip,application/xml,text/plain,text/markdown
+// @Param assignment_id path int true "作业ID"
+// @Param format query string false "文件格式：qti、moodle、gift 或 markdown" default(markdown)
+// @Success 200 {file} file "题目文件"
+// @Header 200 {string} X-Skipped-Questions "未导出的题号"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/question/assignment/{assignment_id}/export [get]
+func (c *QuestionController) Export(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return
+	}
+
+	teacherID, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return
+	}
+
+	file, err := c.questionService.ExportQuestions(ctx.Request.Context(), uint(assignmentID), teacherID, ctx.DefaultQuery("format", "markdown"))
+	if err != nil {
+		logger.Logger.Warn("Failed to export questions",
+			zap.Error(err),
+			zap.Uint64("assignment_id", assignmentID),
+			zap.Uint("teacher_id", teacherID),
+		)
+
+		switch {
+		case errors.Is(err, questionfmt.ErrUnsupportedFormat):
+			c.ParamError("仅支持 qti、moodle、gift 和 markdown 格式")
+		case errors.Is(err, service.ErrAssignmentNotFound):
+			c.Fail(404, err.Error())
+		case errors.Is(err, service.ErrAccessDenied):
+			c.Fail(403, err.Error())
+		default:
+			c.ServerError(err.Error())
+		}
+		return
+	}
+
+	if len(file.Skipped) > 0 {
+		orders := make([]string, len(file.Skipped))
+		for i, order := range file.Skipped {
+			orders[i] = strconv.Itoa(order)
+		}
+		ctx.Header("X-Skipped-Questions", strings.Join(orders, ","))
+	}
+	setAttachmentHeaders(ctx, "questions"+path.Ext(file.Filename), file.Filename, file.ContentType)
+	ctx.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+// questionImportOptions 读取题目导入的表单选项，分值无效时返回 false
+func questionImportOptions(ctx *gin.Context) (*model.QuestionImportOptions, bool) {
+	opts := &model.QuestionImportOptions{
+		Format:      ctx.PostForm("format"),
+		DryRun:      ctx.DefaultPostForm("dry_run", "true") != "false",
+		SkipInvalid: ctx.PostForm("skip_invalid") == "true",
+	}
+	if score := ctx.PostForm("score"); score != "" {
+		value, err := strconv.Atoi(score)
+		if err != nil || value <= 0 {
+			return nil, false
+		}
+		opts.Score = value
+	}
+	return opts, true
+}
+
+// questionFileErrorMessage 返回题目文件读取错误的提示，其他错误返回空字符串
+func questionFileErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, questionfmt.ErrUnsupportedFormat):
+		return "仅支持 QTI 2.1、Moodle XML、GIFT 和 Markdown 格式"
+	case errors.Is(err, questionfmt.ErrTooLarge):
+		return "文件过大"
+	case errors.Is(err, questionfmt.ErrNoQuestions):
+		return "文件中没有题目"
+	case errors.Is(err, questionfmt.ErrInvalidFile):
+		// 包装的错误带有中文的具体原因
+		return "无法解析题目文件：" + strings.TrimPrefix(err.Error(), questionfmt.ErrInvalidFile.Error()+": ")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,454 @@
+package controller
+
+import (
+	"ai-course/internal/base/controller"
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/service"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// QuestionBankController 题库控制器
+type QuestionBankController struct {
+	controller.BaseController
+	questionBankService service.QuestionBankService
+}
+
+// NewQuestionBankController 创建题库控制器
+func NewQuestionBankController(questionBankService service.QuestionBankService) *QuestionBankController {
+	return &QuestionBankController{
+		questionBankService: questionBankService,
+	}
+}
+
+// Create godoc
+// @Summary 添加题库题目
+// @Description 教师向题库添加题目，可设置知识点、难度、章节和共享范围
+// @Tags 题库管理
+// @Accept json
+// @Produce json
+// @Param request body model.CreateBankItemRequest true "题目信息"
+// @Success 200 {object} response.Response "添加成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/question-bank [post]
+func (c *QuestionBankController) Create(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	var req model.CreateBankItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid create question bank item request",
+			zap.Error(err),
+		)
+		c.ParamError("添加题库题目参数无效")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	item, err := c.questionBankService.CreateItem(ctx.Request.Context(), &req, userID)
+	if err != nil {
+		logger.Logger.Error("Failed to create question bank item",
+			zap.Error(err),
+			zap.Uint("owner_id", userID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("添加题库题目成功", item)
+}
+
+// Update godoc
+// @Summary 更新题库题目
+// @Description 创建者更新题库题目；已添加到作业中的题目是独立副本不受影响，sync_drafts 为 true 时同步更新草稿作业中的副本
+// @Tags 题库管理
+// @Accept json
+// @Produce json
+// @Param id path int true "题库题目ID"
+// @Param request body model.UpdateBankItemRequest true "题目信息"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "题目不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/question-bank/{id} [put]
+func (c *QuestionBankController) Update(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("题目ID格式无效")
+		return
+	}
+
+	var req model.UpdateBankItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid update question bank item request",
+			zap.Error(err),
+		)
+		c.ParamError("更新题库题目参数无效")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	item, err := c.questionBankService.UpdateItem(ctx.Request.Context(), uint(id), &req, userID)
+	if err != nil {
+		logger.Logger.Error("Failed to update question bank item",
+			zap.Error(err),
+			zap.Uint64("item_id", id),
+			zap.Uint("user_id", userID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("更新题库题目成功", item)
+}
+
+// Delete godoc
+// @Summary 删除题库题目
+// @Description 创建者删除题库题目，已添加到作业中的题目不受影响
+// @Tags 题库管理
+// @Produce json
+// @Param id path int true "题库题目ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "题目不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/question-bank/{id} [delete]
+func (c *QuestionBankController) Delete(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("题目ID格式无效")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.questionBankService.DeleteItem(ctx.Request.Context(), uint(id), userID); err != nil {
+		logger.Logger.Error("Failed to delete question bank item",
+			zap.Error(err),
+			zap.Uint64("item_id", id),
+			zap.Uint("user_id", userID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("删除题库题目成功", nil)
+}
+
+// Detail godoc
+// @Summary 获取题库题目
+// @Description 获取自己创建或全校共享的题库题目
+// @Tags 题库管理
+// @Produce json
+// @Param id path int true "题库题目ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "题目不存在"
+// @Router /api/question-bank/{id} [get]
+func (c *QuestionBankController) Detail(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("题目ID格式无效")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	item, err := c.questionBankService.GetItem(ctx.Request.Context(), uint(id), userID)
+	if err != nil {
+		c.handleError(err)
+		return
+	}
+
+	c.Success(item)
+}
+
+// Search godoc
+// @Summary 搜索题库
+// @Description 按关键词、题型、知识点、难度、章节和共享范围搜索自己创建或全校共享的题目
+// @Tags 题库管理
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param keyword query string false "题目内容关键词"
+// @Param type query string false "题目类型"
+// @Param knowledge_point query string false "知识点"
+// @Param difficulty query string false "难度（easy/medium/hard）"
+// @Param chapter query string false "章节"
+// @Param scope query string false "共享范围（private/school）"
+// @Param mine query bool false "只看自己创建的题目"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/question-bank/list [get]
+func (c *QuestionBankController) Search(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	var req model.BankItemSearchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.Logger.Warn("Invalid search question bank request",
+			zap.Error(err),
+		)
+		c.ParamError("搜索题库参数无效")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	items, total, err := c.questionBankService.SearchItems(ctx.Request.Context(), &req, userID)
+	if err != nil {
+		logger.Logger.Error("Failed to search question bank",
+			zap.Error(err),
+			zap.Uint("user_id", userID),
+		)
+		c.ServerError(err.Error())
+		return
+	}
+
+	c.Success(gin.H{
+		"list":  items,
+		"total": total,
+		"page":  req.Page,
+		"size":  req.PageSize,
+	})
+}
+
+// AddToAssignment godoc
+// @Summary 从题库添加题目到作业
+// @Description 将题库题目复制到草稿作业中，复制出的题目通过 bank_item_id 引用来源题目
+// @Tags 题库管理
+// @Accept json
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Param request body model.AddFromBankRequest true "题库题目ID列表"
+// @Success 200 {object} response.Response "添加成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业或题目不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/question-bank/assignment/{assignment_id} [post]
+func (c *QuestionBankController) AddToAssignment(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	var req model.AddFromBankRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid add from bank request",
+			zap.Error(err),
+		)
+		c.ParamError("从题库添加题目参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	questions, err := c.questionBankService.AddToAssignment(ctx.Request.Context(), uint(assignmentID), &req, teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to add questions from bank",
+			zap.Error(err),
+			zap.Uint64("assignment_id", assignmentID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	logger.Logger.Info("Questions added from bank",
+		zap.Uint64("assignment_id", assignmentID),
+		zap.Int("count", len(questions)),
+		zap.Uint("teacher_id", teacherID),
+	)
+
+	c.SuccessWithMessage("从题库添加题目成功", questions)
+}
+
+// SaveFromQuestion godoc
+// @Summary 将作业题目保存到题库
+// @Description 将任课班级作业中的题目复制到自己的题库
+// @Tags 题库管理
+// @Accept json
+// @Produce json
+// @Param question_id path int true "题目ID"
+// @Param request body model.SaveToBankRequest false "题库标签"
+// @Success 200 {object} response.Response "保存成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "题目不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/question-bank/from-question/{question_id} [post]
+func (c *QuestionBankController) SaveFromQuestion(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	questionID, err := strconv.ParseUint(ctx.Param("question_id"), 10, 32)
+	if err != nil {
+		c.ParamError("题目ID格式无效")
+		return
+	}
+
+	var req model.SaveToBankRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			logger.Logger.Warn("Invalid save to bank request",
+				zap.Error(err),
+			)
+			c.ParamError("保存到题库参数无效")
+			return
+		}
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	item, err := c.questionBankService.SaveQuestionToBank(ctx.Request.Context(), uint(questionID), &req, teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to save question to bank",
+			zap.Error(err),
+			zap.Uint64("question_id", questionID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("保存到题库成功", item)
+}
+
+// Import godoc
+// @Summary 导入题库题目
+// @Description 上传 QTI 2.1（zip 内容包或单个题目 XML）、Moodle XML、GIFT 或 Markdown 题目文件导入题库，知识点、难度、章节和共享范围应用于全部题目。
+// @Description 校验和保存规则与导入作业题目相同：dry_run 为 true（默认）时只校验不保存，skip_invalid 为 true 时跳过无效题目
+// @Tags 题库管理
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "题目文件（.zip、.xml、.gift、.txt 或 .md）"
+// @Param format formData string false "文件格式：qti、moodle、gift 或 markdown，不填时按扩展名和内容识别"
+// @Param dry_run formData bool false "只校验不保存" default(true)
+// @Param skip_invalid formData bool false "跳过无效题目" default(false)
+// @Param score formData int false "文件中没有分值的题目使用的分值"
+// @Param knowledge_point formData string false "知识点"
+// @Param difficulty formData string false "难度：easy、medium 或 hard" default(medium)
+// @Param chapter formData string false "章节"
+// @Param scope formData string false "共享范围：private 或 school" default(private)
+// @Success 200 {object} response.Response{data=model.QuestionImportReport} "校验或导入成功"
+// @Failure 400 {object} response.Response{data=model.QuestionImportReport} "文件无效或存在无效题目"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/question-bank/import [post]
+func (c *QuestionBankController) Import(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		c.ParamError("请上传题目文件")
+		return
+	}
+
+	var req model.BankImportRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		logger.Logger.Warn("Invalid question bank import request",
+			zap.Error(err),
+		)
+		c.ParamError("导入题库参数无效")
+		return
+	}
+	opts, ok := questionImportOptions(ctx)
+	if !ok {
+		c.ParamError("分值必须为正整数")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	report, err := c.questionBankService.ImportItems(ctx.Request.Context(), userID, file, &req, opts)
+	if err != nil {
+		logger.Logger.Warn("Failed to import question bank items",
+			zap.Error(err),
+			zap.Uint("owner_id", userID),
+			zap.String("filename", file.Filename),
+		)
+		if msg := questionFileErrorMessage(err); msg != "" {
+			c.ParamError(msg)
+			return
+		}
+		if errors.Is(err, service.ErrQuestionImportRejected) {
+			c.FailWithData(400, err.Error(), report)
+			return
+		}
+		c.handleError(err)
+		return
+	}
+
+	if report.Applied {
+		logger.Logger.Info("Question bank items imported",
+			zap.Uint("owner_id", userID),
+			zap.String("format", report.Format),
+			zap.Int("imported", report.Summary.Imported),
+			zap.Int("skipped", report.Summary.Invalid),
+		)
+		c.SuccessWithMessage("导入题库成功", report)
+		return
+	}
+	c.Success(report)
+}
+
+// currentUserID 获取当前登录用户ID，失败时写入未授权响应
+func (c *QuestionBankController) currentUserID(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return 0, false
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return 0, false
+	}
+	return uid, true
+}
+
+// handleError 将服务层错误映射为响应
+func (c *QuestionBankController) handleError(err error) {
+	switch {
+	case errors.Is(err, service.ErrBankItemNotFound), errors.Is(err, service.ErrQuestionNotFound),
+		errors.Is(err, service.ErrAssignmentNotFound):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrAccessDenied):
+		c.Fail(403, err.Error())
+	case errors.Is(err, service.ErrAssignmentPublished), errors.Is(err, service.ErrInvalidBankItem):
+		c.Fail(400, err.Error())
+	default:
+		c.ServerError(err.Error())
+	}
+}
@@ -0,0 +1,264 @@
+package controller
+
+import (
+	"ai-course/internal/base/controller"
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/service"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RegradeController 成绩复核控制器
+type RegradeController struct {
+	controller.BaseController
+	regradeService service.RegradeService
+}
+
+// NewRegradeController 创建成绩复核控制器
+func NewRegradeController(regradeService service.RegradeService) *RegradeController {
+	return &RegradeController{
+		regradeService: regradeService,
+	}
+}
+
+// Create godoc
+// @Summary 申请成绩复核
+// @Description 学生在成绩发布后的复核期限内针对单题得分提出复核申请，同一题同时只能有一个待处理的申请
+// @Tags 成绩复核
+// @Accept json
+// @Produce json
+// @Param request body model.CreateRegradeRequest true "复核申请"
+// @Success 200 {object} response.Response{data=model.RegradeRequest} "申请成功"
+// @Failure 400 {object} response.Response "请求参数错误、成绩未发布或已超过申请期限"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "答案不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/submission/regrade [post]
+func (c *RegradeController) Create(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	var req model.CreateRegradeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid create regrade request",
+			zap.Error(err),
+		)
+		c.ParamError("复核申请参数无效")
+		return
+	}
+
+	studentID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	request, err := c.regradeService.CreateRequest(ctx.Request.Context(), &req, studentID)
+	if err != nil {
+		logger.Logger.Warn("Failed to create regrade request",
+			zap.Error(err),
+			zap.Uint("answer_id", req.AnswerID),
+			zap.Uint("student_id", studentID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("复核申请已提交", request)
+}
+
+// StudentList godoc
+// @Summary 获取我的复核申请
+// @Description 学生获取自己提交的复核申请及处理结果
+// @Tags 成绩复核
+// @Produce json
+// @Param assignment_id query int false "作业ID"
+// @Success 200 {object} response.Response{data=[]model.RegradeRequest} "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/submission/regrades [get]
+func (c *RegradeController) StudentList(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, _ := strconv.ParseUint(ctx.Query("assignment_id"), 10, 32)
+
+	studentID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	requests, err := c.regradeService.GetStudentRequests(ctx.Request.Context(), studentID, uint(assignmentID))
+	if err != nil {
+		logger.Logger.Error("Failed to get student regrade requests",
+			zap.Error(err),
+			zap.Uint("student_id", studentID),
+		)
+		c.ServerError(err.Error())
+		return
+	}
+
+	c.Success(requests)
+}
+
+// AssignmentList godoc
+// @Summary 获取作业复核申请
+// @Description 教师分页获取作业的复核申请，可按状态过滤
+// @Tags 成绩复核
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Param status query string false "申请状态" Enums(open,accepted,rejected)
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/grading/assignment/{assignment_id}/regrades [get]
+func (c *RegradeController) AssignmentList(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	status := model.RegradeStatus(ctx.Query("status"))
+	switch status {
+	case "", model.RegradeStatusOpen, model.RegradeStatusAccepted, model.RegradeStatusRejected:
+	default:
+		c.ParamError("申请状态无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	requests, total, err := c.regradeService.GetAssignmentRequests(ctx.Request.Context(), uint(assignmentID), teacherID, status, page, pageSize)
+	if err != nil {
+		logger.Logger.Error("Failed to get assignment regrade requests",
+			zap.Error(err),
+			zap.Uint("assignment_id", uint(assignmentID)),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(gin.H{
+		"list":  requests,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
+
+// Resolve godoc
+// @Summary 处理复核申请
+// @Description 教师接受或驳回复核申请，接受时调整题目得分并重新计算提交总分，调整前后的分数记录在申请中
+// @Tags 成绩复核
+// @Accept json
+// @Produce json
+// @Param id path int true "复核申请ID"
+// @Param request body model.ResolveRegradeRequest true "处理结果"
+// @Success 200 {object} response.Response{data=model.RegradeRequest} "处理成功"
+// @Failure 400 {object} response.Response "请求参数错误或申请已处理"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "复核申请不存在"
+// @Failure 409 {object} response.Response "答案已被修改"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/grading/regrade/{id}/resolve [post]
+func (c *RegradeController) Resolve(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("复核申请ID格式无效")
+		return
+	}
+
+	var req model.ResolveRegradeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid resolve regrade request",
+			zap.Error(err),
+		)
+		c.ParamError("处理参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	request, err := c.regradeService.ResolveRequest(ctx.Request.Context(), uint(id), &req, teacherID)
+	if err != nil {
+		logger.Logger.Warn("Failed to resolve regrade request",
+			zap.Error(err),
+			zap.Uint("regrade_request_id", uint(id)),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("复核申请已处理", request)
+}
+
+// currentUserID 获取当前登录用户ID
+func (c *RegradeController) currentUserID(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return 0, false
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return 0, false
+	}
+	return uid, true
+}
+
+// handleError 将服务层错误映射为响应
+func (c *RegradeController) handleError(err error) {
+	switch {
+	case errors.Is(err, service.ErrRegradeNotFound):
+		c.Fail(404, "复核申请不存在")
+	case errors.Is(err, service.ErrAnswerNotFound):
+		c.Fail(404, "答案不存在")
+	case errors.Is(err, service.ErrSubmissionNotFound), errors.Is(err, service.ErrAssignmentNotFound),
+		errors.Is(err, service.ErrQuestionNotFound):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrAccessDenied):
+		c.Fail(403, err.Error())
+	case errors.Is(err, service.ErrGradesNotPublished):
+		c.Fail(400, "成绩尚未发布，不能申请复核")
+	case errors.Is(err, service.ErrRegradeWindowClosed):
+		c.Fail(400, "已超过复核申请期限")
+	case errors.Is(err, service.ErrRegradeAlreadyOpen):
+		c.Fail(400, "该题已有待处理的复核申请")
+	case errors.Is(err, service.ErrRegradeResolved):
+		c.Fail(400, "复核申请已处理")
+	case errors.Is(err, service.ErrRegradeScoreRequired):
+		c.Fail(400, "接受复核申请时需要填写调整后的得分")
+	case errors.Is(err, service.ErrRegradeScoreExceeded):
+		c.Fail(400, "调整后的得分不能超过题目分值")
+	case errors.Is(err, service.ErrGradeConflict):
+		c.Conflict(err.Error())
+	default:
+		c.ServerError(err.Error())
+	}
+}
@@ -4,29 +4,44 @@ import (
 	"ai-course/internal/base/controller"
 	"ai-course/internal/logger"
 	"ai-course/internal/service"
+	"errors"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// RoleController 角色控制器
 type RoleController struct {
 	controller.BaseController
 	roleService service.RoleService
 }
 
+// NewRoleController 创建角色控制器
 func NewRoleController(roleService service.RoleService) *RoleController {
 	return &RoleController{roleService: roleService}
 }
 
-func (c *RoleController) RegisterRoutes(r *gin.Engine) {
-	roleGroup := r.Group("/api/role")
-	{
-		roleGroup.POST("/add", c.Add)
-		roleGroup.POST("/edit", c.Edit)
-		roleGroup.POST("/delete", c.Delete)
-	}
+// RegisterRoutes 在指定路由组下注册角色管理路由，调用方负责挂载权限中间件
+func (c *RoleController) RegisterRoutes(roleGroup *gin.RouterGroup) {
+	roleGroup.POST("/add", c.Add)
+	roleGroup.POST("/edit", c.Edit)
+	roleGroup.POST("/delete", c.Delete)
+	roleGroup.GET("/list", c.List)
+	roleGroup.POST("/assign", c.Assign)
 }
 
+// Add godoc
+// @Summary 添加角色
+// @Description 添加新角色（仅管理员）
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Param request body service.CreateRoleDTO true "角色信息"
+// @Success 200 {object} response.Response "添加成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/role/add [post]
 func (c *RoleController) Add(ctx *gin.Context) {
 	c.InitHandler(ctx)
 	var req service.CreateRoleDTO
@@ -37,8 +52,30 @@ func (c *RoleController) Add(ctx *gin.Context) {
 		c.ParamError("添加角色参数无效")
 		return
 	}
+
+	if err := c.roleService.Create(ctx, &req); err != nil {
+		logger.Logger.Error("Failed to add role",
+			zap.Error(err),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("添加角色成功", nil)
 }
 
+// Edit godoc
+// @Summary 编辑角色
+// @Description 修改角色名称（仅管理员）
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Param request body service.UpdateRoleDTO true "角色信息"
+// @Success 200 {object} response.Response "编辑成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "角色不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/role/edit [post]
 func (c *RoleController) Edit(ctx *gin.Context) {
 	c.InitHandler(ctx)
 	var req service.UpdateRoleDTO
@@ -49,48 +86,118 @@ func (c *RoleController) Edit(ctx *gin.Context) {
 		c.ParamError("编辑角色参数无效")
 		return
 	}
+
+	if err := c.roleService.Update(ctx, &req); err != nil {
+		logger.Logger.Error("Failed to edit role",
+			zap.Error(err),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.SuccessWithMessage("编辑角色成功", nil)
 }
 
+// Delete godoc
+// @Summary 删除角色
+// @Description 删除自定义角色，内置角色和仍有用户使用的角色不能删除（仅管理员）
+// @Tags 角色管理
+// @Produce json
+// @Param role_id query string true "角色ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "角色不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/role/delete [post]
 func (c *RoleController) Delete(ctx *gin.Context) {
 	c.InitHandler(ctx)
-	var roleId string
-	if err := ctx.ShouldBindQuery(&roleId); err != nil {
-		logger.Logger.Warn("Invalid delete role request",
+	roleId := ctx.Query("role_id")
+	if roleId == "" {
+		c.ParamError("删除角色参数无效")
+		return
+	}
+
+	if err := c.roleService.Delete(ctx, roleId); err != nil {
+		logger.Logger.Error("Failed to delete role",
 			zap.Error(err),
+			zap.String("role_id", roleId),
 		)
-		c.ParamError("删除角色参数无效")
+		c.handleError(err)
 		return
 	}
+	c.SuccessWithMessage("删除角色成功", nil)
+}
 
-	err := c.roleService.Delete(ctx, roleId)
+// List godoc
+// @Summary 获取角色列表
+// @Description 获取全部角色（仅管理员）
+// @Tags 角色管理
+// @Produce json
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/role/list [get]
+func (c *RoleController) List(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	roles, err := c.roleService.GetAll(ctx)
 	if err != nil {
-		logger.Logger.Error("Failed to delete role",
+		logger.Logger.Error("Failed to get role list",
 			zap.Error(err),
 		)
 		c.ServerError(err.Error())
 		return
 	}
-	c.Success("删除角色成功")
+	c.SuccessWithMessage("获取角色列表成功", roles)
 }
 
-func (c *RoleController) List(ctx *gin.Context) {
+// Assign godoc
+// @Summary 分配用户角色
+// @Description 为指定用户设置角色（仅管理员）
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Param request body service.AssignRoleDTO true "用户及角色"
+// @Success 200 {object} response.Response "分配成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "用户或角色不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/role/assign [post]
+func (c *RoleController) Assign(ctx *gin.Context) {
 	c.InitHandler(ctx)
-	var roleId string
-	if err := ctx.ShouldBindQuery(&roleId); err != nil {
-		logger.Logger.Warn("Invalid list role request",
+	var req service.AssignRoleDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid assign role request",
 			zap.Error(err),
 		)
-		c.ParamError("获取角色列表参数无效")
+		c.ParamError("分配角色参数无效")
 		return
 	}
 
-	roles, err := c.roleService.GetById(ctx, roleId)
+	user, err := c.roleService.AssignToUser(ctx, &req)
 	if err != nil {
-		logger.Logger.Error("Failed to get role list",
+		logger.Logger.Error("Failed to assign role",
 			zap.Error(err),
+			zap.Uint("user_id", req.UserID),
+			zap.String("role_id", req.RoleId),
 		)
-		c.ServerError(err.Error())
+		c.handleError(err)
 		return
 	}
-	c.SuccessWithMessage("获取角色列表成功", roles)
+
+	logger.Logger.Info("Role assigned",
+		zap.Uint("user_id", req.UserID),
+		zap.String("role_id", req.RoleId),
+	)
+	c.SuccessWithMessage("分配角色成功", user)
+}
+
+// handleError 将服务层错误映射为响应
+func (c *RoleController) handleError(err error) {
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound), errors.Is(err, service.ErrUserNotFound):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrRoleExists), errors.Is(err, service.ErrBuiltinRole), errors.Is(err, service.ErrRoleInUse):
+		c.Fail(400, err.Error())
+	default:
+		c.ServerError(err.Error())
+	}
 }
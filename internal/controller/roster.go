@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"ai-course/internal/base/controller"
+	"ai-course/internal/logger"
+	"ai-course/internal/pkg/export"
+	"ai-course/internal/service"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RosterController 用户批量导入控制器
+type RosterController struct {
+	controller.BaseController
+	rosterService service.RosterService
+}
+
+// NewRosterController 创建用户批量导入控制器
+func NewRosterController(rosterService service.RosterService) *RosterController {
+	return &RosterController{rosterService: rosterService}
+}
+
+// RegisterRoutes 在指定路由组下注册用户导入路由，调用方负责挂载权限中间件
+func (c *RosterController) RegisterRoutes(importGroup *gin.RouterGroup) {
+	importGroup.POST("/preview", c.Preview)
+	importGroup.POST("", c.Import)
+}
+
+// Preview godoc
+// @Summary 预览用户导入
+// @Description 上传 CSV 或 XLSX 用户表（仅管理员），表头识别“学号”“姓名”“角色”“班级”列，学号和姓名列必须存在。
+// @Description 角色填写角色ID或角色名称，留空时新用户为学生、已有用户不变；班级填写班级邀请码，可留空。
+// @Description 按学号匹配已有用户，返回每一行将新建、更新还是无变化，以及学号重复、角色或班级不存在等无法导入的原因，不保存任何数据
+// @Tags 用户管理
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "用户表（.csv 或 .xlsx）"
+// @Success 200 {object} response.Response{data=model.RosterImportReport} "预览成功"
+// @Failure 400 {object} response.Response "文件无效或缺少必需的列"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/user/import/preview [post]
+func (c *RosterController) Preview(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		c.ParamError("请上传用户表文件")
+		return
+	}
+
+	report, err := c.rosterService.Preview(ctx.Request.Context(), file)
+	if err != nil {
+		logger.Logger.Warn("Failed to preview roster import",
+			zap.Error(err),
+			zap.String("filename", file.Filename),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(report)
+}
+
+// Import godoc
+// @Summary 导入用户
+// @Description 上传与预览相同的用户表（仅管理员），所有行都有效时在同一事务中新建或更新用户并加入班级，否则返回预览报告且不保存任何数据。
+// @Description 已有用户只更新姓名、角色和班级，不修改密码，重复导入同一文件不会产生变化；新用户生成随机初始密码。
+// @Description 导入成功后返回账号表文件（学号、姓名、角色、班级、状态、初始密码），初始密码只在此时提供一次
+// @Tags 用户管理
+// @Accept multipart/form-data
+// @Produce application/octet-stream
+// @Param file formData file true "用户表（.csv 或 .xlsx）"
+// @Param format formData string false "账号表格式 csv 或 xlsx" default(csv)
+// @Param bom formData bool false "CSV 是否写入 UTF-8 BOM（Excel 打开中文需要）" default(true)
+// @Success 200 {file} file "账号表"
+// @Failure 400 {object} response.Response{data=model.RosterImportReport} "文件无效或存在无法导入的行"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/user/import [post]
+func (c *RosterController) Import(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		c.ParamError("请上传用户表文件")
+		return
+	}
+	format := ctx.PostForm("format")
+	bom := ctx.DefaultPostForm("bom", "true") != "false"
+
+	opened := false
+	report, err := c.rosterService.Import(ctx.Request.Context(), file, format, bom, attachmentOpener(ctx, "accounts", &opened))
+	if err != nil {
+		logger.Logger.Error("Failed to import roster",
+			zap.Error(err),
+			zap.String("filename", file.Filename),
+			zap.Bool("applied", report != nil && report.Applied),
+			zap.Bool("streaming", opened),
+		)
+		switch {
+		case opened:
+			ctx.Abort()
+		case errors.Is(err, service.ErrRosterImportRejected):
+			c.FailWithData(400, err.Error(), report)
+		default:
+			c.handleError(err)
+		}
+		return
+	}
+
+	logger.Logger.Info("Roster import applied",
+		zap.String("filename", file.Filename),
+		zap.Int("created", report.Summary.Create),
+		zap.Int("updated", report.Summary.Update),
+		zap.Int("unchanged", report.Summary.Unchanged),
+	)
+}
+
+// handleError 将服务层错误映射为响应
+func (c *RosterController) handleError(err error) {
+	switch {
+	case errors.Is(err, service.ErrRosterImportHeader):
+		c.ParamError(err.Error())
+	case errors.Is(err, service.ErrExportFormatInvalid):
+		c.ParamError("账号表格式仅支持 csv 和 xlsx")
+	case errors.Is(err, export.ErrUnsupportedFormat):
+		c.ParamError("仅支持 CSV 和 XLSX 文件")
+	case errors.Is(err, export.ErrTooLarge):
+		c.ParamError("文件过大")
+	case errors.Is(err, export.ErrInvalidFile):
+		c.ParamError("无法解析用户表文件")
+	default:
+		c.ServerError(err.Error())
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"ai-course/internal/base/controller"
 	basemiddleware "ai-course/internal/base/middleware"
+	"ai-course/internal/config"
 	"ai-course/internal/logger"
 	"ai-course/internal/middleware"
 	"ai-course/internal/service"
@@ -14,29 +15,53 @@ import (
 
 // Router 路由管理器
 type Router struct {
-	engine            *gin.Engine
-	userService       service.UserService
-	classService      service.ClassService
-	assignmentService service.AssignmentService
-	questionService   service.QuestionService
-	submissionService service.SubmissionService
-	gradingService    service.GradingService
-	attachmentService service.AttachmentService
-	baseCtrl          *controller.BaseController
+	engine              *gin.Engine
+	cfg                 *config.Config
+	userService         service.UserService
+	classService        service.ClassService
+	assignmentService   service.AssignmentService
+	questionService     service.QuestionService
+	submissionService   service.SubmissionService
+	gradingService      service.GradingService
+	attachmentService   service.AttachmentService
+	enrollmentService   service.EnrollmentService
+	roleService         service.RoleService
+	accessService       service.AccessService
+	lessonPlanService   service.LessonPlanService
+	questionBankService service.QuestionBankService
+	regradeService      service.RegradeService
+	extensionService    service.ExtensionService
+	templateService     service.TemplateService
+	gradebookService    service.GradebookService
+	exportService       service.ExportService
+	rosterService       service.RosterService
+	baseCtrl            *controller.BaseController
 }
 
 // NewRouter 创建路由管理器
-func NewRouter(engine *gin.Engine, userService service.UserService, classService service.ClassService, assignmentService service.AssignmentService, questionService service.QuestionService, submissionService service.SubmissionService, gradingService service.GradingService, attachmentService service.AttachmentService) *Router {
+func NewRouter(engine *gin.Engine, cfg *config.Config, userService service.UserService, classService service.ClassService, assignmentService service.AssignmentService, questionService service.QuestionService, submissionService service.SubmissionService, gradingService service.GradingService, attachmentService service.AttachmentService, enrollmentService service.EnrollmentService, roleService service.RoleService, accessService service.AccessService, lessonPlanService service.LessonPlanService, questionBankService service.QuestionBankService, regradeService service.RegradeService, extensionService service.ExtensionService, templateService service.TemplateService, gradebookService service.GradebookService, exportService service.ExportService, rosterService service.RosterService) *Router {
 	return &Router{
-		engine:            engine,
-		userService:       userService,
-		classService:      classService,
-		assignmentService: assignmentService,
-		questionService:   questionService,
-		submissionService: submissionService,
-		gradingService:    gradingService,
-		attachmentService: attachmentService,
-		baseCtrl:          &controller.BaseController{},
+		engine:              engine,
+		cfg:                 cfg,
+		userService:         userService,
+		classService:        classService,
+		assignmentService:   assignmentService,
+		questionService:     questionService,
+		submissionService:   submissionService,
+		gradingService:      gradingService,
+		attachmentService:   attachmentService,
+		enrollmentService:   enrollmentService,
+		roleService:         roleService,
+		accessService:       accessService,
+		lessonPlanService:   lessonPlanService,
+		questionBankService: questionBankService,
+		regradeService:      regradeService,
+		extensionService:    extensionService,
+		templateService:     templateService,
+		gradebookService:    gradebookService,
+		exportService:       exportService,
+		rosterService:       rosterService,
+		baseCtrl:            &controller.BaseController{},
 	}
 }
 
@@ -55,7 +80,8 @@ func (r *Router) RegisterRoutes() {
 	r.engine.Use(basemiddleware.APILogger()) // 添加API日志中间件
 
 	// 创建中间件实例
-	roleMiddleware := middleware.NewRoleMiddleware(r.userService)
+	roleMiddleware := middleware.NewRoleMiddleware(r.userService, r.cfg)
+	ownershipMiddleware := middleware.NewResourceOwnershipMiddleware(r.accessService)
 	
 	// HealthCheck godoc
 	// @Summary 健康检查
@@ -96,72 +122,203 @@ func (r *Router) RegisterRoutes() {
 	apiGroup := r.engine.Group("/api")
 	apiGroup.Use(middleware.AuthMiddleware()) // 所有API都需要认证
 	{
-		// 班级路由组（需要管理员或教师权限）
+		// 角色管理路由组（仅管理员）
+		roleController := NewRoleController(r.roleService)
+		roleGroup := apiGroup.Group("/role")
+		roleGroup.Use(roleMiddleware.RequirePermission(middleware.PermRoleManage))
+		roleController.RegisterRoutes(roleGroup)
+
+		// 用户批量导入路由组（仅管理员）
+		rosterController := NewRosterController(r.rosterService)
+		rosterGroup := apiGroup.Group("/user/import")
+		rosterGroup.Use(roleMiddleware.RequirePermission(middleware.PermRoleManage))
+		rosterController.RegisterRoutes(rosterGroup)
+
+		// 班级路由组
 		classController := NewClassController(r.classService)
+		enrollmentController := NewEnrollmentController(r.enrollmentService)
 		classGroup := apiGroup.Group("/class")
-		classGroup.Use(roleMiddleware.RequireRole("admin", "teacher"))
 		{
-			classGroup.POST("/add", classController.Add)
-			classGroup.PUT("/:id", classController.Edit)
-			classGroup.DELETE("/:id", classController.Delete)
-			classGroup.GET("/list", classController.List)
+			// 学生加入/退出班级
+			classGroup.POST("/join", roleMiddleware.RequirePermission(middleware.PermClassJoin), enrollmentController.Join)                // 通过邀请码加入班级
+			classGroup.POST("/:id/leave", roleMiddleware.RequirePermission(middleware.PermClassJoin), enrollmentController.Leave)          // 退出班级
+			classGroup.GET("/mine", roleMiddleware.RequirePermission(middleware.PermClassJoin), enrollmentController.MyClasses)            // 获取我的班级
+
+			manageClassGroup := classGroup.Group("")
+			manageClassGroup.Use(roleMiddleware.RequirePermission(middleware.PermClassManage))
+			{
+				manageClassGroup.POST("/add", classController.Add)
+				manageClassGroup.PUT("/:id", ownershipMiddleware.CheckClassOwnership(), classController.Edit)
+				manageClassGroup.DELETE("/:id", ownershipMiddleware.CheckClassOwnership(), classController.Delete)
+				manageClassGroup.GET("/list", classController.List)
+
+				// 班级成员管理
+				manageClassGroup.GET("/:id/students", enrollmentController.ListStudents)                          // 获取班级学生列表
+				manageClassGroup.POST("/:id/students", enrollmentController.AddStudents)                          // 添加学生
+				manageClassGroup.DELETE("/:id/students/:student_id", enrollmentController.RemoveStudent)          // 移除学生
+
+				// 协同授课教师管理
+				manageClassGroup.GET("/:id/teachers", enrollmentController.ListTeachers)                          // 获取协同授课教师列表
+				manageClassGroup.POST("/:id/teachers", enrollmentController.AddTeacher)                           // 添加协同授课教师
+				manageClassGroup.DELETE("/:id/teachers/:teacher_id", enrollmentController.RemoveTeacher)          // 移除协同授课教师
+			}
 		}
 
 		// 作业路由组
 		assignmentController := NewAssignmentController(r.assignmentService)
+		extensionController := NewExtensionController(r.extensionService)
+		templateController := NewTemplateController(r.templateService)
 		assignmentGroup := apiGroup.Group("/assignment")
 		{
 			// 教师专用路由
 			teacherAssignmentGroup := assignmentGroup.Group("")
-			teacherAssignmentGroup.Use(roleMiddleware.RequireTeacher())
+			teacherAssignmentGroup.Use(roleMiddleware.RequirePermission(middleware.PermAssignmentWrite))
 			{
 				teacherAssignmentGroup.POST("", assignmentController.Create)                        // 创建作业
-				teacherAssignmentGroup.PUT("/:id", assignmentController.Update)                     // 更新作业
-				teacherAssignmentGroup.DELETE("/:id", assignmentController.Delete)                  // 删除作业
-				teacherAssignmentGroup.POST("/:id/publish", assignmentController.Publish)          // 发布作业
-				teacherAssignmentGroup.POST("/:id/unpublish", assignmentController.Unpublish)      // 取消发布作业
-				teacherAssignmentGroup.GET("/:id/statistics", assignmentController.Statistics)     // 获取作业统计
+				teacherAssignmentGroup.PUT("/:id", ownershipMiddleware.CheckAssignmentOwnership(), assignmentController.Update)                 // 更新作业
+				teacherAssignmentGroup.DELETE("/:id", ownershipMiddleware.CheckAssignmentOwnership(), assignmentController.Delete)              // 删除作业
+				teacherAssignmentGroup.GET("/:id/statistics", ownershipMiddleware.CheckAssignmentOwnership(), assignmentController.Statistics) // 获取作业统计
+				teacherAssignmentGroup.POST("/:id/extensions", ownershipMiddleware.CheckAssignmentOwnership(), extensionController.Grant)                  // 设置学生延期
+				teacherAssignmentGroup.GET("/:id/extensions", ownershipMiddleware.CheckAssignmentOwnership(), extensionController.List)                   // 获取延期列表
+				teacherAssignmentGroup.DELETE("/:id/extensions/:student_id", ownershipMiddleware.CheckAssignmentOwnership(), extensionController.Revoke) // 取消学生延期
+				teacherAssignmentGroup.POST("/:id/clone", ownershipMiddleware.CheckAssignmentOwnership(), templateController.Clone)                       // 复制作业到其他班级
+				teacherAssignmentGroup.POST("/:id/template", ownershipMiddleware.CheckAssignmentOwnership(), templateController.SaveTemplate)             // 保存为作业模板
+			}
+
+			// 发布权限可单独授予（例如助教只能编辑不能发布）
+			publishAssignmentGroup := assignmentGroup.Group("")
+			publishAssignmentGroup.Use(roleMiddleware.RequirePermission(middleware.PermAssignmentPublish), ownershipMiddleware.CheckAssignmentOwnership())
+			{
+				publishAssignmentGroup.POST("/:id/publish", assignmentController.Publish)          // 发布作业
+				publishAssignmentGroup.POST("/:id/unpublish", assignmentController.Unpublish)      // 取消发布作业
 			}
 
 			// 教师和学生都可访问的路由
-			assignmentGroup.GET("/:id", assignmentController.Detail)     // 获取作业详情
+			assignmentGroup.GET("/:id", ownershipMiddleware.CheckAssignmentAccess(), assignmentController.Detail) // 获取作业详情
 			assignmentGroup.GET("/list", assignmentController.List)      // 获取作业列表
 		}
 
+		// 作业模板路由组（教师专用，服务层校验模板归属）
+		templateGroup := apiGroup.Group("/assignment-template")
+		templateGroup.Use(roleMiddleware.RequirePermission(middleware.PermAssignmentWrite))
+		{
+			templateGroup.GET("/list", templateController.List)                    // 获取作业模板列表
+			templateGroup.GET("/:id", templateController.Detail)                   // 获取作业模板详情
+			templateGroup.DELETE("/:id", templateController.Delete)                // 删除作业模板
+			templateGroup.POST("/:id/instantiate", templateController.Instantiate) // 由模板创建作业
+		}
+
 		// 题目路由组（独立路由组，避免冲突）
 		questionController := NewQuestionController(r.questionService, r.assignmentService)
 		questionGroup := apiGroup.Group("/question")
-		questionGroup.Use(roleMiddleware.RequireTeacher()) // 只有教师可以管理题目
+		questionGroup.Use(roleMiddleware.RequirePermission(middleware.PermQuestionWrite)) // 只有教师可以管理题目
+		questionGroup.Use(ownershipMiddleware.CheckAssignmentOwnership())                 // 只能管理自己任课班级的作业题目
 		{
 			questionGroup.POST("/assignment/:assignment_id", questionController.Create)                             // 创建题目
 			questionGroup.PUT("/assignment/:assignment_id/:question_id", questionController.Update)                // 更新题目
 			questionGroup.DELETE("/assignment/:assignment_id/:question_id", questionController.Delete)             // 删除题目
 			questionGroup.GET("/assignment/:assignment_id", questionController.List)                               // 获取题目列表
+			questionGroup.POST("/assignment/:assignment_id/batch", questionController.BatchCreate)                 // 批量创建题目
+			questionGroup.POST("/assignment/:assignment_id/generate", questionController.Generate)                 // AI 生成题目草稿
+			questionGroup.POST("/assignment/:assignment_id/import", questionController.Import)                     // 从 QTI、Moodle XML、GIFT 或 Markdown 文件导入题目
+			questionGroup.GET("/assignment/:assignment_id/export", questionController.Export)                      // 导出题目文件
+		}
+
+		// 题库路由组（教师专用）
+		questionBankController := NewQuestionBankController(r.questionBankService)
+		questionBankGroup := apiGroup.Group("/question-bank")
+		questionBankGroup.Use(roleMiddleware.RequirePermission(middleware.PermQuestionBankWrite))
+		{
+			questionBankGroup.POST("", questionBankController.Create)                                                                         // 添加题库题目
+			questionBankGroup.GET("/list", questionBankController.Search)                                                                     // 搜索题库
+			questionBankGroup.GET("/:id", questionBankController.Detail)                                                                      // 获取题库题目
+			questionBankGroup.PUT("/:id", questionBankController.Update)                                                                      // 更新题库题目
+			questionBankGroup.DELETE("/:id", questionBankController.Delete)                                                                   // 删除题库题目
+			questionBankGroup.POST("/assignment/:assignment_id", ownershipMiddleware.CheckAssignmentOwnership(), questionBankController.AddToAssignment) // 从题库添加题目到作业
+			questionBankGroup.POST("/from-question/:question_id", questionBankController.SaveFromQuestion)                                    // 将作业题目保存到题库
+			questionBankGroup.POST("/import", questionBankController.Import)                                                                  // 从题目文件导入题库
 		}
 
 		// 提交路由组（学生专用）
-		submissionController := NewSubmissionController(r.submissionService)
+		submissionController := NewSubmissionController(r.submissionService, r.assignmentService)
+		regradeController := NewRegradeController(r.regradeService)
 		submissionGroup := apiGroup.Group("/submission")
-		submissionGroup.Use(roleMiddleware.RequireStudent()) // 只有学生可以提交作业
+		submissionGroup.Use(roleMiddleware.RequirePermission(middleware.PermSubmissionWrite)) // 只有学生可以提交作业
 		{
 			submissionGroup.POST("/draft", submissionController.SaveDraft)                                    // 保存草稿
 			submissionGroup.POST("/submit", submissionController.Submit)                                      // 提交作业
 			submissionGroup.GET("/student/assignments", submissionController.GetStudentAssignments)          // 获取学生作业列表
-			submissionGroup.GET("/assignment/:assignment_id", submissionController.GetAssignmentForStudent)  // 获取学生特定作业详情
-			submissionGroup.GET("/:id", submissionController.GetSubmissionDetail)                            // 获取提交详情
+			submissionGroup.GET("/assignment/:assignment_id", ownershipMiddleware.CheckAssignmentAccess(), submissionController.GetAssignmentForStudent) // 获取学生特定作业详情
+			submissionGroup.POST("/assignment/:assignment_id/attempt", ownershipMiddleware.CheckAssignmentAccess(), submissionController.StartAttempt) // 开始新的作答
+			submissionGroup.GET("/assignment/:assignment_id/attempts", ownershipMiddleware.CheckAssignmentAccess(), submissionController.GetAttempts)  // 获取我的作答记录
+			submissionGroup.GET("/:id", ownershipMiddleware.CheckSubmissionOwnership(), submissionController.GetSubmissionDetail)                      // 获取提交详情
+			submissionGroup.POST("/regrade", regradeController.Create)                                       // 申请成绩复核
+			submissionGroup.GET("/regrades", regradeController.StudentList)                                   // 获取我的复核申请
 		}
 
 		// 批改路由组（教师专用）
 		gradingController := NewGradingController(r.submissionService, r.gradingService)
+		exportController := NewExportController(r.exportService)
 		gradingGroup := apiGroup.Group("/grading")
-		gradingGroup.Use(roleMiddleware.RequireTeacher()) // 只有教师可以批改
 		{
-			gradingGroup.GET("/assignment/:assignment_id/submissions", gradingController.GetSubmissionsForGrading)    // 获取待批改提交列表
-			gradingGroup.GET("/submission/:submission_id", gradingController.GetGradingDetail)                         // 获取批改详情
-			gradingGroup.POST("/submission/:submission_id", gradingController.GradeSubmission)                        // 批改提交
-			gradingGroup.POST("/batch", gradingController.BatchGrade)                                                  // 批量批改
-			gradingGroup.POST("/assignment/:assignment_id/publish", gradingController.PublishGrades)                  // 发布成绩
-			gradingGroup.GET("/assignment/:assignment_id/progress", gradingController.GetGradingProgress)             // 获取批改进度
+			readGrading := roleMiddleware.RequirePermission(middleware.PermGradingRead)
+			writeGrading := roleMiddleware.RequirePermission(middleware.PermGradingWrite) // 只有教师可以批改
+			ownAssignment := ownershipMiddleware.CheckAssignmentOwnership()
+			ownSubmission := ownershipMiddleware.CheckSubmissionOwnership()
+			gradingGroup.GET("/assignment/:assignment_id/submissions", readGrading, ownAssignment, gradingController.GetSubmissionsForGrading) // 获取待批改提交列表
+			gradingGroup.GET("/submission/:submission_id", readGrading, ownSubmission, gradingController.GetGradingDetail)                     // 获取批改详情
+			gradingGroup.GET("/submission/:submission_id/timeline", ownSubmission, gradingController.GetGradeTimeline)                          // 获取成绩变更记录（任课教师或学生本人）
+			gradingGroup.POST("/submission/:submission_id", writeGrading, ownSubmission, gradingController.GradeSubmission)                    // 批改提交
+			gradingGroup.POST("/submission/:submission_id/ai-suggest", writeGrading, ownSubmission, gradingController.SuggestGrades)          // 生成AI批改建议
+			gradingGroup.POST("/submission/:submission_id/claim", writeGrading, ownSubmission, gradingController.ClaimSubmission)              // 领取批改
+			gradingGroup.DELETE("/submission/:submission_id/claim", writeGrading, ownSubmission, gradingController.ReleaseSubmission)          // 释放批改
+			gradingGroup.POST("/assignment/:assignment_id/next", writeGrading, ownAssignment, gradingController.ClaimNextSubmission)          // 领取下一份待批改提交
+			gradingGroup.POST("/batch", writeGrading, gradingController.BatchGrade)                                                            // 批量批改（逐条校验权限）
+			gradingGroup.POST("/assignment/:assignment_id/publish", writeGrading, ownAssignment, gradingController.PublishGrades)              // 发布成绩
+			gradingGroup.GET("/assignment/:assignment_id/progress", readGrading, ownAssignment, gradingController.GetGradingProgress)         // 获取批改进度
+			gradingGroup.GET("/assignment/:assignment_id/export", readGrading, ownAssignment, exportController.AssignmentGrades)               // 导出作业成绩（CSV/XLSX）
+			gradingGroup.POST("/assignment/:assignment_id/import", writeGrading, ownAssignment, gradingController.ImportGrades)               // 导入作业成绩（默认只比对）
+			gradingGroup.GET("/assignment/:assignment_id/regrades", readGrading, ownAssignment, regradeController.AssignmentList)             // 获取作业复核申请
+			gradingGroup.POST("/regrade/:id/resolve", writeGrading, regradeController.Resolve)                                               // 处理复核申请（服务层校验权限）
+		}
+
+		// 成绩册路由组
+		gradebookController := NewGradebookController(r.gradebookService)
+		gradebookGroup := apiGroup.Group("/gradebook")
+		{
+			readGrading := roleMiddleware.RequirePermission(middleware.PermGradingRead)
+			writeGrading := roleMiddleware.RequirePermission(middleware.PermGradingWrite)
+			ownAssignment := ownershipMiddleware.CheckAssignmentOwnership()
+			gradebookGroup.GET("/class/:class_id", readGrading, gradebookController.ClassGradebook)                                        // 获取班级成绩册（服务层校验任课教师）
+			gradebookGroup.GET("/class/:class_id/export", readGrading, exportController.ClassGradebook)                                    // 导出班级成绩册（CSV/XLSX）
+			gradebookGroup.GET("/class/:class_id/settings", readGrading, gradebookController.Settings)                                     // 获取成绩册设置
+			gradebookGroup.PUT("/class/:class_id/settings", writeGrading, gradebookController.UpdateSettings)                              // 更新成绩分类和等级
+			gradebookGroup.PUT("/assignment/:assignment_id/category", writeGrading, ownAssignment, gradebookController.SetCategory)         // 设置作业成绩分类
+			gradebookGroup.PUT("/assignment/:assignment_id/marks/:student_id", writeGrading, ownAssignment, gradebookController.Mark)       // 标记免做或缺交
+			gradebookGroup.DELETE("/assignment/:assignment_id/marks/:student_id", writeGrading, ownAssignment, gradebookController.ClearMark) // 清除标记
+			gradebookGroup.GET("/class/:class_id/me", gradebookController.MySummary)                                                       // 获取个人成绩汇总（班级学生）
+		}
+
+		// 教案路由组
+		lessonPlanController := NewLessonPlanController(r.lessonPlanService)
+		lessonPlanGroup := apiGroup.Group("/lesson-plan")
+		{
+			// 教师管理教案
+			teacherLessonPlanGroup := lessonPlanGroup.Group("")
+			teacherLessonPlanGroup.Use(roleMiddleware.RequirePermission(middleware.PermLessonPlanWrite))
+			{
+				teacherLessonPlanGroup.POST("", lessonPlanController.Create)                                                              // 创建教案
+				teacherLessonPlanGroup.GET("/list", lessonPlanController.List)                                                            // 获取教师教案列表
+				teacherLessonPlanGroup.PUT("/:id", ownershipMiddleware.CheckLessonPlanOwnership(), lessonPlanController.Update)           // 更新教案
+				teacherLessonPlanGroup.DELETE("/:id", ownershipMiddleware.CheckLessonPlanOwnership(), lessonPlanController.Delete)        // 删除教案
+				teacherLessonPlanGroup.POST("/:id/publish", ownershipMiddleware.CheckLessonPlanOwnership(), lessonPlanController.Publish) // 发布教案
+				teacherLessonPlanGroup.POST("/:id/unpublish", ownershipMiddleware.CheckLessonPlanOwnership(), lessonPlanController.Unpublish) // 取消发布教案
+				teacherLessonPlanGroup.POST("/:id/clone", ownershipMiddleware.CheckLessonPlanOwnership(), lessonPlanController.Clone)     // 复制教案到其他班级
+			}
+
+			// 班级成员查看教案（学生只能看到已发布的教案）
+			lessonPlanGroup.GET("/class/:class_id", lessonPlanController.ClassList)                                   // 获取班级教案列表
+			lessonPlanGroup.GET("/:id", ownershipMiddleware.CheckLessonPlanAccess(), lessonPlanController.Detail)    // 获取教案详情
 		}
 
 		// 附件路由组
@@ -169,13 +326,13 @@ func (r *Router) RegisterRoutes() {
 		attachmentGroup := apiGroup.Group("/attachment")
 		{
 			// 上传附件（教师专用）
-			attachmentGroup.POST("/assignment/:assignment_id", roleMiddleware.RequireTeacher(), attachmentController.Upload)
+			attachmentGroup.POST("/assignment/:assignment_id", roleMiddleware.RequirePermission(middleware.PermAttachmentWrite), ownershipMiddleware.CheckAssignmentOwnership(), attachmentController.Upload)
 			// 删除附件（教师专用）
-			attachmentGroup.DELETE("/:id", roleMiddleware.RequireTeacher(), attachmentController.Delete)
+			attachmentGroup.DELETE("/:id", roleMiddleware.RequirePermission(middleware.PermAttachmentWrite), ownershipMiddleware.CheckAttachmentOwnership(), attachmentController.Delete)
 			
-			// 查看和下载附件（教师和学生都可以）
-			attachmentGroup.GET("/assignment/:assignment_id", attachmentController.GetByAssignment)  // 获取作业附件列表
-			attachmentGroup.GET("/:id/download", attachmentController.Download)                      // 下载附件
+			// 查看和下载附件（仅所属班级的教师和学生）
+			attachmentGroup.GET("/assignment/:assignment_id", ownershipMiddleware.CheckAssignmentAccess(), attachmentController.GetByAssignment) // 获取作业附件列表
+			attachmentGroup.GET("/:id/download", ownershipMiddleware.CheckAttachmentAccess(), attachmentController.Download)                     // 下载附件
 		}
 	}
 }
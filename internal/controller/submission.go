@@ -5,6 +5,7 @@ import (
 	"ai-course/internal/logger"
 	"ai-course/internal/model"
 	"ai-course/internal/service"
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -15,12 +16,14 @@ import (
 type SubmissionController struct {
 	controller.BaseController
 	submissionService service.SubmissionService
+	assignmentService service.AssignmentService
 }
 
 // NewSubmissionController 创建提交控制器
-func NewSubmissionController(submissionService service.SubmissionService) *SubmissionController {
+func NewSubmissionController(submissionService service.SubmissionService, assignmentService service.AssignmentService) *SubmissionController {
 	return &SubmissionController{
 		submissionService: submissionService,
+		assignmentService: assignmentService,
 	}
 }
 
@@ -73,13 +76,15 @@ func (c *SubmissionController) SaveDraft(ctx *gin.Context) {
 			zap.Uint("assignment_id", req.AssignmentID),
 		)
 		
-		switch err.Error() {
-		case "assignment not found":
+		switch msg := err.Error(); {
+		case msg == "assignment not found":
 			c.Fail(404, "作业不存在")
-		case "assignment is not published":
+		case msg == "assignment is not published":
 			c.Fail(400, "作业未发布")
-		case "cannot change submitted assignment back to draft":
-			c.Fail(400, "已提交的作业不能修改为草稿")
+		case msg == "assignment already submitted":
+			c.Fail(400, "作业已提交，如需重新作答请开始新的作答")
+		case errors.Is(err, service.ErrAttemptNotStarted), errors.Is(err, service.ErrTimeLimitExceeded):
+			c.Fail(400, err.Error())
 		default:
 			c.ServerError(err.Error())
 		}
@@ -144,15 +149,17 @@ func (c *SubmissionController) Submit(ctx *gin.Context) {
 			zap.Uint("assignment_id", req.AssignmentID),
 		)
 		
-		switch err.Error() {
-		case "assignment not found":
+		switch msg := err.Error(); {
+		case msg == "assignment not found":
 			c.Fail(404, "作业不存在")
-		case "assignment is not published":
+		case msg == "assignment is not published":
 			c.Fail(400, "作业未发布")
-		case "assignment deadline has passed":
+		case msg == "assignment deadline has passed":
 			c.Fail(400, "作业已过截止时间")
-		case "assignment already submitted":
+		case msg == "assignment already submitted":
 			c.Fail(400, "作业已提交")
+		case errors.Is(err, service.ErrAttemptNotStarted), errors.Is(err, service.ErrTimeLimitExceeded):
+			c.Fail(400, err.Error())
 		default:
 			c.ServerError(err.Error())
 		}
@@ -170,7 +177,7 @@ func (c *SubmissionController) Submit(ctx *gin.Context) {
 
 // GetStudentAssignments godoc
 // @Summary 获取学生作业列表
-// @Description 获取当前学生的所有作业列表
+// @Description 获取当前学生所在班级的全部已发布作业及提交状态
 // @Tags 作业提交
 // @Produce json
 // @Param page query int false "页码" default(1)
@@ -209,7 +216,7 @@ func (c *SubmissionController) GetStudentAssignments(ctx *gin.Context) {
 		return
 	}
 
-	assignments, total, err := c.submissionService.GetStudentSubmissions(ctx.Request.Context(), studentID, page, pageSize)
+	assignments, total, err := c.assignmentService.GetStudentAssignments(ctx.Request.Context(), studentID, page, pageSize)
 	if err != nil {
 		logger.Logger.Error("Failed to get student assignments",
 			zap.Error(err),
@@ -231,7 +238,7 @@ func (c *SubmissionController) GetStudentAssignments(ctx *gin.Context) {
 
 // GetAssignmentForStudent godoc
 // @Summary 获取学生特定作业详情
-// @Description 获取学生特定作业的详细信息和提交状态
+// @Description 获取学生特定作业的详细信息和提交状态，限时作答进行中时返回服务器计算的剩余秒数
 // @Tags 作业提交
 // @Produce json
 // @Param assignment_id path int true "作业ID"
@@ -334,6 +341,133 @@ func (c *SubmissionController) GetSubmissionDetail(ctx *gin.Context) {
 		c.Fail(403, "无权限查看此提交")
 		return
 	}
+	if submission.StudentID == currentUserID {
+		if err := c.submissionService.RedactForStudent(ctx.Request.Context(), submission); err != nil {
+			logger.Logger.Error("Failed to redact submission for student",
+				zap.Error(err),
+				zap.Uint("submission_id", uint(id)),
+			)
+			c.ServerError(err.Error())
+			return
+		}
+	}
 
 	c.Success(submission)
-}
\ No newline at end of file
+}
+
+// StartAttempt godoc
+// @Summary 开始新的作答
+// @Description 上一次作答提交并自动判分后，在作答次数上限内开始新的作答，新作答为草稿。
+// @Description 限时作业从此时开始计时，到时间后不再接受答案，未提交的草稿由服务器自动交卷
+// @Tags 作业提交
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Success 200 {object} response.Response{data=model.Submission} "开始成功"
+// @Failure 400 {object} response.Response "当前作答尚未提交、已达到作答次数上限或已过截止时间"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/submission/assignment/{assignment_id}/attempt [post]
+func (c *SubmissionController) StartAttempt(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return
+	}
+	studentID, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return
+	}
+
+	submission, err := c.submissionService.StartAttempt(ctx.Request.Context(), uint(assignmentID), studentID)
+	if err != nil {
+		logger.Logger.Warn("Failed to start new attempt",
+			zap.Error(err),
+			zap.Uint("assignment_id", uint(assignmentID)),
+			zap.Uint("student_id", studentID),
+		)
+
+		switch msg := err.Error(); {
+		case errors.Is(err, service.ErrAssignmentNotFound):
+			c.Fail(404, err.Error())
+		case errors.Is(err, service.ErrAccessDenied):
+			c.Fail(403, err.Error())
+		case errors.Is(err, service.ErrAttemptInProgress), errors.Is(err, service.ErrAttemptLimitReached):
+			c.Fail(400, err.Error())
+		case msg == "assignment is not published":
+			c.Fail(400, "作业未发布")
+		case msg == "assignment deadline has passed":
+			c.Fail(400, "作业已过截止时间")
+		default:
+			c.ServerError(err.Error())
+		}
+		return
+	}
+
+	logger.Logger.Info("New attempt started",
+		zap.Uint("submission_id", submission.ID),
+		zap.Uint("student_id", studentID),
+		zap.Int("attempt", submission.Attempt),
+	)
+
+	c.SuccessWithMessage("已开始新的作答", submission)
+}
+
+// GetAttempts godoc
+// @Summary 获取我的作答记录
+// @Description 获取学生在作业上的全部作答，以及按作业计分方式（最高分/最后一次/平均分）计算的有效得分
+// @Tags 作业提交
+// @Produce json
+// @Param assignment_id path int true "作业ID"
+// @Success 200 {object} response.Response{data=model.StudentAttemptsResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/submission/assignment/{assignment_id}/attempts [get]
+func (c *SubmissionController) GetAttempts(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	assignmentID, err := strconv.ParseUint(ctx.Param("assignment_id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return
+	}
+	studentID, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return
+	}
+
+	attempts, err := c.submissionService.GetStudentAttempts(ctx.Request.Context(), uint(assignmentID), studentID)
+	if err != nil {
+		logger.Logger.Error("Failed to get attempts",
+			zap.Error(err),
+			zap.Uint("assignment_id", uint(assignmentID)),
+			zap.Uint("student_id", studentID),
+		)
+		if errors.Is(err, service.ErrAssignmentNotFound) {
+			c.Fail(404, err.Error())
+			return
+		}
+		c.ServerError(err.Error())
+		return
+	}
+
+	c.Success(attempts)
+}
@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"ai-course/internal/base/controller"
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/service"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TemplateController 作业复制和作业模板控制器
+type TemplateController struct {
+	controller.BaseController
+	templateService service.TemplateService
+}
+
+// NewTemplateController 创建作业复制和作业模板控制器
+func NewTemplateController(templateService service.TemplateService) *TemplateController {
+	return &TemplateController{
+		templateService: templateService,
+	}
+}
+
+// Clone godoc
+// @Summary 复制作业
+// @Description 将作业连同题目和附件复制到教师任课的班级，新作业为草稿状态；截止时间、自动关闭时间和迟交最晚时间按原作业相对开始时间的间隔平移到新的开始时间，开始时间在未来时定时发布
+// @Tags 作业管理
+// @Accept json
+// @Produce json
+// @Param id path int true "作业ID"
+// @Param request body model.CloneAssignmentRequest true "目标班级和开始时间"
+// @Success 200 {object} response.Response{data=model.Assignment} "复制成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业或班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/assignment/{id}/clone [post]
+func (c *TemplateController) Clone(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	var req model.CloneAssignmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid clone assignment request",
+			zap.Error(err),
+		)
+		c.ParamError("复制作业参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	assignment, err := c.templateService.CloneAssignment(ctx.Request.Context(), uint(id), &req, teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to clone assignment",
+			zap.Error(err),
+			zap.Uint64("assignment_id", id),
+			zap.Uint("class_id", req.ClassID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	logger.Logger.Info("Assignment cloned successfully",
+		zap.Uint64("source_id", id),
+		zap.Uint("assignment_id", assignment.ID),
+		zap.Uint("class_id", req.ClassID),
+	)
+
+	c.SuccessWithMessage("复制作业成功", assignment)
+}
+
+// SaveTemplate godoc
+// @Summary 保存为作业模板
+// @Description 将作业的设置、题目和附件保存为模板，日期保存为相对开始时间的间隔
+// @Tags 作业模板
+// @Accept json
+// @Produce json
+// @Param id path int true "作业ID"
+// @Param request body model.SaveTemplateRequest true "模板信息"
+// @Success 200 {object} response.Response{data=model.AssignmentTemplate} "保存成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/assignment/{id}/template [post]
+func (c *TemplateController) SaveTemplate(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("作业ID格式无效")
+		return
+	}
+
+	var req model.SaveTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid save template request",
+			zap.Error(err),
+		)
+		c.ParamError("模板参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	template, err := c.templateService.SaveAsTemplate(ctx.Request.Context(), uint(id), &req, teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to save assignment template",
+			zap.Error(err),
+			zap.Uint64("assignment_id", id),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	logger.Logger.Info("Assignment template saved successfully",
+		zap.Uint64("assignment_id", id),
+		zap.Uint("template_id", template.ID),
+	)
+
+	c.SuccessWithMessage("保存作业模板成功", template)
+}
+
+// List godoc
+// @Summary 获取作业模板列表
+// @Description 获取当前教师保存的作业模板
+// @Tags 作业模板
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/assignment-template/list [get]
+func (c *TemplateController) List(ctx *gin.Context) {
+	c.InitHandler(ctx)
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	templates, total, err := c.templateService.GetTeacherTemplates(ctx.Request.Context(), teacherID, page, pageSize)
+	if err != nil {
+		logger.Logger.Error("Failed to get teacher templates",
+			zap.Error(err),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.ServerError(err.Error())
+		return
+	}
+
+	c.Success(gin.H{
+		"list":  templates,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
+
+// Detail godoc
+// @Summary 获取作业模板详情
+// @Description 获取模板的设置、题目和附件，只有创建者和管理员可以查看
+// @Tags 作业模板
+// @Produce json
+// @Param id path int true "模板ID"
+// @Success 200 {object} response.Response{data=model.TemplateDetailResponse} "获取成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "模板不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/assignment-template/{id} [get]
+func (c *TemplateController) Detail(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("模板ID格式无效")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	detail, err := c.templateService.GetTemplateDetail(ctx.Request.Context(), uint(id), userID)
+	if err != nil {
+		logger.Logger.Warn("Failed to get assignment template",
+			zap.Error(err),
+			zap.Uint64("template_id", id),
+		)
+		c.handleError(err)
+		return
+	}
+
+	c.Success(detail)
+}
+
+// Delete godoc
+// @Summary 删除作业模板
+// @Description 删除模板，已由模板创建的作业不受影响
+// @Tags 作业模板
+// @Produce json
+// @Param id path int true "模板ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "模板不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/assignment-template/{id} [delete]
+func (c *TemplateController) Delete(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("模板ID格式无效")
+		return
+	}
+
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.templateService.DeleteTemplate(ctx.Request.Context(), uint(id), userID); err != nil {
+		logger.Logger.Error("Failed to delete assignment template",
+			zap.Error(err),
+			zap.Uint64("template_id", id),
+		)
+		c.handleError(err)
+		return
+	}
+
+	logger.Logger.Info("Assignment template deleted successfully",
+		zap.Uint64("template_id", id),
+	)
+
+	c.SuccessWithMessage("删除作业模板成功", nil)
+}
+
+// Instantiate godoc
+// @Summary 由模板创建作业
+// @Description 在教师任课的班级中按模板创建草稿作业，日期按模板中相对开始时间的间隔平移到新的开始时间，开始时间在未来时定时发布
+// @Tags 作业模板
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param request body model.CloneAssignmentRequest true "目标班级和开始时间"
+// @Success 200 {object} response.Response{data=model.Assignment} "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "模板或班级不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/assignment-template/{id}/instantiate [post]
+func (c *TemplateController) Instantiate(ctx *gin.Context) {
+	c.InitHandler(ctx)
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		c.ParamError("模板ID格式无效")
+		return
+	}
+
+	var req model.CloneAssignmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Warn("Invalid instantiate template request",
+			zap.Error(err),
+		)
+		c.ParamError("创建作业参数无效")
+		return
+	}
+
+	teacherID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	assignment, err := c.templateService.InstantiateTemplate(ctx.Request.Context(), uint(id), &req, teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to instantiate assignment template",
+			zap.Error(err),
+			zap.Uint64("template_id", id),
+			zap.Uint("class_id", req.ClassID),
+			zap.Uint("teacher_id", teacherID),
+		)
+		c.handleError(err)
+		return
+	}
+
+	logger.Logger.Info("Assignment created from template",
+		zap.Uint64("template_id", id),
+		zap.Uint("assignment_id", assignment.ID),
+		zap.Uint("class_id", req.ClassID),
+	)
+
+	c.SuccessWithMessage("由模板创建作业成功", assignment)
+}
+
+// currentUserID 获取当前登录用户ID
+func (c *TemplateController) currentUserID(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		c.Unauthorized("用户未认证")
+		return 0, false
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.Unauthorized("用户ID格式无效")
+		return 0, false
+	}
+	return uid, true
+}
+
+// handleError 将服务层错误映射为响应
+func (c *TemplateController) handleError(err error) {
+	switch {
+	case errors.Is(err, service.ErrTemplateNotFound), errors.Is(err, service.ErrAssignmentNotFound),
+		errors.Is(err, service.ErrClassNotFound):
+		c.Fail(404, err.Error())
+	case errors.Is(err, service.ErrAccessDenied):
+		c.Fail(403, err.Error())
+	case errors.Is(err, service.ErrScheduleInvalid):
+		c.ParamError("定时设置无效：关闭时间不能早于截止时间，且必须晚于定时发布时间")
+	default:
+		c.ServerError(err.Error())
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"ai-course/internal/model"
+	"strings"
+)
+
+// 权限操作标识
+const (
+	PermClassManage       = "class:manage"        // 管理班级及成员
+	PermClassJoin         = "class:join"          // 加入/退出班级
+	PermAssignmentWrite   = "assignment:write"    // 创建、编辑、删除作业
+	PermAssignmentPublish = "assignment:publish"  // 发布、取消发布作业
+	PermQuestionWrite     = "question:write"      // 管理题目
+	PermSubmissionWrite   = "submission:write"    // 作答和提交作业
+	PermGradingRead       = "grading:read"        // 查看批改数据
+	PermGradingWrite      = "grading:write"       // 批改及发布成绩
+	PermAttachmentWrite   = "attachment:write"    // 上传、删除附件
+	PermLessonPlanWrite   = "lesson_plan:write"   // 创建、编辑、发布教案
+	PermQuestionBankWrite = "question_bank:write" // 管理和使用题库
+	PermRoleManage        = "role:manage"         // 管理角色及用户角色
+)
+
+// DefaultPermissions 默认权限矩阵，配置文件 rbac.permissions 中未列出的角色使用这里的权限
+var DefaultPermissions = map[string][]string{
+	model.RoleAdmin: {"*"},
+	model.RoleTeacher: {
+		PermClassManage,
+		PermAssignmentWrite,
+		PermAssignmentPublish,
+		PermQuestionWrite,
+		PermGradingRead,
+		PermGradingWrite,
+		PermAttachmentWrite,
+		PermLessonPlanWrite,
+		PermQuestionBankWrite,
+	},
+	model.RoleStudent: {
+		PermClassJoin,
+		PermSubmissionWrite,
+	},
+}
+
+// matchPermission 检查授权规则是否覆盖指定操作
+// 支持通配符 "*"（全部操作）和 "资源:*"（资源下的全部操作）
+func matchPermission(granted, action string) bool {
+	if granted == "*" || granted == action {
+		return true
+	}
+	if strings.HasSuffix(granted, ":*") {
+		return strings.HasPrefix(action, strings.TrimSuffix(granted, "*"))
+	}
+	return false
+}
@@ -1,8 +1,13 @@
 package middleware
 
 import (
+	"ai-course/internal/config"
 	"ai-course/internal/logger"
 	"ai-course/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -12,59 +17,25 @@ import (
 // RoleMiddleware 角色权限中间件
 type RoleMiddleware struct {
 	userService service.UserService
+	cfg         *config.Config
 }
 
 // NewRoleMiddleware 创建角色权限中间件
-func NewRoleMiddleware(userService service.UserService) *RoleMiddleware {
+func NewRoleMiddleware(userService service.UserService, cfg *config.Config) *RoleMiddleware {
 	return &RoleMiddleware{
 		userService: userService,
+		cfg:         cfg,
 	}
 }
 
 // RequireRole 要求特定角色才能访问
 func (m *RoleMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取当前用户ID
-		userID, exists := c.Get("user_id")
-		if !exists {
-			logger.Logger.Warn("User not authenticated for role check")
-			c.JSON(401, gin.H{
-				"status":  401,
-				"message": "用户未认证",
-			})
-			c.Abort()
-			return
-		}
-
-		uid, ok := userID.(uint)
+		userRole, ok := m.resolveRole(c)
 		if !ok {
-			logger.Logger.Warn("Invalid user ID format for role check")
-			c.JSON(401, gin.H{
-				"status":  401,
-				"message": "用户ID格式无效",
-			})
-			c.Abort()
 			return
 		}
 
-		// 获取用户信息
-		userResponse, err := m.userService.Get(c.Request.Context(), uid)
-		if err != nil {
-			logger.Logger.Error("Failed to get user for role check",
-				zap.Error(err),
-				zap.Uint("user_id", uid),
-			)
-			c.JSON(500, gin.H{
-				"status":  500,
-				"message": "获取用户信息失败",
-			})
-			c.Abort()
-			return
-		}
-
-		// 从用户响应中获取角色（需要添加角色字段到UserResponse）
-		// 暂时先假设有角色字段，实际需要修改UserResponse结构体
-		userRole := "student" // 默认角色，实际应该从userResponse中获取
 		hasPermission := false
 		for _, requiredRole := range roles {
 			if userRole == strings.ToLower(requiredRole) {
@@ -75,7 +46,7 @@ func (m *RoleMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 
 		if !hasPermission {
 			logger.Logger.Warn("User does not have required role",
-				zap.Uint("user_id", uid),
+				zap.Uint("user_id", c.GetUint("user_id")),
 				zap.String("user_role", userRole),
 				zap.Strings("required_roles", roles),
 			)
@@ -87,13 +58,111 @@ func (m *RoleMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 			return
 		}
 
-		// 将用户角色存储到上下文中，供后续处理使用
-		c.Set("user_role", userRole)
-		c.Set("user_response", userResponse)
 		c.Next()
 	}
 }
 
+// RequirePermission 要求当前角色拥有指定操作权限（满足任意一个即可）
+func (m *RoleMiddleware) RequirePermission(actions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRole, ok := m.resolveRole(c)
+		if !ok {
+			return
+		}
+
+		for _, action := range actions {
+			if m.HasPermission(userRole, action) {
+				c.Next()
+				return
+			}
+		}
+
+		logger.Logger.Warn("User does not have required permission",
+			zap.Uint("user_id", c.GetUint("user_id")),
+			zap.String("user_role", userRole),
+			zap.Strings("required_permissions", actions),
+		)
+		c.JSON(403, gin.H{
+			"status":  403,
+			"message": "权限不足",
+		})
+		c.Abort()
+	}
+}
+
+// HasPermission 检查角色是否拥有指定操作权限
+// 配置文件按角色覆盖默认权限矩阵：rbac.permissions 中列出的角色使用配置的权限，未列出的角色仍使用 DefaultPermissions
+func (m *RoleMiddleware) HasPermission(role, action string) bool {
+	permissions := DefaultPermissions[role]
+	if m.cfg != nil {
+		if granted, ok := m.cfg.RBAC.Permissions[role]; ok {
+			permissions = granted
+		}
+	}
+
+	for _, granted := range permissions {
+		if matchPermission(granted, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRole 解析当前用户的角色，结果缓存在请求上下文中，同一请求只查询一次
+// 解析失败时直接写入错误响应并返回 false
+func (m *RoleMiddleware) resolveRole(c *gin.Context) (string, bool) {
+	if role, exists := c.Get("user_role"); exists {
+		if roleStr, ok := role.(string); ok {
+			return roleStr, true
+		}
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Logger.Warn("User not authenticated for role check")
+		c.JSON(401, gin.H{
+			"status":  401,
+			"message": "用户未认证",
+		})
+		c.Abort()
+		return "", false
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		logger.Logger.Warn("Invalid user ID format for role check")
+		c.JSON(401, gin.H{
+			"status":  401,
+			"message": "用户ID格式无效",
+		})
+		c.Abort()
+		return "", false
+	}
+
+	// 获取用户信息
+	userResponse, err := m.userService.Get(c.Request.Context(), uid)
+	if err != nil {
+		logger.Logger.Error("Failed to get user for role check",
+			zap.Error(err),
+			zap.Uint("user_id", uid),
+		)
+		c.JSON(500, gin.H{
+			"status":  500,
+			"message": "获取用户信息失败",
+		})
+		c.Abort()
+		return "", false
+	}
+
+	userRole := strings.ToLower(userResponse.RoleID)
+
+	// 将用户角色存储到上下文中，供后续处理使用
+	c.Set("user_role", userRole)
+	c.Set("user_response", userResponse)
+	return userRole, true
+}
+
 // RequireTeacher 要求教师角色
 func (m *RoleMiddleware) RequireTeacher() gin.HandlerFunc {
 	return m.RequireRole("teacher")
@@ -115,138 +184,210 @@ func (m *RoleMiddleware) RequireAdmin() gin.HandlerFunc {
 }
 
 // ResourceOwnershipMiddleware 资源所有权中间件
+// 根据路由参数中的资源ID校验当前用户是否有权访问该资源，需在 AuthMiddleware 之后使用
 type ResourceOwnershipMiddleware struct {
-	assignmentService service.AssignmentService
-	submissionService service.SubmissionService
+	resources map[string]ownershipResource
 }
 
+// ownershipResource 资源类型的名称、资源ID路由参数及对应的访问校验
+type ownershipResource struct {
+	name   string
+	params []string // 按顺序取第一个非空参数
+	manage ownershipChecker
+	view   ownershipChecker
+}
+
+// ownershipChecker 资源访问校验函数
+type ownershipChecker func(ctx context.Context, resourceID, userID uint) error
+
 // NewResourceOwnershipMiddleware 创建资源所有权中间件
-func NewResourceOwnershipMiddleware(
-	assignmentService service.AssignmentService,
-	submissionService service.SubmissionService,
-) *ResourceOwnershipMiddleware {
+func NewResourceOwnershipMiddleware(accessService service.AccessService) *ResourceOwnershipMiddleware {
 	return &ResourceOwnershipMiddleware{
-		assignmentService: assignmentService,
-		submissionService: submissionService,
+		resources: map[string]ownershipResource{
+			"class":       {name: "班级", params: []string{"id"}, manage: accessService.CheckClassManage},
+			"assignment":  {name: "作业", params: []string{"assignment_id", "id"}, manage: accessService.CheckAssignmentManage, view: accessService.CheckAssignmentView},
+			"submission":  {name: "提交", params: []string{"submission_id", "id"}, manage: accessService.CheckSubmissionView, view: accessService.CheckSubmissionView},
+			"attachment":  {name: "附件", params: []string{"id"}, manage: accessService.CheckAttachmentManage, view: accessService.CheckAttachmentView},
+			"lesson_plan": {name: "教案", params: []string{"id"}, manage: accessService.CheckLessonPlanManage, view: accessService.CheckLessonPlanView},
+		},
 	}
 }
 
-// CheckAssignmentOwnership 检查作业所有权
+// CheckClassOwnership 检查班级管理权限（任课教师或管理员）
+func (m *ResourceOwnershipMiddleware) CheckClassOwnership() gin.HandlerFunc {
+	return m.check("class", m.resources["class"].manage)
+}
+
+// CheckAssignmentOwnership 检查作业管理权限（作业所属班级的任课教师或管理员）
 func (m *ResourceOwnershipMiddleware) CheckAssignmentOwnership() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userID, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(401, gin.H{
-				"status":  401,
-				"message": "用户未认证",
-			})
-			c.Abort()
-			return
-		}
+	return m.check("assignment", m.resources["assignment"].manage)
+}
 
-		_, ok := userID.(uint)
-		if !ok {
-			c.JSON(401, gin.H{
-				"status":  401,
-				"message": "用户ID格式无效",
-			})
-			c.Abort()
-			return
-		}
+// CheckAssignmentAccess 检查作业查看权限（任课教师，或所在班级的学生且作业已发布）
+func (m *ResourceOwnershipMiddleware) CheckAssignmentAccess() gin.HandlerFunc {
+	return m.check("assignment", m.resources["assignment"].view)
+}
 
-		// 获取作业ID参数
-		assignmentIDParam := c.Param("assignment_id")
-		if assignmentIDParam == "" {
-			assignmentIDParam = c.Param("id")
-		}
+// CheckSubmissionOwnership 检查提交查看权限（提交的学生本人或作业所属班级的任课教师）
+func (m *ResourceOwnershipMiddleware) CheckSubmissionOwnership() gin.HandlerFunc {
+	return m.check("submission", m.resources["submission"].manage)
+}
 
-		if assignmentIDParam == "" {
-			c.JSON(400, gin.H{
-				"status":  400,
-				"message": "缺少作业ID参数",
-			})
-			c.Abort()
-			return
-		}
+// CheckAttachmentOwnership 检查附件管理权限
+func (m *ResourceOwnershipMiddleware) CheckAttachmentOwnership() gin.HandlerFunc {
+	return m.check("attachment", m.resources["attachment"].manage)
+}
 
-		// 这里应该验证作业所有权，但为简化实现，先通过
-		// 在实际应用中，需要从数据库检查作业的创建者是否为当前用户
-		c.Next()
-	}
+// CheckAttachmentAccess 检查附件下载权限（仅所属班级成员）
+func (m *ResourceOwnershipMiddleware) CheckAttachmentAccess() gin.HandlerFunc {
+	return m.check("attachment", m.resources["attachment"].view)
 }
 
-// CheckSubmissionOwnership 检查提交所有权
-func (m *ResourceOwnershipMiddleware) CheckSubmissionOwnership() gin.HandlerFunc {
+// CheckLessonPlanOwnership 检查教案管理权限（教案所属班级的任课教师或管理员）
+func (m *ResourceOwnershipMiddleware) CheckLessonPlanOwnership() gin.HandlerFunc {
+	return m.check("lesson_plan", m.resources["lesson_plan"].manage)
+}
+
+// CheckLessonPlanAccess 检查教案查看权限（任课教师，或所在班级的学生且教案已发布）
+func (m *ResourceOwnershipMiddleware) CheckLessonPlanAccess() gin.HandlerFunc {
+	return m.check("lesson_plan", m.resources["lesson_plan"].view)
+}
+
+// check 从路由参数中解析资源ID并执行校验
+func (m *ResourceOwnershipMiddleware) check(resourceType string, checker ownershipChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(401, gin.H{
-				"status":  401,
-				"message": "用户未认证",
-			})
-			c.Abort()
+		if !m.authorize(c, resourceType, checker) {
 			return
 		}
+		c.Next()
+	}
+}
 
-		_, ok := userID.(uint)
-		if !ok {
-			c.JSON(401, gin.H{
-				"status":  401,
-				"message": "用户ID格式无效",
-			})
-			c.Abort()
-			return
-		}
+// authorize 执行资源访问校验，失败时写入错误响应并中止请求
+func (m *ResourceOwnershipMiddleware) authorize(c *gin.Context, resourceType string, checker ownershipChecker) bool {
+	resource := m.resources[resourceType]
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, gin.H{
+			"status":  401,
+			"message": "用户未认证",
+		})
+		c.Abort()
+		return false
+	}
 
-		// 获取提交ID参数
-		submissionIDParam := c.Param("submission_id")
-		if submissionIDParam == "" {
-			submissionIDParam = c.Param("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(401, gin.H{
+			"status":  401,
+			"message": "用户ID格式无效",
+		})
+		c.Abort()
+		return false
+	}
+
+	// 获取资源ID参数
+	var idParam string
+	for _, param := range resource.params {
+		if idParam = c.Param(param); idParam != "" {
+			break
 		}
+	}
 
-		if submissionIDParam == "" {
-			c.JSON(400, gin.H{
-				"status":  400,
-				"message": "缺少提交ID参数",
-			})
-			c.Abort()
-			return
+	resourceID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"status":  400,
+			"message": fmt.Sprintf("缺少或无效的%sID参数", resource.name),
+		})
+		c.Abort()
+		return false
+	}
+
+	if err := checker(c.Request.Context(), uint(resourceID), uid); err != nil {
+		status := 500
+		switch {
+		case errors.Is(err, service.ErrAccessDenied):
+			status = 403
+		case errors.Is(err, service.ErrClassNotFound),
+			errors.Is(err, service.ErrAssignmentNotFound),
+			errors.Is(err, service.ErrSubmissionNotFound),
+			errors.Is(err, service.ErrAttachmentNotFound),
+			errors.Is(err, service.ErrLessonPlanNotFound):
+			status = 404
 		}
 
-		// 这里应该验证提交所有权，但为简化实现，先通过
-		// 在实际应用中，需要从数据库检查提交的学生是否为当前用户
-		c.Next()
+		logger.Logger.Warn("Resource ownership check failed",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.Uint64("resource_id", resourceID),
+			zap.Uint("user_id", uid),
+		)
+		c.JSON(status, gin.H{
+			"status":  status,
+			"message": err.Error(),
+		})
+		c.Abort()
+		return false
 	}
+
+	return true
 }
 
 // PermissionConfig 权限配置
 type PermissionConfig struct {
-	RequiredRoles []string
+	RequiredRoles  []string
 	CheckOwnership bool
-	OwnershipType string // "assignment", "submission", "class"
+	OwnershipType  string // "assignment", "submission", "class", "attachment", "lesson_plan"，按对应的 Check*Ownership 校验
 }
 
 // CheckPermissions 综合权限检查中间件
-func (m *RoleMiddleware) CheckPermissions(config PermissionConfig) gin.HandlerFunc {
+func (m *RoleMiddleware) CheckPermissions(config PermissionConfig, ownership *ResourceOwnershipMiddleware) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 先检查角色权限
 		if len(config.RequiredRoles) > 0 {
-			roleHandler := m.RequireRole(config.RequiredRoles...)
-			roleHandler(c)
-			if c.IsAborted() {
+			userRole, ok := m.resolveRole(c)
+			if !ok {
+				return
+			}
+
+			hasRole := false
+			for _, requiredRole := range config.RequiredRoles {
+				if userRole == strings.ToLower(requiredRole) {
+					hasRole = true
+					break
+				}
+			}
+			if !hasRole {
+				c.JSON(403, gin.H{
+					"status":  403,
+					"message": "权限不足",
+				})
+				c.Abort()
 				return
 			}
 		}
 
 		// 如果需要检查所有权，进行所有权验证
 		if config.CheckOwnership {
-			// 这里可以根据 OwnershipType 来调用不同的所有权检查逻辑
-			// 为简化实现，这里只是示例
-			logger.Logger.Info("Ownership check passed",
-				zap.String("ownership_type", config.OwnershipType),
-			)
+			resource, ok := ownership.resources[config.OwnershipType]
+			if !ok {
+				logger.Logger.Error("Unknown ownership type",
+					zap.String("ownership_type", config.OwnershipType),
+				)
+				c.JSON(500, gin.H{
+					"status":  500,
+					"message": "未知的资源类型",
+				})
+				c.Abort()
+				return
+			}
+			if !ownership.authorize(c, config.OwnershipType, resource.manage) {
+				return
+			}
 		}
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
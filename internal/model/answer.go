@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 	"gorm.io/gorm"
 )
@@ -15,6 +16,19 @@ type Answer struct {
 	IsCorrect    *bool  `gorm:"comment:是否正确(客观题)" json:"is_correct,omitempty"`
 	GradedAt     *time.Time `gorm:"comment:批改时间" json:"graded_at,omitempty"`
 	Feedback     string `gorm:"type:text;comment:题目反馈" json:"feedback,omitempty"`
+	Version      uint   `gorm:"not null;default:0;comment:版本号(乐观锁)" json:"version"`
+
+	// AI 批改建议（草稿，仅教师批改时可见，教师采纳或修改后才计入得分）
+	AIScore        *int       `gorm:"comment:AI建议得分" json:"-"`
+	AIFeedback     string     `gorm:"type:text;comment:AI建议评语" json:"-"`
+	AIConfidence   *float64   `gorm:"comment:AI建议置信度(0-1)" json:"-"`
+	AIModel        string     `gorm:"type:varchar(100);comment:生成建议的模型" json:"-"`
+	AISuggestedAt  *time.Time `gorm:"comment:AI建议生成时间" json:"-"`
+	AISuggestFails int        `gorm:"not null;default:0;comment:AI建议生成失败次数" json:"-"`
+	AIAccepted     bool       `gorm:"default:false;comment:是否采纳AI建议" json:"ai_accepted,omitempty"`
+
+	// 编程题运行结果
+	CodeResult string `gorm:"type:json;comment:编程题运行结果JSON" json:"code_result,omitempty"`
 
 	// 关联关系
 	Submission Submission `gorm:"foreignKey:SubmissionID" json:"submission,omitempty"`
@@ -26,6 +40,91 @@ func (Answer) TableName() string {
 	return "answers"
 }
 
+// AISuggestion AI 批改建议
+type AISuggestion struct {
+	Score       int        `json:"score"`
+	Feedback    string     `json:"feedback"`
+	Confidence  float64    `json:"confidence"`
+	Model       string     `json:"model"`
+	SuggestedAt *time.Time `json:"suggested_at,omitempty"`
+}
+
+// GetAISuggestion 获取答案的 AI 批改建议，没有建议时返回 nil
+func (a *Answer) GetAISuggestion() *AISuggestion {
+	if a.AIScore == nil {
+		return nil
+	}
+	suggestion := &AISuggestion{
+		Score:       *a.AIScore,
+		Feedback:    a.AIFeedback,
+		Model:       a.AIModel,
+		SuggestedAt: a.AISuggestedAt,
+	}
+	if a.AIConfidence != nil {
+		suggestion.Confidence = *a.AIConfidence
+	}
+	return suggestion
+}
+
+// CodeRunResult 编程题运行结果
+type CodeRunResult struct {
+	Language     string           `json:"language"`
+	CompileError string           `json:"compile_error,omitempty"` // 编译错误信息（截断）
+	Passed       int              `json:"passed"`                  // 通过的用例数
+	Total        int              `json:"total"`                   // 用例总数
+	Cases        []CodeCaseResult `json:"cases"`
+	RunAt        time.Time        `json:"run_at"`
+}
+
+// CodeCaseResult 单个测试用例的运行结果
+type CodeCaseResult struct {
+	Index     int    `json:"index"` // 用例序号，从 0 开始
+	Hidden    bool   `json:"hidden,omitempty"`
+	Passed    bool   `json:"passed"`
+	Status    string `json:"status"`           // ok、wrong_answer、compile_error、runtime_error、time_limit、output_limit、not_run
+	Stderr    string `json:"stderr,omitempty"` // 标准错误输出片段
+	RuntimeMs int64  `json:"runtime_ms"`
+}
+
+// GetCodeResult 获取编程题运行结果，没有结果时返回 nil
+func (a *Answer) GetCodeResult() *CodeRunResult {
+	if a.CodeResult == "" {
+		return nil
+	}
+	result := &CodeRunResult{}
+	if err := json.Unmarshal([]byte(a.CodeResult), result); err != nil {
+		return nil
+	}
+	return result
+}
+
+// RedactForStudent 隐藏学生不应看到的批改信息：
+// 题目按答案是否公布隐藏答案和解析，未公布时同时清空各题得分、对错和评语，避免从中推断正确答案
+// 编程题未开放运行结果时清空结果，开放时只保留各用例是否通过和状态，标准错误输出仅教师可见
+func (a *Answer) RedactForStudent(revealed bool) {
+	a.Question.RedactForStudent(revealed)
+	if !revealed {
+		a.Score = 0
+		a.IsCorrect = nil
+		a.Feedback = ""
+	}
+	if !a.Question.IsCode() || a.CodeResult == "" {
+		return
+	}
+	options, err := a.Question.GetCodeOptions()
+	result := a.GetCodeResult()
+	if err != nil || !options.ShowResults || result == nil {
+		a.CodeResult = ""
+		return
+	}
+	for i := range result.Cases {
+		result.Cases[i].Stderr = ""
+	}
+	if data, err := json.Marshal(result); err == nil {
+		a.CodeResult = string(data)
+	}
+}
+
 // IsGraded 检查答案是否已批改
 func (a *Answer) IsGraded() bool {
 	return a.GradedAt != nil
@@ -1,23 +1,102 @@
 package model
 
 import (
+	"encoding/json"
+	"math"
 	"time"
+
 	"gorm.io/gorm"
 )
 
+// LatePolicyMode 迟交策略
+type LatePolicyMode string
+
+const (
+	LatePolicyDisallow LatePolicyMode = "disallow" // 截止后不允许提交（默认）
+	LatePolicyAllow    LatePolicyMode = "allow"    // 允许迟交，可设置最晚提交时间和扣分规则
+)
+
+// LatePenaltyUnit 迟交扣分的计时单位
+type LatePenaltyUnit string
+
+const (
+	LatePenaltyPerHour LatePenaltyUnit = "hour" // 每迟交一小时（不足一小时按一小时计）
+	LatePenaltyPerDay  LatePenaltyUnit = "day"  // 每迟交一天（不足一天按一天计）
+)
+
+// LatePolicy 迟交策略，以 JSON 保存在作业中
+// 扣分按学生得分的百分比计算，例如得分 80、累计扣 10% 时最终得分为 72
+type LatePolicy struct {
+	Mode              LatePolicyMode  `json:"mode" binding:"omitempty,oneof=disallow allow"`
+	Cutoff            *time.Time      `json:"cutoff,omitempty"`                                          // 最晚提交时间，为空表示不限
+	PenaltyPercent    float64         `json:"penalty_percent" binding:"min=0,max=100"`                   // 每个计时单位扣除的百分比
+	PenaltyUnit       LatePenaltyUnit `json:"penalty_unit,omitempty" binding:"omitempty,oneof=hour day"` // 默认按天
+	MaxPenaltyPercent float64         `json:"max_penalty_percent" binding:"min=0,max=100"`               // 扣分上限，0 表示不设上限（最多扣完）
+}
+
+// PenaltyPercentFor 计算迟交指定时长应扣除的百分比
+func (p *LatePolicy) PenaltyPercentFor(late time.Duration) float64 {
+	if late <= 0 || p.PenaltyPercent <= 0 {
+		return 0
+	}
+	unit := 24 * time.Hour
+	if p.PenaltyUnit == LatePenaltyPerHour {
+		unit = time.Hour
+	}
+	units := math.Ceil(float64(late) / float64(unit))
+	percent := units * p.PenaltyPercent
+
+	limit := 100.0
+	if p.MaxPenaltyPercent > 0 {
+		limit = p.MaxPenaltyPercent
+	}
+	return math.Min(percent, limit)
+}
+
+// AttemptScoring 多次作答的计分方式
+type AttemptScoring string
+
+const (
+	AttemptScoringHighest AttemptScoring = "highest" // 取最高分（默认）
+	AttemptScoringLast    AttemptScoring = "last"    // 取最后一次作答的得分
+	AttemptScoringAverage AttemptScoring = "average" // 取各次作答的平均分
+)
+
+// AnswerReveal 向学生公布正确答案和解析的时机
+type AnswerReveal string
+
+const (
+	AnswerRevealNever           AnswerReveal = "never"                  // 不公布
+	AnswerRevealAfterSubmission AnswerReveal = "after_submission"       // 学生提交后（多次作答时学生可在看到答案后重新作答）
+	AnswerRevealAfterDeadline   AnswerReveal = "after_deadline"         // 截止后，有延期的学生按延期后的截止时间
+	AnswerRevealAfterPublished  AnswerReveal = "after_grades_published" // 成绩发布后（默认）
+)
+
 // Assignment 作业模型
 type Assignment struct {
 	gorm.Model
-	Title             string    `gorm:"type:varchar(200);not null;comment:作业标题" json:"title"`
-	Description       string    `gorm:"type:text;comment:作业说明" json:"description"`
-	ClassID           uint      `gorm:"not null;comment:班级ID" json:"class_id"`
-	TeacherID         uint      `gorm:"not null;comment:教师ID" json:"teacher_id"`
-	Deadline          time.Time `gorm:"not null;comment:截止时间" json:"deadline"`
-	TotalScore        int       `gorm:"not null;default:100;comment:总分" json:"total_score"`
-	Status            string    `gorm:"type:enum('draft','published','closed');default:'draft';comment:状态" json:"status"`
-	PublishedAt       *time.Time `gorm:"comment:发布时间" json:"published_at"`
-	GradesPublished   bool      `gorm:"default:false;comment:成绩是否已发布" json:"grades_published"`
-	GradesPublishedAt *time.Time `gorm:"comment:成绩发布时间" json:"grades_published_at"`
+	Title             string         `gorm:"type:varchar(200);not null;comment:作业标题" json:"title"`
+	Description       string         `gorm:"type:text;comment:作业说明" json:"description"`
+	ClassID           uint           `gorm:"not null;comment:班级ID" json:"class_id"`
+	TeacherID         uint           `gorm:"not null;comment:教师ID" json:"teacher_id"`
+	Deadline          time.Time      `gorm:"not null;comment:截止时间" json:"deadline"`
+	TotalScore        int            `gorm:"not null;default:100;comment:总分" json:"total_score"`
+	Status            string         `gorm:"type:enum('draft','published','closed');default:'draft';comment:状态" json:"status"`
+	PublishedAt       *time.Time     `gorm:"comment:发布时间" json:"published_at"`
+	GradesPublished   bool           `gorm:"default:false;comment:成绩是否已发布" json:"grades_published"`
+	GradesPublishedAt *time.Time     `gorm:"comment:成绩发布时间" json:"grades_published_at"`
+	LatePolicy        string         `gorm:"type:json;comment:迟交策略JSON" json:"late_policy,omitempty"`
+	MaxAttempts       int            `gorm:"not null;default:1;comment:允许作答次数，0表示不限" json:"max_attempts"`
+	AttemptScoring    AttemptScoring `gorm:"type:varchar(20);default:'highest';comment:多次作答计分方式" json:"attempt_scoring"`
+	TimeLimitMinutes  int            `gorm:"not null;default:0;comment:限时作答时长(分钟)，0表示不限时" json:"time_limit_minutes"`
+	Randomization     string         `gorm:"type:json;comment:随机组卷设置JSON" json:"randomization,omitempty"`
+	AnswerReveal      AnswerReveal   `gorm:"type:varchar(30);default:'after_grades_published';comment:答案公布时机" json:"answer_reveal"`
+	CategoryID        *uint          `gorm:"index;comment:成绩分类ID" json:"category_id,omitempty"`
+
+	// 定时发布和关闭，由后台调度任务执行；发布后清空 PublishAt
+	PublishAt         *time.Time `gorm:"index;comment:定时发布时间" json:"publish_at,omitempty"`
+	CloseAt           *time.Time `gorm:"index;comment:自动关闭时间" json:"close_at,omitempty"`
+	AutoSubmitOnClose bool       `gorm:"default:false;comment:关闭时是否自动提交未交的草稿" json:"auto_submit_on_close"`
 
 	// 关联关系
 	Class       Class        `gorm:"foreignKey:ClassID" json:"class,omitempty"`
@@ -40,4 +119,77 @@ func (a *Assignment) IsPublished() bool {
 // IsOverdue 检查作业是否已过期
 func (a *Assignment) IsOverdue() bool {
 	return time.Now().After(a.Deadline)
-}
\ No newline at end of file
+}
+
+// IsTimed 检查作业是否为限时作答
+func (a *Assignment) IsTimed() bool {
+	return a.TimeLimitMinutes > 0
+}
+
+// TimeLimit 获取限时作答时长
+func (a *Assignment) TimeLimit() time.Duration {
+	return time.Duration(a.TimeLimitMinutes) * time.Minute
+}
+
+// CanStartAttempt 检查已作答 used 次后能否开始新的作答
+func (a *Assignment) CanStartAttempt(used int) bool {
+	return a.MaxAttempts <= 0 || used < a.MaxAttempts
+}
+
+// GetAttemptScoring 获取多次作答的计分方式，未设置时取最高分
+func (a *Assignment) GetAttemptScoring() AttemptScoring {
+	if a.AttemptScoring == "" {
+		return AttemptScoringHighest
+	}
+	return a.AttemptScoring
+}
+
+// GetAnswerReveal 获取答案公布时机，未设置时成绩发布后公布
+func (a *Assignment) GetAnswerReveal() AnswerReveal {
+	if a.AnswerReveal == "" {
+		return AnswerRevealAfterPublished
+	}
+	return a.AnswerReveal
+}
+
+// AnswersRevealed 检查是否可以向学生公布答案和解析
+// submitted 为学生是否已提交，deadline 为学生的截止时间（含延期）
+func (a *Assignment) AnswersRevealed(submitted bool, deadline, now time.Time) bool {
+	switch a.GetAnswerReveal() {
+	case AnswerRevealAfterSubmission:
+		return submitted
+	case AnswerRevealAfterDeadline:
+		return now.After(deadline)
+	case AnswerRevealAfterPublished:
+		return a.GradesPublished
+	default:
+		return false
+	}
+}
+
+// GetLatePolicy 获取迟交策略，未设置时不允许迟交
+func (a *Assignment) GetLatePolicy() (*LatePolicy, error) {
+	policy := &LatePolicy{Mode: LatePolicyDisallow}
+	if a.LatePolicy == "" {
+		return policy, nil
+	}
+	if err := json.Unmarshal([]byte(a.LatePolicy), policy); err != nil {
+		return nil, err
+	}
+	if policy.Mode == "" {
+		policy.Mode = LatePolicyDisallow
+	}
+	return policy, nil
+}
+
+// GetRandomization 获取随机组卷设置，未设置时所有学生使用相同的试卷
+func (a *Assignment) GetRandomization() (*RandomizationSettings, error) {
+	settings := &RandomizationSettings{}
+	if a.Randomization == "" {
+		return settings, nil
+	}
+	if err := json.Unmarshal([]byte(a.Randomization), settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
@@ -12,6 +12,15 @@ type CreateAssignmentRequest struct {
 	Deadline    time.Time          `json:"deadline" binding:"required"`
 	TotalScore  int                `json:"total_score" binding:"min=1"`
 	Status      string             `json:"status" binding:"oneof=draft published"`
+	LatePolicy  *LatePolicy        `json:"late_policy,omitempty"` // 迟交策略，不填时截止后不允许提交
+	MaxAttempts    *int           `json:"max_attempts,omitempty" binding:"omitempty,min=0,max=100"`                // 允许作答次数，0 表示不限，不填时只能作答一次
+	AttemptScoring AttemptScoring `json:"attempt_scoring,omitempty" binding:"omitempty,oneof=highest last average"` // 多次作答计分方式，默认取最高分
+	TimeLimitMinutes int          `json:"time_limit_minutes,omitempty" binding:"omitempty,min=0,max=1440"`          // 限时作答时长（分钟），0 表示不限时
+	Randomization  *RandomizationSettings `json:"randomization,omitempty"` // 随机组卷设置，不填时所有学生使用相同的试卷
+	AnswerReveal   AnswerReveal   `json:"answer_reveal,omitempty" binding:"omitempty,oneof=never after_submission after_deadline after_grades_published"` // 答案公布时机，默认成绩发布后公布
+	PublishAt         *time.Time `json:"publish_at,omitempty"`           // 定时发布时间，状态为草稿时有效
+	CloseAt           *time.Time `json:"close_at,omitempty"`             // 自动关闭时间，不能早于截止时间，关闭后不再接受提交
+	AutoSubmitOnClose bool       `json:"auto_submit_on_close,omitempty"` // 关闭时自动提交学生未交的草稿并自动判分
 	Questions   []CreateQuestionRequest `json:"questions"`
 }
 
@@ -22,19 +31,87 @@ type UpdateAssignmentRequest struct {
 	Deadline    time.Time `json:"deadline"`
 	TotalScore  int       `json:"total_score" binding:"min=1"`
 	Status      string    `json:"status" binding:"oneof=draft published closed"`
+	LatePolicy  *LatePolicy `json:"late_policy,omitempty"` // 迟交策略，不填时保持不变
+	MaxAttempts    *int           `json:"max_attempts,omitempty" binding:"omitempty,min=0,max=100"`
+	AttemptScoring AttemptScoring `json:"attempt_scoring,omitempty" binding:"omitempty,oneof=highest last average"`
+	TimeLimitMinutes *int         `json:"time_limit_minutes,omitempty" binding:"omitempty,min=0,max=1440"`
+	Randomization  *RandomizationSettings `json:"randomization,omitempty"` // 随机组卷设置，不填时保持不变，只影响之后开始的作答
+	AnswerReveal   AnswerReveal   `json:"answer_reveal,omitempty" binding:"omitempty,oneof=never after_submission after_deadline after_grades_published"`
+	PublishAt         *time.Time `json:"publish_at,omitempty"` // 定时发布时间，不填时保持不变
+	CloseAt           *time.Time `json:"close_at,omitempty"`   // 自动关闭时间，不填时保持不变
+	ClearSchedule     bool       `json:"clear_schedule,omitempty"` // 取消定时发布和自动关闭
+	AutoSubmitOnClose *bool      `json:"auto_submit_on_close,omitempty"`
 }
 
 // CreateQuestionRequest 创建题目请求
 type CreateQuestionRequest struct {
-	Type          QuestionType       `json:"type" binding:"required,oneof=choice fill_blank true_false essay"`
+	Type          QuestionType       `json:"type" binding:"required,oneof=choice fill_blank true_false essay matching ordering numeric cloze code"`
 	Content       string             `json:"content" binding:"required"`
 	Score         int                `json:"score" binding:"required,min=1"`
 	Order         int                `json:"order" binding:"required,min=1"`
-	Options       []QuestionOption   `json:"options,omitempty"`
-	CorrectAnswer string             `json:"correct_answer,omitempty"`
+	Options       []QuestionOption   `json:"options,omitempty"`        // 选择题选项；排序题待排序的项
+	CorrectAnswer string             `json:"correct_answer,omitempty"` // 排序题为按正确顺序排列的选项标识，如 "C,A,B"
 	Reference     string             `json:"reference,omitempty"`
 	Explanation   string             `json:"explanation,omitempty"`
 	IsMultiple    bool               `json:"is_multiple,omitempty"` // 选择题是否多选
+	ScoringRule   *ScoringRule       `json:"scoring_rule,omitempty"` // 客观题计分规则，不填使用默认规则
+	Matching      *MatchingSpec      `json:"matching,omitempty"`     // 连线题设置
+	Numeric       *NumericSpec       `json:"numeric,omitempty"`      // 数值题设置
+	Cloze         []ClozeBlankSpec   `json:"cloze,omitempty" binding:"omitempty,max=50,dive"` // 完形填空各空，顺序与题干中的 {{blank}} 对应
+	Code          *CodeSpec          `json:"code,omitempty"`         // 编程题设置，参考答案可填写在 reference 中
+	Pool          string             `json:"pool,omitempty" binding:"max=50"` // 题目池标签
+}
+
+// MatchingSpec 连线题设置
+type MatchingSpec struct {
+	MatchingOptions
+	Pairs map[string]string `json:"pairs" binding:"required,min=1"` // 正确配对，左列选项标识 -> 右列选项标识
+}
+
+// NumericSpec 数值题设置
+type NumericSpec struct {
+	NumericOptions
+	Value *float64 `json:"value" binding:"required"` // 正确数值
+}
+
+// CodeSpec 编程题设置
+type CodeSpec struct {
+	CodeOptions
+	TestCases []CodeTestCase `json:"test_cases" binding:"required,min=1,max=50,dive"` // 测试用例
+}
+
+// ClozeBlankSpec 完形填空单个空的设置
+type ClozeBlankSpec struct {
+	ClozeBlank
+	Answers []string `json:"answers" binding:"required,min=1,dive,required"` // 可接受的答案，下拉选择时为选项标识
+}
+
+// BatchCreateQuestionsRequest 批量创建题目请求（可直接提交 AI 生成的题目草稿）
+type BatchCreateQuestionsRequest struct {
+	Questions []CreateQuestionRequest `json:"questions" binding:"required,min=1,max=100,dive"`
+}
+
+// GenerateQuestionsRequest AI 生成题目请求
+// 出题材料三选一：直接粘贴的文本、作业附件ID，或以 multipart 表单字段 file 上传的文件（PDF/Markdown/TXT）
+type GenerateQuestionsRequest struct {
+	Text         string         `form:"text" json:"text"`                                                                     // 粘贴的文本材料
+	AttachmentID uint           `form:"attachment_id" json:"attachment_id"`                                                   // 作业附件ID
+	Types        []QuestionType `form:"types" json:"types" binding:"omitempty,dive,oneof=choice fill_blank true_false essay"` // 题目类型，默认全部类型
+	Count        int            `form:"count" json:"count" binding:"omitempty,min=1,max=20"`                                  // 生成数量，默认 5
+	Score        int            `form:"score" json:"score" binding:"omitempty,min=1"`                                         // 每题分值，不填则按题型默认
+}
+
+// RejectedQuestion 未通过校验的题目草稿
+type RejectedQuestion struct {
+	Question CreateQuestionRequest `json:"question"`
+	Reason   string                `json:"reason"`
+}
+
+// GenerateQuestionsResponse AI 生成题目响应，题目草稿未入库，可通过批量创建接口保存
+type GenerateQuestionsResponse struct {
+	Model     string                  `json:"model"`
+	Questions []CreateQuestionRequest `json:"questions"`
+	Rejected  []RejectedQuestion      `json:"rejected,omitempty"`
 }
 
 // UpdateQuestionRequest 更新题目请求
@@ -46,6 +123,12 @@ type UpdateQuestionRequest struct {
 	CorrectAnswer string           `json:"correct_answer,omitempty"`
 	Reference     string           `json:"reference,omitempty"`
 	Explanation   string           `json:"explanation,omitempty"`
+	ScoringRule   *ScoringRule     `json:"scoring_rule,omitempty"` // 不为空时整体替换计分规则
+	Matching      *MatchingSpec    `json:"matching,omitempty"`     // 连线题设置，不为空时整体替换
+	Numeric       *NumericSpec     `json:"numeric,omitempty"`      // 数值题设置，不为空时整体替换
+	Cloze         []ClozeBlankSpec `json:"cloze,omitempty" binding:"omitempty,max=50,dive"` // 完形填空各空，不为空时整体替换
+	Code          *CodeSpec        `json:"code,omitempty"`         // 编程题设置，不为空时整体替换
+	Pool          *string          `json:"pool,omitempty" binding:"omitempty,max=50"` // 题目池标签，传空字符串时移出题目池
 }
 
 // SubmissionRequest 学生提交答案请求
@@ -65,6 +148,7 @@ type AnswerRequest struct {
 type GradeSubmissionRequest struct {
 	Answers         []GradeAnswerRequest `json:"answers" binding:"required"`
 	OverallFeedback string               `json:"overall_feedback"`
+	Version         *uint                `json:"version"` // 批改前读取到的提交版本号，与当前版本不一致时拒绝批改
 }
 
 // GradeAnswerRequest 批改答案请求
@@ -72,6 +156,16 @@ type GradeAnswerRequest struct {
 	QuestionID uint   `json:"question_id" binding:"required"`
 	Score      int    `json:"score" binding:"min=0"`
 	Feedback   string `json:"feedback"`
+	AcceptAI   bool   `json:"accept_ai"` // 采纳 AI 建议：使用建议得分，评语为空时使用建议评语
+	Version    *uint  `json:"version"`   // 批改前读取到的答案版本号
+}
+
+// AISuggestionResult 单题 AI 批改建议结果
+type AISuggestionResult struct {
+	QuestionID uint          `json:"question_id"`
+	AnswerID   uint          `json:"answer_id"`
+	Suggestion *AISuggestion `json:"suggestion,omitempty"`
+	Error      string        `json:"error,omitempty"`
 }
 
 // BatchGradeRequest 批量批改请求
@@ -84,15 +178,24 @@ type BatchGradeItem struct {
 	SubmissionID    uint                 `json:"submission_id" binding:"required"`
 	Answers         []GradeAnswerRequest `json:"answers" binding:"required"`
 	OverallFeedback string               `json:"overall_feedback"`
+	Version         *uint                `json:"version"`
 }
 
 // BatchGradeResult 批量批改结果
 type BatchGradeResult struct {
 	SubmissionID uint   `json:"submission_id"`
 	Success      bool   `json:"success"`
+	Conflict     bool   `json:"conflict,omitempty"` // 提交已被修改或被其他教师领取，需重新获取后再批改
 	Error        string `json:"error,omitempty"`
 }
 
+// GradingLock 提交的批改锁
+type GradingLock struct {
+	SubmissionID uint      `json:"submission_id"`
+	LockedBy     uint      `json:"locked_by"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
 // GradingProgress 批改进度
 type GradingProgress struct {
 	AssignmentID     uint    `json:"assignment_id"`
@@ -132,9 +235,10 @@ type AssignmentListResponse struct {
 // AssignmentDetailResponse 作业详情响应
 type AssignmentDetailResponse struct {
 	Assignment
-	Questions   []QuestionDetailResponse `json:"questions"`
-	Attachments []Attachment             `json:"attachments"`
-	Statistics  AssignmentStatistics     `json:"statistics"`
+	Questions         []QuestionDetailResponse `json:"questions"`
+	QuestionsWithheld bool                     `json:"questions_withheld,omitempty"` // 限时作业开始作答前不向学生返回题目
+	Attachments       []Attachment             `json:"attachments"`
+	Statistics        AssignmentStatistics     `json:"statistics"`
 }
 
 // QuestionDetailResponse 题目详情响应
@@ -143,6 +247,19 @@ type QuestionDetailResponse struct {
 	OptionList  []QuestionOption `json:"option_list,omitempty"`
 	IsMultiple  bool             `json:"is_multiple,omitempty"`
 	CorrectKeys []string         `json:"correct_keys,omitempty"`
+	Matching    *MatchingOptions `json:"matching,omitempty"`     // 连线题左右两列
+	Unit        string           `json:"unit,omitempty"`         // 数值题单位
+	ClozeBlanks []ClozeBlank     `json:"cloze_blanks,omitempty"` // 完形填空各空
+	Code        *CodeOptions     `json:"code,omitempty"`         // 编程题语言和初始代码
+	SampleCases []CodeTestCase   `json:"sample_cases,omitempty"` // 编程题非隐藏的测试用例
+}
+
+// RedactForStudent 隐藏学生不应看到的答案信息，需在构造详情后调用（多选标记和编程题示例用例已从答案中解析）
+func (d *QuestionDetailResponse) RedactForStudent(revealed bool) {
+	d.Question.RedactForStudent(revealed)
+	if !revealed {
+		d.CorrectKeys = nil
+	}
 }
 
 // AssignmentStatistics 作业统计信息
@@ -160,10 +277,25 @@ type AssignmentStatistics struct {
 type StudentAssignmentResponse struct {
 	Assignment  Assignment             `json:"assignment"`
 	Submission  *Submission            `json:"submission,omitempty"`
+	Extension   *DeadlineExtension     `json:"extension,omitempty"` // 学生的延期，仅在作业详情中返回
+	RemainingSeconds *int64            `json:"remaining_seconds,omitempty"` // 限时作答剩余秒数，由服务器计算，仅在作答进行中返回
+	AnswersRevealed  bool              `json:"answers_revealed"` // 是否已公布答案和解析，未公布时题目不包含答案
+	QuestionsWithheld bool             `json:"questions_withheld,omitempty"` // 限时作业开始作答前不返回题目
 	Questions   []QuestionDetailResponse `json:"questions"`
 	Attachments []Attachment           `json:"attachments"`
 }
 
+// StudentAttemptsResponse 学生在作业上的全部作答
+type StudentAttemptsResponse struct {
+	AssignmentID    uint           `json:"assignment_id"`
+	MaxAttempts     int            `json:"max_attempts"` // 0 表示不限
+	AttemptScoring  AttemptScoring `json:"attempt_scoring"`
+	AttemptsUsed    int            `json:"attempts_used"`
+	CanStartAttempt bool           `json:"can_start_attempt"`         // 当前作答已提交且未达到次数上限
+	EffectiveScore  *float64       `json:"effective_score,omitempty"` // 按计分方式计算的已提交作答得分
+	Attempts        []*Submission  `json:"attempts"`
+}
+
 // SubmissionListResponse 提交列表响应
 type SubmissionListResponse struct {
 	ID          uint      `json:"id"`
@@ -192,6 +324,8 @@ type GradingDetailResponse struct {
 
 // QuestionWithAnswer 题目和答案响应
 type QuestionWithAnswer struct {
-	Question Question `json:"question"`
-	Answer   *Answer  `json:"answer,omitempty"`
+	Question     Question      `json:"question"`
+	Answer       *Answer       `json:"answer,omitempty"`
+	AISuggestion *AISuggestion `json:"ai_suggestion,omitempty"` // AI 批改建议（仅主观题）
+	DisplayedOptions []QuestionOption `json:"displayed_options,omitempty"` // 选项打乱时学生看到的选项，学生答案中的选项标识与此对应
 }
\ No newline at end of file
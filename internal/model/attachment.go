@@ -11,9 +11,10 @@ type Attachment struct {
 	UploaderID   uint   `gorm:"not null;comment:上传者ID" json:"uploader_id"`
 	FileName     string `gorm:"type:varchar(255);not null;comment:文件名" json:"file_name"`
 	OriginalName string `gorm:"type:varchar(255);not null;comment:原始文件名" json:"original_name"`
-	FilePath     string `gorm:"type:varchar(500);not null;comment:文件路径" json:"file_path"`
+	FilePath     string `gorm:"type:varchar(500);not null;index;comment:文件路径" json:"file_path"`
 	FileSize     int64  `gorm:"not null;comment:文件大小(字节)" json:"file_size"`
 	ContentType  string `gorm:"type:varchar(100);not null;comment:文件类型" json:"content_type"`
+	ContentHash  string `gorm:"type:varchar(64);comment:文件内容SHA-256，内容相同的附件共用同一文件" json:"content_hash,omitempty"`
 
 	// 关联关系
 	Assignment Assignment `gorm:"foreignKey:AssignmentID" json:"assignment,omitempty"`
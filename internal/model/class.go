@@ -8,6 +8,7 @@ import (
 type Class struct {
 	gorm.Model
 	ClassName   string `gorm:"type:varchar(100);not null;comment:班级名称"`
+	Code        string `gorm:"type:varchar(20);uniqueIndex:idx_class_code;comment:班级邀请码"`
 	Description string `gorm:"type:text;comment:班级描述"`
 	TeacherID   uint   `gorm:"not null;comment:教师ID"`
 }
@@ -16,3 +17,18 @@ type Class struct {
 func (Class) TableName() string {
 	return "classes"
 }
+
+// ClassTeacher 班级协同授课教师模型（班级创建者通过 Class.TeacherID 记录，不在此表中）
+type ClassTeacher struct {
+	gorm.Model
+	ClassID   uint `gorm:"not null;uniqueIndex:idx_class_teacher;comment:班级ID" json:"class_id"`
+	TeacherID uint `gorm:"not null;uniqueIndex:idx_class_teacher;index;comment:教师ID" json:"teacher_id"`
+
+	// 关联关系
+	Teacher User `gorm:"foreignKey:TeacherID" json:"teacher,omitempty"`
+}
+
+// TableName 指定表名
+func (ClassTeacher) TableName() string {
+	return "class_teachers"
+}
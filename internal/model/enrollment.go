@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EnrollmentStatus 选课状态枚举
+type EnrollmentStatus string
+
+const (
+	EnrollmentStatusActive EnrollmentStatus = "active" // 在读
+	EnrollmentStatusLeft   EnrollmentStatus = "left"   // 已退出
+)
+
+// ClassEnrollment 学生-班级关联模型
+type ClassEnrollment struct {
+	gorm.Model
+	ClassID   uint             `gorm:"not null;uniqueIndex:idx_class_student;comment:班级ID" json:"class_id"`
+	StudentID uint             `gorm:"not null;uniqueIndex:idx_class_student;index;comment:学生ID" json:"student_id"`
+	Status    EnrollmentStatus `gorm:"type:enum('active','left');default:'active';comment:状态" json:"status"`
+	JoinedAt  time.Time        `gorm:"not null;comment:加入时间" json:"joined_at"`
+	LeftAt    *time.Time       `gorm:"comment:退出时间" json:"left_at,omitempty"`
+
+	// 关联关系
+	Class   Class `gorm:"foreignKey:ClassID" json:"class,omitempty"`
+	Student User  `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+}
+
+// TableName 指定表名
+func (ClassEnrollment) TableName() string {
+	return "class_enrollments"
+}
+
+// IsActive 检查是否仍在班级中
+func (e *ClassEnrollment) IsActive() bool {
+	return e.Status == EnrollmentStatusActive
+}
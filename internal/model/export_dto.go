@@ -0,0 +1,45 @@
+package model
+
+// GradeExportColumn 成绩导出的列
+type GradeExportColumn string
+
+// 作业成绩导出的列
+const (
+	ExportColumnCode        GradeExportColumn = "code"         // 学号
+	ExportColumnName        GradeExportColumn = "name"         // 姓名
+	ExportColumnQuestions   GradeExportColumn = "questions"    // 各题得分，每题一列
+	ExportColumnTotal       GradeExportColumn = "total"        // 按多次作答计分方式计算的总分
+	ExportColumnStatus      GradeExportColumn = "status"       // 成绩状态
+	ExportColumnAttempts    GradeExportColumn = "attempts"     // 已提交的作答次数
+	ExportColumnLate        GradeExportColumn = "late"         // 是否迟交
+	ExportColumnLatePenalty GradeExportColumn = "late_penalty" // 迟交扣除的分数
+	ExportColumnFeedback    GradeExportColumn = "feedback"     // 教师评语
+	ExportColumnSubmittedAt GradeExportColumn = "submitted_at" // 提交时间
+)
+
+// 班级成绩册导出的列（另含学号和姓名）
+const (
+	ExportColumnAssignments GradeExportColumn = "assignments" // 各作业得分，每个作业一列
+	ExportColumnCategories  GradeExportColumn = "categories"  // 各成绩分类的得分率，每个分类一列
+	ExportColumnPercent     GradeExportColumn = "percent"     // 课程总评百分比
+	ExportColumnLevel       GradeExportColumn = "level"       // 等级
+)
+
+// AssignmentExportColumns 作业成绩导出的全部列，未指定列时按此顺序导出
+var AssignmentExportColumns = []GradeExportColumn{
+	ExportColumnCode, ExportColumnName, ExportColumnQuestions, ExportColumnTotal, ExportColumnStatus,
+	ExportColumnAttempts, ExportColumnLate, ExportColumnLatePenalty, ExportColumnFeedback, ExportColumnSubmittedAt,
+}
+
+// GradebookExportColumns 班级成绩册导出的全部列，未指定列时按此顺序导出
+var GradebookExportColumns = []GradeExportColumn{
+	ExportColumnCode, ExportColumnName, ExportColumnAssignments, ExportColumnCategories,
+	ExportColumnPercent, ExportColumnLevel,
+}
+
+// GradeExportRequest 成绩导出请求
+type GradeExportRequest struct {
+	Format  string              // 导出格式 csv 或 xlsx，为空时为 csv
+	Columns []GradeExportColumn // 导出的列及顺序，为空时导出全部列
+	BOM     bool                // CSV 是否写入 UTF-8 BOM
+}
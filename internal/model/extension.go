@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeadlineExtension 学生的作业延期，延期后以新的截止时间判断是否迟交
+type DeadlineExtension struct {
+	gorm.Model
+	AssignmentID uint      `gorm:"not null;uniqueIndex:idx_extension_assignment_student;comment:作业ID" json:"assignment_id"`
+	StudentID    uint      `gorm:"not null;uniqueIndex:idx_extension_assignment_student;comment:学生ID" json:"student_id"`
+	Deadline     time.Time `gorm:"not null;comment:延期后的截止时间" json:"deadline"`
+	Reason       string    `gorm:"type:varchar(500);comment:延期原因" json:"reason,omitempty"`
+	GrantedBy    uint      `gorm:"not null;comment:批准教师ID" json:"granted_by"`
+
+	// 关联关系
+	Student User `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+}
+
+// TableName 指定表名
+func (DeadlineExtension) TableName() string {
+	return "deadline_extensions"
+}
+
+// GrantExtensionRequest 为学生设置作业延期请求，已有延期时替换
+type GrantExtensionRequest struct {
+	StudentID uint      `json:"student_id" binding:"required"`
+	Deadline  time.Time `json:"deadline" binding:"required"` // 延期后的截止时间，须晚于作业截止时间
+	Reason    string    `json:"reason" binding:"max=500"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// GradeEventSource 成绩变更来源
+type GradeEventSource string
+
+const (
+	GradeEventSourceAuto    GradeEventSource = "auto"    // 系统自动判分
+	GradeEventSourceManual  GradeEventSource = "manual"  // 教师批改
+	GradeEventSourceBatch   GradeEventSource = "batch"   // 教师批量批改
+	GradeEventSourceAI      GradeEventSource = "ai"      // 教师采纳 AI 批改建议
+	GradeEventSourceRegrade GradeEventSource = "regrade" // 复核申请调整
+)
+
+// GradeEvent 成绩变更记录，只追加不修改，与成绩更新写入同一事务
+// AnswerID 为空时表示提交总分和总评的变更
+type GradeEvent struct {
+	ID           uint             `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time        `gorm:"index;comment:变更时间" json:"created_at"`
+	SubmissionID uint             `gorm:"not null;index;comment:提交ID" json:"submission_id"`
+	AnswerID     *uint            `gorm:"index;comment:答案ID" json:"answer_id,omitempty"`
+	QuestionID   *uint            `gorm:"comment:题目ID" json:"question_id,omitempty"`
+	ActorID      *uint            `gorm:"comment:操作人ID，系统自动判分时为空" json:"actor_id,omitempty"`
+	Source       GradeEventSource `gorm:"type:enum('auto','manual','batch','ai','regrade');not null;comment:变更来源" json:"source"`
+	OldScore     int              `gorm:"not null;default:0;comment:变更前得分" json:"old_score"`
+	NewScore     int              `gorm:"not null;default:0;comment:变更后得分" json:"new_score"`
+	OldFeedback  string           `gorm:"type:text;comment:变更前评语" json:"old_feedback,omitempty"`
+	NewFeedback  string           `gorm:"type:text;comment:变更后评语" json:"new_feedback,omitempty"`
+	Note         string           `gorm:"type:varchar(500);comment:备注" json:"note,omitempty"`
+
+	// 关联关系
+	Actor *User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+}
+
+// TableName 指定表名
+func (GradeEvent) TableName() string {
+	return "grade_events"
+}
+
+// NewAnswerGradeEvent 创建单题得分变更记录，oldScore、oldFeedback 为修改前的值
+func NewAnswerGradeEvent(answer *Answer, source GradeEventSource, actorID *uint, oldScore int, oldFeedback string) *GradeEvent {
+	answerID, questionID := answer.ID, answer.QuestionID
+	return &GradeEvent{
+		SubmissionID: answer.SubmissionID,
+		AnswerID:     &answerID,
+		QuestionID:   &questionID,
+		ActorID:      actorID,
+		Source:       source,
+		OldScore:     oldScore,
+		NewScore:     answer.Score,
+		OldFeedback:  oldFeedback,
+		NewFeedback:  answer.Feedback,
+	}
+}
+
+// NewSubmissionGradeEvent 创建提交总分变更记录，oldScore、oldFeedback 为修改前的值
+func NewSubmissionGradeEvent(submission *Submission, source GradeEventSource, actorID *uint, oldScore int, oldFeedback string) *GradeEvent {
+	return &GradeEvent{
+		SubmissionID: submission.ID,
+		ActorID:      actorID,
+		Source:       source,
+		OldScore:     oldScore,
+		NewScore:     submission.Score,
+		OldFeedback:  oldFeedback,
+		NewFeedback:  submission.Feedback,
+	}
+}
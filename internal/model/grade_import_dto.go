@@ -0,0 +1,66 @@
+package model
+
+// GradeImportRowStatus 成绩导入中一行的处理结果
+type GradeImportRowStatus string
+
+const (
+	GradeImportChanged        GradeImportRowStatus = "changed"         // 有得分或评语变化
+	GradeImportUnchanged      GradeImportRowStatus = "unchanged"       // 与现有成绩相同
+	GradeImportUnknownStudent GradeImportRowStatus = "unknown_student" // 学号不是班级在读学生
+	GradeImportNoSubmission   GradeImportRowStatus = "no_submission"   // 学生没有已提交的作答，无法录入得分
+	GradeImportOutOfRange     GradeImportRowStatus = "out_of_range"    // 得分超出题目分值范围
+	GradeImportInvalid        GradeImportRowStatus = "invalid"         // 得分格式错误、学号重复或学生未作答该题
+)
+
+// Blocking 是否阻止导入，存在阻止导入的行时整个文件不会被应用
+func (s GradeImportRowStatus) Blocking() bool {
+	return s != GradeImportChanged && s != GradeImportUnchanged
+}
+
+// GradeImportChange 一道题的得分变化
+type GradeImportChange struct {
+	QuestionID    uint `json:"question_id"`
+	QuestionOrder int  `json:"question_order"` // 题号，从 1 开始
+	OldScore      int  `json:"old_score"`
+	NewScore      int  `json:"new_score"`
+}
+
+// GradeImportRow 导入文件中一行的比对结果
+type GradeImportRow struct {
+	Row          int                  `json:"row"` // 文件中的行号，从 1 开始（含表头）
+	StudentCode  string               `json:"student_code"`
+	StudentName  string               `json:"student_name,omitempty"`
+	SubmissionID uint                 `json:"submission_id,omitempty"`
+	Status       GradeImportRowStatus `json:"status"`
+	Changes      []GradeImportChange  `json:"changes,omitempty"`
+	OldFeedback  *string              `json:"old_feedback,omitempty"` // 评语有变化时为修改前的评语
+	NewFeedback  *string              `json:"new_feedback,omitempty"`
+	Errors       []string             `json:"errors,omitempty"`
+}
+
+// GradeImportSummary 成绩导入统计
+type GradeImportSummary struct {
+	Total          int `json:"total"`
+	Changed        int `json:"changed"`
+	Unchanged      int `json:"unchanged"`
+	UnknownStudent int `json:"unknown_student"`
+	NoSubmission   int `json:"no_submission"`
+	OutOfRange     int `json:"out_of_range"`
+	Invalid        int `json:"invalid"`
+}
+
+// GradeImportReport 成绩导入报告，DryRun 时只比对不保存
+type GradeImportReport struct {
+	AssignmentID uint               `json:"assignment_id"`
+	DryRun       bool               `json:"dry_run"`
+	Applied      bool               `json:"applied"`
+	Summary      GradeImportSummary `json:"summary"`
+	Rows         []*GradeImportRow  `json:"rows"`
+}
+
+// GradingUpdate 一份提交的批改结果，用于在同一事务中保存多份提交的成绩
+type GradingUpdate struct {
+	Submission *Submission
+	Answers    []*Answer // 得分或评语有变化的答案
+	Events     []*GradeEvent
+}
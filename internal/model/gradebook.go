@@ -0,0 +1,265 @@
+package model
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GradeCategory 成绩分类（如平时作业、测验、考试），按权重计入课程总评
+type GradeCategory struct {
+	gorm.Model
+	ClassID    uint    `gorm:"not null;index;comment:班级ID" json:"class_id"`
+	Name       string  `gorm:"type:varchar(50);not null;comment:分类名称" json:"name"`
+	Weight     float64 `gorm:"not null;default:0;comment:权重" json:"weight"`
+	DropLowest int     `gorm:"not null;default:0;comment:去掉最低分的作业数" json:"drop_lowest"`
+	Order      int     `gorm:"not null;default:0;comment:排序" json:"order"`
+}
+
+// TableName 指定表名
+func (GradeCategory) TableName() string {
+	return "grade_categories"
+}
+
+// GradeCutoff 等级分数线，总评百分比不低于 Min 时取该等级
+type GradeCutoff struct {
+	Label string  `json:"label" binding:"required,max=20"`
+	Min   float64 `json:"min" binding:"min=0,max=100"`
+}
+
+// DefaultGradeScale 未设置等级时使用的五级制
+var DefaultGradeScale = []GradeCutoff{
+	{Label: "优秀", Min: 90},
+	{Label: "良好", Min: 80},
+	{Label: "中等", Min: 70},
+	{Label: "及格", Min: 60},
+	{Label: "不及格", Min: 0},
+}
+
+// GradebookSetting 班级成绩册设置
+type GradebookSetting struct {
+	gorm.Model
+	ClassID    uint   `gorm:"not null;uniqueIndex;comment:班级ID" json:"class_id"`
+	GradeScale string `gorm:"type:json;comment:等级分数线JSON" json:"-"`
+}
+
+// TableName 指定表名
+func (GradebookSetting) TableName() string {
+	return "gradebook_settings"
+}
+
+// GetGradeScale 获取按分数线从高到低排列的等级，未设置时返回默认等级
+func (s *GradebookSetting) GetGradeScale() ([]GradeCutoff, error) {
+	if s == nil || s.GradeScale == "" {
+		return DefaultGradeScale, nil
+	}
+	var scale []GradeCutoff
+	if err := json.Unmarshal([]byte(s.GradeScale), &scale); err != nil {
+		return nil, err
+	}
+	if len(scale) == 0 {
+		return DefaultGradeScale, nil
+	}
+	return scale, nil
+}
+
+// GradeLevel 按分数线确定总评百分比对应的等级，低于所有分数线时返回空字符串
+func GradeLevel(scale []GradeCutoff, percent float64) string {
+	for _, cutoff := range scale {
+		if percent >= cutoff.Min {
+			return cutoff.Label
+		}
+	}
+	return ""
+}
+
+// GradeMarkType 教师对学生作业的标记
+type GradeMarkType string
+
+const (
+	GradeMarkExcused GradeMarkType = "excused" // 免做，不计入总评
+	GradeMarkMissing GradeMarkType = "missing" // 缺交，按零分计入总评
+)
+
+// GradeMark 学生作业的免做/缺交标记，同一作业和学生只保留一条
+type GradeMark struct {
+	gorm.Model
+	AssignmentID uint          `gorm:"not null;uniqueIndex:idx_grade_mark;comment:作业ID" json:"assignment_id"`
+	StudentID    uint          `gorm:"not null;uniqueIndex:idx_grade_mark;comment:学生ID" json:"student_id"`
+	Mark         GradeMarkType `gorm:"type:varchar(20);not null;comment:标记" json:"mark"`
+	Note         string        `gorm:"type:varchar(500);comment:备注" json:"note,omitempty"`
+	MarkedBy     uint          `gorm:"not null;comment:标记教师ID" json:"marked_by"`
+}
+
+// TableName 指定表名
+func (GradeMark) TableName() string {
+	return "grade_marks"
+}
+
+// GradeCellStatus 成绩册中学生作业成绩的状态
+type GradeCellStatus string
+
+const (
+	GradeCellGraded  GradeCellStatus = "graded"  // 已批改
+	GradeCellPending GradeCellStatus = "pending" // 已提交待批改
+	GradeCellMissing GradeCellStatus = "missing" // 缺交（教师标记或截止后未提交），按零分计
+	GradeCellExcused GradeCellStatus = "excused" // 免做，不计入总评
+	GradeCellNotDue  GradeCellStatus = "not_due" // 未提交且尚未截止
+)
+
+// Label 返回成绩状态的中文名称，用于导出
+func (s GradeCellStatus) Label() string {
+	switch s {
+	case GradeCellGraded:
+		return "已批改"
+	case GradeCellPending:
+		return "待批改"
+	case GradeCellMissing:
+		return "缺交"
+	case GradeCellExcused:
+		return "免做"
+	case GradeCellNotDue:
+		return "未提交"
+	default:
+		return string(s)
+	}
+}
+
+// GradebookColumn 成绩册中的一次作业
+type GradebookColumn struct {
+	AssignmentID    uint      `json:"assignment_id"`
+	Title           string    `json:"title"`
+	CategoryID      *uint     `json:"category_id,omitempty"`
+	TotalScore      int       `json:"total_score"`
+	Deadline        time.Time `json:"deadline"`
+	GradesPublished bool      `json:"grades_published"`
+}
+
+// GradeCell 学生在一次作业上的成绩，与成绩册的作业列一一对应
+type GradeCell struct {
+	AssignmentID uint            `json:"assignment_id"`
+	Status       GradeCellStatus `json:"status"`
+	Score        *float64        `json:"score,omitempty"`   // 按多次作答计分方式计算的得分，缺交为 0
+	Note         string          `json:"note,omitempty"`    // 教师标记的备注
+	Dropped      bool            `json:"dropped,omitempty"` // 作为最低分被去掉，不计入分类成绩
+}
+
+// CategoryGrade 学生在一个成绩分类上的成绩
+type CategoryGrade struct {
+	CategoryID uint     `json:"category_id"`
+	Name       string   `json:"name"`
+	Weight     float64  `json:"weight"`
+	Score      float64  `json:"score"`
+	MaxScore   float64  `json:"max_score"`
+	Percent    *float64 `json:"percent,omitempty"` // 没有计分的作业时为空，不参与总评
+}
+
+// StudentGrades 学生在班级中的成绩
+type StudentGrades struct {
+	StudentID   uint            `json:"student_id"`
+	StudentName string          `json:"student_name"`
+	StudentCode string          `json:"student_code"`
+	Cells       []GradeCell     `json:"cells"`
+	Categories  []CategoryGrade `json:"categories,omitempty"`
+	Percent     *float64        `json:"percent,omitempty"` // 课程总评百分比，没有计分的作业时为空
+	Level       string          `json:"level,omitempty"`
+}
+
+// Compute 计算分类成绩、课程总评和等级，Cells 须与 columns 一一对应
+// 未设置分类时按全部作业的得分之和计算总评；设置分类后未分类的作业不计入总评，
+// 各分类按权重加权，没有计分作业的分类不参与加权
+func (g *StudentGrades) Compute(columns []GradebookColumn, categories []GradeCategory, scale []GradeCutoff) {
+	g.Categories = nil
+	g.Percent = nil
+	g.Level = ""
+	for i := range g.Cells {
+		g.Cells[i].Dropped = false
+	}
+
+	if len(categories) == 0 {
+		score, maxScore := g.sum(columns, func(GradebookColumn) bool { return true }, 0)
+		g.Percent = percentOf(score, maxScore)
+	} else {
+		var weighted, weights float64
+		for _, category := range categories {
+			id := category.ID
+			inCategory := func(column GradebookColumn) bool {
+				return column.CategoryID != nil && *column.CategoryID == id
+			}
+			score, maxScore := g.sum(columns, inCategory, category.DropLowest)
+			grade := CategoryGrade{
+				CategoryID: category.ID,
+				Name:       category.Name,
+				Weight:     category.Weight,
+				Score:      roundScore(score),
+				MaxScore:   maxScore,
+				Percent:    percentOf(score, maxScore),
+			}
+			if grade.Percent != nil && category.Weight > 0 {
+				weighted += *grade.Percent * category.Weight
+				weights += category.Weight
+			}
+			g.Categories = append(g.Categories, grade)
+		}
+		if weights > 0 {
+			percent := roundScore(weighted / weights)
+			g.Percent = &percent
+		}
+	}
+
+	if g.Percent != nil {
+		g.Level = GradeLevel(scale, *g.Percent)
+	}
+}
+
+// sum 汇总满足条件的已计分作业的得分和满分，按得分率去掉最低的 drop 次作业（至少保留一次）
+func (g *StudentGrades) sum(columns []GradebookColumn, include func(GradebookColumn) bool, drop int) (float64, float64) {
+	var counted []int
+	for i := range g.Cells {
+		cell := &g.Cells[i]
+		if !include(columns[i]) || columns[i].TotalScore <= 0 || cell.Score == nil {
+			continue
+		}
+		if cell.Status == GradeCellGraded || cell.Status == GradeCellMissing {
+			counted = append(counted, i)
+		}
+	}
+
+	if drop > len(counted)-1 {
+		drop = len(counted) - 1
+	}
+	if drop > 0 {
+		sort.SliceStable(counted, func(a, b int) bool {
+			return *g.Cells[counted[a]].Score/float64(columns[counted[a]].TotalScore) <
+				*g.Cells[counted[b]].Score/float64(columns[counted[b]].TotalScore)
+		})
+		for _, i := range counted[:drop] {
+			g.Cells[i].Dropped = true
+		}
+		counted = counted[drop:]
+	}
+
+	var score, maxScore float64
+	for _, i := range counted {
+		score += *g.Cells[i].Score
+		maxScore += float64(columns[i].TotalScore)
+	}
+	return score, maxScore
+}
+
+// percentOf 计算得分率，满分为 0 时返回 nil
+func percentOf(score, maxScore float64) *float64 {
+	if maxScore <= 0 {
+		return nil
+	}
+	percent := roundScore(score / maxScore * 100)
+	return &percent
+}
+
+// roundScore 保留两位小数
+func roundScore(value float64) float64 {
+	return math.Round(value*100) / 100
+}
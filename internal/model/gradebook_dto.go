@@ -0,0 +1,51 @@
+package model
+
+// GradeCategoryRequest 成绩分类请求，ID 为 0 时新建分类
+type GradeCategoryRequest struct {
+	ID         uint    `json:"id"`
+	Name       string  `json:"name" binding:"required,max=50"`
+	Weight     float64 `json:"weight" binding:"min=0,max=100"`
+	DropLowest int     `json:"drop_lowest" binding:"min=0,max=20"` // 去掉最低分的作业数，至少保留一次作业
+}
+
+// UpdateGradebookSettingsRequest 更新成绩册设置请求
+// Categories 整体替换：带 ID 的分类更新，未列出的分类删除（其中的作业变为未分类）
+type UpdateGradebookSettingsRequest struct {
+	Categories []GradeCategoryRequest `json:"categories" binding:"max=20,dive"`
+	GradeScale []GradeCutoff          `json:"grade_scale" binding:"max=20,dive"` // 为空时使用默认等级
+}
+
+// GradebookSettingsResponse 成绩册设置响应
+type GradebookSettingsResponse struct {
+	ClassID    uint            `json:"class_id"`
+	Categories []GradeCategory `json:"categories"`
+	GradeScale []GradeCutoff   `json:"grade_scale"`
+}
+
+// SetAssignmentCategoryRequest 设置作业成绩分类请求，CategoryID 为空时取消分类
+type SetAssignmentCategoryRequest struct {
+	CategoryID *uint `json:"category_id"`
+}
+
+// MarkGradeRequest 标记学生作业免做或缺交请求
+type MarkGradeRequest struct {
+	Mark GradeMarkType `json:"mark" binding:"required,oneof=excused missing"`
+	Note string        `json:"note" binding:"max=500"`
+}
+
+// GradebookResponse 班级成绩册（学生×作业矩阵）
+type GradebookResponse struct {
+	ClassID     uint              `json:"class_id"`
+	Categories  []GradeCategory   `json:"categories"`
+	GradeScale  []GradeCutoff     `json:"grade_scale"`
+	Assignments []GradebookColumn `json:"assignments"`
+	Students    []*StudentGrades  `json:"students"`
+}
+
+// GradeSummaryResponse 学生个人成绩汇总，只包含已发布成绩的作业
+type GradeSummaryResponse struct {
+	ClassID     uint              `json:"class_id"`
+	GradeScale  []GradeCutoff     `json:"grade_scale"`
+	Assignments []GradebookColumn `json:"assignments"`
+	Grades      *StudentGrades    `json:"grades"`
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LessonPlanStatus 教案状态枚举
+type LessonPlanStatus string
+
+const (
+	LessonPlanStatusDraft     LessonPlanStatus = "draft"     // 草稿
+	LessonPlanStatusPublished LessonPlanStatus = "published" // 已发布（班级学生可见）
+)
+
+// LessonPlanUsage 教案关联作业的用途
+type LessonPlanUsage string
+
+const (
+	LessonPlanUsageExercise LessonPlanUsage = "exercise" // 课堂练习
+	LessonPlanUsageHomework LessonPlanUsage = "homework" // 课后作业
+)
+
+// LessonPlan 教案模型
+type LessonPlan struct {
+	gorm.Model
+	Title           string           `gorm:"type:varchar(200);not null;comment:教案标题" json:"title"`
+	ClassID         uint             `gorm:"not null;index;comment:班级ID" json:"class_id"`
+	TeacherID       uint             `gorm:"not null;index;comment:教师ID" json:"teacher_id"`
+	Objectives      string           `gorm:"type:text;comment:教学目标" json:"objectives"`
+	KeyPoints       string           `gorm:"type:text;comment:教学重点" json:"key_points"`
+	DifficultPoints string           `gorm:"type:text;comment:教学难点" json:"difficult_points"`
+	Status          LessonPlanStatus `gorm:"type:enum('draft','published');default:'draft';comment:状态" json:"status"`
+	PublishedAt     *time.Time       `gorm:"comment:发布时间" json:"published_at"`
+	SourcePlanID    *uint            `gorm:"comment:复制来源教案ID" json:"source_plan_id,omitempty"`
+
+	// 关联关系
+	Class       Class                  `gorm:"foreignKey:ClassID" json:"class,omitempty"`
+	Teacher     User                   `gorm:"foreignKey:TeacherID" json:"teacher,omitempty"`
+	Steps       []LessonPlanStep       `gorm:"foreignKey:LessonPlanID" json:"steps,omitempty"`
+	Assignments []LessonPlanAssignment `gorm:"foreignKey:LessonPlanID" json:"assignments,omitempty"`
+	Attachments []LessonPlanAttachment `gorm:"foreignKey:LessonPlanID" json:"attachments,omitempty"`
+}
+
+// TableName 指定表名
+func (LessonPlan) TableName() string {
+	return "lesson_plans"
+}
+
+// IsPublished 检查教案是否已发布
+func (p *LessonPlan) IsPublished() bool {
+	return p.Status == LessonPlanStatusPublished
+}
+
+// TotalDuration 计算教学环节总时长（分钟）
+func (p *LessonPlan) TotalDuration() int {
+	total := 0
+	for _, step := range p.Steps {
+		total += step.Duration
+	}
+	return total
+}
+
+// LessonPlanStep 教学环节
+type LessonPlanStep struct {
+	gorm.Model
+	LessonPlanID uint   `gorm:"not null;index;comment:教案ID" json:"lesson_plan_id"`
+	Order        int    `gorm:"not null;comment:环节顺序" json:"order"`
+	Title        string `gorm:"type:varchar(200);not null;comment:环节名称" json:"title"`
+	Content      string `gorm:"type:text;comment:环节内容" json:"content"`
+	Duration     int    `gorm:"not null;default:0;comment:时长(分钟)" json:"duration"`
+}
+
+// TableName 指定表名
+func (LessonPlanStep) TableName() string {
+	return "lesson_plan_steps"
+}
+
+// LessonPlanAssignment 教案关联的作业（课堂练习或课后作业）
+type LessonPlanAssignment struct {
+	gorm.Model
+	LessonPlanID uint            `gorm:"not null;index;comment:教案ID" json:"lesson_plan_id"`
+	AssignmentID uint            `gorm:"not null;index;comment:作业ID" json:"assignment_id"`
+	Usage        LessonPlanUsage `gorm:"type:enum('exercise','homework');default:'homework';comment:用途" json:"usage"`
+	Order        int             `gorm:"not null;default:0;comment:顺序" json:"order"`
+
+	// 关联关系
+	Assignment Assignment `gorm:"foreignKey:AssignmentID" json:"assignment,omitempty"`
+}
+
+// TableName 指定表名
+func (LessonPlanAssignment) TableName() string {
+	return "lesson_plan_assignments"
+}
+
+// LessonPlanAttachment 教案关联的附件
+type LessonPlanAttachment struct {
+	gorm.Model
+	LessonPlanID uint `gorm:"not null;index;comment:教案ID" json:"lesson_plan_id"`
+	AttachmentID uint `gorm:"not null;index;comment:附件ID" json:"attachment_id"`
+
+	// 关联关系
+	Attachment Attachment `gorm:"foreignKey:AttachmentID" json:"attachment,omitempty"`
+}
+
+// TableName 指定表名
+func (LessonPlanAttachment) TableName() string {
+	return "lesson_plan_attachments"
+}
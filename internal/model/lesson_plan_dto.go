@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// LessonPlanStepRequest 教学环节请求
+type LessonPlanStepRequest struct {
+	Title    string `json:"title" binding:"required,max=200"`
+	Content  string `json:"content"`
+	Duration int    `json:"duration" binding:"min=0,max=600"` // 时长（分钟）
+}
+
+// LessonPlanAssignmentRequest 教案关联作业请求
+type LessonPlanAssignmentRequest struct {
+	AssignmentID uint            `json:"assignment_id" binding:"required"`
+	Usage        LessonPlanUsage `json:"usage" binding:"omitempty,oneof=exercise homework"` // 默认为课后作业
+}
+
+// CreateLessonPlanRequest 创建教案请求
+type CreateLessonPlanRequest struct {
+	Title           string                        `json:"title" binding:"required,max=200"`
+	ClassID         uint                          `json:"class_id" binding:"required"`
+	Objectives      string                        `json:"objectives"`
+	KeyPoints       string                        `json:"key_points"`
+	DifficultPoints string                        `json:"difficult_points"`
+	Steps           []LessonPlanStepRequest       `json:"steps" binding:"dive"`
+	Assignments     []LessonPlanAssignmentRequest `json:"assignments" binding:"dive"`
+	AttachmentIDs   []uint                        `json:"attachment_ids"`
+}
+
+// UpdateLessonPlanRequest 更新教案请求
+// 字符串字段为空时保持不变；Steps、Assignments、AttachmentIDs 未提供时保持不变，提供空数组时清空
+type UpdateLessonPlanRequest struct {
+	Title           string                        `json:"title" binding:"max=200"`
+	Objectives      string                        `json:"objectives"`
+	KeyPoints       string                        `json:"key_points"`
+	DifficultPoints string                        `json:"difficult_points"`
+	Steps           []LessonPlanStepRequest       `json:"steps" binding:"omitempty,dive"`
+	Assignments     []LessonPlanAssignmentRequest `json:"assignments" binding:"omitempty,dive"`
+	AttachmentIDs   []uint                        `json:"attachment_ids"`
+}
+
+// CloneLessonPlanRequest 复制教案请求
+type CloneLessonPlanRequest struct {
+	ClassID uint   `json:"class_id" binding:"required"` // 目标班级
+	Title   string `json:"title" binding:"max=200"`     // 新标题，默认沿用原标题
+}
+
+// LessonPlanListResponse 教案列表响应
+type LessonPlanListResponse struct {
+	ID            uint             `json:"id"`
+	Title         string           `json:"title"`
+	ClassID       uint             `json:"class_id"`
+	ClassName     string           `json:"class_name"`
+	Status        LessonPlanStatus `json:"status"`
+	StepCount     int              `json:"step_count"`
+	TotalDuration int              `json:"total_duration"` // 教学环节总时长（分钟）
+	CreatedAt     time.Time        `json:"created_at"`
+	PublishedAt   *time.Time       `json:"published_at"`
+}
+
+// LessonPlanDetailResponse 教案详情响应
+type LessonPlanDetailResponse struct {
+	LessonPlan
+	TotalDuration int `json:"total_duration"` // 教学环节总时长（分钟）
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+
 	"gorm.io/gorm"
 )
 
@@ -12,20 +14,31 @@ const (
 	QuestionTypeFillBlank  QuestionType = "fill_blank" // 填空题
 	QuestionTypeTrueFalse  QuestionType = "true_false" // 判断题
 	QuestionTypeEssay      QuestionType = "essay"      // 简答题
+	QuestionTypeMatching   QuestionType = "matching"   // 连线题
+	QuestionTypeOrdering   QuestionType = "ordering"   // 排序题
+	QuestionTypeNumeric    QuestionType = "numeric"    // 数值题
+	QuestionTypeCloze      QuestionType = "cloze"      // 完形填空
+	QuestionTypeCode       QuestionType = "code"       // 编程题
 )
 
+// ClozePlaceholder 完形填空题干中空的占位符
+const ClozePlaceholder = "{{blank}}"
+
 // Question 题目模型
 type Question struct {
 	gorm.Model
 	AssignmentID uint         `gorm:"not null;comment:作业ID" json:"assignment_id"`
-	Type         QuestionType `gorm:"type:enum('choice','fill_blank','true_false','essay');not null;comment:题目类型" json:"type"`
+	Type         QuestionType `gorm:"type:enum('choice','fill_blank','true_false','essay','matching','ordering','numeric','cloze','code');not null;comment:题目类型" json:"type"`
 	Content      string       `gorm:"type:text;not null;comment:题目内容" json:"content"`
 	Score        int          `gorm:"not null;default:10;comment:分值" json:"score"`
 	Order        int          `gorm:"not null;comment:题目顺序" json:"order"`
-	Options      string       `gorm:"type:json;comment:选择题选项JSON" json:"options,omitempty"`      // 选择题选项，JSON格式存储
+	Options      string       `gorm:"type:json;comment:题目选项JSON" json:"options,omitempty"`      // 题目选项，JSON格式存储，结构随题型不同
 	CorrectAnswer string       `gorm:"type:text;comment:正确答案" json:"correct_answer,omitempty"`    // 客观题的正确答案
 	Reference     string       `gorm:"type:text;comment:参考答案" json:"reference,omitempty"`        // 主观题的参考答案
 	Explanation   string       `gorm:"type:text;comment:题目解析" json:"explanation,omitempty"`      // 题目解析
+	BankItemID    *uint        `gorm:"index;comment:题库来源ID" json:"bank_item_id,omitempty"`      // 从题库添加时的来源题目
+	ScoringRule   string       `gorm:"type:json;comment:计分规则JSON" json:"scoring_rule,omitempty"` // 客观题计分规则，JSON格式存储，为空时使用默认规则
+	Pool          string       `gorm:"type:varchar(50);index;comment:题目池标签" json:"pool,omitempty"` // 题目池标签，随机组卷时从同一题目池中抽题
 
 	// 关联关系
 	Assignment Assignment `gorm:"foreignKey:AssignmentID" json:"assignment,omitempty"`
@@ -39,7 +52,13 @@ func (Question) TableName() string {
 
 // IsObjective 判断是否为客观题（可自动判分）
 func (q *Question) IsObjective() bool {
-	return q.Type == QuestionTypeChoice || q.Type == QuestionTypeFillBlank || q.Type == QuestionTypeTrueFalse
+	switch q.Type {
+	case QuestionTypeChoice, QuestionTypeFillBlank, QuestionTypeTrueFalse,
+		QuestionTypeMatching, QuestionTypeOrdering, QuestionTypeNumeric, QuestionTypeCloze:
+		return true
+	default:
+		return false
+	}
 }
 
 // IsSubjective 判断是否为主观题（需人工判分）
@@ -47,12 +66,112 @@ func (q *Question) IsSubjective() bool {
 	return q.Type == QuestionTypeEssay
 }
 
+// IsCode 判断是否为编程题（在沙箱中运行测试用例判分）
+func (q *Question) IsCode() bool {
+	return q.Type == QuestionTypeCode
+}
+
+// RedactForStudent 隐藏学生不应看到的答案信息
+// 编程题的测试用例（含隐藏用例）保存在正确答案中，始终隐藏；答案未公布时隐藏正确答案、参考答案、解析和计分规则（填空题可接受的答案保存在计分规则中）
+func (q *Question) RedactForStudent(revealed bool) {
+	if q.IsCode() {
+		q.CorrectAnswer = ""
+	}
+	if revealed {
+		return
+	}
+	q.CorrectAnswer = ""
+	q.Reference = ""
+	q.Explanation = ""
+	q.ScoringRule = ""
+}
+
+// GetCodeOptions 解析编程题设置
+func (q *Question) GetCodeOptions() (*CodeOptions, error) {
+	options := &CodeOptions{}
+	if q.Options == "" {
+		return options, nil
+	}
+	if err := json.Unmarshal([]byte(q.Options), options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// GetScoringRule 解析题目的计分规则，未设置时返回默认规则
+func (q *Question) GetScoringRule() (*ScoringRule, error) {
+	rule := &ScoringRule{}
+	if q.ScoringRule == "" {
+		return rule, nil
+	}
+	if err := json.Unmarshal([]byte(q.ScoringRule), rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// MultiSelectMode 多选题计分方式
+type MultiSelectMode string
+
+const (
+	MultiSelectAllOrNothing MultiSelectMode = "all_or_nothing" // 完全选对得满分，否则不得分（默认）
+	MultiSelectProportional MultiSelectMode = "proportional"   // 按 选对数/(正确选项数+错选数) 的比例得分
+	MultiSelectPenalty      MultiSelectMode = "penalty"        // 每选对一项得一份分，每错选一项扣分，最低为 0
+	MultiSelectAnyWrongZero MultiSelectMode = "any_wrong_zero" // 有错选不得分，少选按选对比例得分
+)
+
+// ScoringRule 客观题计分规则
+type ScoringRule struct {
+	// 多选题
+	MultiSelectMode MultiSelectMode `json:"multi_select_mode,omitempty" binding:"omitempty,oneof=all_or_nothing proportional penalty any_wrong_zero"`
+	WrongPenalty    float64         `json:"wrong_penalty,omitempty" binding:"min=0"` // penalty 模式下每个错选扣除的分值，为 0 时扣除一个正确选项的分值
+
+	// 填空题
+	Blanks           [][]string `json:"blanks,omitempty" binding:"omitempty,max=50,dive,min=1,dive,required"` // 每个空可接受的答案，各空单独计分；为空时以正确答案作为唯一的空
+	CaseSensitive    bool       `json:"case_sensitive,omitempty"`                                            // 区分大小写，默认忽略大小写
+	StrictWhitespace bool       `json:"strict_whitespace,omitempty"`                                         // 严格比较空白字符，默认忽略首尾空白并合并连续空白
+	Tolerance        *float64   `json:"tolerance,omitempty" binding:"omitempty,min=0"`                       // 数值答案允许的绝对误差，设置后双方均为数值时按误差比较
+}
+
 // QuestionOption 选择题选项结构
 type QuestionOption struct {
 	Key   string `json:"key"`   // 选项标识 A, B, C, D
 	Value string `json:"value"` // 选项内容
 }
 
+// MatchingOptions 连线题选项，学生将左列的每一项与右列的一项配对，右列可包含干扰项
+type MatchingOptions struct {
+	Left  []QuestionOption `json:"left" binding:"required,min=2"`
+	Right []QuestionOption `json:"right" binding:"required,min=2"`
+}
+
+// NumericOptions 数值题设置
+type NumericOptions struct {
+	Unit      string  `json:"unit,omitempty" binding:"max=20"` // 单位，学生作答时可带可不带
+	Tolerance float64 `json:"tolerance,omitempty" binding:"min=0"` // 允许的绝对误差
+}
+
+// ClozeBlank 完形填空的空，Choices 不为空时为下拉选择，否则为自由填写
+type ClozeBlank struct {
+	Choices []QuestionOption `json:"choices,omitempty"`
+}
+
+// CodeOptions 编程题设置
+type CodeOptions struct {
+	Language    string `json:"language" binding:"required,oneof=python c cpp javascript"` // 编程语言
+	StarterCode string `json:"starter_code,omitempty"`                                     // 初始代码
+	ShowResults bool   `json:"show_results,omitempty"`                                     // 学生是否可以查看运行结果（只显示是否通过和运行状态，错误输出仅教师可见）
+}
+
+// CodeTestCase 编程题测试用例，每个用例分值相同
+type CodeTestCase struct {
+	Input          string `json:"input"`                                                   // 标准输入
+	ExpectedOutput string `json:"expected_output"`                                         // 期望的标准输出，比较时忽略行尾空白和末尾空行
+	Hidden         bool   `json:"hidden,omitempty"`                                        // 隐藏用例不向学生展示输入和输出
+	TimeLimitMs    int    `json:"time_limit_ms,omitempty" binding:"omitempty,min=100,max=10000"` // CPU 时间限制，默认 2000ms
+	MemoryLimitMB  int    `json:"memory_limit_mb,omitempty" binding:"omitempty,min=16,max=1024"` // 内存限制，默认 256MB
+}
+
 // ChoiceQuestion 选择题专用结构（用于前端交互）
 type ChoiceQuestion struct {
 	Question
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// BankItemScope 题库题目共享范围
+type BankItemScope string
+
+const (
+	BankItemScopePrivate BankItemScope = "private" // 仅创建者可见
+	BankItemScopeSchool  BankItemScope = "school"  // 全校教师可见
+)
+
+// Difficulty 题目难度
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"   // 简单
+	DifficultyMedium Difficulty = "medium" // 中等
+	DifficultyHard   Difficulty = "hard"   // 困难
+)
+
+// QuestionBankItem 题库题目模型，独立于作业存在，添加到作业时复制为 Question
+type QuestionBankItem struct {
+	gorm.Model
+	OwnerID        uint          `gorm:"not null;index;comment:创建者ID" json:"owner_id"`
+	Scope          BankItemScope `gorm:"type:enum('private','school');default:'private';index;comment:共享范围" json:"scope"`
+	Type           QuestionType  `gorm:"type:enum('choice','fill_blank','true_false','essay','matching','ordering','numeric','cloze','code');not null;comment:题目类型" json:"type"`
+	Content        string        `gorm:"type:text;not null;comment:题目内容" json:"content"`
+	Score          int           `gorm:"not null;default:10;comment:建议分值" json:"score"`
+	Options        string        `gorm:"type:json;comment:题目选项JSON" json:"options,omitempty"`
+	CorrectAnswer  string        `gorm:"type:text;comment:正确答案" json:"correct_answer,omitempty"`
+	Reference      string        `gorm:"type:text;comment:参考答案" json:"reference,omitempty"`
+	Explanation    string        `gorm:"type:text;comment:题目解析" json:"explanation,omitempty"`
+	ScoringRule    string        `gorm:"type:json;comment:计分规则JSON" json:"scoring_rule,omitempty"`
+	KnowledgePoint string        `gorm:"type:varchar(200);index;comment:知识点" json:"knowledge_point"`
+	Difficulty     Difficulty    `gorm:"type:enum('easy','medium','hard');default:'medium';index;comment:难度" json:"difficulty"`
+	Chapter        string        `gorm:"type:varchar(200);index;comment:章节" json:"chapter"`
+	UsageCount     int           `gorm:"not null;default:0;comment:被添加到作业的次数" json:"usage_count"`
+
+	// 关联关系
+	Owner User `gorm:"foreignKey:OwnerID" json:"owner,omitempty"`
+}
+
+// TableName 指定表名
+func (QuestionBankItem) TableName() string {
+	return "question_bank_items"
+}
+
+// IsVisibleTo 检查题目对指定用户是否可见
+func (i *QuestionBankItem) IsVisibleTo(userID uint) bool {
+	return i.OwnerID == userID || i.Scope == BankItemScopeSchool
+}
+
+// ToQuestion 将题库题目复制为作业题目，并保留来源引用
+func (i *QuestionBankItem) ToQuestion(assignmentID uint, order int) *Question {
+	bankItemID := i.ID
+	return &Question{
+		AssignmentID:  assignmentID,
+		Type:          i.Type,
+		Content:       i.Content,
+		Score:         i.Score,
+		Order:         order,
+		Options:       i.Options,
+		CorrectAnswer: i.CorrectAnswer,
+		Reference:     i.Reference,
+		Explanation:   i.Explanation,
+		ScoringRule:   i.ScoringRule,
+		BankItemID:    &bankItemID,
+	}
+}
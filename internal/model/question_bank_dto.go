@@ -0,0 +1,82 @@
+package model
+
+// CreateBankItemRequest 创建题库题目请求
+type CreateBankItemRequest struct {
+	Type           QuestionType     `json:"type" binding:"required,oneof=choice fill_blank true_false essay matching ordering numeric cloze code"`
+	Content        string           `json:"content" binding:"required"`
+	Score          int              `json:"score" binding:"required,min=1"`
+	Options        []QuestionOption `json:"options,omitempty"`
+	CorrectAnswer  string           `json:"correct_answer,omitempty"`
+	Reference      string           `json:"reference,omitempty"`
+	Explanation    string           `json:"explanation,omitempty"`
+	IsMultiple     bool             `json:"is_multiple,omitempty"` // 选择题是否多选
+	ScoringRule    *ScoringRule     `json:"scoring_rule,omitempty"`
+	Matching       *MatchingSpec    `json:"matching,omitempty"`
+	Numeric        *NumericSpec     `json:"numeric,omitempty"`
+	Cloze          []ClozeBlankSpec `json:"cloze,omitempty" binding:"omitempty,max=50,dive"`
+	Code           *CodeSpec        `json:"code,omitempty"`
+	KnowledgePoint string           `json:"knowledge_point" binding:"max=200"`
+	Difficulty     Difficulty       `json:"difficulty" binding:"omitempty,oneof=easy medium hard"` // 默认为中等
+	Chapter        string           `json:"chapter" binding:"max=200"`
+	Scope          BankItemScope    `json:"scope" binding:"omitempty,oneof=private school"` // 默认为私有
+}
+
+// UpdateBankItemRequest 更新题库题目请求，字段为空时保持不变
+type UpdateBankItemRequest struct {
+	Content        string           `json:"content"`
+	Score          int              `json:"score" binding:"omitempty,min=1"`
+	Options        []QuestionOption `json:"options,omitempty"`
+	CorrectAnswer  string           `json:"correct_answer,omitempty"`
+	Reference      string           `json:"reference,omitempty"`
+	Explanation    string           `json:"explanation,omitempty"`
+	IsMultiple     *bool            `json:"is_multiple,omitempty"`
+	ScoringRule    *ScoringRule     `json:"scoring_rule,omitempty"`
+	Matching       *MatchingSpec    `json:"matching,omitempty"`
+	Numeric        *NumericSpec     `json:"numeric,omitempty"`
+	Cloze          []ClozeBlankSpec `json:"cloze,omitempty" binding:"omitempty,max=50,dive"`
+	Code           *CodeSpec        `json:"code,omitempty"`
+	KnowledgePoint *string          `json:"knowledge_point" binding:"omitempty,max=200"`
+	Difficulty     Difficulty       `json:"difficulty" binding:"omitempty,oneof=easy medium hard"`
+	Chapter        *string          `json:"chapter" binding:"omitempty,max=200"`
+	Scope          BankItemScope    `json:"scope" binding:"omitempty,oneof=private school"`
+	SyncDrafts     bool             `json:"sync_drafts"` // 是否同步到草稿作业中由该题目复制的题目，已发布作业中的题目始终不受影响
+}
+
+// BankItemSearchRequest 题库搜索请求
+type BankItemSearchRequest struct {
+	Page           int           `form:"page" binding:"omitempty,min=1"`
+	PageSize       int           `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Keyword        string        `form:"keyword"` // 题目内容关键词
+	Type           QuestionType  `form:"type" binding:"omitempty,oneof=choice fill_blank true_false essay matching ordering numeric cloze code"`
+	KnowledgePoint string        `form:"knowledge_point"`
+	Difficulty     Difficulty    `form:"difficulty" binding:"omitempty,oneof=easy medium hard"`
+	Chapter        string        `form:"chapter"`
+	Scope          BankItemScope `form:"scope" binding:"omitempty,oneof=private school"`
+	Mine           bool          `form:"mine"` // 只看自己创建的题目
+}
+
+// BankItemFilter 题库查询条件
+type BankItemFilter struct {
+	ViewerID       uint // 查看者ID，只返回其可见的题目；为 0 时不限制（管理员）
+	OwnerID        uint // 创建者ID，为 0 时不限制
+	Keyword        string
+	Type           QuestionType
+	KnowledgePoint string
+	Difficulty     Difficulty
+	Chapter        string
+	Scope          BankItemScope
+}
+
+// AddFromBankRequest 从题库添加题目到作业请求
+type AddFromBankRequest struct {
+	ItemIDs []uint `json:"item_ids" binding:"required,min=1,max=100"` // 题库题目ID，按顺序添加
+	Score   int    `json:"score" binding:"omitempty,min=1"`           // 统一分值，不填则使用题库建议分值
+}
+
+// SaveToBankRequest 将作业题目保存到题库请求
+type SaveToBankRequest struct {
+	KnowledgePoint string        `json:"knowledge_point" binding:"max=200"`
+	Difficulty     Difficulty    `json:"difficulty" binding:"omitempty,oneof=easy medium hard"`
+	Chapter        string        `json:"chapter" binding:"max=200"`
+	Scope          BankItemScope `json:"scope" binding:"omitempty,oneof=private school"`
+}
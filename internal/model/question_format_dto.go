@@ -0,0 +1,64 @@
+package model
+
+// QuestionImportStatus 导入文件中一道题目的处理结果
+type QuestionImportStatus string
+
+const (
+	QuestionImportValid    QuestionImportStatus = "valid"    // 校验通过，试运行或因其他题目无效而未保存
+	QuestionImportImported QuestionImportStatus = "imported" // 已保存
+	QuestionImportInvalid  QuestionImportStatus = "invalid"  // 无法转换或未通过创建题目的校验
+)
+
+// QuestionImportOptions 题目导入选项
+type QuestionImportOptions struct {
+	Format      string // qti、moodle、gift 或 markdown，为空时按文件扩展名和内容识别
+	DryRun      bool   // 只校验不保存
+	SkipInvalid bool   // 跳过无效的题目并保存其余题目，否则存在无效题目时不保存任何题目
+	Score       int    // 文件中没有分值的题目使用的分值，为 0 时按题型使用默认分值
+}
+
+// BankImportRequest 导入题库时应用于全部题目的分类
+type BankImportRequest struct {
+	KnowledgePoint string        `form:"knowledge_point" binding:"max=200"`
+	Difficulty     Difficulty    `form:"difficulty" binding:"omitempty,oneof=easy medium hard"` // 默认为中等
+	Chapter        string        `form:"chapter" binding:"max=200"`
+	Scope          BankItemScope `form:"scope" binding:"omitempty,oneof=private school"` // 默认为私有
+}
+
+// QuestionImportItem 导入文件中一道题目的结果
+type QuestionImportItem struct {
+	Index      int                    `json:"index"` // 在文件中的序号，从 1 开始
+	Name       string                 `json:"name,omitempty"`
+	Type       QuestionType           `json:"type,omitempty"`
+	Status     QuestionImportStatus   `json:"status"`
+	Error      string                 `json:"error,omitempty"`
+	Question   *CreateQuestionRequest `json:"question,omitempty"`     // 转换得到的题目，无法转换时为空
+	QuestionID uint                   `json:"question_id,omitempty"`  // 导入作业后的题目ID
+	BankItemID uint                   `json:"bank_item_id,omitempty"` // 导入题库后的题库题目ID
+}
+
+// QuestionImportSummary 题目导入统计
+type QuestionImportSummary struct {
+	Total    int `json:"total"`
+	Valid    int `json:"valid"`
+	Invalid  int `json:"invalid"`
+	Imported int `json:"imported"`
+}
+
+// QuestionImportReport 题目导入报告，DryRun 时只校验不保存
+type QuestionImportReport struct {
+	Format       string                `json:"format"`
+	AssignmentID uint                  `json:"assignment_id,omitempty"` // 导入题库时为空
+	DryRun       bool                  `json:"dry_run"`
+	Applied      bool                  `json:"applied"`
+	Summary      QuestionImportSummary `json:"summary"`
+	Items        []*QuestionImportItem `json:"items"`
+}
+
+// QuestionExportFile 导出的题目文件
+type QuestionExportFile struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	Skipped     []int // 因格式无法表示而跳过的题号
+}
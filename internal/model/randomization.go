@@ -0,0 +1,119 @@
+package model
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+)
+
+// RandomizationSettings 随机组卷设置，以 JSON 保存在作业中
+// 未列在 Pools 中的题目池全部出题，未设置题目池的题目始终出题
+type RandomizationSettings struct {
+	ShuffleQuestions bool       `json:"shuffle_questions,omitempty"`                     // 打乱题目顺序
+	ShuffleOptions   bool       `json:"shuffle_options,omitempty"`                       // 打乱选择题选项顺序
+	Pools            []PoolDraw `json:"pools,omitempty" binding:"omitempty,max=50,dive"` // 从题目池中随机抽题
+}
+
+// PoolDraw 从指定题目池中随机抽取的题目数量，题目数不足时全部出题
+type PoolDraw struct {
+	Pool  string `json:"pool" binding:"required,max=50"`
+	Count int    `json:"count" binding:"required,min=1"`
+}
+
+// IsEnabled 检查是否需要为每个学生单独组卷
+func (r *RandomizationSettings) IsEnabled() bool {
+	return r.ShuffleQuestions || r.ShuffleOptions || len(r.Pools) > 0
+}
+
+// QuestionLayout 学生作答的试卷布局，生成后保存在提交中，保证学生每次看到的试卷一致
+type QuestionLayout struct {
+	Questions []LayoutQuestion `json:"questions"`
+}
+
+// LayoutQuestion 试卷中的一道题
+// 选项打乱时，学生看到的第 i 个选项仍使用原来第 i 个选项的标识，内容为 OptionKeys[i] 对应的原选项
+type LayoutQuestion struct {
+	QuestionID uint     `json:"question_id"`
+	OptionKeys []string `json:"option_keys,omitempty"` // 按展示顺序排列的原选项标识，为空表示选项未打乱
+}
+
+// Find 查找试卷中的题目，题目未被抽中时返回 nil
+func (l *QuestionLayout) Find(questionID uint) *LayoutQuestion {
+	for i := range l.Questions {
+		if l.Questions[i].QuestionID == questionID {
+			return &l.Questions[i]
+		}
+	}
+	return nil
+}
+
+// AttemptSeed 根据作业、学生和作答次序计算随机种子，同一次作答在保存前后生成相同的试卷
+func AttemptSeed(assignmentID, studentID uint, attempt int) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(strconv.FormatUint(uint64(assignmentID), 10) + ":" +
+		strconv.FormatUint(uint64(studentID), 10) + ":" + strconv.Itoa(attempt)))
+	return int64(h.Sum64())
+}
+
+// BuildQuestionLayout 按随机种子抽题并打乱题目和选项顺序，questions 需按题目顺序排列
+// 相同的种子、题目和设置总是得到相同的试卷
+func BuildQuestionLayout(seed int64, questions []Question, settings *RandomizationSettings) *QuestionLayout {
+	rng := rand.New(rand.NewSource(seed))
+
+	// 从题目池中抽题
+	drawn := make(map[uint]bool)
+	limited := make(map[string]bool, len(settings.Pools))
+	for _, draw := range settings.Pools {
+		limited[draw.Pool] = true
+		var candidates []uint
+		for _, q := range questions {
+			if q.Pool == draw.Pool {
+				candidates = append(candidates, q.ID)
+			}
+		}
+		rng.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+		if draw.Count < len(candidates) {
+			candidates = candidates[:draw.Count]
+		}
+		for _, id := range candidates {
+			drawn[id] = true
+		}
+	}
+
+	layout := &QuestionLayout{}
+	selected := make([]*Question, 0, len(questions))
+	for i := range questions {
+		q := &questions[i]
+		if q.Pool != "" && limited[q.Pool] && !drawn[q.ID] {
+			continue
+		}
+		selected = append(selected, q)
+	}
+	if settings.ShuffleQuestions {
+		rng.Shuffle(len(selected), func(i, j int) {
+			selected[i], selected[j] = selected[j], selected[i]
+		})
+	}
+
+	for _, q := range selected {
+		item := LayoutQuestion{QuestionID: q.ID}
+		if settings.ShuffleOptions && q.Type == QuestionTypeChoice {
+			var options []QuestionOption
+			if err := json.Unmarshal([]byte(q.Options), &options); err == nil && len(options) > 1 {
+				keys := make([]string, len(options))
+				for i, option := range options {
+					keys[i] = option.Key
+				}
+				rng.Shuffle(len(keys), func(i, j int) {
+					keys[i], keys[j] = keys[j], keys[i]
+				})
+				item.OptionKeys = keys
+			}
+		}
+		layout.Questions = append(layout.Questions, item)
+	}
+	return layout
+}
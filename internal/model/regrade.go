@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RegradeStatus 复核申请状态
+type RegradeStatus string
+
+const (
+	RegradeStatusOpen     RegradeStatus = "open"     // 待处理
+	RegradeStatusAccepted RegradeStatus = "accepted" // 已接受，得分已调整
+	RegradeStatusRejected RegradeStatus = "rejected" // 已驳回
+)
+
+// RegradeRequest 成绩复核申请模型，学生在成绩发布后针对单题得分提出
+type RegradeRequest struct {
+	gorm.Model
+	AssignmentID    uint          `gorm:"not null;index;comment:作业ID" json:"assignment_id"`
+	SubmissionID    uint          `gorm:"not null;index;comment:提交ID" json:"submission_id"`
+	AnswerID        uint          `gorm:"not null;index;comment:答案ID" json:"answer_id"`
+	QuestionID      uint          `gorm:"not null;comment:题目ID" json:"question_id"`
+	StudentID       uint          `gorm:"not null;index;comment:申请学生ID" json:"student_id"`
+	Reason          string        `gorm:"type:text;not null;comment:申请理由" json:"reason"`
+	Status          RegradeStatus `gorm:"type:enum('open','accepted','rejected');default:'open';index;comment:处理状态" json:"status"`
+	OriginalScore   int           `gorm:"not null;default:0;comment:申请时的题目得分" json:"original_score"`
+	TeacherResponse string        `gorm:"type:text;comment:教师答复" json:"teacher_response,omitempty"`
+	ResolvedBy      *uint         `gorm:"comment:处理教师ID" json:"resolved_by,omitempty"`
+	ResolvedAt      *time.Time    `gorm:"comment:处理时间" json:"resolved_at,omitempty"`
+
+	// 接受申请时的得分变更，保留调整前后的题目得分和提交总分
+	OldScore      *int `gorm:"comment:调整前题目得分" json:"old_score,omitempty"`
+	NewScore      *int `gorm:"comment:调整后题目得分" json:"new_score,omitempty"`
+	OldTotalScore *int `gorm:"comment:调整前提交总分" json:"old_total_score,omitempty"`
+	NewTotalScore *int `gorm:"comment:调整后提交总分" json:"new_total_score,omitempty"`
+
+	// 关联关系
+	Student  User     `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+	Question Question `gorm:"foreignKey:QuestionID" json:"question,omitempty"`
+	Answer   Answer   `gorm:"foreignKey:AnswerID" json:"answer,omitempty"`
+}
+
+// TableName 指定表名
+func (RegradeRequest) TableName() string {
+	return "regrade_requests"
+}
+
+// IsOpen 检查申请是否待处理
+func (r *RegradeRequest) IsOpen() bool {
+	return r.Status == RegradeStatusOpen
+}
@@ -0,0 +1,15 @@
+package model
+
+// CreateRegradeRequest 学生提交复核申请请求
+type CreateRegradeRequest struct {
+	AnswerID uint   `json:"answer_id" binding:"required"`
+	Reason   string `json:"reason" binding:"required,max=2000"`
+}
+
+// ResolveRegradeRequest 教师处理复核申请请求
+type ResolveRegradeRequest struct {
+	Status   RegradeStatus `json:"status" binding:"required,oneof=accepted rejected"`
+	Response string        `json:"response" binding:"max=2000"`
+	NewScore *int          `json:"new_score" binding:"omitempty,min=0"` // 接受时必填，调整后的题目得分
+	Version  *uint         `json:"version"`                             // 处理前读取到的答案版本号
+}
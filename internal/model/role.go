@@ -2,15 +2,27 @@ package model
 
 import "time"
 
+// 内置角色ID
+const (
+	RoleAdmin   = "admin"   // 管理员
+	RoleTeacher = "teacher" // 教师
+	RoleStudent = "student" // 学生
+)
+
 type Role struct {
-	RoleId   string    `gorm:"type:varchar(20);primaryKey;not null" json:"role_id"`
-	RoleName string    `gorm:"type:varchar(50);not null" json:"role_name"`
-	CreateAt time.Time `gorm:"type:datetime;not null" json:"create_at"`
-	UpdateAt time.Time `gorm:"type:datetime;not null" json:"update_at"`
-	DeleteAt time.Time `gorm:"type:datetime;not null" json:"delete_at"`
+	RoleId   string     `gorm:"type:varchar(20);primaryKey;not null" json:"role_id"`
+	RoleName string     `gorm:"type:varchar(50);not null" json:"role_name"`
+	CreateAt time.Time  `gorm:"type:datetime;not null" json:"create_at"`
+	UpdateAt time.Time  `gorm:"type:datetime;not null" json:"update_at"`
+	DeleteAt *time.Time `gorm:"type:datetime" json:"delete_at,omitempty"`
 }
 
 // TableName 指定表名
 func (Role) TableName() string {
 	return "roles"
 }
+
+// IsBuiltinRole 检查是否为内置角色
+func IsBuiltinRole(roleId string) bool {
+	return roleId == RoleAdmin || roleId == RoleTeacher || roleId == RoleStudent
+}
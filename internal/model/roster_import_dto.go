@@ -0,0 +1,77 @@
+package model
+
+// RosterImportRowStatus 用户导入中一行的处理结果
+type RosterImportRowStatus string
+
+const (
+	RosterImportCreate        RosterImportRowStatus = "create"         // 新建用户
+	RosterImportUpdate        RosterImportRowStatus = "update"         // 更新已有用户的姓名、角色或班级
+	RosterImportUnchanged     RosterImportRowStatus = "unchanged"      // 与现有用户相同
+	RosterImportDuplicateCode RosterImportRowStatus = "duplicate_code" // 学号在文件中重复
+	RosterImportUnknownClass  RosterImportRowStatus = "unknown_class"  // 班级邀请码不存在
+	RosterImportUnknownRole   RosterImportRowStatus = "unknown_role"   // 角色不存在
+	RosterImportInvalid       RosterImportRowStatus = "invalid"        // 学号或姓名为空、长度不符等
+)
+
+// Blocking 是否阻止导入，存在阻止导入的行时整个文件不会被应用
+func (s RosterImportRowStatus) Blocking() bool {
+	return s != RosterImportCreate && s != RosterImportUpdate && s != RosterImportUnchanged
+}
+
+// Label 状态的中文名称，用于账号表
+func (s RosterImportRowStatus) Label() string {
+	switch s {
+	case RosterImportCreate:
+		return "新建"
+	case RosterImportUpdate:
+		return "更新"
+	case RosterImportUnchanged:
+		return "无变化"
+	default:
+		return string(s)
+	}
+}
+
+// RosterImportRow 导入文件中一行的校验结果
+type RosterImportRow struct {
+	Row       int                   `json:"row"` // 文件中的行号，从 1 开始（含表头）
+	Code      string                `json:"code"`
+	Name      string                `json:"name,omitempty"`
+	RoleID    string                `json:"role_id,omitempty"`
+	ClassCode string                `json:"class_code,omitempty"` // 班级邀请码
+	ClassID   uint                  `json:"class_id,omitempty"`
+	ClassName string                `json:"class_name,omitempty"`
+	UserID    uint                  `json:"user_id,omitempty"` // 已有用户的ID
+	Status    RosterImportRowStatus `json:"status"`
+	OldName   string                `json:"old_name,omitempty"`    // 姓名有变化时为修改前的姓名
+	OldRoleID string                `json:"old_role_id,omitempty"` // 角色有变化时为修改前的角色
+	JoinClass bool                  `json:"join_class,omitempty"`  // 是否加入班级（学生）或成为协同授课教师（教师）
+	Errors    []string              `json:"errors,omitempty"`
+}
+
+// RosterImportSummary 用户导入统计
+type RosterImportSummary struct {
+	Total         int `json:"total"`
+	Create        int `json:"create"`
+	Update        int `json:"update"`
+	Unchanged     int `json:"unchanged"`
+	DuplicateCode int `json:"duplicate_code"`
+	UnknownClass  int `json:"unknown_class"`
+	UnknownRole   int `json:"unknown_role"`
+	Invalid       int `json:"invalid"`
+}
+
+// RosterImportReport 用户导入报告
+type RosterImportReport struct {
+	Applied bool                `json:"applied"`
+	Summary RosterImportSummary `json:"summary"`
+	Rows    []*RosterImportRow  `json:"rows"`
+}
+
+// RosterEntry 导入时需要保存的一个用户及其班级关系，用于在同一事务中保存
+type RosterEntry struct {
+	User      *User // ID 为 0 时新建，否则更新
+	Save      bool  // 用户信息是否需要保存
+	ClassID   uint  // 为 0 时不处理班级关系
+	AsTeacher bool  // 作为协同授课教师加入班级，否则作为学生加入
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// SchedulerLock 后台调度任务的领导锁
+// 多个实例同时运行时，只有持有未过期锁的实例执行调度，持有者每次调度时续期
+type SchedulerLock struct {
+	Name      string    `gorm:"type:varchar(50);primaryKey;comment:锁名称" json:"name"`
+	Owner     string    `gorm:"type:varchar(100);not null;default:'';comment:持有者实例ID" json:"owner"`
+	ExpiresAt time.Time `gorm:"not null;comment:锁过期时间" json:"expires_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SchedulerLock) TableName() string {
+	return "scheduler_locks"
+}
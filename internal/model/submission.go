@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+	"math"
 	"time"
 	"gorm.io/gorm"
 )
@@ -17,14 +19,34 @@ const (
 // Submission 学生作业提交模型
 type Submission struct {
 	gorm.Model
-	AssignmentID uint             `gorm:"not null;comment:作业ID" json:"assignment_id"`
-	StudentID    uint             `gorm:"not null;comment:学生ID" json:"student_id"`
+	AssignmentID uint             `gorm:"not null;uniqueIndex:idx_submission_attempt;comment:作业ID" json:"assignment_id"`
+	StudentID    uint             `gorm:"not null;uniqueIndex:idx_submission_attempt;comment:学生ID" json:"student_id"`
+	Attempt      int              `gorm:"not null;default:1;uniqueIndex:idx_submission_attempt;comment:作答次序(从1开始)" json:"attempt"`
 	Status       SubmissionStatus `gorm:"type:enum('draft','submitted','graded');default:'draft';comment:提交状态" json:"status"`
 	Score        int              `gorm:"default:0;comment:总得分" json:"score"`
 	SubmittedAt  *time.Time       `gorm:"comment:提交时间" json:"submitted_at"`
 	GradedAt     *time.Time       `gorm:"comment:批改时间" json:"graded_at"`
 	GradedBy     uint             `gorm:"comment:批改教师ID" json:"graded_by,omitempty"`
 	Feedback     string           `gorm:"type:text;comment:教师反馈" json:"feedback,omitempty"`
+	Version      uint             `gorm:"not null;default:0;comment:版本号(乐观锁)" json:"version"`
+
+	// 限时作答，开始作答时记录，截止后不再接受答案，未提交的草稿由后台自动交卷
+	StartedAt     *time.Time `gorm:"comment:开始作答时间" json:"started_at,omitempty"`
+	ExpiresAt     *time.Time `gorm:"index;comment:限时作答截止时间" json:"expires_at,omitempty"`
+	AutoSubmitted bool       `gorm:"default:false;comment:是否超时自动交卷" json:"auto_submitted,omitempty"`
+
+	// 随机组卷，开始作答时按种子生成学生的试卷布局，答案中的选项标识为学生看到的标识
+	Seed   int64  `gorm:"default:0;comment:随机组卷种子" json:"-"`
+	Layout string `gorm:"type:json;comment:试卷布局JSON" json:"-"`
+
+	// 迟交信息，提交时按作业的迟交策略确定扣分比例，计算总分时自动扣除
+	IsLate             bool    `gorm:"default:false;comment:是否迟交" json:"is_late"`
+	LatePenaltyPercent float64 `gorm:"default:0;comment:迟交扣分比例(%)" json:"late_penalty_percent,omitempty"`
+	LatePenalty        int     `gorm:"default:0;comment:迟交扣除的分数" json:"late_penalty,omitempty"`
+
+	// 批改锁，领取后其他教师在过期前不能批改该提交
+	GradingLockedBy      *uint      `gorm:"index;comment:领取批改的教师ID" json:"grading_locked_by,omitempty"`
+	GradingLockExpiresAt *time.Time `gorm:"comment:批改锁过期时间" json:"grading_lock_expires_at,omitempty"`
 
 	// 关联关系
 	Assignment Assignment `gorm:"foreignKey:AssignmentID" json:"assignment,omitempty"`
@@ -51,4 +73,86 @@ func (s *Submission) IsGraded() bool {
 // CanBeModified 检查是否可以修改
 func (s *Submission) CanBeModified() bool {
 	return s.Status == SubmissionStatusDraft
-}
\ No newline at end of file
+}
+
+// IsExpired 检查限时作答是否已到时间
+func (s *Submission) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && now.After(*s.ExpiresAt)
+}
+
+// RemainingTime 获取限时作答的剩余时间，已到时间时返回 0
+func (s *Submission) RemainingTime(now time.Time) time.Duration {
+	if s.ExpiresAt == nil || !now.Before(*s.ExpiresAt) {
+		return 0
+	}
+	return s.ExpiresAt.Sub(now)
+}
+
+// GetLayout 获取学生的试卷布局，作业未随机组卷时返回 nil
+func (s *Submission) GetLayout() (*QuestionLayout, error) {
+	if s.Layout == "" {
+		return nil, nil
+	}
+	layout := &QuestionLayout{}
+	if err := json.Unmarshal([]byte(s.Layout), layout); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+// ApplyLatePenalty 根据各题得分之和计算最终总分，按迟交扣分比例扣除
+func (s *Submission) ApplyLatePenalty(rawScore int) {
+	s.LatePenalty = 0
+	if s.LatePenaltyPercent > 0 && rawScore > 0 {
+		s.LatePenalty = int(math.Round(float64(rawScore) * s.LatePenaltyPercent / 100))
+	}
+	s.Score = rawScore - s.LatePenalty
+}
+
+// IsLockedByOther 检查提交是否被其他教师领取且批改锁尚未过期
+func (s *Submission) IsLockedByOther(graderID uint, now time.Time) bool {
+	return s.GradingLockedBy != nil && *s.GradingLockedBy != graderID &&
+		s.GradingLockExpiresAt != nil && s.GradingLockExpiresAt.After(now)
+}
+
+// RedactForStudent 隐藏学生不应看到的批改信息和答案，需要预加载 Answers.Question
+// 作业的完整题目（随机组卷时包含题目池和原选项顺序）不返回给学生
+func (s *Submission) RedactForStudent(revealed bool) {
+	for i := range s.Answers {
+		s.Answers[i].RedactForStudent(revealed)
+	}
+	s.Assignment.Questions = nil
+}
+
+// EffectiveScore 按计分方式计算学生在作业上的有效得分
+// 只统计传入的作答，由调用方按状态筛选；没有可统计的作答时返回 false
+func EffectiveScore(scoring AttemptScoring, attempts []*Submission) (float64, bool) {
+	if len(attempts) == 0 {
+		return 0, false
+	}
+
+	switch scoring {
+	case AttemptScoringLast:
+		last := attempts[0]
+		for _, attempt := range attempts[1:] {
+			if attempt.Attempt > last.Attempt {
+				last = attempt
+			}
+		}
+		return float64(last.Score), true
+	case AttemptScoringAverage:
+		total := 0
+		for _, attempt := range attempts {
+			total += attempt.Score
+		}
+		return float64(total) / float64(len(attempts)), true
+	default:
+		best := attempts[0].Score
+		for _, attempt := range attempts[1:] {
+			if attempt.Score > best {
+				best = attempt.Score
+			}
+		}
+		return float64(best), true
+	}
+}
@@ -0,0 +1,219 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AssignmentTemplate 作业模板，保存作业的设置、题目和附件，可在任意班级中创建作业
+type AssignmentTemplate struct {
+	gorm.Model
+	OwnerID            uint   `gorm:"not null;index;comment:创建者ID" json:"owner_id"`
+	Title              string `gorm:"type:varchar(200);not null;comment:模板标题" json:"title"`
+	Description        string `gorm:"type:text;comment:模板说明" json:"description"`
+	SourceAssignmentID *uint  `gorm:"index;comment:来源作业ID" json:"source_assignment_id,omitempty"`
+	Content            string `gorm:"type:json;comment:作业内容快照JSON" json:"-"`
+
+	// 关联关系
+	Owner       User                 `gorm:"foreignKey:OwnerID" json:"owner,omitempty"`
+	Attachments []TemplateAttachment `gorm:"foreignKey:TemplateID" json:"attachments,omitempty"`
+}
+
+// TableName 指定表名
+func (AssignmentTemplate) TableName() string {
+	return "assignment_templates"
+}
+
+// GetContent 解析模板的作业内容快照
+func (t *AssignmentTemplate) GetContent() (*AssignmentSnapshot, error) {
+	snapshot := &AssignmentSnapshot{}
+	if t.Content == "" {
+		return snapshot, nil
+	}
+	if err := json.Unmarshal([]byte(t.Content), snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// TemplateAttachment 模板附件，与作业附件共用磁盘上的文件
+type TemplateAttachment struct {
+	gorm.Model
+	TemplateID   uint   `gorm:"not null;index;comment:模板ID" json:"template_id"`
+	FileName     string `gorm:"type:varchar(255);not null;comment:文件名" json:"file_name"`
+	OriginalName string `gorm:"type:varchar(255);not null;comment:原始文件名" json:"original_name"`
+	FilePath     string `gorm:"type:varchar(500);not null;index;comment:文件路径" json:"-"`
+	FileSize     int64  `gorm:"not null;comment:文件大小(字节)" json:"file_size"`
+	ContentType  string `gorm:"type:varchar(100);not null;comment:文件类型" json:"content_type"`
+	ContentHash  string `gorm:"type:varchar(64);comment:文件内容SHA-256" json:"content_hash,omitempty"`
+}
+
+// TableName 指定表名
+func (TemplateAttachment) TableName() string {
+	return "template_attachments"
+}
+
+// AssignmentSnapshot 作业内容快照，用于复制作业和作业模板
+// 截止时间等日期保存为相对作业开始时间的偏移（秒），在新的开始时间上还原
+type AssignmentSnapshot struct {
+	Title             string             `json:"title"`
+	Description       string             `json:"description"`
+	TotalScore        int                `json:"total_score"`
+	DeadlineOffset    int64              `json:"deadline_offset"`
+	CloseOffset       *int64             `json:"close_offset,omitempty"`
+	LatePolicy        *LatePolicy        `json:"late_policy,omitempty"`   // 最晚提交时间保存在 CutoffOffset 中
+	CutoffOffset      *int64             `json:"cutoff_offset,omitempty"` // 迟交最晚提交时间的偏移
+	MaxAttempts       int                `json:"max_attempts"`
+	AttemptScoring    AttemptScoring     `json:"attempt_scoring"`
+	TimeLimitMinutes  int                `json:"time_limit_minutes"`
+	Randomization     string             `json:"randomization,omitempty"`
+	AnswerReveal      AnswerReveal       `json:"answer_reveal"`
+	AutoSubmitOnClose bool               `json:"auto_submit_on_close"`
+	Questions         []SnapshotQuestion `json:"questions"`
+}
+
+// SnapshotQuestion 快照中的题目
+type SnapshotQuestion struct {
+	Type          QuestionType `json:"type"`
+	Content       string       `json:"content"`
+	Score         int          `json:"score"`
+	Order         int          `json:"order"`
+	Options       string       `json:"options,omitempty"`
+	CorrectAnswer string       `json:"correct_answer,omitempty"`
+	Reference     string       `json:"reference,omitempty"`
+	Explanation   string       `json:"explanation,omitempty"`
+	BankItemID    *uint        `json:"bank_item_id,omitempty"`
+	ScoringRule   string       `json:"scoring_rule,omitempty"`
+	Pool          string       `json:"pool,omitempty"`
+}
+
+// StartTime 获取作业的开始时间，依次取定时发布时间、发布时间和创建时间，复制时以此为基准平移日期
+func (a *Assignment) StartTime() time.Time {
+	switch {
+	case a.PublishAt != nil:
+		return *a.PublishAt
+	case a.PublishedAt != nil:
+		return *a.PublishedAt
+	default:
+		return a.CreatedAt
+	}
+}
+
+// NewAssignmentSnapshot 生成作业内容快照，需要预加载 Questions
+func NewAssignmentSnapshot(a *Assignment) (*AssignmentSnapshot, error) {
+	start := a.StartTime()
+	offset := func(t time.Time) int64 {
+		return int64(t.Sub(start) / time.Second)
+	}
+
+	snapshot := &AssignmentSnapshot{
+		Title:             a.Title,
+		Description:       a.Description,
+		TotalScore:        a.TotalScore,
+		DeadlineOffset:    offset(a.Deadline),
+		MaxAttempts:       a.MaxAttempts,
+		AttemptScoring:    a.AttemptScoring,
+		TimeLimitMinutes:  a.TimeLimitMinutes,
+		Randomization:     a.Randomization,
+		AnswerReveal:      a.AnswerReveal,
+		AutoSubmitOnClose: a.AutoSubmitOnClose,
+	}
+	if a.CloseAt != nil {
+		closeOffset := offset(*a.CloseAt)
+		snapshot.CloseOffset = &closeOffset
+	}
+	if a.LatePolicy != "" {
+		policy, err := a.GetLatePolicy()
+		if err != nil {
+			return nil, err
+		}
+		if policy.Cutoff != nil {
+			cutoffOffset := offset(*policy.Cutoff)
+			snapshot.CutoffOffset = &cutoffOffset
+			policy.Cutoff = nil
+		}
+		snapshot.LatePolicy = policy
+	}
+
+	snapshot.Questions = make([]SnapshotQuestion, len(a.Questions))
+	for i, q := range a.Questions {
+		snapshot.Questions[i] = SnapshotQuestion{
+			Type:          q.Type,
+			Content:       q.Content,
+			Score:         q.Score,
+			Order:         q.Order,
+			Options:       q.Options,
+			CorrectAnswer: q.CorrectAnswer,
+			Reference:     q.Reference,
+			Explanation:   q.Explanation,
+			BankItemID:    q.BankItemID,
+			ScoringRule:   q.ScoringRule,
+			Pool:          q.Pool,
+		}
+	}
+	return snapshot, nil
+}
+
+// NewAssignment 按快照在班级中创建草稿作业（含题目），日期按新的开始时间平移
+// 开始时间晚于当前时间时设置为定时发布
+func (s *AssignmentSnapshot) NewAssignment(classID, teacherID uint, start time.Time) (*Assignment, error) {
+	at := func(offset int64) time.Time {
+		return start.Add(time.Duration(offset) * time.Second)
+	}
+
+	assignment := &Assignment{
+		Title:             s.Title,
+		Description:       s.Description,
+		ClassID:           classID,
+		TeacherID:         teacherID,
+		Deadline:          at(s.DeadlineOffset),
+		TotalScore:        s.TotalScore,
+		Status:            "draft",
+		MaxAttempts:       s.MaxAttempts,
+		AttemptScoring:    s.AttemptScoring,
+		TimeLimitMinutes:  s.TimeLimitMinutes,
+		Randomization:     s.Randomization,
+		AnswerReveal:      s.AnswerReveal,
+		AutoSubmitOnClose: s.AutoSubmitOnClose,
+	}
+	if start.After(time.Now()) {
+		publishAt := start
+		assignment.PublishAt = &publishAt
+	}
+	if s.CloseOffset != nil {
+		closeAt := at(*s.CloseOffset)
+		assignment.CloseAt = &closeAt
+	}
+	if s.LatePolicy != nil {
+		policy := *s.LatePolicy
+		if s.CutoffOffset != nil {
+			cutoff := at(*s.CutoffOffset)
+			policy.Cutoff = &cutoff
+		}
+		policyJSON, err := json.Marshal(policy)
+		if err != nil {
+			return nil, err
+		}
+		assignment.LatePolicy = string(policyJSON)
+	}
+
+	assignment.Questions = make([]Question, len(s.Questions))
+	for i, q := range s.Questions {
+		assignment.Questions[i] = Question{
+			Type:          q.Type,
+			Content:       q.Content,
+			Score:         q.Score,
+			Order:         q.Order,
+			Options:       q.Options,
+			CorrectAnswer: q.CorrectAnswer,
+			Reference:     q.Reference,
+			Explanation:   q.Explanation,
+			BankItemID:    q.BankItemID,
+			ScoringRule:   q.ScoringRule,
+			Pool:          q.Pool,
+		}
+	}
+	return assignment, nil
+}
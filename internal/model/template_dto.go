@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// CloneAssignmentRequest 复制作业或由模板创建作业请求
+type CloneAssignmentRequest struct {
+	ClassID   uint      `json:"class_id" binding:"required"`   // 目标班级
+	StartDate time.Time `json:"start_date" binding:"required"` // 新的开始时间，截止时间等日期按原作业相对开始时间的间隔平移
+	Title     string    `json:"title" binding:"max=200"`       // 新标题，默认沿用原标题
+}
+
+// SaveTemplateRequest 将作业保存为模板请求
+type SaveTemplateRequest struct {
+	Title       string `json:"title" binding:"max=200"` // 模板标题，默认沿用作业标题
+	Description string `json:"description"`
+}
+
+// TemplateListResponse 作业模板列表响应
+type TemplateListResponse struct {
+	ID              uint      `json:"id"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	TotalScore      int       `json:"total_score"`
+	QuestionCount   int       `json:"question_count"`
+	AttachmentCount int       `json:"attachment_count"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// TemplateDetailResponse 作业模板详情响应
+type TemplateDetailResponse struct {
+	*AssignmentTemplate
+	Content *AssignmentSnapshot `json:"content"`
+}
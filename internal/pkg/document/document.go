@@ -0,0 +1,218 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxDocumentSize 可解析的文档最大字节数
+const MaxDocumentSize = 20 << 20
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported document format")
+	ErrNoText            = errors.New("no text found in document")
+	ErrTooLarge          = errors.New("document too large")
+)
+
+// IsSupported 检查文件扩展名是否支持提取文本
+func IsSupported(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".txt", ".md", ".markdown", ".pdf":
+		return true
+	default:
+		return false
+	}
+}
+
+// ExtractText 根据文件扩展名从文档中提取纯文本，支持 TXT、Markdown 和 PDF
+func ExtractText(filename string, r io.Reader) (string, error) {
+	if !IsSupported(filename) {
+		return "", ErrUnsupportedFormat
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxDocumentSize+1))
+	if err != nil {
+		return "", fmt.Errorf("read document failed: %w", err)
+	}
+	if len(data) > MaxDocumentSize {
+		return "", ErrTooLarge
+	}
+
+	var text string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		text = extractPDFText(data)
+	case ".md", ".markdown":
+		text = stripMarkdown(string(data))
+	default:
+		text = string(data)
+	}
+
+	text = strings.TrimSpace(strings.ToValidUTF8(text, ""))
+	if text == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+var (
+	markdownImage    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markdownHeading  = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s*`)
+	markdownListItem = regexp.MustCompile(`(?m)^\s*(?:[-*+]|\d+\.)\s+`)
+	markdownEmphasis = regexp.MustCompile("[*_`~]{1,3}")
+)
+
+// stripMarkdown 去掉常见的 Markdown 标记，保留正文文字
+func stripMarkdown(text string) string {
+	text = markdownImage.ReplaceAllString(text, "$1")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = markdownHeading.ReplaceAllString(text, "")
+	text = markdownListItem.ReplaceAllString(text, "")
+	text = markdownEmphasis.ReplaceAllString(text, "")
+	return text
+}
+
+var (
+	pdfStream     = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	pdfTextBlock  = regexp.MustCompile(`(?s)BT(.*?)ET`)
+	pdfTextString = regexp.MustCompile(`\((?:\\.|[^\\)])*\)|<[0-9A-Fa-f\s]*>`)
+	pdfTextOp     = regexp.MustCompile(`(?s)(\[.*?\]|\((?:\\.|[^\\)])*\)|<[0-9A-Fa-f\s]*>)\s*(Tj|TJ|'|")|(T\*|Td|TD)`)
+)
+
+// extractPDFText 从 PDF 内容流中提取文本
+// 仅处理文本绘制操作符（Tj/TJ/'/"）中的字符串，支持 FlateDecode 压缩流；
+// 使用自定义字体编码（例如部分中文 CID 字体）的文档可能无法正确提取
+func extractPDFText(data []byte) string {
+	var out strings.Builder
+	for _, match := range pdfStream.FindAllSubmatch(data, -1) {
+		content := match[1]
+		if inflated, err := inflate(content); err == nil {
+			content = inflated
+		}
+		for _, block := range pdfTextBlock.FindAllSubmatch(content, -1) {
+			writePDFTextBlock(&out, block[1])
+			out.WriteByte('\n')
+		}
+	}
+	return out.String()
+}
+
+// writePDFTextBlock 输出 BT/ET 文本块中的文字
+func writePDFTextBlock(out *strings.Builder, block []byte) {
+	for _, op := range pdfTextOp.FindAllSubmatch(block, -1) {
+		if len(op[3]) > 0 {
+			// 换行操作符
+			out.WriteByte('\n')
+			continue
+		}
+		for _, str := range pdfTextString.FindAll(op[1], -1) {
+			out.WriteString(decodePDFString(str))
+		}
+	}
+}
+
+// decodePDFString 解码 PDF 字符串字面量（括号字符串或十六进制字符串）
+func decodePDFString(raw []byte) string {
+	var decoded []byte
+	if raw[0] == '<' {
+		hex := bytes.Map(func(r rune) rune {
+			if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+				return -1
+			}
+			return r
+		}, raw[1:len(raw)-1])
+		if len(hex)%2 == 1 {
+			hex = append(hex, '0')
+		}
+		decoded = make([]byte, len(hex)/2)
+		for i := range decoded {
+			fmt.Sscanf(string(hex[2*i:2*i+2]), "%02x", &decoded[i])
+		}
+	} else {
+		decoded = unescapePDFLiteral(raw[1 : len(raw)-1])
+	}
+
+	// UTF-16BE 编码（带 BOM）
+	if len(decoded) >= 2 && decoded[0] == 0xFE && decoded[1] == 0xFF {
+		return decodeUTF16BE(decoded[2:])
+	}
+	if utf8.Valid(decoded) {
+		return string(decoded)
+	}
+	// 按 Latin-1 解释
+	runes := make([]rune, len(decoded))
+	for i, b := range decoded {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// unescapePDFLiteral 处理括号字符串中的转义序列
+func unescapePDFLiteral(s []byte) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			out = append(out, s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'b', 'f':
+			// 忽略退格和换页
+		case '\r', '\n':
+			// 续行
+		default:
+			if s[i] >= '0' && s[i] <= '7' {
+				value := 0
+				j := i
+				for ; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+					value = value*8 + int(s[j]-'0')
+				}
+				out = append(out, byte(value))
+				i = j - 1
+			} else {
+				out = append(out, s[i])
+			}
+		}
+	}
+	return out
+}
+
+// decodeUTF16BE 解码 UTF-16BE 字节序列
+func decodeUTF16BE(b []byte) string {
+	runes := make([]rune, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		r := rune(b[i])<<8 | rune(b[i+1])
+		if r >= 0xD800 && r < 0xDC00 && i+3 < len(b) {
+			low := rune(b[i+2])<<8 | rune(b[i+3])
+			r = (r-0xD800)<<10 + (low - 0xDC00) + 0x10000
+			i += 2
+		}
+		runes = append(runes, r)
+	}
+	return string(runes)
+}
+
+// inflate 解压 FlateDecode 流
+func inflate(data []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(io.LimitReader(reader, MaxDocumentSize))
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// utf8BOM UTF-8 字节顺序标记
+const utf8BOM = "\xEF\xBB\xBF"
+
+// csvWriter CSV 表格写入器
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, opts Options) (*csvWriter, error) {
+	if opts.BOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return nil, err
+		}
+	}
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+// Write 写入一行
+func (c *csvWriter) Write(row []interface{}) error {
+	record := make([]string, len(row))
+	for i, value := range row {
+		if s, ok := formatNumber(value); ok {
+			record[i] = s
+			continue
+		}
+		record[i] = escapeFormula(formatText(value))
+	}
+	return c.w.Write(record)
+}
+
+// Close 刷新缓冲区
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula 文本以公式字符开头时加单引号前缀，避免表格软件将学生填写的内容当作公式执行
+func escapeFormula(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + s
+	}
+	return s
+}
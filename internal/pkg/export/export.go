@@ -0,0 +1,104 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Format 导出文件格式
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ParseFormat 解析导出格式，为空时使用 CSV
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ContentType 返回格式对应的 MIME 类型
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension 返回格式对应的文件扩展名（含点号）
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Options 导出选项
+type Options struct {
+	SheetName string // XLSX 工作表名称
+	BOM       bool   // CSV 是否写入 UTF-8 BOM，Excel 需要 BOM 才能正确识别中文
+}
+
+// Writer 逐行写入表格，第一行为表头；写入的数据直接输出到底层 io.Writer，不在内存中缓存整个文件
+// 单元格支持 nil（空）、string 以及整数和浮点数，数字在 XLSX 中写为数值单元格
+type Writer interface {
+	Write(row []interface{}) error
+	// Close 写入文件尾并刷新缓冲区，不关闭底层 io.Writer
+	Close() error
+}
+
+// NewWriter 按格式创建表格写入器
+func NewWriter(w io.Writer, format Format, opts Options) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, opts)
+	case FormatXLSX:
+		return newXLSXWriter(w, opts)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// formatNumber 将数字格式化为字符串，不是数字时返回 false
+func formatNumber(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// formatText 将非数字的单元格格式化为字符串
+func formatText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		if s, ok := formatNumber(v); ok {
+			return s
+		}
+		return ""
+	}
+}
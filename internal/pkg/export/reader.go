@@ -0,0 +1,341 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+const (
+	// MaxImportSize 可导入的表格文件最大字节数
+	MaxImportSize = 10 << 20
+	// maxXLSXPartSize XLSX 中单个文件解压后的最大字节数，防止压缩炸弹
+	maxXLSXPartSize = 100 << 20
+	// maxXLSXRows、maxXLSXColumns Excel 工作表的最大行数和列数，超出的单元格引用视为无效文件
+	maxXLSXRows    = 1 << 20
+	maxXLSXColumns = 1 << 14
+	// maxXLSXCells 工作表按单元格引用补齐空行空列后的最大单元格数，防止稀疏引用占用大量内存
+	maxXLSXCells = 2 << 20
+)
+
+var (
+	ErrTooLarge    = errors.New("spreadsheet file too large")
+	ErrInvalidFile = errors.New("invalid spreadsheet file")
+)
+
+// FormatOf 根据文件扩展名确定表格格式
+func FormatOf(filename string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ReadRows 根据文件扩展名读取 CSV 或 XLSX 表格的全部行，XLSX 只读取第一个工作表
+// CSV 可以是带或不带 BOM 的 UTF-8，也可以是 GBK（Excel 在中文系统中另存为 CSV 的默认编码）
+func ReadRows(filename string, r io.Reader) ([][]string, error) {
+	format, err := FormatOf(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxImportSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read spreadsheet failed: %w", err)
+	}
+	if len(data) > MaxImportSize {
+		return nil, ErrTooLarge
+	}
+
+	if format == FormatXLSX {
+		return readXLSX(data)
+	}
+	return readCSV(data)
+}
+
+// readCSV 读取 CSV，自动识别编码
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte(utf8BOM))
+	if !utf8.Valid(data) {
+		decoded, err := simplifiedchinese.GBK.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, ErrInvalidFile
+		}
+		data = decoded
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return rows, nil
+}
+
+// readXLSX 读取 XLSX 第一个工作表的单元格文本
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrInvalidFile
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	var sharedStrings []string
+	if f := files["xl/sharedStrings.xml"]; f != nil {
+		if sharedStrings, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+	sheet := files[sheetPath]
+	if sheet == nil {
+		return nil, ErrInvalidFile
+	}
+	return readSheet(sheet, sharedStrings)
+}
+
+// firstSheetPath 从工作簿和关系文件中找到第一个工作表的路径
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(files["xl/workbook.xml"], &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", ErrInvalidFile
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", ErrInvalidFile
+}
+
+// readSharedStrings 读取共享字符串表，富文本按片段拼接
+func readSharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := decodePart(f, &sst); err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		text := item.Text
+		for _, run := range item.Runs {
+			text += run.Text
+		}
+		strs[i] = text
+	}
+	return strs, nil
+}
+
+// xlsxCell 工作表中的单元格
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"is"`
+}
+
+// readSheet 逐行读取工作表，按单元格引用放到对应的列，缺少的行和列补为空
+func readSheet(f *zip.File, sharedStrings []string) ([][]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, ErrInvalidFile
+	}
+	defer rc.Close()
+
+	var rows [][]string
+	cells := 0
+	decoder := xml.NewDecoder(io.LimitReader(rc, maxXLSXPartSize))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		rowNum := len(rows) + 1
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "r" {
+				if n, err := strconv.Atoi(attr.Value); err == nil && n >= rowNum {
+					rowNum = n
+				}
+			}
+		}
+		if rowNum > maxXLSXRows {
+			return nil, fmt.Errorf("%w: row %d out of sheet range", ErrInvalidFile, rowNum)
+		}
+		cells += rowNum - 1 - len(rows)
+		if cells > maxXLSXCells {
+			return nil, ErrTooLarge
+		}
+		for len(rows) < rowNum-1 {
+			rows = append(rows, nil)
+		}
+
+		var row []string
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+			}
+			if end, ok := token.(xml.EndElement); ok && end.Name.Local == "row" {
+				break
+			}
+			start, ok := token.(xml.StartElement)
+			if !ok || start.Name.Local != "c" {
+				continue
+			}
+			var cell xlsxCell
+			if err := decoder.DecodeElement(&cell, &start); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+			}
+
+			col := len(row)
+			if cell.Ref != "" {
+				if index, ok := columnIndex(cell.Ref); ok && index >= col {
+					col = index
+				}
+			}
+			if col >= maxXLSXColumns {
+				return nil, fmt.Errorf("%w: cell %s out of sheet range", ErrInvalidFile, cell.Ref)
+			}
+			cells += col + 1 - len(row)
+			if cells > maxXLSXCells {
+				return nil, ErrTooLarge
+			}
+			for len(row) < col {
+				row = append(row, "")
+			}
+			row = append(row, cell.text(sharedStrings))
+		}
+		rows = append(rows, row)
+	}
+}
+
+// text 返回单元格的文本
+func (c *xlsxCell) text(sharedStrings []string) string {
+	switch c.Type {
+	case "s":
+		index, err := strconv.Atoi(strings.TrimSpace(c.Value))
+		if err != nil || index < 0 || index >= len(sharedStrings) {
+			return ""
+		}
+		return sharedStrings[index]
+	case "inlineStr":
+		text := c.Inline.Text
+		for _, run := range c.Inline.Runs {
+			text += run.Text
+		}
+		return text
+	case "b":
+		if c.Value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "", "n":
+		// 数值按最短形式输出，去掉浮点误差（如 8.0000000000000002）
+		if v, err := strconv.ParseFloat(c.Value, 64); err == nil {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return c.Value
+	default:
+		return c.Value
+	}
+}
+
+// columnIndex 从单元格引用（如 AB12）解析从 0 开始的列序号
+func columnIndex(ref string) (int, bool) {
+	index := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+		n++
+		if index > maxXLSXColumns {
+			// 超出最大列数时不再累加，避免溢出
+			return index - 1, true
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return index - 1, true
+}
+
+// decodePart 解析 XLSX 中的 XML 文件
+func decodePart(f *zip.File, v interface{}) error {
+	if f == nil {
+		return ErrInvalidFile
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return ErrInvalidFile
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, maxXLSXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeClient 本地假客户端，不访问网络，按顺序返回预设回复并记录收到的对话，用于测试和本地开发
+// 预设回复用完后重复返回最后一条；Err 不为空时每次调用都返回该错误
+type FakeClient struct {
+	Replies []string
+	Err     error
+	Name    string
+
+	mu    sync.Mutex
+	calls [][]Message
+}
+
+// NewFakeClient 创建返回预设回复的假客户端
+func NewFakeClient(replies ...string) *FakeClient {
+	return &FakeClient{Replies: replies, Name: "fake"}
+}
+
+// Chat 记录对话并返回下一条预设回复
+func (c *FakeClient) Chat(ctx context.Context, messages []Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	index := len(c.calls)
+	c.calls = append(c.calls, append([]Message(nil), messages...))
+	if c.Err != nil {
+		return "", c.Err
+	}
+	if len(c.Replies) == 0 {
+		return "", ErrEmptyResponse
+	}
+	if index >= len(c.Replies) {
+		index = len(c.Replies) - 1
+	}
+	return c.Replies[index], nil
+}
+
+// Model 返回模型名称
+func (c *FakeClient) Model() string {
+	return c.Name
+}
+
+// Calls 返回已收到的全部对话
+func (c *FakeClient) Calls() [][]Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]Message(nil), c.calls...)
+}
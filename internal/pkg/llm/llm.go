@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	RoleSystem = "system"
+	RoleUser   = "user"
+
+	DefaultTimeout = 60 * time.Second
+	// MaxResponseSize 读取接口响应体的最大字节数
+	MaxResponseSize = 4 << 20
+)
+
+var ErrEmptyResponse = errors.New("llm returned empty response")
+
+// Message 对话消息
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Client 大模型对话客户端接口
+type Client interface {
+	// Chat 发送对话并返回模型回复内容
+	Chat(ctx context.Context, messages []Message) (string, error)
+	// Model 返回模型名称
+	Model() string
+}
+
+// OpenAIClient OpenAI 兼容接口（/chat/completions）客户端
+type OpenAIClient struct {
+	baseURL     string
+	apiKey      string
+	model       string
+	temperature float64
+	httpClient  *http.Client
+}
+
+// NewOpenAIClient 创建 OpenAI 兼容接口客户端
+func NewOpenAIClient(baseURL, apiKey, model string, temperature float64, timeout time.Duration) *OpenAIClient {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &OpenAIClient{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		apiKey:      apiKey,
+		model:       model,
+		temperature: temperature,
+		httpClient:  &http.Client{Timeout: timeout},
+	}
+}
+
+// chatRequest 对话请求体
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+}
+
+// chatResponse 对话响应体
+type chatResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Chat 发送对话并返回模型回复内容
+func (c *OpenAIClient) Chat(ctx context.Context, messages []Message) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:       c.model,
+		Messages:    messages,
+		Temperature: c.temperature,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal chat request failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create chat request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send chat request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize+1))
+	if err != nil {
+		return "", fmt.Errorf("read chat response failed: %w", err)
+	}
+	if len(respBody) > MaxResponseSize {
+		return "", fmt.Errorf("chat response exceeds %d bytes", MaxResponseSize)
+	}
+
+	var result chatResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("decode chat response failed (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != nil {
+			return "", fmt.Errorf("chat request failed (status %d): %s", resp.StatusCode, result.Error.Message)
+		}
+		return "", fmt.Errorf("chat request failed with status %d", resp.StatusCode)
+	}
+	if len(result.Choices) == 0 || strings.TrimSpace(result.Choices[0].Message.Content) == "" {
+		return "", ErrEmptyResponse
+	}
+
+	return result.Choices[0].Message.Content, nil
+}
+
+// Model 返回模型名称
+func (c *OpenAIClient) Model() string {
+	return c.model
+}
+
+// ExtractJSON 从模型回复中提取 JSON 内容，兼容 ```json 代码块和前后附带说明文字的情况
+func ExtractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+		content = strings.TrimSpace(content)
+	}
+
+	// 取第一个 JSON 对象或数组
+	start := strings.IndexAny(content, "{[")
+	if start < 0 {
+		return content
+	}
+	closing := byte('}')
+	if content[start] == '[' {
+		closing = ']'
+	}
+	end := strings.LastIndexByte(content, closing)
+	if end < start {
+		return content[start:]
+	}
+	return content[start : end+1]
+}
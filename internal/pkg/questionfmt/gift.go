@@ -0,0 +1,421 @@
+package questionfmt
+
+import (
+	"ai-course/internal/model"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// readGIFT 读取 GIFT 文本，题目之间以空行分隔，// 开头的行为注释，$CATEGORY 分类不计为题目
+//
+// 答案块的写法：{} 简答题，{T}/{F} 判断题，{#数值:误差} 或 {#最小..最大} 数值题，{=左 -> 右 ...} 连线题，
+// 含 ~ 的为选择题（多个得分选项或部分得分时为多选题），只有 = 的为填空题；答案块中 #### 之后的内容作为解析，简答题作为参考答案
+func readGIFT(text string) []Item {
+	var items []Item
+	for _, block := range giftBlocks(text) {
+		if strings.HasPrefix(block, "$CATEGORY") {
+			continue
+		}
+		name, q, err := giftQuestion(block)
+		item := Item{Name: name, Question: q, Err: err}
+		if err != nil {
+			item.Question = nil
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// giftBlocks 去掉注释后按空行拆分题目
+func giftBlocks(text string) []string {
+	var blocks []string
+	var lines []string
+	flush := func() {
+		if len(lines) > 0 {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+			lines = nil
+		}
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "//"):
+		default:
+			lines = append(lines, trimmed)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// giftQuestion 解析一道 GIFT 题目，返回题目名称
+func giftQuestion(block string) (string, *model.CreateQuestionRequest, error) {
+	name := ""
+	if strings.HasPrefix(block, "::") {
+		if end := indexUnescaped(block[2:], "::"); end >= 0 {
+			name = strings.TrimSpace(unescapeGIFT(block[2 : end+2]))
+			block = strings.TrimSpace(block[end+4:])
+		}
+	}
+	if name == "" {
+		text, _, _ := strings.Cut(block, "{")
+		name = summary(unescapeGIFT(text))
+	}
+	format := ""
+	if strings.HasPrefix(block, "[") {
+		if end := strings.Index(block, "]"); end > 0 {
+			format = strings.ToLower(block[1:end])
+			block = block[end+1:]
+		}
+	}
+
+	open := indexUnescaped(block, "{")
+	if open < 0 {
+		return name, nil, errors.New("缺少答案块")
+	}
+	closeAt := indexUnescaped(block[open:], "}")
+	if closeAt < 0 {
+		return name, nil, errors.New("答案块缺少 }")
+	}
+	closeAt += open
+	toText := func(s string) string {
+		s = unescapeGIFT(strings.TrimSpace(s))
+		if format == "html" {
+			return htmlToText(s)
+		}
+		return strings.TrimSpace(s)
+	}
+	content := toText(block[:open])
+	if after := toText(block[closeAt+1:]); after != "" {
+		content += " ____ " + after
+	}
+
+	body := strings.TrimSpace(block[open+1 : closeAt])
+	feedback := ""
+	if at := indexUnescaped(body, "####"); at >= 0 {
+		feedback = toText(body[at+4:])
+		body = strings.TrimSpace(body[:at])
+	}
+	q := &model.CreateQuestionRequest{Content: content, Explanation: feedback}
+
+	answer, _ := cutEscaped(body, '#')
+	switch strings.ToUpper(strings.TrimSpace(answer)) {
+	case "":
+		if body == "" {
+			q.Type = model.QuestionTypeEssay
+			q.Reference, q.Explanation = feedback, ""
+			return name, q, nil
+		}
+	case "T", "TRUE":
+		q.Type, q.CorrectAnswer = model.QuestionTypeTrueFalse, boolAnswer(true)
+		return name, q, nil
+	case "F", "FALSE":
+		q.Type, q.CorrectAnswer = model.QuestionTypeTrueFalse, boolAnswer(false)
+		return name, q, nil
+	}
+
+	if strings.HasPrefix(body, "#") {
+		return name, q, giftNumeric(q, body[1:])
+	}
+
+	answers := giftAnswers(body)
+	if len(answers) == 0 {
+		return name, nil, errors.New("答案块中没有答案")
+	}
+	hasWrong, isMatching := false, false
+	for _, a := range answers {
+		if a.mark == '~' {
+			hasWrong = true
+		}
+		if indexUnescaped(a.text, "->") >= 0 {
+			isMatching = true
+		}
+	}
+
+	switch {
+	case isMatching:
+		q.Type = model.QuestionTypeMatching
+		q.Matching = &model.MatchingSpec{Pairs: make(map[string]string)}
+		rightKeys := make(map[string]string)
+		for _, a := range answers {
+			at := indexUnescaped(a.text, "->")
+			if at < 0 {
+				return name, nil, fmt.Errorf("连线题答案“%s”缺少 ->", unescapeGIFT(a.text))
+			}
+			left, right := toText(a.text[:at]), toText(a.text[at+2:])
+			rightKey, ok := rightKeys[right]
+			if !ok {
+				rightKey = optionKey(len(q.Matching.Right))
+				rightKeys[right] = rightKey
+				q.Matching.Right = append(q.Matching.Right, model.QuestionOption{Key: rightKey, Value: right})
+			}
+			if left != "" {
+				leftKey := strconv.Itoa(len(q.Matching.Left) + 1)
+				q.Matching.Left = append(q.Matching.Left, model.QuestionOption{Key: leftKey, Value: left})
+				q.Matching.Pairs[leftKey] = rightKey
+			}
+		}
+	case hasWrong:
+		q.Type = model.QuestionTypeChoice
+		positive := 0
+		for _, a := range answers {
+			if a.weight > 0 {
+				positive++
+			}
+			// 部分得分或倒扣分只用于多选题
+			if (a.weight > 0 && a.weight < 100) || a.weight < 0 {
+				q.IsMultiple = true
+			}
+		}
+		q.IsMultiple = q.IsMultiple || positive > 1
+		var keys []string
+		for i, a := range answers {
+			key := optionKey(i)
+			q.Options = append(q.Options, model.QuestionOption{Key: key, Value: toText(a.text)})
+			if a.weight > 0 {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			return name, nil, errors.New("没有得分的选项")
+		}
+		q.CorrectAnswer = strings.Join(keys, ",")
+	default:
+		q.Type = model.QuestionTypeFillBlank
+		var accepted []string
+		for _, a := range answers {
+			if a.weight >= 100 {
+				accepted = append(accepted, toText(a.text))
+			}
+		}
+		if len(accepted) == 0 {
+			return name, nil, errors.New("没有满分的答案")
+		}
+		setBlankAnswers(q, [][]string{accepted}, false)
+	}
+	return name, q, nil
+}
+
+// giftAnswer 答案块中的一个答案
+type giftAnswer struct {
+	mark   byte    // = 或 ~
+	weight float64 // 得分百分比
+	text   string  // 未去转义的答案文本，不含答案反馈
+}
+
+// giftAnswers 按未转义的 = 和 ~ 拆分答案
+func giftAnswers(body string) []giftAnswer {
+	var answers []giftAnswer
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		a := giftAnswer{mark: body[start]}
+		text := body[start+1 : end]
+		if a.mark == '=' {
+			a.weight = 100
+		}
+		if strings.HasPrefix(text, "%") {
+			if end := strings.Index(text[1:], "%"); end >= 0 {
+				a.weight, _ = strconv.ParseFloat(text[1:end+1], 64)
+				text = text[end+2:]
+			}
+		}
+		text, _ = cutEscaped(text, '#')
+		a.text = strings.TrimSpace(text)
+		answers = append(answers, a)
+	}
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			i++
+		case '=', '~':
+			flush(i)
+			start = i
+		}
+	}
+	flush(len(body))
+	return answers
+}
+
+// giftNumeric 解析数值题答案：数值:误差、最小..最大 或 数值
+func giftNumeric(q *model.CreateQuestionRequest, body string) error {
+	q.Type = model.QuestionTypeNumeric
+	answer := body
+	if answers := giftAnswers(body); len(answers) > 0 {
+		answer = answers[0].text
+		for _, a := range answers {
+			if a.weight >= 100 {
+				answer = a.text
+				break
+			}
+		}
+	} else {
+		answer, _ = cutEscaped(answer, '#')
+	}
+	answer = strings.TrimSpace(unescapeGIFT(answer))
+
+	var value, tolerance float64
+	var err error
+	if low, high, ok := strings.Cut(answer, ".."); ok {
+		var lower, upper float64
+		if lower, err = strconv.ParseFloat(strings.TrimSpace(low), 64); err == nil {
+			upper, err = strconv.ParseFloat(strings.TrimSpace(high), 64)
+		}
+		// 按区间端点的小数位数取整，避免浮点运算产生的尾数
+		decimals := max(decimalPlaces(low), decimalPlaces(high)) + 1
+		value, _ = strconv.ParseFloat(strconv.FormatFloat((lower+upper)/2, 'f', decimals, 64), 64)
+		tolerance, _ = strconv.ParseFloat(strconv.FormatFloat((upper-lower)/2, 'f', decimals, 64), 64)
+	} else if v, tol, ok := strings.Cut(answer, ":"); ok {
+		if value, err = strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			tolerance, err = strconv.ParseFloat(strings.TrimSpace(tol), 64)
+		}
+	} else {
+		value, err = strconv.ParseFloat(answer, 64)
+	}
+	if err != nil || tolerance < 0 {
+		return fmt.Errorf("数值题答案“%s”无效", answer)
+	}
+	q.Numeric = &model.NumericSpec{Value: &value, NumericOptions: model.NumericOptions{Tolerance: tolerance}}
+	return nil
+}
+
+// decimalPlaces 返回数值文本的小数位数
+func decimalPlaces(s string) int {
+	if _, fraction, ok := strings.Cut(strings.TrimSpace(s), "."); ok {
+		return len(fraction)
+	}
+	return 0
+}
+
+// indexUnescaped 返回 sub 第一次未被反斜杠转义出现的位置
+func indexUnescaped(s, sub string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeGIFT 去掉 GIFT 转义，\n 为换行
+func unescapeGIFT(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// giftEscaper 转义 GIFT 特殊字符，换行写为 \n 以免空行拆分题目
+var giftEscaper = strings.NewReplacer(`\`, `\\`, "~", `\~`, "=", `\=`, "#", `\#`, "{", `\{`, "}", `\}`, ":", `\:`, "\r\n", `\n`, "\n", `\n`)
+
+// writeGIFT 写出 GIFT 文本；排序题、完形填空、编程题和多空填空题无法表示，跳过；数值题的单位不导出
+func writeGIFT(w io.Writer, title string, questions []*model.CreateQuestionRequest) ([]int, error) {
+	var b strings.Builder
+	title = strings.Join(strings.Fields(title), " ")
+	fmt.Fprintf(&b, "// %s\n$CATEGORY: $course$/top/%s\n\n", title, strings.ReplaceAll(title, "/", "-"))
+
+	var skipped []int
+	for i, q := range questions {
+		answer, ok := giftAnswerBlock(q)
+		if !ok {
+			skipped = append(skipped, i)
+			continue
+		}
+		fmt.Fprintf(&b, "::%s::[markdown]%s{%s}\n\n", giftEscaper.Replace(questionName(q, i)), giftEscaper.Replace(q.Content), answer)
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return nil, err
+	}
+	return skipped, nil
+}
+
+// giftAnswerBlock 生成答案块的内容，无法表示时返回 false
+func giftAnswerBlock(q *model.CreateQuestionRequest) (string, bool) {
+	var parts []string
+	switch q.Type {
+	case model.QuestionTypeChoice:
+		keys := make(map[string]bool)
+		for _, key := range correctKeys(q.CorrectAnswer) {
+			keys[key] = true
+		}
+		for _, option := range q.Options {
+			value := giftEscaper.Replace(option.Value)
+			switch {
+			case !q.IsMultiple && keys[option.Key]:
+				parts = append(parts, "="+value)
+			case !q.IsMultiple:
+				parts = append(parts, "~"+value)
+			case keys[option.Key]:
+				parts = append(parts, "~%"+formatFraction(100/float64(len(keys)))+"%"+value)
+			default:
+				parts = append(parts, "~%-100%"+value)
+			}
+		}
+	case model.QuestionTypeTrueFalse:
+		value, _ := parseBool(q.CorrectAnswer)
+		parts = append(parts, strings.ToUpper(boolAnswer(value)))
+	case model.QuestionTypeFillBlank:
+		blanks := blankAnswers(q)
+		if len(blanks) != 1 {
+			return "", false
+		}
+		for _, accepted := range blanks[0] {
+			parts = append(parts, "="+giftEscaper.Replace(accepted))
+		}
+	case model.QuestionTypeNumeric:
+		if q.Numeric == nil || q.Numeric.Value == nil {
+			return "", false
+		}
+		parts = append(parts, "#"+formatFloat(*q.Numeric.Value)+":"+formatFloat(q.Numeric.Tolerance))
+	case model.QuestionTypeEssay:
+		if q.Reference != "" {
+			return "####" + giftEscaper.Replace(q.Reference), true
+		}
+		return "", true
+	case model.QuestionTypeMatching:
+		if q.Matching == nil {
+			return "", false
+		}
+		rights := make(map[string]string, len(q.Matching.Right))
+		for _, option := range q.Matching.Right {
+			rights[option.Key] = option.Value
+		}
+		used := make(map[string]bool)
+		for _, left := range q.Matching.Left {
+			rightKey := q.Matching.Pairs[left.Key]
+			used[rightKey] = true
+			parts = append(parts, "="+giftEscaper.Replace(left.Value)+" -> "+giftEscaper.Replace(rights[rightKey]))
+		}
+		for _, right := range q.Matching.Right {
+			if !used[right.Key] {
+				parts = append(parts, "= -> "+giftEscaper.Replace(right.Value))
+			}
+		}
+	default:
+		return "", false
+	}
+	if q.Explanation != "" {
+		parts = append(parts, "####"+giftEscaper.Replace(q.Explanation))
+	}
+	return strings.Join(parts, " "), true
+}
@@ -0,0 +1,621 @@
+package questionfmt
+
+import (
+	"ai-course/internal/model"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Markdown 题目格式，便于在编辑器中手写或由其他文档转换：
+//
+//	# 试卷标题（可省略，导入时忽略）
+//
+//	## 1. 单选题（5分）
+//	题干，可以有多行，可以包含代码块
+//
+//	A. 选项一
+//	B. 选项二
+//
+//	答案：A
+//	解析：解析内容，可以有多行
+//
+// 每道题以二级标题开始，标题中依次为可省略的题号、题型和分值。题型可写为单选题、多选题、判断题、填空题、简答题、
+// 连线题、排序题、数值题、完形填空、编程题或对应的英文题型名称（choice、fill_blank 等），省略时按答案推断。
+// 题干之后为选项和字段行，字段名后跟中文或英文冒号，字段名也可以使用括号中的英文：
+//
+//   - 答案（answer）：选择题为选项字母，如 “A,C”；判断题为 “正确” 或 “错误”；排序题为按正确顺序排列的字母，如 “C,A,B”；
+//     连线题为 “1-A, 2-C”；填空题和完形填空各空以 “;” 分隔，同一空的多个可接受答案以 “|” 分隔，答案中的这两个字符写为 “\;” 和 “\|”
+//   - 参考答案（reference）、解析（explanation）：可以有多行，直到下一个字段
+//   - 分值（score）、单位（unit）、误差（tolerance）、语言（language）、题目池（pool）
+//   - 区分大小写（case_sensitive）：填空题和完形填空填写 “是” 时比较答案区分大小写
+//
+// 连线题的左列写为 “1. 内容”，右列写为 “A. 内容”。完形填空在题干中以 {{blank}} 标记空，下拉选择的空写为
+// “空2：A. 选项 | B. 选项”，答案中该空填写选项字母。编程题的初始代码、测试用例和参考代码使用带标记的代码块：
+// starter、input（隐藏用例为 input hidden）、output、reference，每个 input 之后紧跟对应的 output。
+
+// markdownTypes 题型名称
+var markdownTypes = map[string]struct {
+	typ      model.QuestionType
+	multiple bool
+}{
+	"单选题": {model.QuestionTypeChoice, false}, "单选": {model.QuestionTypeChoice, false}, "single_choice": {model.QuestionTypeChoice, false},
+	"多选题": {model.QuestionTypeChoice, true}, "多选": {model.QuestionTypeChoice, true}, "multiple_choice": {model.QuestionTypeChoice, true},
+	"选择题": {model.QuestionTypeChoice, false}, "choice": {model.QuestionTypeChoice, false},
+	"判断题": {model.QuestionTypeTrueFalse, false}, "判断": {model.QuestionTypeTrueFalse, false}, "true_false": {model.QuestionTypeTrueFalse, false},
+	"填空题": {model.QuestionTypeFillBlank, false}, "填空": {model.QuestionTypeFillBlank, false}, "fill_blank": {model.QuestionTypeFillBlank, false},
+	"简答题": {model.QuestionTypeEssay, false}, "简答": {model.QuestionTypeEssay, false}, "问答题": {model.QuestionTypeEssay, false}, "essay": {model.QuestionTypeEssay, false},
+	"连线题": {model.QuestionTypeMatching, false}, "匹配题": {model.QuestionTypeMatching, false}, "matching": {model.QuestionTypeMatching, false},
+	"排序题": {model.QuestionTypeOrdering, false}, "ordering": {model.QuestionTypeOrdering, false},
+	"数值题": {model.QuestionTypeNumeric, false}, "numeric": {model.QuestionTypeNumeric, false},
+	"完形填空": {model.QuestionTypeCloze, false}, "完形填空题": {model.QuestionTypeCloze, false}, "cloze": {model.QuestionTypeCloze, false},
+	"编程题": {model.QuestionTypeCode, false}, "code": {model.QuestionTypeCode, false},
+}
+
+// markdownFields 字段名称
+var markdownFields = map[string]string{
+	"答案": "answer", "正确答案": "answer", "answer": "answer",
+	"参考答案": "reference", "reference": "reference",
+	"解析": "explanation", "explanation": "explanation",
+	"分值": "score", "score": "score",
+	"单位": "unit", "unit": "unit",
+	"误差": "tolerance", "tolerance": "tolerance",
+	"语言": "language", "language": "language",
+	"题目池": "pool", "pool": "pool",
+	"区分大小写": "case_sensitive", "case_sensitive": "case_sensitive",
+}
+
+var (
+	markdownNumber     = regexp.MustCompile(`^(?:第\s*)?\d+\s*(?:[.．、)）]|题|$)\s*`)
+	markdownScore      = regexp.MustCompile(`[（(\[]?\s*(\d+(?:\.\d+)?)\s*(?:分|points?|pts?)\s*[)）\]]?`)
+	markdownField      = regexp.MustCompile(`^([^\s:：]+)\s*[:：]\s*(.*)$`)
+	markdownOption     = regexp.MustCompile(`^([A-Z])\s*[.．、)）]\s*(.*)$`)
+	markdownLeft       = regexp.MustCompile(`^(\d+)\s*[.．、)）]\s*(.*)$`)
+	markdownClozeBlank = regexp.MustCompile(`^(?:空|blank\s*)(\d+)\s*[:：]\s*(.*)$`)
+	markdownPair       = regexp.MustCompile(`(\d+)\s*(?:->|→|-|=|:|：)?\s*([A-Z])`)
+	markdownFence      = regexp.MustCompile("^(`{3,})\\s*(.*)$")
+	markdownBackticks  = regexp.MustCompile("`+")
+)
+
+// markdownQuestion 一道题目解析过程中的内容
+type markdownQuestion struct {
+	heading string
+	content []string
+	options []model.QuestionOption
+	left    []model.QuestionOption
+	choices map[int][]model.QuestionOption // 完形填空下拉选择的空，从 1 开始
+	fields  map[string]string
+	fences  []markdownBlock
+}
+
+// markdownBlock 带标记的代码块
+type markdownBlock struct {
+	info string
+	body string
+}
+
+// readMarkdown 读取 Markdown 题目文档，见文件开头的格式说明
+func readMarkdown(text string) []Item {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var items []Item
+	var current *markdownQuestion
+	var field string // 正在读取的多行字段
+	finish := func() {
+		if current == nil {
+			return
+		}
+		name, q, err := current.question()
+		if err != nil {
+			q = nil
+		}
+		items = append(items, Item{Name: name, Question: q, Err: err})
+		current = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "## ") {
+			finish()
+			current = &markdownQuestion{heading: strings.TrimSpace(trimmed[3:]), fields: make(map[string]string), choices: make(map[int][]model.QuestionOption)}
+			field = ""
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		// 代码块：带标记的为编程题设置，其他的原样保留在题干或多行字段中
+		if m := markdownFence.FindStringSubmatch(trimmed); m != nil {
+			var body []string
+			j := i + 1
+			for ; j < len(lines); j++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[j]), m[1]) && strings.Trim(strings.TrimSpace(lines[j]), "`") == "" {
+					break
+				}
+				body = append(body, lines[j])
+			}
+			info := strings.ToLower(strings.Join(strings.Fields(m[2]), " "))
+			switch info {
+			case "starter", "input", "input hidden", "output", "reference":
+				current.fences = append(current.fences, markdownBlock{info: info, body: strings.Join(body, "\n")})
+				field = ""
+			default:
+				end := min(j, len(lines)-1)
+				block := lines[i : end+1]
+				if field != "" {
+					current.fields[field] += "\n" + strings.Join(block, "\n")
+				} else {
+					current.content = append(current.content, block...)
+				}
+			}
+			i = j
+			continue
+		}
+
+		if m := markdownField.FindStringSubmatch(trimmed); m != nil {
+			if name, ok := markdownFields[strings.ToLower(m[1])]; ok {
+				current.fields[name] = m[2]
+				field = ""
+				if name == "reference" || name == "explanation" {
+					field = name
+				}
+				continue
+			}
+		}
+		if m := markdownClozeBlank.FindStringSubmatch(trimmed); m != nil && field == "" {
+			index, _ := strconv.Atoi(m[1])
+			for j, choice := range splitMarkdownAnswer(m[2], "|") {
+				key, value := optionKey(j), choice
+				if om := markdownOption.FindStringSubmatch(choice); om != nil {
+					key, value = om[1], om[2]
+				}
+				current.choices[index] = append(current.choices[index], model.QuestionOption{Key: key, Value: value})
+			}
+			continue
+		}
+		if field != "" {
+			current.fields[field] += "\n" + line
+			continue
+		}
+		if m := markdownOption.FindStringSubmatch(trimmed); m != nil {
+			current.options = append(current.options, model.QuestionOption{Key: m[1], Value: strings.TrimSpace(m[2])})
+			continue
+		}
+		if m := markdownLeft.FindStringSubmatch(trimmed); m != nil && len(current.options) == 0 && current.isMatching() {
+			current.left = append(current.left, model.QuestionOption{Key: m[1], Value: strings.TrimSpace(m[2])})
+			continue
+		}
+		if len(current.options) == 0 && len(current.left) == 0 {
+			current.content = append(current.content, line)
+		}
+	}
+	finish()
+	return items
+}
+
+// label 解析标题中的题型和分值，返回去掉题号和分值后的标题
+func (m *markdownQuestion) label() (string, model.QuestionType, bool, int) {
+	heading := markdownNumber.ReplaceAllString(m.heading, "")
+	score := 0
+	if match := markdownScore.FindStringSubmatch(heading); match != nil {
+		score = roundScore(match[1])
+		heading = strings.Replace(heading, match[0], "", 1)
+	}
+	heading = strings.Trim(heading, " \t[]【】()（）:：")
+	if t, ok := markdownTypes[strings.ToLower(heading)]; ok {
+		return heading, t.typ, t.multiple, score
+	}
+	return heading, "", false, score
+}
+
+// isMatching 标题中的题型是否为连线题，用于区分左列和题干中的编号列表
+func (m *markdownQuestion) isMatching() bool {
+	_, typ, _, _ := m.label()
+	return typ == model.QuestionTypeMatching
+}
+
+// question 转换为题目请求
+func (m *markdownQuestion) question() (string, *model.CreateQuestionRequest, error) {
+	name, typ, multiple, score := m.label()
+	content := strings.TrimSpace(strings.Join(m.content, "\n"))
+	switch {
+	case typ == "" && content == "":
+		// 标题中没有题型时标题可以是题干
+		content = name
+	case typ != "" || name == "":
+		name = summary(content)
+	}
+	fields := make(map[string]string, len(m.fields))
+	for key, value := range m.fields {
+		fields[key] = strings.TrimSpace(value)
+	}
+	answer := fields["answer"]
+
+	q := &model.CreateQuestionRequest{
+		Type:        typ,
+		Content:     content,
+		Score:       score,
+		Reference:   fields["reference"],
+		Explanation: fields["explanation"],
+		Pool:        fields["pool"],
+		IsMultiple:  multiple,
+	}
+	if value, ok := fields["score"]; ok {
+		q.Score = roundScore(value)
+	}
+	if q.Type == "" {
+		q.Type = m.inferType(content, answer, fields)
+	}
+
+	switch q.Type {
+	case model.QuestionTypeChoice:
+		q.Options = m.options
+		keys := markdownKeys(answer)
+		if len(keys) == 0 {
+			return name, nil, errors.New("缺少答案")
+		}
+		q.IsMultiple = q.IsMultiple || len(keys) > 1
+		q.CorrectAnswer = strings.Join(keys, ",")
+	case model.QuestionTypeTrueFalse:
+		value, ok := parseBool(answer)
+		if !ok {
+			return name, nil, fmt.Errorf("无法识别判断题答案“%s”", answer)
+		}
+		q.CorrectAnswer = boolAnswer(value)
+	case model.QuestionTypeOrdering:
+		q.Options = m.options
+		q.CorrectAnswer = strings.Join(markdownKeys(answer), ",")
+	case model.QuestionTypeMatching:
+		q.Matching = &model.MatchingSpec{MatchingOptions: model.MatchingOptions{Left: m.left, Right: m.options}, Pairs: make(map[string]string)}
+		for _, pair := range markdownPair.FindAllStringSubmatch(strings.ToUpper(answer), -1) {
+			q.Matching.Pairs[pair[1]] = pair[2]
+		}
+	case model.QuestionTypeFillBlank:
+		blanks := markdownBlanks(answer)
+		if len(blanks) == 0 {
+			return name, nil, errors.New("缺少答案")
+		}
+		caseSensitive, _ := parseBool(fields["case_sensitive"])
+		setBlankAnswers(q, blanks, caseSensitive)
+	case model.QuestionTypeNumeric:
+		value, tolerance, err := markdownNumeric(answer, fields["tolerance"])
+		if err != nil {
+			return name, nil, err
+		}
+		q.Numeric = &model.NumericSpec{Value: &value, NumericOptions: model.NumericOptions{Unit: fields["unit"], Tolerance: tolerance}}
+	case model.QuestionTypeCloze:
+		blanks := markdownBlanks(answer)
+		count := strings.Count(content, model.ClozePlaceholder)
+		for i := 0; i < count; i++ {
+			var blank model.ClozeBlankSpec
+			blank.Choices = m.choices[i+1]
+			if i < len(blanks) {
+				blank.Answers = blanks[i]
+			}
+			if len(blank.Choices) > 0 {
+				blank.Answers = markdownKeys(strings.Join(blank.Answers, ","))
+			}
+			q.Cloze = append(q.Cloze, blank)
+		}
+		if caseSensitive, _ := parseBool(fields["case_sensitive"]); caseSensitive {
+			q.ScoringRule = &model.ScoringRule{CaseSensitive: true}
+		}
+	case model.QuestionTypeCode:
+		q.Code = &model.CodeSpec{CodeOptions: model.CodeOptions{Language: markdownLanguage(fields["language"])}}
+		for i := 0; i < len(m.fences); i++ {
+			block := m.fences[i]
+			switch block.info {
+			case "starter":
+				q.Code.StarterCode = block.body
+			case "reference":
+				q.Reference = block.body
+			case "input", "input hidden":
+				if i+1 >= len(m.fences) || m.fences[i+1].info != "output" {
+					return name, nil, fmt.Errorf("第 %d 个测试用例缺少 output", len(q.Code.TestCases)+1)
+				}
+				q.Code.TestCases = append(q.Code.TestCases, model.CodeTestCase{
+					Input:          block.body,
+					ExpectedOutput: m.fences[i+1].body,
+					Hidden:         block.info == "input hidden",
+				})
+				i++
+			}
+		}
+	case model.QuestionTypeEssay:
+	default:
+		return name, nil, errors.New("无法识别题型，请在标题中写明题型")
+	}
+	return name, q, nil
+}
+
+// inferType 标题中没有题型时按题目内容推断
+func (m *markdownQuestion) inferType(content, answer string, fields map[string]string) model.QuestionType {
+	switch {
+	case strings.Contains(content, model.ClozePlaceholder):
+		return model.QuestionTypeCloze
+	case fields["language"] != "" || len(m.fences) > 0:
+		return model.QuestionTypeCode
+	case len(m.options) > 0:
+		return model.QuestionTypeChoice
+	case answer == "" && fields["reference"] != "":
+		return model.QuestionTypeEssay
+	}
+	if _, ok := parseBool(answer); ok && answer != "0" && answer != "1" {
+		return model.QuestionTypeTrueFalse
+	}
+	if _, err := strconv.ParseFloat(answer, 64); err == nil && (fields["unit"] != "" || fields["tolerance"] != "") {
+		return model.QuestionTypeNumeric
+	}
+	if answer != "" {
+		return model.QuestionTypeFillBlank
+	}
+	return ""
+}
+
+// markdownKeys 解析选项字母答案，支持 “A,C”、“A、C” 和 “AC”
+func markdownKeys(answer string) []string {
+	keys := correctKeys(strings.ToUpper(answer))
+	if len(keys) == 1 && len(keys[0]) > 1 && strings.Trim(keys[0], "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "" {
+		keys = strings.Split(keys[0], "")
+	}
+	return keys
+}
+
+// markdownBlanks 解析填空答案：各空以 ; 分隔，同一空的可接受答案以 | 分隔
+func markdownBlanks(answer string) [][]string {
+	var blanks [][]string
+	for _, blank := range splitMarkdownAnswer(answer, ";；") {
+		var accepted []string
+		for _, value := range splitMarkdownAnswer(blank, "|") {
+			if value != "" {
+				accepted = append(accepted, value)
+			}
+		}
+		if len(accepted) > 0 {
+			blanks = append(blanks, accepted)
+		}
+	}
+	return blanks
+}
+
+// splitMarkdownAnswer 按未转义的分隔符拆分并去掉分隔符的转义
+func splitMarkdownAnswer(s string, seps string) []string {
+	var parts []string
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\\' && i+1 < len(runes) && strings.ContainsRune(";；|", runes[i+1]) {
+			b.WriteRune(runes[i+1])
+			i++
+			continue
+		}
+		if strings.ContainsRune(seps, r) {
+			parts = append(parts, strings.TrimSpace(b.String()))
+			b.Reset()
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return append(parts, strings.TrimSpace(b.String()))
+}
+
+// markdownNumeric 解析数值题答案，误差可以单独填写，也可以写为 “3.14 ± 0.01”
+func markdownNumeric(answer, toleranceField string) (float64, float64, error) {
+	tolerance := 0.0
+	if value, t, ok := strings.Cut(answer, "±"); ok {
+		answer, toleranceField = value, t
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(answer), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("数值题答案“%s”不是数值", answer)
+	}
+	if strings.TrimSpace(toleranceField) != "" {
+		if tolerance, err = strconv.ParseFloat(strings.TrimSpace(toleranceField), 64); err != nil || tolerance < 0 {
+			return 0, 0, fmt.Errorf("误差“%s”无效", toleranceField)
+		}
+	}
+	return value, tolerance, nil
+}
+
+// markdownLanguage 规范化编程语言名称
+func markdownLanguage(value string) string {
+	switch language := strings.ToLower(strings.TrimSpace(value)); language {
+	case "python3", "py":
+		return "python"
+	case "c++":
+		return "cpp"
+	case "js", "node":
+		return "javascript"
+	default:
+		return language
+	}
+}
+
+// markdownLabels 导出时使用的题型名称
+var markdownLabels = map[model.QuestionType]string{
+	model.QuestionTypeFillBlank: "填空题",
+	model.QuestionTypeTrueFalse: "判断题",
+	model.QuestionTypeEssay:     "简答题",
+	model.QuestionTypeMatching:  "连线题",
+	model.QuestionTypeOrdering:  "排序题",
+	model.QuestionTypeNumeric:   "数值题",
+	model.QuestionTypeCloze:     "完形填空",
+	model.QuestionTypeCode:      "编程题",
+}
+
+// markdownAnswerEscaper 转义填空答案中的分隔符
+var markdownAnswerEscaper = strings.NewReplacer(";", `\;`, "；", `\；`, "|", `\|`)
+
+// writeMarkdown 写出 Markdown 题目文档，所有题型都可以表示
+func writeMarkdown(w io.Writer, title string, questions []*model.CreateQuestionRequest) ([]int, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", strings.Join(strings.Fields(title), " "))
+
+	for i, q := range questions {
+		label := markdownLabels[q.Type]
+		if q.Type == model.QuestionTypeChoice {
+			label = "单选题"
+			if q.IsMultiple {
+				label = "多选题"
+			}
+		}
+		fmt.Fprintf(&b, "\n## %d. %s（%d分）\n\n%s\n", i+1, label, q.Score, strings.TrimSpace(q.Content))
+
+		options := func(options []model.QuestionOption) {
+			b.WriteString("\n")
+			for _, option := range options {
+				fmt.Fprintf(&b, "%s. %s\n", option.Key, strings.Join(strings.Fields(option.Value), " "))
+			}
+		}
+		var answer string
+		switch q.Type {
+		case model.QuestionTypeChoice, model.QuestionTypeOrdering:
+			rekeyed, keys := markdownRekey(q.Options, optionKey)
+			options(rekeyed)
+			answer = strings.Join(markdownMapKeys(correctKeys(q.CorrectAnswer), keys), ",")
+		case model.QuestionTypeTrueFalse:
+			if value, _ := parseBool(q.CorrectAnswer); value {
+				answer = "正确"
+			} else {
+				answer = "错误"
+			}
+		case model.QuestionTypeFillBlank:
+			answer = markdownBlankAnswer(blankAnswers(q))
+		case model.QuestionTypeMatching:
+			if q.Matching != nil {
+				left, leftKeys := markdownRekey(q.Matching.Left, func(i int) string { return strconv.Itoa(i + 1) })
+				right, rightKeys := markdownRekey(q.Matching.Right, optionKey)
+				options(left)
+				options(right)
+				var pairs []string
+				for _, option := range q.Matching.Left {
+					if rightKey, ok := q.Matching.Pairs[option.Key]; ok {
+						pairs = append(pairs, leftKeys[option.Key]+"-"+rightKeys[rightKey])
+					}
+				}
+				answer = strings.Join(pairs, ", ")
+			}
+		case model.QuestionTypeNumeric:
+			if q.Numeric != nil && q.Numeric.Value != nil {
+				answer = formatFloat(*q.Numeric.Value)
+			}
+		case model.QuestionTypeCloze:
+			var blanks [][]string
+			var choiceLines []string
+			for j, blank := range q.Cloze {
+				if len(blank.Choices) == 0 {
+					blanks = append(blanks, blank.Answers)
+				} else {
+					rekeyed, keys := markdownRekey(blank.Choices, optionKey)
+					blanks = append(blanks, markdownMapKeys(blank.Answers, keys))
+					var choices []string
+					for _, choice := range rekeyed {
+						choices = append(choices, choice.Key+". "+markdownAnswerEscaper.Replace(choice.Value))
+					}
+					choiceLines = append(choiceLines, fmt.Sprintf("空%d：%s", j+1, strings.Join(choices, " | ")))
+				}
+			}
+			if len(choiceLines) > 0 {
+				fmt.Fprintf(&b, "\n%s\n", strings.Join(choiceLines, "\n"))
+			}
+			answer = markdownBlankAnswer(blanks)
+		case model.QuestionTypeCode:
+			if q.Code != nil {
+				fmt.Fprintf(&b, "\n语言：%s\n", q.Code.Language)
+				if q.Code.StarterCode != "" {
+					b.WriteString("\n" + markdownFenceBlock("starter", q.Code.StarterCode))
+				}
+				for _, tc := range q.Code.TestCases {
+					info := "input"
+					if tc.Hidden {
+						info = "input hidden"
+					}
+					b.WriteString("\n" + markdownFenceBlock(info, tc.Input) + markdownFenceBlock("output", tc.ExpectedOutput))
+				}
+				if q.Reference != "" {
+					b.WriteString("\n" + markdownFenceBlock("reference", q.Reference))
+				}
+			}
+		}
+
+		b.WriteString("\n")
+		if answer != "" {
+			fmt.Fprintf(&b, "答案：%s\n", answer)
+		}
+		if q.Type == model.QuestionTypeNumeric && q.Numeric != nil {
+			if q.Numeric.Tolerance > 0 {
+				fmt.Fprintf(&b, "误差：%s\n", formatFloat(q.Numeric.Tolerance))
+			}
+			if q.Numeric.Unit != "" {
+				fmt.Fprintf(&b, "单位：%s\n", q.Numeric.Unit)
+			}
+		}
+		if q.ScoringRule != nil && q.ScoringRule.CaseSensitive && (q.Type == model.QuestionTypeFillBlank || q.Type == model.QuestionTypeCloze) {
+			b.WriteString("区分大小写：是\n")
+		}
+		if q.Pool != "" {
+			fmt.Fprintf(&b, "题目池：%s\n", q.Pool)
+		}
+		if q.Reference != "" && q.Type != model.QuestionTypeCode {
+			fmt.Fprintf(&b, "参考答案：%s\n", strings.TrimSpace(q.Reference))
+		}
+		if q.Explanation != "" {
+			fmt.Fprintf(&b, "解析：%s\n", strings.TrimSpace(q.Explanation))
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// markdownRekey 按位置重新编排选项标识，使其符合文档格式（字母或数字），返回原标识到新标识的映射
+func markdownRekey(options []model.QuestionOption, key func(int) string) ([]model.QuestionOption, map[string]string) {
+	rekeyed := make([]model.QuestionOption, len(options))
+	keys := make(map[string]string, len(options))
+	for i, option := range options {
+		rekeyed[i] = model.QuestionOption{Key: key(i), Value: option.Value}
+		keys[option.Key] = rekeyed[i].Key
+	}
+	return rekeyed, keys
+}
+
+// markdownMapKeys 将原选项标识转换为重新编排后的标识
+func markdownMapKeys(keys []string, mapping map[string]string) []string {
+	mapped := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if k, ok := mapping[key]; ok {
+			mapped = append(mapped, k)
+		}
+	}
+	return mapped
+}
+
+// markdownBlankAnswer 生成填空答案
+func markdownBlankAnswer(blanks [][]string) string {
+	parts := make([]string, len(blanks))
+	for i, accepted := range blanks {
+		escaped := make([]string, len(accepted))
+		for j, value := range accepted {
+			escaped[j] = markdownAnswerEscaper.Replace(value)
+		}
+		parts[i] = strings.Join(escaped, "|")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// markdownFenceBlock 生成带标记的代码块，围栏长度大于内容中最长的连续反引号
+func markdownFenceBlock(info, body string) string {
+	longest := 0
+	for _, run := range markdownBackticks.FindAllString(body, -1) {
+		longest = max(longest, len(run))
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fmt.Sprintf("%s%s\n%s\n%s\n", fence, info, strings.TrimRight(body, "\n"), fence)
+}
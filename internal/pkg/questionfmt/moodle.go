@@ -0,0 +1,500 @@
+package questionfmt
+
+import (
+	"ai-course/internal/model"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// readMoodle 读取 Moodle XML，分类（category）不计为题目
+//
+// 题型对应关系：multichoice → 选择题，truefalse → 判断题，shortanswer → 填空题，numerical → 数值题，essay → 简答题，
+// matching → 连线题，ordering → 排序题，multianswer（嵌入式完形填空）→ 完形填空。HTML 格式的文本转换为纯文本，图片等文件不会导入
+func readMoodle(data []byte) ([]Item, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, err
+	}
+	if root.Name != "quiz" {
+		return nil, fmt.Errorf("%w: 根元素不是 quiz", ErrInvalidFile)
+	}
+
+	var items []Item
+	for _, node := range root.childrenNamed("question") {
+		qtype := node.attr("type")
+		if qtype == "category" {
+			continue
+		}
+		item := Item{Name: strings.TrimSpace(node.child("name").child("text").text())}
+		item.Question, item.Err = moodleQuestion(qtype, node)
+		if item.Err != nil {
+			item.Question = nil
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// moodleAnswer Moodle 题目的一个答案
+type moodleAnswer struct {
+	text     string
+	fraction float64
+	node     *xmlNode
+}
+
+// moodleQuestion 将一道 Moodle 题目转换为题目请求
+func moodleQuestion(qtype string, node *xmlNode) (*model.CreateQuestionRequest, error) {
+	q := &model.CreateQuestionRequest{
+		Content:     moodleText(node.child("questiontext"), "html"),
+		Explanation: moodleText(node.child("generalfeedback"), "html"),
+		Score:       roundScore(node.child("defaultgrade").text()),
+	}
+
+	var answers []moodleAnswer
+	for _, answer := range node.childrenNamed("answer") {
+		fraction, _ := strconv.ParseFloat(strings.TrimSpace(answer.attr("fraction")), 64)
+		answers = append(answers, moodleAnswer{
+			text:     moodleText(answer, "plain_text"),
+			fraction: fraction,
+			node:     answer,
+		})
+	}
+
+	switch qtype {
+	case "multichoice":
+		q.Type = model.QuestionTypeChoice
+		q.IsMultiple = strings.TrimSpace(node.child("single").text()) == "false"
+		var keys []string
+		for i, answer := range answers {
+			key := optionKey(i)
+			q.Options = append(q.Options, model.QuestionOption{Key: key, Value: answer.text})
+			if (q.IsMultiple && answer.fraction > 0) || (!q.IsMultiple && answer.fraction >= 100) {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			return nil, errors.New("没有得分的选项")
+		}
+		q.CorrectAnswer = strings.Join(keys, ",")
+	case "truefalse":
+		q.Type = model.QuestionTypeTrueFalse
+		for _, answer := range answers {
+			if answer.fraction >= 100 {
+				value, ok := parseBool(answer.text)
+				if !ok {
+					return nil, fmt.Errorf("无法识别判断题答案“%s”", answer.text)
+				}
+				q.CorrectAnswer = boolAnswer(value)
+			}
+		}
+	case "shortanswer":
+		q.Type = model.QuestionTypeFillBlank
+		var accepted []string
+		for _, answer := range answers {
+			if answer.fraction >= 100 && answer.text != "" {
+				accepted = append(accepted, answer.text)
+			}
+		}
+		if len(accepted) == 0 {
+			return nil, errors.New("没有满分的答案")
+		}
+		setBlankAnswers(q, [][]string{accepted}, strings.TrimSpace(node.child("usecase").text()) == "1")
+	case "numerical":
+		q.Type = model.QuestionTypeNumeric
+		for _, answer := range answers {
+			if answer.fraction < 100 {
+				continue
+			}
+			value, err := strconv.ParseFloat(answer.text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("数值题答案“%s”不是数值", answer.text)
+			}
+			tolerance, _ := strconv.ParseFloat(strings.TrimSpace(answer.node.child("tolerance").text()), 64)
+			q.Numeric = &model.NumericSpec{Value: &value, NumericOptions: model.NumericOptions{Tolerance: tolerance}}
+			break
+		}
+		if q.Numeric == nil {
+			return nil, errors.New("没有满分的答案")
+		}
+		for _, unit := range node.child("units").childrenNamed("unit") {
+			if multiplier, _ := strconv.ParseFloat(strings.TrimSpace(unit.child("multiplier").text()), 64); multiplier == 1 {
+				q.Numeric.Unit = strings.TrimSpace(unit.child("unit_name").text())
+				break
+			}
+		}
+	case "essay":
+		q.Type = model.QuestionTypeEssay
+		q.Reference = moodleText(node.child("graderinfo"), "html")
+	case "matching":
+		q.Type = model.QuestionTypeMatching
+		q.Matching = &model.MatchingSpec{Pairs: make(map[string]string)}
+		rightKeys := make(map[string]string)
+		for _, sub := range node.childrenNamed("subquestion") {
+			right := strings.TrimSpace(sub.child("answer").child("text").text())
+			if right == "" {
+				continue
+			}
+			rightKey, ok := rightKeys[right]
+			if !ok {
+				rightKey = optionKey(len(q.Matching.Right))
+				rightKeys[right] = rightKey
+				q.Matching.Right = append(q.Matching.Right, model.QuestionOption{Key: rightKey, Value: right})
+			}
+			// 没有题干的子问题是右列的干扰项
+			if left := moodleText(sub, "html"); left != "" {
+				leftKey := strconv.Itoa(len(q.Matching.Left) + 1)
+				q.Matching.Left = append(q.Matching.Left, model.QuestionOption{Key: leftKey, Value: left})
+				q.Matching.Pairs[leftKey] = rightKey
+			}
+		}
+	case "ordering":
+		q.Type = model.QuestionTypeOrdering
+		// 答案按正确顺序排列，fraction 为位置序号
+		sort.SliceStable(answers, func(i, j int) bool { return answers[i].fraction < answers[j].fraction })
+		texts := make([]string, len(answers))
+		for i, answer := range answers {
+			texts[i] = answer.text
+		}
+		q.Options, q.CorrectAnswer = scrambleOrdering(texts)
+	case "multianswer":
+		q.Type = model.QuestionTypeCloze
+		content, blanks, caseSensitive, err := parseMoodleCloze(q.Content)
+		if err != nil {
+			return nil, err
+		}
+		q.Content = content
+		q.Cloze = blanks
+		if caseSensitive {
+			q.ScoringRule = &model.ScoringRule{CaseSensitive: true}
+		}
+	case "description":
+		return nil, errors.New("说明文字不是题目")
+	default:
+		return nil, fmt.Errorf("不支持的 Moodle 题型 %s", qtype)
+	}
+	return q, nil
+}
+
+// moodleText 读取 Moodle 文本元素，HTML 格式转换为纯文本；未设置 format 时使用 defaultFormat
+func moodleText(node *xmlNode, defaultFormat string) string {
+	if node == nil {
+		return ""
+	}
+	text := node.child("text").text()
+	format := node.attr("format")
+	if format == "" {
+		format = defaultFormat
+	}
+	if format == "html" {
+		return htmlToText(text)
+	}
+	return strings.TrimSpace(text)
+}
+
+// scrambleOrdering 由按正确顺序排列的项生成排序题：选项按轮换后的顺序展示（每一项都不在正确位置上），
+// 标识按展示顺序编排，避免选项标识的字母顺序泄露答案
+func scrambleOrdering(texts []string) ([]model.QuestionOption, string) {
+	n := len(texts)
+	options := make([]model.QuestionOption, n)
+	keys := make([]string, n)
+	for i := range texts {
+		display := (i + n - 1) % n // 第 i 项展示在 display 位置
+		key := optionKey(display)
+		options[display] = model.QuestionOption{Key: key, Value: texts[i]}
+		keys[i] = key
+	}
+	return options, strings.Join(keys, ",")
+}
+
+// moodleCloze 嵌入式完形填空的空，如 {1:SHORTANSWER:=北京~=Beijing} 或 {1:MULTICHOICE:=对~错}
+var moodleCloze = regexp.MustCompile(`\{(\d*):([A-Za-z_]+):((?:\\.|[^\\}])*)\}`)
+
+// parseMoodleCloze 将嵌入式完形填空转换为题干占位符和各空设置
+func parseMoodleCloze(text string) (string, []model.ClozeBlankSpec, bool, error) {
+	var blanks []model.ClozeBlankSpec
+	caseSensitive := false
+	var parseErr error
+	content := moodleCloze.ReplaceAllStringFunc(text, func(match string) string {
+		parts := moodleCloze.FindStringSubmatch(match)
+		kind := strings.ToUpper(parts[2])
+		var blank model.ClozeBlankSpec
+		for i, answer := range splitEscaped(parts[3], '~') {
+			answer, _ = cutEscaped(answer, '#') // 去掉答案反馈
+			correct := false
+			if strings.HasPrefix(answer, "=") {
+				correct, answer = true, answer[1:]
+			} else if strings.HasPrefix(answer, "%") {
+				if end := strings.Index(answer[1:], "%"); end >= 0 {
+					fraction, _ := strconv.ParseFloat(answer[1:end+1], 64)
+					correct, answer = fraction >= 100, answer[end+2:]
+				}
+			}
+			answer = strings.TrimSpace(unescapeMoodle(answer))
+
+			switch {
+			case strings.HasPrefix(kind, "MULTICHOICE") || strings.HasPrefix(kind, "MC"):
+				key := optionKey(i)
+				blank.Choices = append(blank.Choices, model.QuestionOption{Key: key, Value: answer})
+				if correct {
+					blank.Answers = append(blank.Answers, key)
+				}
+			case kind == "NUMERICAL" || kind == "NM":
+				if value, _, ok := strings.Cut(answer, ":"); correct && ok {
+					answer = value
+				}
+				fallthrough
+			default:
+				if correct && answer != "" {
+					blank.Answers = append(blank.Answers, answer)
+				}
+			}
+		}
+		switch kind {
+		case "SHORTANSWER_C", "SAC", "MWC":
+			caseSensitive = true
+		}
+		if len(blank.Answers) == 0 && parseErr == nil {
+			parseErr = fmt.Errorf("第 %d 个空没有满分的答案", len(blanks)+1)
+		}
+		blanks = append(blanks, blank)
+		return model.ClozePlaceholder
+	})
+	if parseErr != nil {
+		return "", nil, false, parseErr
+	}
+	if len(blanks) == 0 {
+		return "", nil, false, errors.New("完形填空题干中没有空")
+	}
+	return content, blanks, caseSensitive, nil
+}
+
+// splitEscaped 按未转义的分隔符拆分
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// cutEscaped 在第一个未转义的分隔符处拆分
+func cutEscaped(s string, sep byte) (string, string) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+// unescapeMoodle 去掉反斜杠转义
+func unescapeMoodle(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// moodleClozeEscaper 转义嵌入式完形填空答案中的特殊字符
+var moodleClozeEscaper = strings.NewReplacer(`\`, `\\`, "}", `\}`, "~", `\~`, "#", `\#`, "/", `\/`)
+
+// writeMoodle 写出 Moodle XML，题目放在以 title 命名的分类下；编程题和多空填空题无法表示，跳过
+func writeMoodle(w io.Writer, title string, questions []*model.CreateQuestionRequest) ([]int, error) {
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<quiz>\n")
+	b.WriteString("  <question type=\"category\">\n")
+	fmt.Fprintf(&b, "    <category><text>%s</text></category>\n", escapeXML("$course$/top/"+strings.ReplaceAll(title, "/", "-")))
+	b.WriteString("  </question>\n")
+
+	var skipped []int
+	for i, q := range questions {
+		body, qtype, ok := moodleQuestionBody(q)
+		if !ok {
+			skipped = append(skipped, i)
+			continue
+		}
+		fmt.Fprintf(&b, "  <question type=\"%s\">\n", qtype)
+		fmt.Fprintf(&b, "    <name><text>%s</text></name>\n", escapeXML(questionName(q, i)))
+		content := q.Content
+		if q.Type == model.QuestionTypeCloze {
+			content = moodleClozeText(q)
+		}
+		fmt.Fprintf(&b, "    <questiontext format=\"markdown\"><text>%s</text></questiontext>\n", cdata(content))
+		fmt.Fprintf(&b, "    <generalfeedback format=\"markdown\"><text>%s</text></generalfeedback>\n", cdata(q.Explanation))
+		fmt.Fprintf(&b, "    <defaultgrade>%d</defaultgrade>\n", q.Score)
+		b.WriteString("    <hidden>0</hidden>\n")
+		b.WriteString(body)
+		b.WriteString("  </question>\n")
+	}
+	b.WriteString("</quiz>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return nil, err
+	}
+	return skipped, nil
+}
+
+// moodleQuestionBody 生成题型相关的元素，返回 Moodle 题型；无法表示时返回 false
+func moodleQuestionBody(q *model.CreateQuestionRequest) (string, string, bool) {
+	var b strings.Builder
+	answer := func(fraction string, text string, extra string) {
+		fmt.Fprintf(&b, "    <answer fraction=\"%s\" format=\"plain_text\">\n      <text>%s</text>\n%s    </answer>\n", fraction, escapeXML(text), extra)
+	}
+
+	switch q.Type {
+	case model.QuestionTypeChoice:
+		keys := make(map[string]bool)
+		for _, key := range correctKeys(q.CorrectAnswer) {
+			keys[key] = true
+		}
+		fmt.Fprintf(&b, "    <single>%t</single>\n    <shuffleanswers>true</shuffleanswers>\n    <answernumbering>ABCD</answernumbering>\n", !q.IsMultiple)
+		for _, option := range q.Options {
+			fraction := "0"
+			if keys[option.Key] {
+				fraction = formatFraction(100 / float64(len(keys)))
+			}
+			answer(fraction, option.Value, "")
+		}
+		return b.String(), "multichoice", true
+	case model.QuestionTypeTrueFalse:
+		value, _ := parseBool(q.CorrectAnswer)
+		for _, option := range []bool{true, false} {
+			fraction := "0"
+			if option == value {
+				fraction = "100"
+			}
+			answer(fraction, boolAnswer(option), "")
+		}
+		return b.String(), "truefalse", true
+	case model.QuestionTypeFillBlank:
+		blanks := blankAnswers(q)
+		if len(blanks) != 1 {
+			return "", "", false
+		}
+		usecase := 0
+		if q.ScoringRule != nil && q.ScoringRule.CaseSensitive {
+			usecase = 1
+		}
+		fmt.Fprintf(&b, "    <usecase>%d</usecase>\n", usecase)
+		for _, accepted := range blanks[0] {
+			answer("100", accepted, "")
+		}
+		return b.String(), "shortanswer", true
+	case model.QuestionTypeNumeric:
+		if q.Numeric == nil || q.Numeric.Value == nil {
+			return "", "", false
+		}
+		answer("100", formatFloat(*q.Numeric.Value), fmt.Sprintf("      <tolerance>%s</tolerance>\n", formatFloat(q.Numeric.Tolerance)))
+		if q.Numeric.Unit != "" {
+			fmt.Fprintf(&b, "    <units>\n      <unit>\n        <multiplier>1</multiplier>\n        <unit_name>%s</unit_name>\n      </unit>\n    </units>\n", escapeXML(q.Numeric.Unit))
+		}
+		return b.String(), "numerical", true
+	case model.QuestionTypeEssay:
+		b.WriteString("    <responseformat>editor</responseformat>\n    <responserequired>1</responserequired>\n    <responsefieldlines>15</responsefieldlines>\n    <attachments>0</attachments>\n")
+		fmt.Fprintf(&b, "    <graderinfo format=\"markdown\"><text>%s</text></graderinfo>\n", cdata(q.Reference))
+		return b.String(), "essay", true
+	case model.QuestionTypeMatching:
+		if q.Matching == nil {
+			return "", "", false
+		}
+		rights := make(map[string]string, len(q.Matching.Right))
+		for _, option := range q.Matching.Right {
+			rights[option.Key] = option.Value
+		}
+		used := make(map[string]bool)
+		b.WriteString("    <shuffleanswers>true</shuffleanswers>\n")
+		for _, left := range q.Matching.Left {
+			rightKey := q.Matching.Pairs[left.Key]
+			used[rightKey] = true
+			fmt.Fprintf(&b, "    <subquestion format=\"markdown\">\n      <text>%s</text>\n      <answer><text>%s</text></answer>\n    </subquestion>\n", cdata(left.Value), escapeXML(rights[rightKey]))
+		}
+		// 没有配对的右列选项作为干扰项
+		for _, right := range q.Matching.Right {
+			if !used[right.Key] {
+				fmt.Fprintf(&b, "    <subquestion format=\"markdown\">\n      <text></text>\n      <answer><text>%s</text></answer>\n    </subquestion>\n", escapeXML(right.Value))
+			}
+		}
+		return b.String(), "matching", true
+	case model.QuestionTypeOrdering:
+		values := make(map[string]string, len(q.Options))
+		for _, option := range q.Options {
+			values[option.Key] = option.Value
+		}
+		b.WriteString("    <layouttype>VERTICAL</layouttype>\n    <selecttype>ALL</selecttype>\n    <selectcount>0</selectcount>\n    <gradingtype>ABSOLUTE_POSITION</gradingtype>\n")
+		for i, key := range correctKeys(q.CorrectAnswer) {
+			answer(strconv.Itoa(i+1), values[key], "")
+		}
+		return b.String(), "ordering", true
+	case model.QuestionTypeCloze:
+		return "", "multianswer", true
+	default:
+		return "", "", false
+	}
+}
+
+// moodleClozeText 将题干中的占位符替换为嵌入式完形填空的代码
+func moodleClozeText(q *model.CreateQuestionRequest) string {
+	parts := strings.Split(q.Content, model.ClozePlaceholder)
+	var b strings.Builder
+	for i, part := range parts {
+		b.WriteString(part)
+		if i == len(parts)-1 {
+			break
+		}
+		if i >= len(q.Cloze) {
+			b.WriteString(model.ClozePlaceholder)
+			continue
+		}
+		blank := q.Cloze[i]
+		var answers []string
+		if len(blank.Choices) > 0 {
+			correct := make(map[string]bool)
+			for _, key := range blank.Answers {
+				correct[key] = true
+			}
+			for _, choice := range blank.Choices {
+				prefix := ""
+				if correct[choice.Key] {
+					prefix = "="
+				}
+				answers = append(answers, prefix+moodleClozeEscaper.Replace(choice.Value))
+			}
+			fmt.Fprintf(&b, "{1:MULTICHOICE:%s}", strings.Join(answers, "~"))
+			continue
+		}
+		for _, answer := range blank.Answers {
+			answers = append(answers, "="+moodleClozeEscaper.Replace(answer))
+		}
+		kind := "SHORTANSWER"
+		if q.ScoringRule != nil && q.ScoringRule.CaseSensitive {
+			kind = "SHORTANSWER_C"
+		}
+		fmt.Fprintf(&b, "{1:%s:%s}", kind, strings.Join(answers, "~"))
+	}
+	return b.String()
+}
+
+// formatFraction 输出 Moodle 的得分比例，保留 5 位小数
+func formatFraction(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 5, 64)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
@@ -0,0 +1,849 @@
+package questionfmt
+
+import (
+	"ai-course/internal/model"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QTI 2.1 的对应关系：
+//   - 选择题、判断题：choiceInteraction，判断题为只有“正确”“错误”两个选项的单选
+//   - 排序题：orderInteraction；连线题：matchInteraction（directedPair），左右两列的标识分别为 L1、L2…… 和 R1、R2……
+//   - 简答题：extendedTextInteraction，参考答案放在 view="scorer" 的 rubricBlock 中
+//   - 填空题：每个空一个单独成段的 textEntryInteraction，可接受的答案写入 mapping
+//   - 数值题：baseType 为 float 的 textEntryInteraction，误差写入评分规则的 equal 条件，单位不导出
+//   - 完形填空：题干中的 textEntryInteraction 和 inlineChoiceInteraction
+//
+// 分值读写 MAXSCORE，解析读写 modalFeedback。编程题无法表示，导出时跳过
+
+const (
+	qtiNamespace      = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	qtiSchemaLocation = "http://www.imsglobal.org/xsd/imsqti_v2p1 http://www.imsglobal.org/xsd/qti/qtiv2p1/imsqti_v2p1.xsd"
+	qtiItemDir        = "items/"
+)
+
+// readQTI 读取 QTI 2.1 内容包（zip）或单个 assessmentItem 文档
+func readQTI(data []byte) ([]Item, error) {
+	if bytes.HasPrefix(data, []byte("PK")) {
+		return readQTIPackage(data)
+	}
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, err
+	}
+	switch root.Name {
+	case "assessmentItem":
+		name, q, err := qtiItem(root)
+		if err != nil {
+			q = nil
+		}
+		return []Item{{Name: name, Question: q, Err: err}}, nil
+	case "questestinterop":
+		return nil, fmt.Errorf("%w: 不支持 QTI 1.2，请导出为 QTI 2.1", ErrInvalidFile)
+	case "assessmentTest":
+		return nil, fmt.Errorf("%w: assessmentTest 需要与题目文件一起以内容包（zip）导入", ErrInvalidFile)
+	default:
+		return nil, fmt.Errorf("%w: 根元素 %s 不是 QTI 题目", ErrInvalidFile, root.Name)
+	}
+}
+
+// readQTIPackage 读取内容包，按清单中题目资源的顺序导入；没有清单时按文件名顺序导入全部题目文档
+func readQTIPackage(data []byte) ([]Item, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[path.Clean(f.Name)] = f
+	}
+
+	// 解压后的总大小同样受导入大小限制
+	budget := int64(MaxImportSize)
+	readFile := func(f *zip.File) ([]byte, error) {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		defer rc.Close()
+		content, err := io.ReadAll(io.LimitReader(rc, budget+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if int64(len(content)) > budget {
+			return nil, ErrTooLarge
+		}
+		budget -= int64(len(content))
+		return content, nil
+	}
+
+	var hrefs []string
+	if manifest, ok := files["imsmanifest.xml"]; ok {
+		content, err := readFile(manifest)
+		if err != nil {
+			return nil, err
+		}
+		root, err := parseXML(content)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range root.find("resource") {
+			if strings.HasPrefix(resource.attr("type"), "imsqti_item") && resource.attr("href") != "" {
+				hrefs = append(hrefs, path.Clean(resource.attr("href")))
+			}
+		}
+	} else {
+		for name := range files {
+			if strings.EqualFold(path.Ext(name), ".xml") {
+				hrefs = append(hrefs, name)
+			}
+		}
+		sort.Strings(hrefs)
+	}
+
+	var items []Item
+	for _, href := range hrefs {
+		f, ok := files[href]
+		if !ok {
+			items = append(items, Item{Name: href, Err: errors.New("内容包中缺少题目文件")})
+			continue
+		}
+		content, err := readFile(f)
+		if err != nil {
+			return nil, err
+		}
+		root, err := parseXML(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")))
+		if err != nil {
+			items = append(items, Item{Name: href, Err: errors.New("题目文件不是有效的 XML")})
+			continue
+		}
+		if root.Name != "assessmentItem" {
+			continue
+		}
+		name, q, err := qtiItem(root)
+		if name == "" {
+			name = href
+		}
+		if err != nil {
+			q = nil
+		}
+		items = append(items, Item{Name: name, Question: q, Err: err})
+	}
+	return items, nil
+}
+
+// qtiDeclaration 题目的作答变量声明
+type qtiDeclaration struct {
+	cardinality   string
+	baseType      string
+	correct       []string // 标准答案
+	mapped        []string // mapping 中得分的取值
+	caseSensitive bool
+}
+
+// accepted 返回全部可接受的取值，标准答案在前
+func (d *qtiDeclaration) accepted() []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, value := range append(append([]string{}, d.correct...), d.mapped...) {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// qtiInteraction 题目正文中的交互及其父元素
+type qtiInteraction struct {
+	node   *xmlNode
+	parent *xmlNode
+}
+
+// qtiItem 将一个 assessmentItem 转换为题目请求，返回题目名称
+func qtiItem(root *xmlNode) (string, *model.CreateQuestionRequest, error) {
+	name := strings.TrimSpace(root.attr("title"))
+	if name == "" {
+		name = root.attr("identifier")
+	}
+
+	declarations := make(map[string]*qtiDeclaration)
+	for _, node := range root.childrenNamed("responseDeclaration") {
+		d := &qtiDeclaration{cardinality: node.attr("cardinality"), baseType: node.attr("baseType")}
+		for _, value := range node.child("correctResponse").childrenNamed("value") {
+			d.correct = append(d.correct, strings.TrimSpace(value.text()))
+		}
+		for _, entry := range node.child("mapping").childrenNamed("mapEntry") {
+			if value, _ := strconv.ParseFloat(entry.attr("mappedValue"), 64); value > 0 {
+				d.mapped = append(d.mapped, strings.TrimSpace(entry.attr("mapKey")))
+			}
+			if entry.attr("caseSensitive") == "true" {
+				d.caseSensitive = true
+			}
+		}
+		declarations[node.attr("identifier")] = d
+	}
+	declaration := func(interaction *xmlNode) *qtiDeclaration {
+		if d, ok := declarations[interaction.attr("responseIdentifier")]; ok {
+			return d
+		}
+		return &qtiDeclaration{}
+	}
+
+	q := &model.CreateQuestionRequest{}
+	for _, node := range root.childrenNamed("outcomeDeclaration") {
+		if node.attr("identifier") == "MAXSCORE" {
+			q.Score = roundScore(node.child("defaultValue").child("value").text())
+		}
+	}
+	var feedback []string
+	for _, node := range root.childrenNamed("modalFeedback") {
+		if text := qtiText(node, nil); text != "" {
+			feedback = append(feedback, text)
+		}
+	}
+	q.Explanation = strings.Join(feedback, "\n\n")
+
+	body := root.child("itemBody")
+	if body == nil {
+		return name, nil, errors.New("缺少 itemBody")
+	}
+	interactions := qtiInteractions(body)
+	if len(interactions) == 0 {
+		return name, nil, errors.New("题目中没有作答交互")
+	}
+
+	inline := true
+	for _, interaction := range interactions {
+		switch interaction.node.Name {
+		case "textEntryInteraction", "inlineChoiceInteraction":
+		default:
+			inline = false
+		}
+	}
+	if !inline {
+		if len(interactions) > 1 {
+			return name, nil, errors.New("一道题目包含多个作答交互，无法转换")
+		}
+		q.Content = qtiText(body, nil)
+		node := interactions[0].node
+		d := declaration(node)
+		var err error
+		switch node.Name {
+		case "choiceInteraction":
+			err = qtiChoice(q, node, d)
+		case "orderInteraction":
+			err = qtiOrder(q, node, d)
+		case "matchInteraction":
+			err = qtiMatch(q, node, d)
+		case "extendedTextInteraction":
+			q.Type = model.QuestionTypeEssay
+			var reference []string
+			for _, rubric := range body.find("rubricBlock") {
+				if strings.Contains(rubric.attr("view"), "scorer") {
+					reference = append(reference, qtiText(rubric, nil))
+				}
+			}
+			if len(reference) == 0 {
+				reference = d.correct
+			}
+			q.Reference = strings.Join(reference, "\n\n")
+		default:
+			err = fmt.Errorf("不支持的交互 %s", node.Name)
+		}
+		if err != nil {
+			return name, nil, err
+		}
+		return name, q, nil
+	}
+
+	// 只有一个浮点数填空的为数值题
+	if first := interactions[0]; len(interactions) == 1 && first.node.Name == "textEntryInteraction" {
+		if d := declaration(first.node); d.baseType == "float" || d.baseType == "integer" {
+			q.Type = model.QuestionTypeNumeric
+			q.Content = qtiText(body, func(*xmlNode) string { return "" })
+			if len(d.correct) == 0 {
+				return name, nil, errors.New("数值题缺少标准答案")
+			}
+			value, err := strconv.ParseFloat(d.correct[0], 64)
+			if err != nil {
+				return name, nil, fmt.Errorf("数值题标准答案“%s”不是数值", d.correct[0])
+			}
+			q.Numeric = &model.NumericSpec{Value: &value}
+			for _, equal := range root.child("responseProcessing").find("equal") {
+				if fields := strings.Fields(equal.attr("tolerance")); len(fields) > 0 {
+					q.Numeric.Tolerance, _ = strconv.ParseFloat(fields[0], 64)
+					break
+				}
+			}
+			return name, q, nil
+		}
+	}
+
+	// 全部为单独成段的文本填空时为填空题，否则为完形填空
+	standalone := true
+	for _, interaction := range interactions {
+		if interaction.node.Name != "textEntryInteraction" {
+			standalone = false
+			break
+		}
+		for _, sibling := range interaction.parent.Children {
+			if sibling != interaction.node && strings.TrimSpace(sibling.text()) != "" {
+				standalone = false
+			}
+		}
+	}
+	if standalone {
+		q.Type = model.QuestionTypeFillBlank
+		q.Content = qtiText(body, func(*xmlNode) string { return "" })
+		var blanks [][]string
+		caseSensitive := false
+		for i, interaction := range interactions {
+			d := declaration(interaction.node)
+			accepted := d.accepted()
+			if len(accepted) == 0 {
+				return name, nil, fmt.Errorf("第 %d 个空缺少标准答案", i+1)
+			}
+			blanks = append(blanks, accepted)
+			caseSensitive = caseSensitive || d.caseSensitive
+		}
+		setBlankAnswers(q, blanks, caseSensitive)
+		return name, q, nil
+	}
+
+	q.Type = model.QuestionTypeCloze
+	q.Content = qtiText(body, func(*xmlNode) string { return model.ClozePlaceholder })
+	for i, interaction := range interactions {
+		d := declaration(interaction.node)
+		var blank model.ClozeBlankSpec
+		if interaction.node.Name == "inlineChoiceInteraction" {
+			keys := make(map[string]string)
+			for j, choice := range interaction.node.childrenNamed("inlineChoice") {
+				key := optionKey(j)
+				keys[choice.attr("identifier")] = key
+				blank.Choices = append(blank.Choices, model.QuestionOption{Key: key, Value: qtiText(choice, nil)})
+			}
+			for _, value := range d.accepted() {
+				if key, ok := keys[value]; ok {
+					blank.Answers = append(blank.Answers, key)
+				}
+			}
+		} else {
+			blank.Answers = d.accepted()
+			if d.caseSensitive {
+				q.ScoringRule = &model.ScoringRule{CaseSensitive: true}
+			}
+		}
+		if len(blank.Answers) == 0 {
+			return name, nil, fmt.Errorf("第 %d 个空缺少标准答案", i+1)
+		}
+		q.Cloze = append(q.Cloze, blank)
+	}
+	return name, q, nil
+}
+
+// qtiChoice 读取选择题，两个选项分别为“正确”“错误”的单选题读取为判断题
+func qtiChoice(q *model.CreateQuestionRequest, node *xmlNode, d *qtiDeclaration) error {
+	q.Type = model.QuestionTypeChoice
+	q.IsMultiple = node.attr("maxChoices") != "1" || d.cardinality == "multiple"
+	keys := make(map[string]string)
+	var bools []bool
+	for i, choice := range node.find("simpleChoice") {
+		key := optionKey(i)
+		value := qtiText(choice, nil)
+		keys[choice.attr("identifier")] = key
+		q.Options = append(q.Options, model.QuestionOption{Key: key, Value: value})
+		if b, ok := parseBool(choice.attr("identifier")); ok {
+			bools = append(bools, b)
+		} else if b, ok := parseBool(value); ok {
+			bools = append(bools, b)
+		}
+	}
+
+	var correct []string
+	for _, value := range d.accepted() {
+		key, ok := keys[value]
+		if !ok {
+			return fmt.Errorf("标准答案 %s 不是选项", value)
+		}
+		correct = append(correct, key)
+	}
+	if len(correct) == 0 {
+		return errors.New("缺少标准答案")
+	}
+
+	if !q.IsMultiple && len(q.Options) == 2 && len(bools) == 2 && bools[0] != bools[1] {
+		q.Type = model.QuestionTypeTrueFalse
+		q.CorrectAnswer = boolAnswer(bools[0] == (correct[0] == q.Options[0].Key))
+		q.Options = nil
+		return nil
+	}
+	q.CorrectAnswer = strings.Join(correct, ",")
+	return nil
+}
+
+// qtiOrder 读取排序题
+func qtiOrder(q *model.CreateQuestionRequest, node *xmlNode, d *qtiDeclaration) error {
+	q.Type = model.QuestionTypeOrdering
+	keys := make(map[string]string)
+	for i, choice := range node.find("simpleChoice") {
+		key := optionKey(i)
+		keys[choice.attr("identifier")] = key
+		q.Options = append(q.Options, model.QuestionOption{Key: key, Value: qtiText(choice, nil)})
+	}
+	var order []string
+	for _, value := range d.correct {
+		key, ok := keys[value]
+		if !ok {
+			return fmt.Errorf("标准答案 %s 不是选项", value)
+		}
+		order = append(order, key)
+	}
+	if len(order) == 0 {
+		return errors.New("缺少标准答案")
+	}
+	q.CorrectAnswer = strings.Join(order, ",")
+	return nil
+}
+
+// qtiMatch 读取连线题，第一组为左列，第二组为右列
+func qtiMatch(q *model.CreateQuestionRequest, node *xmlNode, d *qtiDeclaration) error {
+	sets := node.childrenNamed("simpleMatchSet")
+	if len(sets) != 2 {
+		return errors.New("连线题需要两组选项")
+	}
+	q.Type = model.QuestionTypeMatching
+	q.Matching = &model.MatchingSpec{Pairs: make(map[string]string)}
+	leftKeys, rightKeys := make(map[string]string), make(map[string]string)
+	for i, choice := range sets[0].childrenNamed("simpleAssociableChoice") {
+		key := strconv.Itoa(i + 1)
+		leftKeys[choice.attr("identifier")] = key
+		q.Matching.Left = append(q.Matching.Left, model.QuestionOption{Key: key, Value: qtiText(choice, nil)})
+	}
+	for i, choice := range sets[1].childrenNamed("simpleAssociableChoice") {
+		key := optionKey(i)
+		rightKeys[choice.attr("identifier")] = key
+		q.Matching.Right = append(q.Matching.Right, model.QuestionOption{Key: key, Value: qtiText(choice, nil)})
+	}
+	for _, value := range d.accepted() {
+		fields := strings.Fields(value)
+		if len(fields) != 2 {
+			return fmt.Errorf("无法识别配对 %s", value)
+		}
+		left, right := fields[0], fields[1]
+		if _, ok := leftKeys[left]; !ok {
+			left, right = right, left
+		}
+		leftKey, ok := leftKeys[left]
+		rightKey, ok2 := rightKeys[right]
+		if !ok || !ok2 {
+			return fmt.Errorf("配对 %s 不是选项", value)
+		}
+		if _, exists := q.Matching.Pairs[leftKey]; !exists {
+			q.Matching.Pairs[leftKey] = rightKey
+		}
+	}
+	if len(q.Matching.Pairs) == 0 {
+		return errors.New("缺少标准答案")
+	}
+	return nil
+}
+
+// qtiInteractions 按文档顺序返回题目正文中的交互，不进入交互内部
+func qtiInteractions(n *xmlNode) []qtiInteraction {
+	var interactions []qtiInteraction
+	for _, c := range n.Children {
+		if strings.HasSuffix(c.Name, "Interaction") {
+			interactions = append(interactions, qtiInteraction{node: c, parent: n})
+			continue
+		}
+		interactions = append(interactions, qtiInteractions(c)...)
+	}
+	return interactions
+}
+
+var (
+	qtiSpaces     = regexp.MustCompile(`[ \t\r\n]+`)
+	qtiBlockNames = map[string]bool{
+		"p": true, "div": true, "li": true, "ul": true, "ol": true, "blockquote": true, "pre": true, "table": true, "tr": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "prompt": true,
+	}
+)
+
+// qtiText 将 XHTML 内容转换为纯文本：段落之间空一行，br 为换行；
+// 行内交互由 blank 决定替换的文本（为 nil 时忽略），其他交互只保留提示语，评分说明（rubricBlock）不计入
+func qtiText(n *xmlNode, blank func(*xmlNode) string) string {
+	var b strings.Builder
+	var walk func(*xmlNode)
+	walk = func(n *xmlNode) {
+		switch {
+		case n.Name == "":
+			b.WriteString(qtiSpaces.ReplaceAllString(n.Text, " "))
+		case n.Name == "br":
+			b.WriteString("\n")
+		case n.Name == "rubricBlock":
+		case n.Name == "textEntryInteraction" || n.Name == "inlineChoiceInteraction":
+			if blank != nil {
+				b.WriteString(blank(n))
+			}
+		case strings.HasSuffix(n.Name, "Interaction"):
+			if prompt := n.child("prompt"); prompt != nil {
+				walk(prompt)
+			}
+		case qtiBlockNames[n.Name]:
+			b.WriteString("\n\n")
+			for _, c := range n.Children {
+				walk(c)
+			}
+			b.WriteString("\n\n")
+		default:
+			for _, c := range n.Children {
+				walk(c)
+			}
+		}
+	}
+	for _, c := range n.Children {
+		walk(c)
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// writeQTI 写出 QTI 2.1 内容包：每道题目一个 assessmentItem，另附按题目顺序引用全部题目的 assessmentTest
+func writeQTI(w io.Writer, title string, questions []*model.CreateQuestionRequest) ([]int, error) {
+	archive := zip.NewWriter(w)
+	var skipped []int
+	var identifiers []string
+	for i, q := range questions {
+		item, ok := qtiItemXML(q, i)
+		if !ok {
+			skipped = append(skipped, i)
+			continue
+		}
+		identifier := fmt.Sprintf("Q%d", i+1)
+		if err := writeZipFile(archive, qtiItemDir+identifier+".xml", item); err != nil {
+			return nil, err
+		}
+		identifiers = append(identifiers, identifier)
+	}
+
+	var test strings.Builder
+	fmt.Fprintf(&test, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<assessmentTest xmlns=\"%s\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\" xsi:schemaLocation=\"%s\" identifier=\"TEST\" title=\"%s\">\n", qtiNamespace, qtiSchemaLocation, escapeXML(title))
+	test.WriteString("  <testPart identifier=\"PART\" navigationMode=\"nonlinear\" submissionMode=\"simultaneous\">\n")
+	fmt.Fprintf(&test, "    <assessmentSection identifier=\"SECTION\" title=\"%s\" visible=\"true\">\n", escapeXML(title))
+	for _, identifier := range identifiers {
+		fmt.Fprintf(&test, "      <assessmentItemRef identifier=\"%s\" href=\"%s%s.xml\"/>\n", identifier, qtiItemDir, identifier)
+	}
+	test.WriteString("    </assessmentSection>\n  </testPart>\n</assessmentTest>\n")
+	if err := writeZipFile(archive, "assessment.xml", test.String()); err != nil {
+		return nil, err
+	}
+
+	var manifest strings.Builder
+	manifest.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<manifest xmlns=\"http://www.imsglobal.org/xsd/imscp_v1p1\" identifier=\"MANIFEST\">\n")
+	manifest.WriteString("  <metadata>\n    <schema>QTIv2.1 Package</schema>\n    <schemaversion>1.0.0</schemaversion>\n  </metadata>\n  <organizations/>\n  <resources>\n")
+	for _, identifier := range identifiers {
+		href := qtiItemDir + identifier + ".xml"
+		fmt.Fprintf(&manifest, "    <resource identifier=\"%s\" type=\"imsqti_item_xmlv2p1\" href=\"%s\">\n      <file href=\"%s\"/>\n    </resource>\n", identifier, href, href)
+	}
+	manifest.WriteString("    <resource identifier=\"TEST\" type=\"imsqti_test_xmlv2p1\" href=\"assessment.xml\">\n      <file href=\"assessment.xml\"/>\n")
+	for _, identifier := range identifiers {
+		fmt.Fprintf(&manifest, "      <dependency identifierref=\"%s\"/>\n", identifier)
+	}
+	manifest.WriteString("    </resource>\n  </resources>\n</manifest>\n")
+	if err := writeZipFile(archive, "imsmanifest.xml", manifest.String()); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return skipped, nil
+}
+
+// writeZipFile 向内容包写入一个文件
+func writeZipFile(archive *zip.Writer, name, content string) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, content)
+	return err
+}
+
+// qtiResponse 导出时一个作答变量的声明和评分条件
+type qtiResponse struct {
+	identifier    string
+	cardinality   string
+	baseType      string
+	correct       []string
+	mapping       []string // 不为空时按 mapping 判断是否答对，用于多个可接受答案
+	caseSensitive bool
+	tolerance     *float64 // 数值题误差
+}
+
+// qtiItemXML 生成一道题目的 assessmentItem 文档，无法表示时返回 false
+func qtiItemXML(q *model.CreateQuestionRequest, index int) (string, bool) {
+	var body strings.Builder
+	var responses []qtiResponse
+	single := func(baseType string, correct []string) qtiResponse {
+		return qtiResponse{identifier: "RESPONSE", cardinality: "single", baseType: baseType, correct: correct}
+	}
+
+	switch q.Type {
+	case model.QuestionTypeChoice:
+		body.WriteString(qtiParagraphs(q.Content, nil))
+		maxChoices := 1
+		cardinality := "single"
+		if q.IsMultiple {
+			maxChoices, cardinality = 0, "multiple"
+		}
+		fmt.Fprintf(&body, "    <choiceInteraction responseIdentifier=\"RESPONSE\" shuffle=\"false\" maxChoices=\"%d\">\n", maxChoices)
+		for i, option := range q.Options {
+			fmt.Fprintf(&body, "      <simpleChoice identifier=\"%s\">%s</simpleChoice>\n", qtiIdentifier("C", option.Key, i), escapeXML(option.Value))
+		}
+		body.WriteString("    </choiceInteraction>\n")
+		var correct []string
+		for _, key := range correctKeys(q.CorrectAnswer) {
+			correct = append(correct, qtiIdentifier("C", key, qtiOptionIndex(q.Options, key)))
+		}
+		responses = append(responses, qtiResponse{identifier: "RESPONSE", cardinality: cardinality, baseType: "identifier", correct: correct})
+	case model.QuestionTypeTrueFalse:
+		body.WriteString(qtiParagraphs(q.Content, nil))
+		body.WriteString("    <choiceInteraction responseIdentifier=\"RESPONSE\" shuffle=\"false\" maxChoices=\"1\">\n")
+		body.WriteString("      <simpleChoice identifier=\"true\">正确</simpleChoice>\n      <simpleChoice identifier=\"false\">错误</simpleChoice>\n")
+		body.WriteString("    </choiceInteraction>\n")
+		value, _ := parseBool(q.CorrectAnswer)
+		responses = append(responses, single("identifier", []string{boolAnswer(value)}))
+	case model.QuestionTypeOrdering:
+		body.WriteString(qtiParagraphs(q.Content, nil))
+		body.WriteString("    <orderInteraction responseIdentifier=\"RESPONSE\" shuffle=\"false\">\n")
+		for i, option := range q.Options {
+			fmt.Fprintf(&body, "      <simpleChoice identifier=\"%s\">%s</simpleChoice>\n", qtiIdentifier("C", option.Key, i), escapeXML(option.Value))
+		}
+		body.WriteString("    </orderInteraction>\n")
+		var correct []string
+		for _, key := range correctKeys(q.CorrectAnswer) {
+			correct = append(correct, qtiIdentifier("C", key, qtiOptionIndex(q.Options, key)))
+		}
+		responses = append(responses, qtiResponse{identifier: "RESPONSE", cardinality: "ordered", baseType: "identifier", correct: correct})
+	case model.QuestionTypeMatching:
+		if q.Matching == nil {
+			return "", false
+		}
+		body.WriteString(qtiParagraphs(q.Content, nil))
+		fmt.Fprintf(&body, "    <matchInteraction responseIdentifier=\"RESPONSE\" shuffle=\"false\" maxAssociations=\"%d\">\n      <simpleMatchSet>\n", len(q.Matching.Left))
+		for i, option := range q.Matching.Left {
+			fmt.Fprintf(&body, "        <simpleAssociableChoice identifier=\"%s\" matchMax=\"1\">%s</simpleAssociableChoice>\n", fmt.Sprintf("L%d", i+1), escapeXML(option.Value))
+		}
+		body.WriteString("      </simpleMatchSet>\n      <simpleMatchSet>\n")
+		for i, option := range q.Matching.Right {
+			fmt.Fprintf(&body, "        <simpleAssociableChoice identifier=\"%s\" matchMax=\"%d\">%s</simpleAssociableChoice>\n", fmt.Sprintf("R%d", i+1), len(q.Matching.Left), escapeXML(option.Value))
+		}
+		body.WriteString("      </simpleMatchSet>\n    </matchInteraction>\n")
+		var correct []string
+		for i, option := range q.Matching.Left {
+			rightKey, ok := q.Matching.Pairs[option.Key]
+			if !ok {
+				continue
+			}
+			correct = append(correct, fmt.Sprintf("L%d R%d", i+1, qtiOptionIndex(q.Matching.Right, rightKey)+1))
+		}
+		responses = append(responses, qtiResponse{identifier: "RESPONSE", cardinality: "multiple", baseType: "directedPair", correct: correct})
+	case model.QuestionTypeEssay:
+		body.WriteString(qtiParagraphs(q.Content, nil))
+		if q.Reference != "" {
+			fmt.Fprintf(&body, "    <rubricBlock view=\"scorer\">\n%s    </rubricBlock>\n", qtiParagraphs(q.Reference, nil))
+		}
+		body.WriteString("    <extendedTextInteraction responseIdentifier=\"RESPONSE\" expectedLines=\"10\"/>\n")
+		responses = append(responses, single("string", nil))
+	case model.QuestionTypeFillBlank:
+		blanks := blankAnswers(q)
+		if len(blanks) == 0 {
+			return "", false
+		}
+		body.WriteString(qtiParagraphs(q.Content, nil))
+		caseSensitive := q.ScoringRule != nil && q.ScoringRule.CaseSensitive
+		for i, accepted := range blanks {
+			identifier := qtiBlankIdentifier(i, len(blanks))
+			fmt.Fprintf(&body, "    <p><textEntryInteraction responseIdentifier=\"%s\" expectedLength=\"20\"/></p>\n", identifier)
+			responses = append(responses, qtiResponse{identifier: identifier, cardinality: "single", baseType: "string",
+				correct: accepted[:1], mapping: accepted, caseSensitive: caseSensitive})
+		}
+	case model.QuestionTypeNumeric:
+		if q.Numeric == nil || q.Numeric.Value == nil {
+			return "", false
+		}
+		body.WriteString(qtiParagraphs(q.Content, nil))
+		body.WriteString("    <p><textEntryInteraction responseIdentifier=\"RESPONSE\" expectedLength=\"10\"/></p>\n")
+		tolerance := q.Numeric.Tolerance
+		response := single("float", []string{formatFloat(*q.Numeric.Value)})
+		response.tolerance = &tolerance
+		responses = append(responses, response)
+	case model.QuestionTypeCloze:
+		caseSensitive := q.ScoringRule != nil && q.ScoringRule.CaseSensitive
+		blank := 0
+		body.WriteString(qtiParagraphs(q.Content, func() string {
+			if blank >= len(q.Cloze) {
+				return escapeXML(model.ClozePlaceholder)
+			}
+			spec := q.Cloze[blank]
+			identifier := qtiBlankIdentifier(blank, len(q.Cloze))
+			blank++
+			if len(spec.Choices) == 0 {
+				responses = append(responses, qtiResponse{identifier: identifier, cardinality: "single", baseType: "string",
+					correct: spec.Answers[:1], mapping: spec.Answers, caseSensitive: caseSensitive})
+				return fmt.Sprintf("<textEntryInteraction responseIdentifier=\"%s\" expectedLength=\"15\"/>", identifier)
+			}
+			var choices strings.Builder
+			var answers []string
+			for i, choice := range spec.Choices {
+				fmt.Fprintf(&choices, "<inlineChoice identifier=\"%s\">%s</inlineChoice>", qtiIdentifier("C", choice.Key, i), escapeXML(choice.Value))
+			}
+			for _, key := range spec.Answers {
+				answers = append(answers, qtiIdentifier("C", key, qtiOptionIndex(spec.Choices, key)))
+			}
+			responses = append(responses, qtiResponse{identifier: identifier, cardinality: "single", baseType: "identifier",
+				correct: answers[:1], mapping: answers})
+			return fmt.Sprintf("<inlineChoiceInteraction responseIdentifier=\"%s\" shuffle=\"false\">%s</inlineChoiceInteraction>", identifier, choices.String())
+		}))
+		if blank == 0 {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<assessmentItem xmlns=\"%s\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\" xsi:schemaLocation=\"%s\" identifier=\"Q%d\" title=\"%s\" adaptive=\"false\" timeDependent=\"false\">\n",
+		qtiNamespace, qtiSchemaLocation, index+1, escapeXML(questionName(q, index)))
+	for _, r := range responses {
+		fmt.Fprintf(&b, "  <responseDeclaration identifier=\"%s\" cardinality=\"%s\" baseType=\"%s\">\n", r.identifier, r.cardinality, r.baseType)
+		if len(r.correct) > 0 {
+			b.WriteString("    <correctResponse>\n")
+			for _, value := range r.correct {
+				fmt.Fprintf(&b, "      <value>%s</value>\n", escapeXML(value))
+			}
+			b.WriteString("    </correctResponse>\n")
+		}
+		if len(r.mapping) > 0 {
+			b.WriteString("    <mapping defaultValue=\"0\">\n")
+			for _, value := range r.mapping {
+				fmt.Fprintf(&b, "      <mapEntry mapKey=\"%s\" mappedValue=\"1\" caseSensitive=\"%t\"/>\n", escapeXML(value), r.caseSensitive)
+			}
+			b.WriteString("    </mapping>\n")
+		}
+		b.WriteString("  </responseDeclaration>\n")
+	}
+	b.WriteString("  <outcomeDeclaration identifier=\"SCORE\" cardinality=\"single\" baseType=\"float\">\n    <defaultValue><value>0</value></defaultValue>\n  </outcomeDeclaration>\n")
+	fmt.Fprintf(&b, "  <outcomeDeclaration identifier=\"MAXSCORE\" cardinality=\"single\" baseType=\"float\">\n    <defaultValue><value>%d</value></defaultValue>\n  </outcomeDeclaration>\n", q.Score)
+	if q.Explanation != "" {
+		b.WriteString("  <outcomeDeclaration identifier=\"FEEDBACK\" cardinality=\"single\" baseType=\"identifier\"/>\n")
+	}
+	fmt.Fprintf(&b, "  <itemBody>\n%s  </itemBody>\n", body.String())
+	b.WriteString(qtiResponseProcessing(responses, q))
+	if q.Explanation != "" {
+		fmt.Fprintf(&b, "  <modalFeedback outcomeIdentifier=\"FEEDBACK\" identifier=\"EXPLANATION\" showHide=\"show\">\n%s  </modalFeedback>\n", qtiParagraphs(q.Explanation, nil))
+	}
+	b.WriteString("</assessmentItem>\n")
+	return b.String(), true
+}
+
+// qtiResponseProcessing 生成评分规则：每个答对的作答变量得到分值的相应份额，有解析时作答后显示解析
+func qtiResponseProcessing(responses []qtiResponse, q *model.CreateQuestionRequest) string {
+	var b strings.Builder
+	b.WriteString("  <responseProcessing>\n")
+	var scored []qtiResponse
+	for _, r := range responses {
+		if len(r.correct) > 0 {
+			scored = append(scored, r)
+		}
+	}
+	for _, r := range scored {
+		var condition string
+		switch {
+		case r.tolerance != nil:
+			t := formatFloat(*r.tolerance)
+			condition = fmt.Sprintf("<equal toleranceMode=\"absolute\" tolerance=\"%s %s\"><variable identifier=\"%s\"/><correct identifier=\"%s\"/></equal>", t, t, r.identifier, r.identifier)
+		case len(r.mapping) > 0:
+			condition = fmt.Sprintf("<gt><mapResponse identifier=\"%s\"/><baseValue baseType=\"float\">0</baseValue></gt>", r.identifier)
+		default:
+			condition = fmt.Sprintf("<match><variable identifier=\"%s\"/><correct identifier=\"%s\"/></match>", r.identifier, r.identifier)
+		}
+		share := formatFraction(float64(q.Score) / float64(len(scored)))
+		fmt.Fprintf(&b, "    <responseCondition>\n      <responseIf>\n        %s\n        <setOutcomeValue identifier=\"SCORE\"><sum><variable identifier=\"SCORE\"/><baseValue baseType=\"float\">%s</baseValue></sum></setOutcomeValue>\n      </responseIf>\n    </responseCondition>\n", condition, share)
+	}
+	if q.Explanation != "" {
+		b.WriteString("    <setOutcomeValue identifier=\"FEEDBACK\"><baseValue baseType=\"identifier\">EXPLANATION</baseValue></setOutcomeValue>\n")
+	}
+	b.WriteString("  </responseProcessing>\n")
+	return b.String()
+}
+
+// qtiParagraphBreak 段落之间的空行
+var qtiParagraphBreak = regexp.MustCompile(`\n\s*\n`)
+
+// qtiParagraphs 将纯文本转换为段落，空行分段、单个换行为 br；blank 不为 nil 时替换完形填空的占位符
+func qtiParagraphs(text string, blank func() string) string {
+	var b strings.Builder
+	for _, paragraph := range qtiParagraphBreak.Split(strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n")), -1) {
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		content := strings.ReplaceAll(escapeXML(paragraph), "\n", "<br/>")
+		if blank != nil {
+			parts := strings.Split(content, model.ClozePlaceholder)
+			var p strings.Builder
+			for i, part := range parts {
+				p.WriteString(part)
+				if i < len(parts)-1 {
+					p.WriteString(blank())
+				}
+			}
+			content = p.String()
+		}
+		fmt.Fprintf(&b, "    <p>%s</p>\n", content)
+	}
+	return b.String()
+}
+
+// qtiIdentifierPattern QTI 标识必须以字母或下划线开头
+var qtiIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// qtiIdentifier 由选项标识生成 QTI 标识，不符合要求时使用前缀和位置
+func qtiIdentifier(prefix, key string, index int) string {
+	if qtiIdentifierPattern.MatchString(key) {
+		return key
+	}
+	return fmt.Sprintf("%s%d", prefix, index+1)
+}
+
+// qtiOptionIndex 返回选项标识的位置，不存在时返回 -1
+func qtiOptionIndex(options []model.QuestionOption, key string) int {
+	for i, option := range options {
+		if option.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// qtiBlankIdentifier 返回第 i 个空的作答变量标识，只有一个空时为 RESPONSE
+func qtiBlankIdentifier(i, total int) string {
+	if total == 1 {
+		return "RESPONSE"
+	}
+	return fmt.Sprintf("RESPONSE_%d", i+1)
+}
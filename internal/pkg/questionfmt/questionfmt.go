@@ -0,0 +1,298 @@
+// Package questionfmt 在题目与常见题目交换格式之间转换，支持 IMS QTI 2.1、Moodle XML、GIFT 和 Markdown
+//
+// 题目统一以 model.CreateQuestionRequest 表示，与创建题目接口的请求一致：导入得到的题目可以直接按创建题目的规则校验后保存，
+// 导出前由调用方将已保存的题目还原为请求。各格式无法表示的题型在导出时跳过，由 Write 返回被跳过的题目。
+package questionfmt
+
+import (
+	"ai-course/internal/model"
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxImportSize 可导入的题目文件最大字节数
+const MaxImportSize = 20 << 20
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported question format")
+	ErrTooLarge          = errors.New("question file too large")
+	ErrInvalidFile       = errors.New("invalid question file")
+	ErrNoQuestions       = errors.New("no questions found in file")
+)
+
+// Format 题目交换格式
+type Format string
+
+const (
+	FormatQTI      Format = "qti"      // IMS QTI 2.1，导出为内容包（zip），导入支持内容包和单个题目 XML
+	FormatMoodle   Format = "moodle"   // Moodle XML
+	FormatGIFT     Format = "gift"     // Moodle GIFT 文本格式
+	FormatMarkdown Format = "markdown" // 本系统的 Markdown 题目格式，见 markdown.go
+)
+
+// ParseFormat 解析格式名称
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "qti", "qti21":
+		return FormatQTI, nil
+	case "moodle", "moodle_xml", "xml":
+		return FormatMoodle, nil
+	case "gift":
+		return FormatGIFT, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// Extension 返回导出文件的扩展名（含点号）
+func (f Format) Extension() string {
+	switch f {
+	case FormatQTI:
+		return ".zip"
+	case FormatMoodle:
+		return ".xml"
+	case FormatGIFT:
+		return ".gift"
+	default:
+		return ".md"
+	}
+}
+
+// ContentType 返回导出文件的 MIME 类型
+func (f Format) ContentType() string {
+	switch f {
+	case FormatQTI:
+		return "application/zip"
+	case FormatMoodle:
+		return "application/xml; charset=utf-8"
+	case FormatGIFT:
+		return "text/plain; charset=utf-8"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+// Detect 根据文件扩展名和内容识别格式，XML 文件按根元素区分 Moodle XML 和 QTI
+func Detect(filename string, data []byte) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".zip":
+		return FormatQTI, nil
+	case ".gift":
+		return FormatGIFT, nil
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".xml":
+		switch xmlRootName(data) {
+		case "quiz":
+			return FormatMoodle, nil
+		case "assessmentItem", "assessmentTest", "questestinterop":
+			return FormatQTI, nil
+		}
+		return "", ErrUnsupportedFormat
+	case ".txt":
+		// GIFT 文件通常以 .txt 保存，按是否包含答案块区分 GIFT 和 Markdown
+		if bytes.Contains(data, []byte("{")) && bytes.Contains(data, []byte("}")) && !bytes.Contains(data, []byte("\n## ")) {
+			return FormatGIFT, nil
+		}
+		return FormatMarkdown, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// Item 导入文件中的一道题目
+type Item struct {
+	Index    int                          // 在文件中的序号，从 1 开始
+	Name     string                       // 题目名称或标识
+	Question *model.CreateQuestionRequest // 解析得到的题目，Err 不为空时为 nil
+	Err      error                        // 无法转换为题目的原因
+}
+
+// Read 读取题目文件，单道题目无法转换时记录在该题的 Err 中，只有整个文件无法解析时返回错误
+// 题目的分值在文件中未设置时为 0，Order 按文件中的顺序从 1 开始
+func Read(format Format, r io.Reader) ([]Item, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxImportSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read question file failed: %w", err)
+	}
+	if len(data) > MaxImportSize {
+		return nil, ErrTooLarge
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var items []Item
+	switch format {
+	case FormatQTI:
+		items, err = readQTI(data)
+	case FormatMoodle:
+		items, err = readMoodle(data)
+	case FormatGIFT:
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("%w: 文件不是 UTF-8 编码", ErrInvalidFile)
+		}
+		items = readGIFT(string(data))
+	case FormatMarkdown:
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("%w: 文件不是 UTF-8 编码", ErrInvalidFile)
+		}
+		items = readMarkdown(string(data))
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNoQuestions
+	}
+
+	for i := range items {
+		items[i].Index = i + 1
+		if items[i].Question != nil {
+			items[i].Question.Order = i + 1
+		}
+	}
+	return items, nil
+}
+
+// Write 将题目写为指定格式，title 为试卷或分类名称；返回因格式无法表示而跳过的题目下标（从 0 开始）
+func Write(w io.Writer, format Format, title string, questions []*model.CreateQuestionRequest) ([]int, error) {
+	switch format {
+	case FormatQTI:
+		return writeQTI(w, title, questions)
+	case FormatMoodle:
+		return writeMoodle(w, title, questions)
+	case FormatGIFT:
+		return writeGIFT(w, title, questions)
+	case FormatMarkdown:
+		return writeMarkdown(w, title, questions)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// optionKey 按位置生成选项标识：A、B、C……，超过 26 项时使用序号
+func optionKey(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return strconv.Itoa(i + 1)
+}
+
+// blankAnswers 返回填空题每个空可接受的答案
+func blankAnswers(q *model.CreateQuestionRequest) [][]string {
+	if q.ScoringRule != nil && len(q.ScoringRule.Blanks) > 0 {
+		return q.ScoringRule.Blanks
+	}
+	if answer := strings.TrimSpace(q.CorrectAnswer); answer != "" {
+		return [][]string{{answer}}
+	}
+	return nil
+}
+
+// setBlankAnswers 设置填空题的答案：只有一个答案时写入正确答案，否则写入计分规则
+func setBlankAnswers(q *model.CreateQuestionRequest, blanks [][]string, caseSensitive bool) {
+	if len(blanks) == 0 {
+		return
+	}
+	q.CorrectAnswer = blanks[0][0]
+	if len(blanks) > 1 || len(blanks[0]) > 1 || caseSensitive {
+		q.ScoringRule = &model.ScoringRule{Blanks: blanks, CaseSensitive: caseSensitive}
+	}
+}
+
+// correctKeys 返回选择题或排序题的正确选项标识，支持 "A,C" 和 JSON 数组
+func correctKeys(answer string) []string {
+	answer = strings.TrimSpace(answer)
+	if strings.HasPrefix(answer, "[") {
+		answer = strings.Trim(answer, "[]")
+		answer = strings.ReplaceAll(answer, `"`, "")
+	}
+	var keys []string
+	for _, key := range strings.FieldsFunc(answer, func(r rune) bool {
+		return r == ',' || r == '，' || r == ' ' || r == '、'
+	}) {
+		keys = append(keys, strings.TrimSpace(key))
+	}
+	return keys
+}
+
+// parseBool 解析判断题答案
+func parseBool(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "t", "1", "对", "正确", "是", "√", "yes":
+		return true, true
+	case "false", "f", "0", "错", "错误", "否", "×", "no":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// boolAnswer 返回判断题答案的文本
+func boolAnswer(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+// formatFloat 以最短形式输出数值
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// roundScore 将文件中的分值转换为整数分值，无效时返回 0
+func roundScore(value string) int {
+	score, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || score < 0.5 {
+		return 0
+	}
+	return int(score + 0.5)
+}
+
+var (
+	htmlBreak    = regexp.MustCompile(`(?i)<br\s*/?>|</(?:p|div|li|h[1-6]|tr)>`)
+	htmlTag      = regexp.MustCompile(`<[^>]*>`)
+	blankLines   = regexp.MustCompile(`\n{3,}`)
+	trailingSpan = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// htmlToText 将 HTML 片段转换为纯文本，段落和换行保留为换行
+func htmlToText(s string) string {
+	s = htmlBreak.ReplaceAllString(s, "\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = trailingSpan.ReplaceAllString(s, "\n")
+	s = blankLines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+// questionName 以题干开头作为题目名称
+func questionName(q *model.CreateQuestionRequest, index int) string {
+	if text := summary(q.Content); text != "" {
+		return text
+	}
+	return fmt.Sprintf("Q%d", index+1)
+}
+
+// summary 返回文本开头的 30 个字符，空白合并为一个空格
+func summary(text string) string {
+	text = strings.Join(strings.Fields(strings.ReplaceAll(text, model.ClozePlaceholder, "___")), " ")
+	if utf8.RuneCountInString(text) > 30 {
+		text = string([]rune(text)[:30]) + "…"
+	}
+	return text
+}
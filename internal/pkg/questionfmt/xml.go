@@ -0,0 +1,155 @@
+package questionfmt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlNode 通用的 XML 元素树，用于读取 QTI 题干这类混合内容的文档；名称均为去掉命名空间的本地名称
+type xmlNode struct {
+	Name     string // 元素名称，文本节点为空
+	Attrs    map[string]string
+	Children []*xmlNode
+	Text     string // 文本节点的内容
+}
+
+// parseXML 解析 XML 文档，返回根元素；允许 HTML 实体（如 &nbsp;）
+func parseXML(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Entity = xml.HTMLEntity
+
+	var root *xmlNode
+	var stack []*xmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{Name: t.Name.Local, Attrs: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				node.Attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, &xmlNode{Text: string(t)})
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("%w: 缺少根元素", ErrInvalidFile)
+	}
+	return root, nil
+}
+
+// xmlRootName 返回 XML 文档根元素的本地名称，无法解析时返回空
+func xmlRootName(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// child 返回第一个指定名称的子元素
+func (n *xmlNode) child(name string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// childrenNamed 返回指定名称的全部子元素
+func (n *xmlNode) childrenNamed(name string) []*xmlNode {
+	if n == nil {
+		return nil
+	}
+	var nodes []*xmlNode
+	for _, c := range n.Children {
+		if c.Name == name {
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+// find 按文档顺序返回指定名称的全部后代元素
+func (n *xmlNode) find(name string) []*xmlNode {
+	if n == nil {
+		return nil
+	}
+	var nodes []*xmlNode
+	for _, c := range n.Children {
+		if c.Name == name {
+			nodes = append(nodes, c)
+		}
+		nodes = append(nodes, c.find(name)...)
+	}
+	return nodes
+}
+
+// attr 返回属性值
+func (n *xmlNode) attr(name string) string {
+	if n == nil {
+		return ""
+	}
+	return n.Attrs[name]
+}
+
+// text 返回全部后代文本节点拼接的内容
+func (n *xmlNode) text() string {
+	if n == nil {
+		return ""
+	}
+	if n.Name == "" {
+		return n.Text
+	}
+	var b strings.Builder
+	for _, c := range n.Children {
+		b.WriteString(c.text())
+	}
+	return b.String()
+}
+
+// xmlEscaper 转义 XML 文本和属性值中的特殊字符，保留换行以便阅读
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+// escapeXML 转义 XML 文本和属性值
+func escapeXML(s string) string {
+	return xmlEscaper.Replace(s)
+}
+
+// cdata 将文本包装为 CDATA 段，文本中的 "]]>" 拆分到两个段中
+func cdata(s string) string {
+	return "<![CDATA[" + strings.ReplaceAll(s, "]]>", "]]]]><![CDATA[>") + "]]>"
+}
@@ -0,0 +1,160 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// nobodyID 以 root 运行服务时，沙箱进程切换到的非特权用户
+const nobodyID = 65534
+
+// exec 在沙箱中执行命令，timeLimit 为 CPU 时间限制
+func (r *Runner) exec(ctx context.Context, dir string, command []string, input string, timeLimit time.Duration, memoryLimitMB int) (*CaseResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*timeLimit+time.Second)
+	defer cancel()
+
+	cpuSeconds := int((timeLimit + time.Second - 1) / time.Second)
+	script := fmt.Sprintf("ulimit -t %d; ulimit -d %d; ulimit -f %d; ulimit -c 0; exec \"$@\"",
+		cpuSeconds, memoryLimitMB<<10, fileSizeLimitKB)
+	// 重新执行当前程序，在新的命名空间中切换到沙箱根文件系统后再执行命令
+	cmd := exec.Command("/proc/self/exe", append([]string{dir, "/bin/sh", "-c", script, "sandbox"}, command...)...)
+	cmd.Args[0] = "sandbox-init"
+	cmd.Dir = dir
+	cmd.Env = []string{
+		initEnv + "=1",
+		"PATH=/usr/local/bin:/usr/bin:/bin",
+		"HOME=/work",
+		"TMPDIR=/tmp",
+		"LANG=C.UTF-8",
+	}
+	cmd.Stdin = strings.NewReader(input)
+	stdout := &limitedBuffer{limit: r.cfg.MaxOutput}
+	stderr := &limitedBuffer{limit: r.cfg.MaxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.SysProcAttr = isolatedProcAttr()
+
+	errReader, errWriter, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("create sandbox pipe failed: %w", err)
+	}
+	defer errReader.Close()
+	cmd.ExtraFiles = []*os.File{errWriter}
+
+	start := time.Now()
+	err = cmd.Start()
+	errWriter.Close()
+	if err != nil {
+		return nil, fmt.Errorf("start sandbox process failed: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var waitErr error
+	timedOut := false
+	select {
+	case waitErr = <-done:
+	case <-ctx.Done():
+		timedOut = true
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		waitErr = <-done
+	}
+
+	// 初始化失败说明沙箱不可用，不能当作程序的运行错误
+	if initErr, _ := io.ReadAll(errReader); len(initErr) > 0 {
+		return nil, fmt.Errorf("sandbox init failed: %s", initErr)
+	}
+
+	result := &CaseResult{
+		Status:  StatusOK,
+		Stdout:  stdout.String(),
+		Stderr:  stderr.String(),
+		Runtime: time.Since(start),
+	}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	switch {
+	case timedOut || exceededCPU(cmd, timeLimit):
+		result.Status = StatusTimeLimit
+	case stdout.truncated || stderr.truncated || exitedBySignal(cmd, syscall.SIGXFSZ):
+		result.Status = StatusOutputLimit
+	case waitErr != nil:
+		var exitErr *exec.ExitError
+		if !errors.As(waitErr, &exitErr) {
+			return nil, fmt.Errorf("wait sandbox process failed: %w", waitErr)
+		}
+		result.Status = StatusRuntimeError
+	}
+	return result, nil
+}
+
+// exceededCPU 判断进程是否超出 CPU 时间限制
+// rlimit 只能按整秒设置，因此同时比较实际消耗的 CPU 时间；被 rlimit 终止时统计值可能略低于限制
+func exceededCPU(cmd *exec.Cmd, timeLimit time.Duration) bool {
+	if cmd.ProcessState == nil {
+		return false
+	}
+	used := cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()
+	if used > timeLimit {
+		return true
+	}
+	killed := exitedBySignal(cmd, syscall.SIGXCPU) || exitedBySignal(cmd, syscall.SIGKILL)
+	return killed && used+100*time.Millisecond >= timeLimit
+}
+
+// exitedBySignal 判断进程是否被指定信号终止
+func exitedBySignal(cmd *exec.Cmd, sig syscall.Signal) bool {
+	if cmd.ProcessState == nil {
+		return false
+	}
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == sig
+}
+
+// isolatedProcAttr 进程隔离设置：独立进程组，独立的用户、挂载、网络、PID、IPC 和 UTS 命名空间
+// 命名空间中的 root 映射到宿主机的 nobody 用户（以 root 运行服务时）或服务自身的用户，只用于构造沙箱根文件系统
+func isolatedProcAttr() *syscall.SysProcAttr {
+	attr := &syscall.SysProcAttr{
+		Setpgid:   true,
+		Pdeathsig: syscall.SIGKILL,
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET |
+			syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+	}
+	if os.Geteuid() == 0 {
+		// 映射后切换到命名空间中的 root（即宿主机的 nobody），并清空附加组
+		attr.UidMappings[0].HostID = nobodyID
+		attr.GidMappings[0].HostID = nobodyID
+		attr.GidMappingsEnableSetgroups = true
+		attr.Credential = &syscall.Credential{Uid: 0, Gid: 0}
+	}
+	return attr
+}
+
+// prepareDir 创建沙箱根目录的挂载点，以 root 运行时放开工作目录权限，使 nobody 用户可以写入编译产物
+func prepareDir(dir string) error {
+	if err := os.Mkdir(filepath.Join(dir, "root"), 0o755); err != nil {
+		return fmt.Errorf("prepare sandbox dir failed: %w", err)
+	}
+	if os.Geteuid() != 0 {
+		return nil
+	}
+	for _, path := range []string{dir, filepath.Join(dir, "work")} {
+		if err := os.Chmod(path, 0o777); err != nil {
+			return fmt.Errorf("prepare sandbox dir failed: %w", err)
+		}
+	}
+	return nil
+}
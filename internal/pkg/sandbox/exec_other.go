@@ -0,0 +1,18 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"time"
+)
+
+// exec 非 Linux 平台无法提供进程隔离，拒绝运行
+func (r *Runner) exec(ctx context.Context, dir string, command []string, input string, timeLimit time.Duration, memoryLimitMB int) (*CaseResult, error) {
+	return nil, ErrUnavailable
+}
+
+// prepareDir 非 Linux 平台无需处理
+func prepareDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,231 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// initEnv 标记由 exec 重新执行的当前程序，进程启动时进入沙箱根文件系统后执行命令
+	initEnv = "AI_COURSE_SANDBOX_INIT"
+	// initErrorFD 沙箱初始化失败时写入错误信息的文件描述符（ExtraFiles 的第一个）
+	initErrorFD = 3
+	// processLimit 沙箱中同时存在的进程数上限，防止 fork 炸弹
+	// 每次运行使用独立的用户命名空间，进程数只统计本次运行的进程
+	processLimit = 64
+
+	// securebits（linux/securebits.h）：禁止 uid 0 在 execve 时获得能力、禁止提升 ambient 能力，并锁定设置
+	secbitNoRoot             = 1 << 0
+	secbitNoRootLocked       = 1 << 1
+	secbitNoAmbientRaise     = 1 << 6
+	secbitNoAmbientRaiseLock = 1 << 7
+)
+
+// systemPaths 只读挂载到沙箱中的系统目录，宿主机上是符号链接时（如 /bin -> usr/bin）在沙箱中创建同样的链接
+var systemPaths = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32"}
+
+// etcPaths 编译器和解释器需要的 /etc 文件，其余配置（包括服务自身的配置）不可见
+var etcPaths = []string{"/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d", "/etc/alternatives", "/etc/localtime"}
+
+// devices 绑定到沙箱 /dev 中的设备
+var devices = []string{"/dev/null", "/dev/zero", "/dev/full", "/dev/random", "/dev/urandom"}
+
+func init() {
+	if os.Getenv(initEnv) == "" {
+		return
+	}
+	// 能力集按线程生效，固定线程保证降权和 execve 在同一线程
+	runtime.LockOSThread()
+
+	errPipe := os.NewFile(initErrorFD, "sandbox-init")
+	fail := func(err error) {
+		fmt.Fprintf(errPipe, "%v", err)
+		os.Exit(127)
+	}
+	if len(os.Args) < 3 {
+		fail(fmt.Errorf("missing sandbox command"))
+	}
+	if err := enterRootfs(os.Args[1]); err != nil {
+		fail(err)
+	}
+	if err := dropPrivileges(); err != nil {
+		fail(err)
+	}
+
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, initEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	unix.CloseOnExec(initErrorFD)
+	err := unix.Exec(os.Args[2], os.Args[2:], env)
+	fail(fmt.Errorf("exec %s: %w", os.Args[2], err))
+}
+
+// enterRootfs 在新的挂载命名空间中构造最小根文件系统并切换过去：
+// 系统目录只读，/work 为工作目录，/tmp 为空的 tmpfs，宿主机的其他文件（配置、上传文件等）均不可见
+func enterRootfs(dir string) error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	root := filepath.Join(dir, "root")
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=1m,mode=755"); err != nil {
+		return fmt.Errorf("mount rootfs: %w", err)
+	}
+
+	for _, path := range systemPaths {
+		if err := bindReadOnly(root, path); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "etc"), 0o755); err != nil {
+		return err
+	}
+	for _, path := range etcPaths {
+		if err := bindReadOnly(root, path); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(root, "dev"), 0o755); err != nil {
+		return err
+	}
+	for _, path := range devices {
+		if err := bindReadOnly(root, path); err != nil {
+			return err
+		}
+	}
+	for name, target := range map[string]string{"fd": "/proc/self/fd", "stdin": "/proc/self/fd/0", "stdout": "/proc/self/fd/1", "stderr": "/proc/self/fd/2"} {
+		if err := os.Symlink(target, filepath.Join(root, "dev", name)); err != nil {
+			return err
+		}
+	}
+
+	// 新的 PID 命名空间中的 /proc 只能看到沙箱内的进程；部分容器环境不允许挂载 proc，此时不提供 /proc
+	if err := os.Mkdir(filepath.Join(root, "proc"), 0o555); err != nil {
+		return err
+	}
+	_ = unix.Mount("proc", filepath.Join(root, "proc"), "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+
+	if err := os.Mkdir(filepath.Join(root, "tmp"), 0o777); err != nil {
+		return err
+	}
+	if err := unix.Mount("tmpfs", filepath.Join(root, "tmp"), "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, fmt.Sprintf("size=%dk,mode=1777", fileSizeLimitKB)); err != nil {
+		return fmt.Errorf("mount tmp: %w", err)
+	}
+
+	work := filepath.Join(root, "work")
+	if err := os.Mkdir(work, 0o755); err != nil {
+		return err
+	}
+	if err := unix.Mount(filepath.Join(dir, "work"), work, "", unix.MS_BIND, ""); err != nil {
+		return fmt.Errorf("mount work dir: %w", err)
+	}
+	if err := remount(work, filepath.Join(dir, "work"), unix.MS_NOSUID|unix.MS_NODEV); err != nil {
+		return err
+	}
+
+	// pivot_root 后卸载旧的根目录，沙箱中不再有通往宿主机文件系统的路径
+	if err := unix.Chdir(root); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("pivot root: %w", err)
+	}
+	if err := unix.Unmount(".", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("unmount old root: %w", err)
+	}
+	if err := unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_BIND|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount rootfs read-only: %w", err)
+	}
+	return unix.Chdir("/work")
+}
+
+// bindReadOnly 将宿主机路径只读绑定到沙箱根目录下的同名路径，路径不存在时跳过
+func bindReadOnly(root, path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(root, path)
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	case info.IsDir():
+		err = os.Mkdir(target, 0o755)
+	default:
+		err = os.WriteFile(target, nil, 0o644)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := unix.Mount(path, target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", path, err)
+	}
+	return remount(target, path, unix.MS_RDONLY|unix.MS_NOSUID)
+}
+
+// remount 为绑定挂载增加挂载选项
+// 用户命名空间中不能去掉宿主机挂载已有的选项（如 nodev），因此保留源路径的现有选项
+func remount(target, source string, flags uintptr) error {
+	var st unix.Statfs_t
+	if err := unix.Statfs(source, &st); err != nil {
+		return fmt.Errorf("statfs %s: %w", source, err)
+	}
+	for _, f := range []struct{ st, ms uintptr }{
+		{unix.ST_RDONLY, unix.MS_RDONLY},
+		{unix.ST_NOSUID, unix.MS_NOSUID},
+		{unix.ST_NODEV, unix.MS_NODEV},
+		{unix.ST_NOEXEC, unix.MS_NOEXEC},
+		{unix.ST_NOATIME, unix.MS_NOATIME},
+		{unix.ST_NODIRATIME, unix.MS_NODIRATIME},
+		{unix.ST_RELATIME, unix.MS_RELATIME},
+	} {
+		if uintptr(st.Flags)&f.st != 0 {
+			flags |= f.ms
+		}
+	}
+	if err := unix.Mount("", target, "", unix.MS_REMOUNT|unix.MS_BIND|flags, ""); err != nil {
+		return fmt.Errorf("remount %s: %w", target, err)
+	}
+	return nil
+}
+
+// dropPrivileges 限制进程数并放弃用户命名空间中的全部能力，执行的程序无法再修改挂载或提升权限
+func dropPrivileges() error {
+	if err := unix.Setrlimit(unix.RLIMIT_NPROC, &unix.Rlimit{Cur: processLimit, Max: processLimit}); err != nil {
+		return fmt.Errorf("set process limit: %w", err)
+	}
+	for c := 0; ; c++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil {
+			if err == unix.EINVAL {
+				break
+			}
+			return fmt.Errorf("drop capability %d: %w", c, err)
+		}
+	}
+	// 命名空间中的 uid 为 0，禁止 execve 时按 root 重新获得能力
+	securebits := secbitNoRoot | secbitNoRootLocked | secbitNoAmbientRaise | secbitNoAmbientRaiseLock
+	if err := unix.Prctl(unix.PR_SET_SECUREBITS, uintptr(securebits), 0, 0, 0); err != nil {
+		return fmt.Errorf("set securebits: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultTimeLimit      = 2 * time.Second
+	DefaultMemoryLimitMB  = 256
+	DefaultCompileTimeout = 30 * time.Second
+	DefaultMaxOutput      = 1 << 20
+	DefaultMaxConcurrent  = 2
+
+	// 编译阶段的内存上限，编译器需要的内存通常远多于程序本身
+	compileMemoryLimitMB = 1024
+	// 程序写入文件的大小上限（KB）
+	fileSizeLimitKB = 10 << 10
+)
+
+var (
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+	ErrUnavailable         = errors.New("sandbox is not available on this platform")
+)
+
+// Status 运行状态
+type Status string
+
+const (
+	StatusOK           Status = "ok"            // 正常结束
+	StatusCompileError Status = "compile_error" // 编译失败
+	StatusRuntimeError Status = "runtime_error" // 非零退出或被信号终止
+	StatusTimeLimit    Status = "time_limit"    // 超出时间限制
+	StatusOutputLimit  Status = "output_limit"  // 输出超出限制
+)
+
+// Language 编程语言的编译和运行命令，命令在工作目录中执行
+type Language struct {
+	SourceFile string   // 源文件名
+	Compile    []string // 编译命令，为空表示解释执行
+	Run        []string // 运行命令
+}
+
+// Languages 支持的语言
+var Languages = map[string]Language{
+	"python":     {SourceFile: "main.py", Run: []string{"python3", "-S", "main.py"}},
+	"c":          {SourceFile: "main.c", Compile: []string{"gcc", "-O2", "-std=c11", "-o", "main", "main.c", "-lm"}, Run: []string{"./main"}},
+	"cpp":        {SourceFile: "main.cpp", Compile: []string{"g++", "-O2", "-std=c++17", "-o", "main", "main.cpp"}, Run: []string{"./main"}},
+	"javascript": {SourceFile: "main.js", Run: []string{"node", "main.js"}},
+}
+
+// LanguageNames 返回支持的语言名称
+func LanguageNames() []string {
+	names := make([]string, 0, len(Languages))
+	for name := range Languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Config 沙箱配置
+type Config struct {
+	WorkDir        string        // 临时工作目录的父目录，默认系统临时目录
+	CompileTimeout time.Duration // 编译超时时间
+	MaxOutput      int           // 每次运行捕获的标准输出/标准错误最大字节数
+	MaxConcurrent  int           // 同时运行的程序数量上限
+}
+
+// Case 一次运行的输入和资源限制
+type Case struct {
+	Input         string
+	TimeLimit     time.Duration // CPU 时间限制，墙钟时间限制为其两倍
+	MemoryLimitMB int           // 数据段内存限制
+}
+
+// CaseResult 单次运行结果
+type CaseResult struct {
+	Status   Status
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Runtime  time.Duration
+}
+
+// Result 编译和运行结果
+type Result struct {
+	CompileError string       // 编译错误输出，不为空时 Cases 为空
+	Cases        []CaseResult // 与输入的 Case 一一对应
+}
+
+// Runner 本地沙箱运行器
+// 每次提交在独立的临时目录中编译运行，进程放入独立的用户、挂载和网络命名空间（无网络），
+// 只能看到只读的系统目录、工作目录和空的 /tmp，放弃全部能力后执行，
+// 并通过 rlimit 限制 CPU 时间、内存、文件大小和进程数，超时后终止整个进程组
+type Runner struct {
+	cfg  Config
+	slot chan struct{}
+}
+
+// NewRunner 创建沙箱运行器
+func NewRunner(cfg Config) *Runner {
+	if cfg.CompileTimeout <= 0 {
+		cfg.CompileTimeout = DefaultCompileTimeout
+	}
+	if cfg.MaxOutput <= 0 {
+		cfg.MaxOutput = DefaultMaxOutput
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = DefaultMaxConcurrent
+	}
+	return &Runner{cfg: cfg, slot: make(chan struct{}, cfg.MaxConcurrent)}
+}
+
+// Run 编译源代码并依次运行每个用例
+func (r *Runner) Run(ctx context.Context, language, source string, cases []Case) (*Result, error) {
+	lang, ok := Languages[language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, language)
+	}
+
+	select {
+	case r.slot <- struct{}{}:
+		defer func() { <-r.slot }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	dir, err := os.MkdirTemp(r.cfg.WorkDir, "sandbox-")
+	if err != nil {
+		return nil, fmt.Errorf("create sandbox dir failed: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 源代码和编译产物放在 work 子目录，沙箱中挂载为 /work
+	work := filepath.Join(dir, "work")
+	if err := os.Mkdir(work, 0o755); err != nil {
+		return nil, fmt.Errorf("create sandbox dir failed: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(work, lang.SourceFile), []byte(source), 0o644); err != nil {
+		return nil, fmt.Errorf("write source file failed: %w", err)
+	}
+	if err := prepareDir(dir); err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	if len(lang.Compile) > 0 {
+		compiled, err := r.exec(ctx, dir, lang.Compile, "", r.cfg.CompileTimeout, compileMemoryLimitMB)
+		if err != nil {
+			return nil, err
+		}
+		if compiled.Status != StatusOK {
+			result.CompileError = compiled.Stderr + compiled.Stdout
+			if compiled.Status == StatusTimeLimit {
+				result.CompileError = "compilation timed out"
+			}
+			return result, nil
+		}
+	}
+
+	for _, c := range cases {
+		timeLimit := c.TimeLimit
+		if timeLimit <= 0 {
+			timeLimit = DefaultTimeLimit
+		}
+		memoryLimit := c.MemoryLimitMB
+		if memoryLimit <= 0 {
+			memoryLimit = DefaultMemoryLimitMB
+		}
+
+		caseResult, err := r.exec(ctx, dir, lang.Run, c.Input, timeLimit, memoryLimit)
+		if err != nil {
+			return nil, err
+		}
+		result.Cases = append(result.Cases, *caseResult)
+	}
+	return result, nil
+}
+
+// limitedBuffer 超出上限后丢弃多余输出的缓冲区
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := b.limit - b.buf.Len(); remain < len(p) {
+		b.truncated = true
+		if remain > 0 {
+			b.buf.Write(p[:remain])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// Snippet 截取输出的前 n 个字节，用于保存错误信息
+func Snippet(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n
+	for cut > 0 && !isRuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "...(" + strconv.Itoa(len(s)-cut) + " bytes truncated)"
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
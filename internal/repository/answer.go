@@ -18,6 +18,10 @@ type AnswerRepository interface {
 	GetBySubmissionID(ctx context.Context, submissionID uint) ([]*model.Answer, error)
 	GetByQuestionID(ctx context.Context, questionID uint) ([]*model.Answer, error)
 	GetBySubmissionAndQuestion(ctx context.Context, submissionID, questionID uint) (*model.Answer, error)
+	// GetPendingAISuggestions 获取已提交、尚未批改且没有 AI 建议的主观题答案（预加载 Question），生成失败达到 maxFails 次的不再返回
+	GetPendingAISuggestions(ctx context.Context, maxFails, limit int) ([]*model.Answer, error)
+	// SaveAISuggestion 保存 AI 建议和失败次数，答案已被教师批改时不修改
+	SaveAISuggestion(ctx context.Context, answer *model.Answer) error
 	
 	// 批量操作
 	CreateBatch(ctx context.Context, answers []*model.Answer) error
@@ -41,7 +45,7 @@ func NewAnswerRepository(db DB, cache Cache) AnswerRepository {
 
 // Create 创建答案
 func (r *answerRepository) Create(ctx context.Context, answer *model.Answer) error {
-	if err := r.db.WithContext(ctx).Create(answer).Error; err != nil {
+	if err := r.db.WithContext(ctx).Create(answer); err != nil {
 		return fmt.Errorf("create answer failed: %w", err)
 	}
 	return nil
@@ -50,16 +54,18 @@ func (r *answerRepository) Create(ctx context.Context, answer *model.Answer) err
 // GetByID 根据ID获取答案
 func (r *answerRepository) GetByID(ctx context.Context, id uint) (*model.Answer, error) {
 	var answer model.Answer
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&answer).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&answer)
 	if err != nil {
 		return nil, fmt.Errorf("get answer by id failed: %w", err)
 	}
 	return &answer, nil
 }
 
-// Update 更新答案
+// Update 更新答案，同时递增版本号
 func (r *answerRepository) Update(ctx context.Context, answer *model.Answer) error {
-	if err := r.db.WithContext(ctx).Save(answer).Error; err != nil {
+	answer.Version++
+	if err := r.db.WithContext(ctx).Save(answer); err != nil {
+		answer.Version--
 		return fmt.Errorf("update answer failed: %w", err)
 	}
 	return nil
@@ -67,7 +73,7 @@ func (r *answerRepository) Update(ctx context.Context, answer *model.Answer) err
 
 // Delete 删除答案
 func (r *answerRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&model.Answer{}, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Delete(&model.Answer{}, id); err != nil {
 		return fmt.Errorf("delete answer failed: %w", err)
 	}
 	return nil
@@ -80,7 +86,7 @@ func (r *answerRepository) GetBySubmissionID(ctx context.Context, submissionID u
 		Preload("Question").
 		Where("submission_id = ?", submissionID).
 		Order("question_id ASC").
-		Find(&answers).Error
+		Find(&answers)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get answers by submission id failed: %w", err)
@@ -96,7 +102,7 @@ func (r *answerRepository) GetByQuestionID(ctx context.Context, questionID uint)
 		Preload("Submission").
 		Preload("Submission.Student").
 		Where("question_id = ?", questionID).
-		Find(&answers).Error
+		Find(&answers)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get answers by question id failed: %w", err)
@@ -110,7 +116,7 @@ func (r *answerRepository) GetBySubmissionAndQuestion(ctx context.Context, submi
 	var answer model.Answer
 	err := r.db.WithContext(ctx).
 		Where("submission_id = ? AND question_id = ?", submissionID, questionID).
-		First(&answer).Error
+		First(&answer)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get answer by submission and question failed: %w", err)
@@ -119,6 +125,44 @@ func (r *answerRepository) GetBySubmissionAndQuestion(ctx context.Context, submi
 	return &answer, nil
 }
 
+// GetPendingAISuggestions 获取待生成 AI 建议的主观题答案，按答案创建顺序处理
+// 只处理已提交待批改的作答中尚未批改（graded_at 为空）且失败次数未达上限的答案
+func (r *answerRepository) GetPendingAISuggestions(ctx context.Context, maxFails, limit int) ([]*model.Answer, error) {
+	var answers []*model.Answer
+	err := r.db.WithContext(ctx).
+		Preload("Question").
+		Where("ai_suggested_at IS NULL AND graded_at IS NULL AND ai_suggest_fails < ?", maxFails).
+		Where("question_id IN (SELECT id FROM questions WHERE type = ? AND deleted_at IS NULL)", model.QuestionTypeEssay).
+		Where("submission_id IN (SELECT id FROM submissions WHERE status = ? AND deleted_at IS NULL)", model.SubmissionStatusSubmitted).
+		Order("id ASC").
+		Limit(limit).
+		Find(&answers)
+	if err != nil {
+		return nil, fmt.Errorf("get pending ai suggestions failed: %w", err)
+	}
+	return answers, nil
+}
+
+// SaveAISuggestion 只更新 AI 建议相关字段，生成期间教师已批改该答案或整份提交时不写入
+func (r *answerRepository) SaveAISuggestion(ctx context.Context, answer *model.Answer) error {
+	_, err := r.db.WithContext(ctx).
+		Model(&model.Answer{}).
+		Where("id = ? AND graded_at IS NULL", answer.ID).
+		Where("submission_id IN (SELECT id FROM submissions WHERE status = ? AND deleted_at IS NULL)", model.SubmissionStatusSubmitted).
+		Updates(map[string]interface{}{
+			"ai_score":         answer.AIScore,
+			"ai_feedback":      answer.AIFeedback,
+			"ai_confidence":    answer.AIConfidence,
+			"ai_model":         answer.AIModel,
+			"ai_suggested_at":  answer.AISuggestedAt,
+			"ai_suggest_fails": answer.AISuggestFails,
+		})
+	if err != nil {
+		return fmt.Errorf("save ai suggestion failed: %w", err)
+	}
+	return nil
+}
+
 // CreateBatch 批量创建答案
 func (r *answerRepository) CreateBatch(ctx context.Context, answers []*model.Answer) error {
 	if len(answers) == 0 {
@@ -128,7 +172,7 @@ func (r *answerRepository) CreateBatch(ctx context.Context, answers []*model.Ans
 	// 使用事务批量创建
 	return r.db.WithContext(ctx).Transaction(func(tx DB) error {
 		for _, answer := range answers {
-			if err := tx.Create(answer).Error; err != nil {
+			if err := tx.Create(answer); err != nil {
 				return fmt.Errorf("create answer batch failed: %w", err)
 			}
 		}
@@ -145,7 +189,8 @@ func (r *answerRepository) UpdateBatch(ctx context.Context, answers []*model.Ans
 	// 使用事务批量更新
 	return r.db.WithContext(ctx).Transaction(func(tx DB) error {
 		for _, answer := range answers {
-			if err := tx.Save(answer).Error; err != nil {
+			answer.Version++
+			if err := tx.Save(answer); err != nil {
 				return fmt.Errorf("update answer batch failed: %w", err)
 			}
 		}
@@ -157,7 +202,7 @@ func (r *answerRepository) UpdateBatch(ctx context.Context, answers []*model.Ans
 func (r *answerRepository) DeleteBySubmissionID(ctx context.Context, submissionID uint) error {
 	err := r.db.WithContext(ctx).
 		Where("submission_id = ?", submissionID).
-		Delete(&model.Answer{}).Error
+		Delete(&model.Answer{})
 	
 	if err != nil {
 		return fmt.Errorf("delete answers by submission id failed: %w", err)
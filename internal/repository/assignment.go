@@ -4,6 +4,8 @@ import (
 	"ai-course/internal/model"
 	"context"
 	"fmt"
+	"math"
+	"time"
 )
 
 // AssignmentRepository 作业仓储接口
@@ -25,6 +27,16 @@ type AssignmentRepository interface {
 	
 	// 统计操作
 	GetSubmissionStats(ctx context.Context, assignmentID uint) (*model.AssignmentStatistics, error)
+	
+	// 定时发布和关闭
+	// GetDueForPublish 获取到达定时发布时间的草稿作业
+	GetDueForPublish(ctx context.Context, now time.Time, limit int) ([]*model.Assignment, error)
+	// GetDueForClose 获取到达自动关闭时间的已发布作业
+	GetDueForClose(ctx context.Context, now time.Time, limit int) ([]*model.Assignment, error)
+	// PublishScheduled 按定时发布作业，作业已被发布或取消定时时返回 false
+	PublishScheduled(ctx context.Context, assignment *model.Assignment, now time.Time) (bool, error)
+	// CloseScheduled 按时关闭作业，作业已不是发布状态或取消了自动关闭时返回 false
+	CloseScheduled(ctx context.Context, assignment *model.Assignment, now time.Time) (bool, error)
 }
 
 // assignmentRepository 作业仓储实现
@@ -43,7 +55,7 @@ func NewAssignmentRepository(db DB, cache Cache) AssignmentRepository {
 
 // Create 创建作业
 func (r *assignmentRepository) Create(ctx context.Context, assignment *model.Assignment) error {
-	if err := r.db.WithContext(ctx).Create(assignment).Error; err != nil {
+	if err := r.db.WithContext(ctx).Create(assignment); err != nil {
 		return fmt.Errorf("create assignment failed: %w", err)
 	}
 	return nil
@@ -52,7 +64,7 @@ func (r *assignmentRepository) Create(ctx context.Context, assignment *model.Ass
 // GetByID 根据ID获取作业
 func (r *assignmentRepository) GetByID(ctx context.Context, id uint) (*model.Assignment, error) {
 	var assignment model.Assignment
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&assignment).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&assignment)
 	if err != nil {
 		return nil, fmt.Errorf("get assignment by id failed: %w", err)
 	}
@@ -61,7 +73,7 @@ func (r *assignmentRepository) GetByID(ctx context.Context, id uint) (*model.Ass
 
 // Update 更新作业
 func (r *assignmentRepository) Update(ctx context.Context, assignment *model.Assignment) error {
-	if err := r.db.WithContext(ctx).Save(assignment).Error; err != nil {
+	if err := r.db.WithContext(ctx).Save(assignment); err != nil {
 		return fmt.Errorf("update assignment failed: %w", err)
 	}
 	return nil
@@ -69,7 +81,7 @@ func (r *assignmentRepository) Update(ctx context.Context, assignment *model.Ass
 
 // Delete 删除作业
 func (r *assignmentRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&model.Assignment{}, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Delete(&model.Assignment{}, id); err != nil {
 		return fmt.Errorf("delete assignment failed: %w", err)
 	}
 	return nil
@@ -77,11 +89,15 @@ func (r *assignmentRepository) Delete(ctx context.Context, id uint) error {
 
 // GetByTeacherID 根据教师ID获取作业列表
 func (r *assignmentRepository) GetByTeacherID(ctx context.Context, teacherID uint, offset, limit int) ([]*model.Assignment, int64, error) {
-	db := r.db.WithContext(ctx).Where("teacher_id = ?", teacherID)
+	// 包含教师创建的作业以及其协同授课班级中的作业
+	db := r.db.WithContext(ctx).Where(
+		"teacher_id = ? OR class_id IN (SELECT class_id FROM class_teachers WHERE teacher_id = ? AND deleted_at IS NULL)",
+		teacherID, teacherID,
+	)
 	
 	// 获取总数
 	var total int64
-	if err := db.Model(&model.Assignment{}).Count(&total).Error; err != nil {
+	if err := db.Model(&model.Assignment{}).Count(&total); err != nil {
 		return nil, 0, fmt.Errorf("count assignments failed: %w", err)
 	}
 	
@@ -91,7 +107,7 @@ func (r *assignmentRepository) GetByTeacherID(ctx context.Context, teacherID uin
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(limit).
-		Find(&assignments).Error
+		Find(&assignments)
 	
 	if err != nil {
 		return nil, 0, fmt.Errorf("get assignments by teacher id failed: %w", err)
@@ -106,7 +122,7 @@ func (r *assignmentRepository) GetByClassID(ctx context.Context, classID uint, o
 	
 	// 获取总数
 	var total int64
-	if err := db.Model(&model.Assignment{}).Count(&total).Error; err != nil {
+	if err := db.Model(&model.Assignment{}).Count(&total); err != nil {
 		return nil, 0, fmt.Errorf("count assignments failed: %w", err)
 	}
 	
@@ -116,7 +132,7 @@ func (r *assignmentRepository) GetByClassID(ctx context.Context, classID uint, o
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(limit).
-		Find(&assignments).Error
+		Find(&assignments)
 	
 	if err != nil {
 		return nil, 0, fmt.Errorf("get assignments by class id failed: %w", err)
@@ -127,19 +143,30 @@ func (r *assignmentRepository) GetByClassID(ctx context.Context, classID uint, o
 
 // GetByStudentID 根据学生ID获取作业列表（通过班级关联）
 func (r *assignmentRepository) GetByStudentID(ctx context.Context, studentID uint, offset, limit int) ([]*model.Assignment, int64, error) {
-	// 首先获取学生所在的班级
-	var user model.User
-	if err := r.db.WithContext(ctx).Where("id = ?", studentID).First(&user).Error; err != nil {
-		return nil, 0, fmt.Errorf("get student failed: %w", err)
-	}
+	// 学生所在班级的已发布作业
+	query := "status <> ? AND class_id IN (SELECT class_id FROM class_enrollments WHERE student_id = ? AND status = ? AND deleted_at IS NULL)"
+	args := []interface{}{"draft", studentID, model.EnrollmentStatusActive}
 	
-	// TODO: 这里需要根据实际的学生-班级关联关系来实现
-	// 目前假设通过 User 表的某个字段关联班级，需要根据实际模型调整
+	// 获取总数
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&model.Assignment{}).Where(query, args...).Count(&total); err != nil {
+		return nil, 0, fmt.Errorf("count student assignments failed: %w", err)
+	}
 	
+	// 获取列表
 	var assignments []*model.Assignment
-	var total int64
+	err := r.db.WithContext(ctx).
+		Preload("Class").
+		Where(query, args...).
+		Order("deadline ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&assignments)
+	
+	if err != nil {
+		return nil, 0, fmt.Errorf("get assignments by student id failed: %w", err)
+	}
 	
-	// 暂时返回空结果，实际实现需要根据学生-班级关联关系查询
 	return assignments, total, nil
 }
 
@@ -149,7 +176,7 @@ func (r *assignmentRepository) GetPublishedByClassID(ctx context.Context, classI
 	err := r.db.WithContext(ctx).
 		Where("class_id = ? AND status = ?", classID, "published").
 		Order("created_at DESC").
-		Find(&assignments).Error
+		Find(&assignments)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get published assignments failed: %w", err)
@@ -165,11 +192,11 @@ func (r *assignmentRepository) GetDetailByID(ctx context.Context, id uint) (*mod
 		Preload("Class").
 		Preload("Teacher").
 		Preload("Questions", func(db DB) DB {
-			return db.Where("").Order("\"order\" ASC") // 按题目顺序排序
+			return db.Order("`order` ASC") // 按题目顺序排序
 		}).
 		Preload("Attachments").
 		Where("id = ?", id).
-		First(&assignment).Error
+		First(&assignment)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get assignment detail failed: %w", err)
@@ -182,63 +209,135 @@ func (r *assignmentRepository) GetDetailByID(ctx context.Context, id uint) (*mod
 func (r *assignmentRepository) GetSubmissionStats(ctx context.Context, assignmentID uint) (*model.AssignmentStatistics, error) {
 	stats := &model.AssignmentStatistics{}
 	
-	// 获取该作业所属班级的学生总数
-	// TODO: 需要根据实际的学生-班级关联关系来实现
-	// 这里暂时写一个示例实现
+	// 获取该作业所属班级的在读学生总数
+	var assignment model.Assignment
+	if err := r.db.WithContext(ctx).Select("id", "class_id", "attempt_scoring").Where("id = ?", assignmentID).First(&assignment); err != nil {
+		return nil, fmt.Errorf("get assignment failed: %w", err)
+	}
+	
+	var totalStudents int64
+	if err := r.db.WithContext(ctx).
+		Model(&model.ClassEnrollment{}).
+		Where("class_id = ? AND status = ?", assignment.ClassID, model.EnrollmentStatusActive).
+		Count(&totalStudents); err != nil {
+		return nil, fmt.Errorf("count class students failed: %w", err)
+	}
 	
-	// 获取已提交的学生数
+	// 获取已提交的学生数（多次作答的学生只计一次）
 	var submittedCount int64
 	if err := r.db.WithContext(ctx).
 		Model(&model.Submission{}).
 		Where("assignment_id = ? AND status IN ?", assignmentID, []string{"submitted", "graded"}).
-		Count(&submittedCount).Error; err != nil {
+		Select("COUNT(DISTINCT student_id)").
+		Scan(&submittedCount); err != nil {
 		return nil, fmt.Errorf("count submitted assignments failed: %w", err)
 	}
 	
-	// 获取已批改的学生数
-	var gradedCount int64
+	// 获取已批改的作答，按学生汇总后按计分方式计算每个学生的有效得分
+	var graded []*model.Submission
 	if err := r.db.WithContext(ctx).
-		Model(&model.Submission{}).
+		Select("student_id", "attempt", "score").
 		Where("assignment_id = ? AND status = ?", assignmentID, "graded").
-		Count(&gradedCount).Error; err != nil {
-		return nil, fmt.Errorf("count graded assignments failed: %w", err)
+		Find(&graded); err != nil {
+		return nil, fmt.Errorf("get graded submissions failed: %w", err)
 	}
 	
-	// 计算平均分
-	var avgScore float64
-	if err := r.db.WithContext(ctx).
-		Model(&model.Submission{}).
-		Where("assignment_id = ? AND status = ?", assignmentID, "graded").
-		Select("AVG(score)").
-		Scan(&avgScore).Error; err != nil {
-		return nil, fmt.Errorf("calculate average score failed: %w", err)
+	attempts := make(map[uint][]*model.Submission)
+	for _, submission := range graded {
+		attempts[submission.StudentID] = append(attempts[submission.StudentID], submission)
 	}
 	
-	// 获取最高分和最低分
-	var maxScore, minScore int
-	r.db.WithContext(ctx).
-		Model(&model.Submission{}).
-		Where("assignment_id = ? AND status = ?", assignmentID, "graded").
-		Select("MAX(score)").
-		Scan(&maxScore)
-	
-	r.db.WithContext(ctx).
-		Model(&model.Submission{}).
-		Where("assignment_id = ? AND status = ?", assignmentID, "graded").
-		Select("MIN(score)").
-		Scan(&minScore)
+	var totalScore float64
+	scoring := assignment.GetAttemptScoring()
+	for _, studentAttempts := range attempts {
+		score, _ := model.EffectiveScore(scoring, studentAttempts)
+		rounded := int(math.Round(score))
+		if stats.GradedCount == 0 || rounded > stats.MaxScore {
+			stats.MaxScore = rounded
+		}
+		if stats.GradedCount == 0 || rounded < stats.MinScore {
+			stats.MinScore = rounded
+		}
+		totalScore += score
+		stats.GradedCount++
+	}
+	if stats.GradedCount > 0 {
+		stats.AverageScore = totalScore / float64(stats.GradedCount)
+	}
 	
 	stats.SubmittedCount = int(submittedCount)
-	stats.GradedCount = int(gradedCount)
-	stats.AverageScore = avgScore
-	stats.MaxScore = maxScore
-	stats.MinScore = minScore
 	
-	// TODO: 计算总学生数和提交率
-	stats.TotalStudents = 30 // 暂时硬编码，需要根据实际业务逻辑实现
+	// 计算提交率
+	stats.TotalStudents = int(totalStudents)
 	if stats.TotalStudents > 0 {
 		stats.SubmissionRate = float64(stats.SubmittedCount) / float64(stats.TotalStudents) * 100
 	}
 	
 	return stats, nil
-}
\ No newline at end of file
+}
+
+// GetDueForPublish 获取到达定时发布时间的草稿作业
+func (r *assignmentRepository) GetDueForPublish(ctx context.Context, now time.Time, limit int) ([]*model.Assignment, error) {
+	var assignments []*model.Assignment
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND publish_at IS NOT NULL AND publish_at <= ?", "draft", now).
+		Order("publish_at ASC").
+		Limit(limit).
+		Find(&assignments)
+	if err != nil {
+		return nil, fmt.Errorf("get assignments due for publish failed: %w", err)
+	}
+	return assignments, nil
+}
+
+// GetDueForClose 获取到达自动关闭时间的已发布作业
+func (r *assignmentRepository) GetDueForClose(ctx context.Context, now time.Time, limit int) ([]*model.Assignment, error) {
+	var assignments []*model.Assignment
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND close_at IS NOT NULL AND close_at <= ?", "published", now).
+		Order("close_at ASC").
+		Limit(limit).
+		Find(&assignments)
+	if err != nil {
+		return nil, fmt.Errorf("get assignments due for close failed: %w", err)
+	}
+	return assignments, nil
+}
+
+// PublishScheduled 按定时发布作业，条件更新保证多个实例或教师手动操作时只发布一次
+func (r *assignmentRepository) PublishScheduled(ctx context.Context, assignment *model.Assignment, now time.Time) (bool, error) {
+	rows, err := r.db.WithContext(ctx).
+		Model(&model.Assignment{}).
+		Where("id = ? AND status = ? AND publish_at IS NOT NULL AND publish_at <= ?", assignment.ID, "draft", now).
+		Updates(map[string]interface{}{
+			"status":       "published",
+			"published_at": now,
+			"publish_at":   nil,
+		})
+	if err != nil {
+		return false, fmt.Errorf("publish scheduled assignment failed: %w", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+	assignment.Status = "published"
+	assignment.PublishedAt = &now
+	assignment.PublishAt = nil
+	return true, nil
+}
+
+// CloseScheduled 按时关闭作业，条件更新保证多个实例或教师手动操作时只关闭一次
+func (r *assignmentRepository) CloseScheduled(ctx context.Context, assignment *model.Assignment, now time.Time) (bool, error) {
+	rows, err := r.db.WithContext(ctx).
+		Model(&model.Assignment{}).
+		Where("id = ? AND status = ? AND close_at IS NOT NULL AND close_at <= ?", assignment.ID, "published", now).
+		Updates(map[string]interface{}{"status": "closed"})
+	if err != nil {
+		return false, fmt.Errorf("close scheduled assignment failed: %w", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+	assignment.Status = "closed"
+	return true, nil
+}
@@ -17,6 +17,8 @@ type AttachmentRepository interface {
 	// 查询操作
 	GetByAssignmentID(ctx context.Context, assignmentID uint) ([]*model.Attachment, error)
 	GetByUploaderID(ctx context.Context, uploaderID uint) ([]*model.Attachment, error)
+	// CountByFilePath 统计引用同一磁盘文件的作业附件和模板附件数量
+	CountByFilePath(ctx context.Context, filePath string) (int64, error)
 }
 
 // attachmentRepository 附件仓储实现
@@ -35,7 +37,7 @@ func NewAttachmentRepository(db DB, cache Cache) AttachmentRepository {
 
 // Create 创建附件
 func (r *attachmentRepository) Create(ctx context.Context, attachment *model.Attachment) error {
-	if err := r.db.WithContext(ctx).Create(attachment).Error; err != nil {
+	if err := r.db.WithContext(ctx).Create(attachment); err != nil {
 		return fmt.Errorf("create attachment failed: %w", err)
 	}
 	return nil
@@ -48,7 +50,7 @@ func (r *attachmentRepository) GetByID(ctx context.Context, id uint) (*model.Att
 		Preload("Assignment").
 		Preload("Uploader").
 		Where("id = ?", id).
-		First(&attachment).Error
+		First(&attachment)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get attachment by id failed: %w", err)
@@ -59,7 +61,7 @@ func (r *attachmentRepository) GetByID(ctx context.Context, id uint) (*model.Att
 
 // Update 更新附件
 func (r *attachmentRepository) Update(ctx context.Context, attachment *model.Attachment) error {
-	if err := r.db.WithContext(ctx).Save(attachment).Error; err != nil {
+	if err := r.db.WithContext(ctx).Save(attachment); err != nil {
 		return fmt.Errorf("update attachment failed: %w", err)
 	}
 	return nil
@@ -67,7 +69,7 @@ func (r *attachmentRepository) Update(ctx context.Context, attachment *model.Att
 
 // Delete 删除附件
 func (r *attachmentRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&model.Attachment{}, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Delete(&model.Attachment{}, id); err != nil {
 		return fmt.Errorf("delete attachment failed: %w", err)
 	}
 	return nil
@@ -80,7 +82,7 @@ func (r *attachmentRepository) GetByAssignmentID(ctx context.Context, assignment
 		Preload("Uploader").
 		Where("assignment_id = ?", assignmentID).
 		Order("created_at DESC").
-		Find(&attachments).Error
+		Find(&attachments)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get attachments by assignment id failed: %w", err)
@@ -96,11 +98,23 @@ func (r *attachmentRepository) GetByUploaderID(ctx context.Context, uploaderID u
 		Preload("Assignment").
 		Where("uploader_id = ?", uploaderID).
 		Order("created_at DESC").
-		Find(&attachments).Error
+		Find(&attachments)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get attachments by uploader id failed: %w", err)
 	}
 	
 	return attachments, nil
-}
\ No newline at end of file
+}
+
+// CountByFilePath 统计引用同一磁盘文件的作业附件和模板附件数量
+func (r *attachmentRepository) CountByFilePath(ctx context.Context, filePath string) (int64, error) {
+	var attachments, templateAttachments int64
+	if err := r.db.WithContext(ctx).Model(&model.Attachment{}).Where("file_path = ?", filePath).Count(&attachments); err != nil {
+		return 0, fmt.Errorf("count attachments by file path failed: %w", err)
+	}
+	if err := r.db.WithContext(ctx).Model(&model.TemplateAttachment{}).Where("file_path = ?", filePath).Count(&templateAttachments); err != nil {
+		return 0, fmt.Errorf("count template attachments by file path failed: %w", err)
+	}
+	return attachments + templateAttachments, nil
+}
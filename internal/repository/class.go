@@ -21,6 +21,14 @@ type ClassRepository interface {
 	FindByCode(ctx context.Context, code string) (*model.Class, error)
 	// List 获取班级列表
 	List(ctx context.Context, offset, limit int) (int64, []*model.Class, error)
+	// IsTeacher 检查用户是否为班级的任课教师（创建者或协同授课教师）
+	IsTeacher(ctx context.Context, classID, teacherID uint) (bool, error)
+	// AddCoTeacher 添加协同授课教师
+	AddCoTeacher(ctx context.Context, classID, teacherID uint) error
+	// RemoveCoTeacher 移除协同授课教师
+	RemoveCoTeacher(ctx context.Context, classID, teacherID uint) error
+	// GetCoTeachers 获取班级的协同授课教师列表
+	GetCoTeachers(ctx context.Context, classID uint) ([]*model.ClassTeacher, error)
 	// GetDB 获取数据库实例
 	GetDB() DB
 }
@@ -148,6 +156,58 @@ func (r *classRepository) List(ctx context.Context, offset, limit int) (int64, [
 	return total, classes, nil
 }
 
+// IsTeacher 检查用户是否为班级的任课教师（创建者或协同授课教师）
+func (r *classRepository) IsTeacher(ctx context.Context, classID, teacherID uint) (bool, error) {
+	class, err := r.FindByID(ctx, classID)
+	if err != nil {
+		return false, err
+	}
+	if class.TeacherID == teacherID {
+		return true, nil
+	}
+
+	var count int64
+	err = r.db.WithContext(ctx).
+		Model(&model.ClassTeacher{}).
+		Where("class_id = ? AND teacher_id = ?", classID, teacherID).
+		Count(&count)
+	if err != nil {
+		return false, fmt.Errorf("check class teacher failed: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// AddCoTeacher 添加协同授课教师
+func (r *classRepository) AddCoTeacher(ctx context.Context, classID, teacherID uint) error {
+	return r.db.WithContext(ctx).Create(&model.ClassTeacher{
+		ClassID:   classID,
+		TeacherID: teacherID,
+	})
+}
+
+// RemoveCoTeacher 移除协同授课教师
+func (r *classRepository) RemoveCoTeacher(ctx context.Context, classID, teacherID uint) error {
+	// 物理删除，避免软删除记录占用唯一索引导致无法再次添加
+	return r.db.WithContext(ctx).Exec(
+		"DELETE FROM class_teachers WHERE class_id = ? AND teacher_id = ?", classID, teacherID,
+	)
+}
+
+// GetCoTeachers 获取班级的协同授课教师列表
+func (r *classRepository) GetCoTeachers(ctx context.Context, classID uint) ([]*model.ClassTeacher, error) {
+	var teachers []*model.ClassTeacher
+	err := r.db.WithContext(ctx).
+		Preload("Teacher").
+		Where("class_id = ?", classID).
+		Order("created_at ASC").
+		Find(&teachers)
+	if err != nil {
+		return nil, fmt.Errorf("get co-teachers failed: %w", err)
+	}
+	return teachers, nil
+}
+
 // GetDB 获取数据库实例
 func (r *classRepository) GetDB() DB {
 	return r.db
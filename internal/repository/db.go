@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
 )
 
+// ErrVersionConflict 带版本号的条件更新未命中任何记录，说明记录已被其他请求修改
+var ErrVersionConflict = errors.New("record version conflict")
+
 // DB 数据库接口
 type DB interface {
 	WithContext(ctx context.Context) DB
@@ -16,6 +20,7 @@ type DB interface {
 	Model(value interface{}) DB
 	Table(name string) DB
 	Exec(sql string, values ...interface{}) error
+	Updates(values interface{}) (int64, error)
 	Raw(sql string, values ...interface{}) DB
 	Transaction(fc func(tx DB) error) error
 	AutoMigrate(dst ...interface{}) error
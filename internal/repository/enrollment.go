@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"ai-course/internal/model"
+	"context"
+	"fmt"
+)
+
+// EnrollmentRepository 班级成员仓储接口
+type EnrollmentRepository interface {
+	// 基础CRUD操作
+	Create(ctx context.Context, enrollment *model.ClassEnrollment) error
+	Update(ctx context.Context, enrollment *model.ClassEnrollment) error
+
+	// 查询操作
+	GetByClassAndStudent(ctx context.Context, classID, studentID uint) (*model.ClassEnrollment, error)
+	GetByClassID(ctx context.Context, classID uint, status model.EnrollmentStatus, offset, limit int) ([]*model.ClassEnrollment, int64, error)
+	GetActiveClassIDsByStudentID(ctx context.Context, studentID uint) ([]uint, error)
+	IsActiveMember(ctx context.Context, classID, studentID uint) (bool, error)
+
+	// 统计操作
+	CountActiveByClassID(ctx context.Context, classID uint) (int64, error)
+}
+
+// enrollmentRepository 班级成员仓储实现
+type enrollmentRepository struct {
+	db    DB
+	cache Cache
+}
+
+// NewEnrollmentRepository 创建班级成员仓储实例
+func NewEnrollmentRepository(db DB, cache Cache) EnrollmentRepository {
+	return &enrollmentRepository{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// Create 创建成员记录
+func (r *enrollmentRepository) Create(ctx context.Context, enrollment *model.ClassEnrollment) error {
+	if err := r.db.WithContext(ctx).Create(enrollment); err != nil {
+		return fmt.Errorf("create enrollment failed: %w", err)
+	}
+	return nil
+}
+
+// Update 更新成员记录
+func (r *enrollmentRepository) Update(ctx context.Context, enrollment *model.ClassEnrollment) error {
+	if err := r.db.WithContext(ctx).Save(enrollment); err != nil {
+		return fmt.Errorf("update enrollment failed: %w", err)
+	}
+	return nil
+}
+
+// GetByClassAndStudent 根据班级ID和学生ID获取成员记录（不区分状态）
+func (r *enrollmentRepository) GetByClassAndStudent(ctx context.Context, classID, studentID uint) (*model.ClassEnrollment, error) {
+	var enrollment model.ClassEnrollment
+	err := r.db.WithContext(ctx).
+		Where("class_id = ? AND student_id = ?", classID, studentID).
+		First(&enrollment)
+
+	if err != nil {
+		return nil, fmt.Errorf("get enrollment by class and student failed: %w", err)
+	}
+
+	return &enrollment, nil
+}
+
+// GetByClassID 分页获取班级成员列表，status 为空时返回全部状态
+func (r *enrollmentRepository) GetByClassID(ctx context.Context, classID uint, status model.EnrollmentStatus, offset, limit int) ([]*model.ClassEnrollment, int64, error) {
+	query := "class_id = ?"
+	args := []interface{}{classID}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+
+	// 获取总数
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&model.ClassEnrollment{}).Where(query, args...).Count(&total); err != nil {
+		return nil, 0, fmt.Errorf("count enrollments failed: %w", err)
+	}
+
+	// 获取列表
+	var enrollments []*model.ClassEnrollment
+	err := r.db.WithContext(ctx).
+		Preload("Student").
+		Where(query, args...).
+		Order("joined_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&enrollments)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("get enrollments by class id failed: %w", err)
+	}
+
+	return enrollments, total, nil
+}
+
+// GetActiveClassIDsByStudentID 获取学生当前所在的班级ID列表
+func (r *enrollmentRepository) GetActiveClassIDsByStudentID(ctx context.Context, studentID uint) ([]uint, error) {
+	var classIDs []uint
+	err := r.db.WithContext(ctx).
+		Model(&model.ClassEnrollment{}).
+		Where("student_id = ? AND status = ?", studentID, model.EnrollmentStatusActive).
+		Select("class_id").
+		Scan(&classIDs)
+
+	if err != nil {
+		return nil, fmt.Errorf("get class ids by student id failed: %w", err)
+	}
+
+	return classIDs, nil
+}
+
+// IsActiveMember 检查学生是否为班级的在读成员
+func (r *enrollmentRepository) IsActiveMember(ctx context.Context, classID, studentID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.ClassEnrollment{}).
+		Where("class_id = ? AND student_id = ? AND status = ?", classID, studentID, model.EnrollmentStatusActive).
+		Count(&count)
+
+	if err != nil {
+		return false, fmt.Errorf("check enrollment failed: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// CountActiveByClassID 统计班级在读学生数
+func (r *enrollmentRepository) CountActiveByClassID(ctx context.Context, classID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.ClassEnrollment{}).
+		Where("class_id = ? AND status = ?", classID, model.EnrollmentStatusActive).
+		Count(&count)
+
+	if err != nil {
+		return 0, fmt.Errorf("count enrollments by class id failed: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"ai-course/internal/model"
+	"context"
+	"fmt"
+)
+
+// ExtensionRepository 作业延期仓储接口
+type ExtensionRepository interface {
+	// Upsert 为学生设置延期，已有延期时替换
+	Upsert(ctx context.Context, extension *model.DeadlineExtension) error
+	GetByAssignmentAndStudent(ctx context.Context, assignmentID, studentID uint) (*model.DeadlineExtension, error)
+	GetByAssignmentID(ctx context.Context, assignmentID uint) ([]*model.DeadlineExtension, error)
+	// Delete 取消学生的延期，返回是否存在延期记录
+	Delete(ctx context.Context, assignmentID, studentID uint) (bool, error)
+}
+
+// extensionRepository 作业延期仓储实现
+type extensionRepository struct {
+	db    DB
+	cache Cache
+}
+
+// NewExtensionRepository 创建作业延期仓储实例
+func NewExtensionRepository(db DB, cache Cache) ExtensionRepository {
+	return &extensionRepository{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// Upsert 为学生设置延期，同一作业和学生只保留一条记录（唯一索引包含软删除的行，因此物理删除旧记录）
+func (r *extensionRepository) Upsert(ctx context.Context, extension *model.DeadlineExtension) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		if err := tx.Exec("DELETE FROM deadline_extensions WHERE assignment_id = ? AND student_id = ?",
+			extension.AssignmentID, extension.StudentID); err != nil {
+			return err
+		}
+		return tx.Create(extension)
+	})
+	if err != nil {
+		return fmt.Errorf("save deadline extension failed: %w", err)
+	}
+	return nil
+}
+
+// GetByAssignmentAndStudent 获取学生在作业上的延期
+func (r *extensionRepository) GetByAssignmentAndStudent(ctx context.Context, assignmentID, studentID uint) (*model.DeadlineExtension, error) {
+	var extension model.DeadlineExtension
+	err := r.db.WithContext(ctx).
+		Where("assignment_id = ? AND student_id = ?", assignmentID, studentID).
+		First(&extension)
+	if err != nil {
+		return nil, fmt.Errorf("get deadline extension failed: %w", err)
+	}
+	return &extension, nil
+}
+
+// GetByAssignmentID 获取作业的全部延期（包含学生信息）
+func (r *extensionRepository) GetByAssignmentID(ctx context.Context, assignmentID uint) ([]*model.DeadlineExtension, error) {
+	var extensions []*model.DeadlineExtension
+	err := r.db.WithContext(ctx).
+		Preload("Student").
+		Where("assignment_id = ?", assignmentID).
+		Order("deadline ASC").
+		Find(&extensions)
+	if err != nil {
+		return nil, fmt.Errorf("get deadline extensions by assignment failed: %w", err)
+	}
+	return extensions, nil
+}
+
+// Delete 取消学生的延期，返回是否存在延期记录
+func (r *extensionRepository) Delete(ctx context.Context, assignmentID, studentID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.DeadlineExtension{}).
+		Where("assignment_id = ? AND student_id = ?", assignmentID, studentID).
+		Count(&count)
+	if err != nil {
+		return false, fmt.Errorf("count deadline extensions failed: %w", err)
+	}
+	if count == 0 {
+		return false, nil
+	}
+	if err := r.db.WithContext(ctx).Exec("DELETE FROM deadline_extensions WHERE assignment_id = ? AND student_id = ?",
+		assignmentID, studentID); err != nil {
+		return false, fmt.Errorf("delete deadline extension failed: %w", err)
+	}
+	return true, nil
+}
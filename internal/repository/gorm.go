@@ -66,6 +66,12 @@ func (db *GormDB) Exec(sql string, values ...interface{}) error {
 	return db.DB.Exec(sql, values...).Error
 }
 
+// Updates 实现 DB 接口，返回受影响的行数，用于带条件的乐观锁更新
+func (db *GormDB) Updates(values interface{}) (int64, error) {
+	result := db.DB.Updates(values)
+	return result.RowsAffected, result.Error
+}
+
 // Raw 实现 DB 接口
 func (db *GormDB) Raw(sql string, values ...interface{}) DB {
 	return &GormDB{DB: db.DB.Raw(sql, values...)}
@@ -100,7 +106,15 @@ func (db *GormDB) Limit(limit int) DB {
 }
 
 // Preload 实现 DB 接口
+// 支持以 func(DB) DB 形式传入预加载条件，会被转换为 GORM 原生的 scope 函数
 func (db *GormDB) Preload(query string, args ...interface{}) DB {
+	for i, arg := range args {
+		if scope, ok := arg.(func(DB) DB); ok {
+			args[i] = func(tx *gorm.DB) *gorm.DB {
+				return scope(&GormDB{DB: tx}).(*GormDB).DB
+			}
+		}
+	}
 	return &GormDB{DB: db.DB.Preload(query, args...)}
 }
 
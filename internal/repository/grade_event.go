@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"ai-course/internal/model"
+	"context"
+	"fmt"
+)
+
+// GradeEventRepository 成绩变更记录仓储接口
+// 记录只能随成绩更新在同一事务中写入（见 SubmissionRepository.SaveGrading 等），这里仅提供查询
+type GradeEventRepository interface {
+	GetBySubmissionID(ctx context.Context, submissionID uint) ([]*model.GradeEvent, error)
+}
+
+// gradeEventRepository 成绩变更记录仓储实现
+type gradeEventRepository struct {
+	db    DB
+	cache Cache
+}
+
+// NewGradeEventRepository 创建成绩变更记录仓储实例
+func NewGradeEventRepository(db DB, cache Cache) GradeEventRepository {
+	return &gradeEventRepository{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// GetBySubmissionID 按时间顺序获取提交的成绩变更记录
+func (r *gradeEventRepository) GetBySubmissionID(ctx context.Context, submissionID uint) ([]*model.GradeEvent, error) {
+	var events []*model.GradeEvent
+	err := r.db.WithContext(ctx).
+		Preload("Actor").
+		Where("submission_id = ?", submissionID).
+		Order("created_at ASC, id ASC").
+		Find(&events)
+	if err != nil {
+		return nil, fmt.Errorf("get grade events by submission id failed: %w", err)
+	}
+	return events, nil
+}
+
+// createGradeEvents 在事务中写入成绩变更记录
+func createGradeEvents(tx DB, events []*model.GradeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := tx.Create(events); err != nil {
+		return fmt.Errorf("create grade events failed: %w", err)
+	}
+	return nil
+}
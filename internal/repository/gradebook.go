@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"ai-course/internal/model"
+	"context"
+	"fmt"
+)
+
+// GradebookRepository 成绩册仓储接口
+type GradebookRepository interface {
+	// 成绩分类
+	GetCategories(ctx context.Context, classID uint) ([]*model.GradeCategory, error)
+	GetCategoryByID(ctx context.Context, id uint) (*model.GradeCategory, error)
+	// ReplaceCategories 整体替换班级的成绩分类，被删除分类中的作业变为未分类
+	ReplaceCategories(ctx context.Context, classID uint, categories []*model.GradeCategory) error
+	// SetAssignmentCategory 设置作业的成绩分类，categoryID 为 nil 时取消分类
+	SetAssignmentCategory(ctx context.Context, assignmentID uint, categoryID *uint) error
+
+	// 成绩册设置
+	GetSetting(ctx context.Context, classID uint) (*model.GradebookSetting, error)
+	SaveSetting(ctx context.Context, setting *model.GradebookSetting) error
+
+	// 免做和缺交标记
+	// UpsertMark 设置学生作业的标记，已有标记时替换
+	UpsertMark(ctx context.Context, mark *model.GradeMark) error
+	// DeleteMark 清除学生作业的标记，返回是否存在标记
+	DeleteMark(ctx context.Context, assignmentID, studentID uint) (bool, error)
+
+	// 成绩汇总数据，studentIDs 为空时返回全部学生
+	// GetClassAssignments 获取班级中已发布和已关闭的作业，按截止时间排序
+	GetClassAssignments(ctx context.Context, classID uint) ([]*model.Assignment, error)
+	// GetSubmittedAttempts 获取作业中已提交和已批改的作答（只包含计分和导出所需的字段，不含答案）
+	GetSubmittedAttempts(ctx context.Context, assignmentIDs []uint, studentIDs []uint) ([]*model.Submission, error)
+	GetMarks(ctx context.Context, assignmentIDs []uint, studentIDs []uint) ([]*model.GradeMark, error)
+	GetExtensions(ctx context.Context, assignmentIDs []uint, studentIDs []uint) ([]*model.DeadlineExtension, error)
+	// GetAnswerScores 获取作答中各题的得分（只包含题目ID、得分和批改时间）
+	GetAnswerScores(ctx context.Context, submissionIDs []uint) ([]*model.Answer, error)
+}
+
+// gradebookRepository 成绩册仓储实现
+type gradebookRepository struct {
+	db    DB
+	cache Cache
+}
+
+// NewGradebookRepository 创建成绩册仓储实例
+func NewGradebookRepository(db DB, cache Cache) GradebookRepository {
+	return &gradebookRepository{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// GetCategories 获取班级的成绩分类
+func (r *gradebookRepository) GetCategories(ctx context.Context, classID uint) ([]*model.GradeCategory, error) {
+	var categories []*model.GradeCategory
+	err := r.db.WithContext(ctx).
+		Where("class_id = ?", classID).
+		Order("`order` ASC, id ASC").
+		Find(&categories)
+	if err != nil {
+		return nil, fmt.Errorf("get grade categories failed: %w", err)
+	}
+	return categories, nil
+}
+
+// GetCategoryByID 根据ID获取成绩分类
+func (r *gradebookRepository) GetCategoryByID(ctx context.Context, id uint) (*model.GradeCategory, error) {
+	var category model.GradeCategory
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&category); err != nil {
+		return nil, fmt.Errorf("get grade category by id failed: %w", err)
+	}
+	return &category, nil
+}
+
+// ReplaceCategories 整体替换班级的成绩分类，已有分类需带原ID
+func (r *gradebookRepository) ReplaceCategories(ctx context.Context, classID uint, categories []*model.GradeCategory) error {
+	keep := make([]uint, 0, len(categories))
+	for _, category := range categories {
+		if category.ID != 0 {
+			keep = append(keep, category.ID)
+		}
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		if len(keep) > 0 {
+			if err := tx.Exec("UPDATE assignments SET category_id = NULL WHERE class_id = ? AND category_id NOT IN ?", classID, keep); err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM grade_categories WHERE class_id = ? AND id NOT IN ?", classID, keep); err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Exec("UPDATE assignments SET category_id = NULL WHERE class_id = ? AND category_id IS NOT NULL", classID); err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM grade_categories WHERE class_id = ?", classID); err != nil {
+				return err
+			}
+		}
+		for _, category := range categories {
+			if err := tx.Save(category); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("replace grade categories failed: %w", err)
+	}
+	return nil
+}
+
+// SetAssignmentCategory 设置作业的成绩分类
+func (r *gradebookRepository) SetAssignmentCategory(ctx context.Context, assignmentID uint, categoryID *uint) error {
+	_, err := r.db.WithContext(ctx).
+		Model(&model.Assignment{}).
+		Where("id = ?", assignmentID).
+		Updates(map[string]interface{}{"category_id": categoryID})
+	if err != nil {
+		return fmt.Errorf("set assignment category failed: %w", err)
+	}
+	return nil
+}
+
+// GetSetting 获取班级的成绩册设置
+func (r *gradebookRepository) GetSetting(ctx context.Context, classID uint) (*model.GradebookSetting, error) {
+	var setting model.GradebookSetting
+	if err := r.db.WithContext(ctx).Where("class_id = ?", classID).First(&setting); err != nil {
+		return nil, fmt.Errorf("get gradebook setting failed: %w", err)
+	}
+	return &setting, nil
+}
+
+// SaveSetting 保存班级的成绩册设置
+func (r *gradebookRepository) SaveSetting(ctx context.Context, setting *model.GradebookSetting) error {
+	if err := r.db.WithContext(ctx).Save(setting); err != nil {
+		return fmt.Errorf("save gradebook setting failed: %w", err)
+	}
+	return nil
+}
+
+// UpsertMark 设置学生作业的标记（唯一索引包含软删除的行，因此物理删除旧记录）
+func (r *gradebookRepository) UpsertMark(ctx context.Context, mark *model.GradeMark) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		if err := tx.Exec("DELETE FROM grade_marks WHERE assignment_id = ? AND student_id = ?",
+			mark.AssignmentID, mark.StudentID); err != nil {
+			return err
+		}
+		return tx.Create(mark)
+	})
+	if err != nil {
+		return fmt.Errorf("save grade mark failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteMark 清除学生作业的标记
+func (r *gradebookRepository) DeleteMark(ctx context.Context, assignmentID, studentID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.GradeMark{}).
+		Where("assignment_id = ? AND student_id = ?", assignmentID, studentID).
+		Count(&count)
+	if err != nil {
+		return false, fmt.Errorf("count grade marks failed: %w", err)
+	}
+	if count == 0 {
+		return false, nil
+	}
+	if err := r.db.WithContext(ctx).Exec("DELETE FROM grade_marks WHERE assignment_id = ? AND student_id = ?",
+		assignmentID, studentID); err != nil {
+		return false, fmt.Errorf("delete grade mark failed: %w", err)
+	}
+	return true, nil
+}
+
+// GetClassAssignments 获取班级中已发布和已关闭的作业
+func (r *gradebookRepository) GetClassAssignments(ctx context.Context, classID uint) ([]*model.Assignment, error) {
+	var assignments []*model.Assignment
+	err := r.db.WithContext(ctx).
+		Where("class_id = ? AND status IN ?", classID, []string{"published", "closed"}).
+		Order("deadline ASC, id ASC").
+		Find(&assignments)
+	if err != nil {
+		return nil, fmt.Errorf("get class assignments failed: %w", err)
+	}
+	return assignments, nil
+}
+
+// GetSubmittedAttempts 获取作业中已提交和已批改的作答
+func (r *gradebookRepository) GetSubmittedAttempts(ctx context.Context, assignmentIDs []uint, studentIDs []uint) ([]*model.Submission, error) {
+	var submissions []*model.Submission
+	if len(assignmentIDs) == 0 {
+		return submissions, nil
+	}
+	db := r.db.WithContext(ctx).
+		Select("id", "assignment_id", "student_id", "attempt", "status", "score", "submitted_at", "is_late", "late_penalty", "feedback").
+		Where("assignment_id IN ? AND status IN ?", assignmentIDs, []model.SubmissionStatus{model.SubmissionStatusSubmitted, model.SubmissionStatusGraded})
+	if len(studentIDs) > 0 {
+		db = db.Where("student_id IN ?", studentIDs)
+	}
+	if err := db.Find(&submissions); err != nil {
+		return nil, fmt.Errorf("get submitted attempts failed: %w", err)
+	}
+	return submissions, nil
+}
+
+// GetMarks 获取作业中学生的免做和缺交标记
+func (r *gradebookRepository) GetMarks(ctx context.Context, assignmentIDs []uint, studentIDs []uint) ([]*model.GradeMark, error) {
+	var marks []*model.GradeMark
+	if len(assignmentIDs) == 0 {
+		return marks, nil
+	}
+	db := r.db.WithContext(ctx).Where("assignment_id IN ?", assignmentIDs)
+	if len(studentIDs) > 0 {
+		db = db.Where("student_id IN ?", studentIDs)
+	}
+	if err := db.Find(&marks); err != nil {
+		return nil, fmt.Errorf("get grade marks failed: %w", err)
+	}
+	return marks, nil
+}
+
+// GetExtensions 获取作业中学生的延期
+func (r *gradebookRepository) GetExtensions(ctx context.Context, assignmentIDs []uint, studentIDs []uint) ([]*model.DeadlineExtension, error) {
+	var extensions []*model.DeadlineExtension
+	if len(assignmentIDs) == 0 {
+		return extensions, nil
+	}
+	db := r.db.WithContext(ctx).Where("assignment_id IN ?", assignmentIDs)
+	if len(studentIDs) > 0 {
+		db = db.Where("student_id IN ?", studentIDs)
+	}
+	if err := db.Find(&extensions); err != nil {
+		return nil, fmt.Errorf("get deadline extensions failed: %w", err)
+	}
+	return extensions, nil
+}
+
+// GetAnswerScores 获取作答中各题的得分
+func (r *gradebookRepository) GetAnswerScores(ctx context.Context, submissionIDs []uint) ([]*model.Answer, error) {
+	var answers []*model.Answer
+	if len(submissionIDs) == 0 {
+		return answers, nil
+	}
+	err := r.db.WithContext(ctx).
+		Select("submission_id", "question_id", "score", "graded_at").
+		Where("submission_id IN ?", submissionIDs).
+		Find(&answers)
+	if err != nil {
+		return nil, fmt.Errorf("get answer scores failed: %w", err)
+	}
+	return answers, nil
+}
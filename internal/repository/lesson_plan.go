@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"ai-course/internal/model"
+	"context"
+	"fmt"
+)
+
+// LessonPlanRepository 教案仓储接口
+type LessonPlanRepository interface {
+	// 基础CRUD操作
+	Create(ctx context.Context, plan *model.LessonPlan) error
+	GetByID(ctx context.Context, id uint) (*model.LessonPlan, error)
+	Update(ctx context.Context, plan *model.LessonPlan) error
+	Delete(ctx context.Context, id uint) error
+
+	// 查询操作
+	GetByTeacherID(ctx context.Context, teacherID uint, offset, limit int) ([]*model.LessonPlan, int64, error)
+	GetByClassID(ctx context.Context, classID uint, status model.LessonPlanStatus) ([]*model.LessonPlan, error)
+
+	// 教案详情（包含教学环节、关联作业和附件）
+	GetDetailByID(ctx context.Context, id uint) (*model.LessonPlan, error)
+}
+
+// lessonPlanRepository 教案仓储实现
+type lessonPlanRepository struct {
+	db    DB
+	cache Cache
+}
+
+// NewLessonPlanRepository 创建教案仓储实例
+func NewLessonPlanRepository(db DB, cache Cache) LessonPlanRepository {
+	return &lessonPlanRepository{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// Create 创建教案（同时写入教学环节及关联的作业和附件）
+func (r *lessonPlanRepository) Create(ctx context.Context, plan *model.LessonPlan) error {
+	if err := r.db.WithContext(ctx).Create(plan); err != nil {
+		return fmt.Errorf("create lesson plan failed: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取教案
+func (r *lessonPlanRepository) GetByID(ctx context.Context, id uint) (*model.LessonPlan, error) {
+	var plan model.LessonPlan
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&plan); err != nil {
+		return nil, fmt.Errorf("get lesson plan by id failed: %w", err)
+	}
+	return &plan, nil
+}
+
+// Update 更新教案
+// Steps、Assignments、Attachments 为 nil 时保持不变，否则整体替换为新的内容
+func (r *lessonPlanRepository) Update(ctx context.Context, plan *model.LessonPlan) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		// 物理删除旧记录，新记录随教案一起写入
+		if plan.Steps != nil {
+			if err := tx.Exec("DELETE FROM lesson_plan_steps WHERE lesson_plan_id = ?", plan.ID); err != nil {
+				return err
+			}
+		}
+		if plan.Assignments != nil {
+			if err := tx.Exec("DELETE FROM lesson_plan_assignments WHERE lesson_plan_id = ?", plan.ID); err != nil {
+				return err
+			}
+		}
+		if plan.Attachments != nil {
+			if err := tx.Exec("DELETE FROM lesson_plan_attachments WHERE lesson_plan_id = ?", plan.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Save(plan)
+	})
+	if err != nil {
+		return fmt.Errorf("update lesson plan failed: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除教案
+func (r *lessonPlanRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&model.LessonPlan{}, id); err != nil {
+		return fmt.Errorf("delete lesson plan failed: %w", err)
+	}
+	return nil
+}
+
+// GetByTeacherID 根据教师ID获取教案列表
+func (r *lessonPlanRepository) GetByTeacherID(ctx context.Context, teacherID uint, offset, limit int) ([]*model.LessonPlan, int64, error) {
+	// 包含教师创建的教案以及其任课班级中的教案
+	db := r.db.WithContext(ctx).Where(
+		"teacher_id = ? OR class_id IN (SELECT id FROM classes WHERE teacher_id = ? AND deleted_at IS NULL) OR class_id IN (SELECT class_id FROM class_teachers WHERE teacher_id = ? AND deleted_at IS NULL)",
+		teacherID, teacherID, teacherID,
+	)
+
+	// 获取总数
+	var total int64
+	if err := db.Model(&model.LessonPlan{}).Count(&total); err != nil {
+		return nil, 0, fmt.Errorf("count lesson plans failed: %w", err)
+	}
+
+	// 获取列表
+	var plans []*model.LessonPlan
+	err := db.Preload("Class").
+		Preload("Steps").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&plans)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("get lesson plans by teacher id failed: %w", err)
+	}
+
+	return plans, total, nil
+}
+
+// GetByClassID 获取班级的教案列表，status 为空时返回全部状态
+func (r *lessonPlanRepository) GetByClassID(ctx context.Context, classID uint, status model.LessonPlanStatus) ([]*model.LessonPlan, error) {
+	db := r.db.WithContext(ctx).Where("class_id = ?", classID)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var plans []*model.LessonPlan
+	err := db.Preload("Class").
+		Preload("Steps").
+		Order("created_at DESC").
+		Find(&plans)
+
+	if err != nil {
+		return nil, fmt.Errorf("get lesson plans by class id failed: %w", err)
+	}
+
+	return plans, nil
+}
+
+// GetDetailByID 获取教案详情（包含教学环节、关联作业和附件）
+func (r *lessonPlanRepository) GetDetailByID(ctx context.Context, id uint) (*model.LessonPlan, error) {
+	var plan model.LessonPlan
+	err := r.db.WithContext(ctx).
+		Preload("Class").
+		Preload("Teacher").
+		Preload("Steps", func(db DB) DB {
+			return db.Order("`order` ASC")
+		}).
+		Preload("Assignments", func(db DB) DB {
+			return db.Order("`order` ASC")
+		}).
+		Preload("Assignments.Assignment").
+		Preload("Attachments").
+		Preload("Attachments.Attachment").
+		Where("id = ?", id).
+		First(&plan)
+
+	if err != nil {
+		return nil, fmt.Errorf("get lesson plan detail failed: %w", err)
+	}
+
+	return &plan, nil
+}
@@ -3,7 +3,9 @@ package repository
 import (
 	"ai-course/internal/logger"
 	"ai-course/internal/model"
+	"ai-course/internal/utils"
 	"context"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -28,6 +30,22 @@ func AutoMigrate(db DB) error {
 		&model.Submission{},
 		&model.Answer{},
 		&model.Attachment{},
+		&model.ClassEnrollment{},
+		&model.ClassTeacher{},
+		&model.LessonPlan{},
+		&model.LessonPlanStep{},
+		&model.LessonPlanAssignment{},
+		&model.LessonPlanAttachment{},
+		&model.QuestionBankItem{},
+		&model.RegradeRequest{},
+		&model.GradeEvent{},
+		&model.DeadlineExtension{},
+		&model.SchedulerLock{},
+		&model.AssignmentTemplate{},
+		&model.TemplateAttachment{},
+		&model.GradeCategory{},
+		&model.GradebookSetting{},
+		&model.GradeMark{},
 	)
 
 	if err != nil {
@@ -35,6 +53,18 @@ func AutoMigrate(db DB) error {
 		return err
 	}
 
+	// 初始化内置角色
+	if err := seedRoles(db); err != nil {
+		logger.Logger.Error("Failed to seed roles", zap.Error(err))
+		return err
+	}
+
+	// 为历史班级补齐邀请码
+	if err := backfillClassCodes(db); err != nil {
+		logger.Logger.Error("Failed to backfill class codes", zap.Error(err))
+		return err
+	}
+
 	logger.Logger.Info("Database migration completed successfully")
 	return nil
 }
@@ -51,7 +81,7 @@ func cleanupDuplicateData(db DB) error {
 		WHERE table_schema = DATABASE() 
 		AND table_name = 'users'
 	`).Scan(&tableExists)
-	
+
 	if err != nil {
 		logger.Logger.Error("Failed to check if users table exists", zap.Error(err))
 		return err
@@ -69,7 +99,7 @@ func cleanupDuplicateData(db DB) error {
 		FROM users 
 		WHERE code = '' OR code IS NULL
 	`).Scan(&emptyCodeCount)
-	
+
 	if err != nil {
 		logger.Logger.Error("Failed to check empty code records", zap.Error(err))
 		return err
@@ -97,3 +127,68 @@ func cleanupDuplicateData(db DB) error {
 	logger.Logger.Info("Duplicate data cleanup completed successfully")
 	return nil
 }
+
+// backfillClassCodes 为没有邀请码的班级生成邀请码，邀请码有唯一索引，生成时跳过已被占用（包括已删除班级）的邀请码
+func backfillClassCodes(db DB) error {
+	var classes []*model.Class
+	if err := db.WithContext(context.Background()).Where("code = '' OR code IS NULL").Find(&classes); err != nil {
+		return err
+	}
+
+	for _, class := range classes {
+		var code string
+		for {
+			var err error
+			if code, err = utils.GenerateInviteCode(8); err != nil {
+				return err
+			}
+			var used int64
+			if err := db.WithContext(context.Background()).Raw("SELECT COUNT(*) FROM classes WHERE code = ?", code).Scan(&used); err != nil {
+				return err
+			}
+			if used == 0 {
+				break
+			}
+		}
+		class.Code = code
+		if err := db.WithContext(context.Background()).Save(class); err != nil {
+			return err
+		}
+	}
+
+	if len(classes) > 0 {
+		logger.Logger.Info("Class codes backfilled", zap.Int("count", len(classes)))
+	}
+	return nil
+}
+
+// seedRoles 创建内置角色，并将未设置角色的历史用户归为学生
+func seedRoles(db DB) error {
+	builtinRoles := []model.Role{
+		{RoleId: model.RoleAdmin, RoleName: "管理员"},
+		{RoleId: model.RoleTeacher, RoleName: "教师"},
+		{RoleId: model.RoleStudent, RoleName: "学生"},
+	}
+
+	now := time.Now()
+	for _, role := range builtinRoles {
+		var count int64
+		if err := db.WithContext(context.Background()).Model(&model.Role{}).Where("role_id = ?", role.RoleId).Count(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		role.CreateAt = now
+		role.UpdateAt = now
+		if err := db.WithContext(context.Background()).Create(&role); err != nil {
+			return err
+		}
+		logger.Logger.Info("Builtin role created", zap.String("role_id", role.RoleId))
+	}
+
+	return db.WithContext(context.Background()).Exec(
+		"UPDATE users SET role_id = ? WHERE role_id = '' OR role_id IS NULL", model.RoleStudent,
+	)
+}
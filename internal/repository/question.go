@@ -17,6 +17,8 @@ type QuestionRepository interface {
 	// 查询操作
 	GetByAssignmentID(ctx context.Context, assignmentID uint) ([]*model.Question, error)
 	GetByAssignmentIDWithOrder(ctx context.Context, assignmentID uint) ([]*model.Question, error)
+	// GetDraftCopiesByBankItemID 获取草稿作业中由指定题库题目复制的题目
+	GetDraftCopiesByBankItemID(ctx context.Context, bankItemID uint) ([]*model.Question, error)
 	
 	// 批量操作
 	CreateBatch(ctx context.Context, questions []*model.Question) error
@@ -39,7 +41,7 @@ func NewQuestionRepository(db DB, cache Cache) QuestionRepository {
 
 // Create 创建题目
 func (r *questionRepository) Create(ctx context.Context, question *model.Question) error {
-	if err := r.db.WithContext(ctx).Create(question).Error; err != nil {
+	if err := r.db.WithContext(ctx).Create(question); err != nil {
 		return fmt.Errorf("create question failed: %w", err)
 	}
 	return nil
@@ -48,7 +50,7 @@ func (r *questionRepository) Create(ctx context.Context, question *model.Questio
 // GetByID 根据ID获取题目
 func (r *questionRepository) GetByID(ctx context.Context, id uint) (*model.Question, error) {
 	var question model.Question
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&question).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&question)
 	if err != nil {
 		return nil, fmt.Errorf("get question by id failed: %w", err)
 	}
@@ -57,7 +59,7 @@ func (r *questionRepository) GetByID(ctx context.Context, id uint) (*model.Quest
 
 // Update 更新题目
 func (r *questionRepository) Update(ctx context.Context, question *model.Question) error {
-	if err := r.db.WithContext(ctx).Save(question).Error; err != nil {
+	if err := r.db.WithContext(ctx).Save(question); err != nil {
 		return fmt.Errorf("update question failed: %w", err)
 	}
 	return nil
@@ -65,7 +67,7 @@ func (r *questionRepository) Update(ctx context.Context, question *model.Questio
 
 // Delete 删除题目
 func (r *questionRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&model.Question{}, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Delete(&model.Question{}, id); err != nil {
 		return fmt.Errorf("delete question failed: %w", err)
 	}
 	return nil
@@ -76,7 +78,7 @@ func (r *questionRepository) GetByAssignmentID(ctx context.Context, assignmentID
 	var questions []*model.Question
 	err := r.db.WithContext(ctx).
 		Where("assignment_id = ?", assignmentID).
-		Find(&questions).Error
+		Find(&questions)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get questions by assignment id failed: %w", err)
@@ -90,8 +92,8 @@ func (r *questionRepository) GetByAssignmentIDWithOrder(ctx context.Context, ass
 	var questions []*model.Question
 	err := r.db.WithContext(ctx).
 		Where("assignment_id = ?", assignmentID).
-		Order("\"order\" ASC").
-		Find(&questions).Error
+		Order("`order` ASC").
+		Find(&questions)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get questions by assignment id with order failed: %w", err)
@@ -114,11 +116,25 @@ func (r *questionRepository) CreateBatch(ctx context.Context, questions []*model
 func (r *questionRepository) DeleteByAssignmentID(ctx context.Context, assignmentID uint) error {
 	err := r.db.WithContext(ctx).
 		Where("assignment_id = ?", assignmentID).
-		Delete(&model.Question{}).Error
+		Delete(&model.Question{})
 	
 	if err != nil {
 		return fmt.Errorf("delete questions by assignment id failed: %w", err)
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// GetDraftCopiesByBankItemID 获取草稿作业中由指定题库题目复制的题目
+func (r *questionRepository) GetDraftCopiesByBankItemID(ctx context.Context, bankItemID uint) ([]*model.Question, error) {
+	var questions []*model.Question
+	err := r.db.WithContext(ctx).
+		Where("bank_item_id = ? AND assignment_id IN (SELECT id FROM assignments WHERE status = ? AND deleted_at IS NULL)", bankItemID, "draft").
+		Find(&questions)
+	
+	if err != nil {
+		return nil, fmt.Errorf("get draft copies by bank item id failed: %w", err)
+	}
+	
+	return questions, nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"ai-course/internal/model"
+	"context"
+	"fmt"
+)
+
+// QuestionBankRepository 题库仓储接口
+type QuestionBankRepository interface {
+	// 基础CRUD操作
+	Create(ctx context.Context, item *model.QuestionBankItem) error
+	// CreateBatch 在同一事务中创建多个题库题目
+	CreateBatch(ctx context.Context, items []*model.QuestionBankItem) error
+	GetByID(ctx context.Context, id uint) (*model.QuestionBankItem, error)
+	Update(ctx context.Context, item *model.QuestionBankItem) error
+	Delete(ctx context.Context, id uint) error
+
+	// 查询操作
+	Search(ctx context.Context, filter *model.BankItemFilter, offset, limit int) ([]*model.QuestionBankItem, int64, error)
+
+	// IncrementUsage 增加题目被使用次数
+	IncrementUsage(ctx context.Context, ids []uint) error
+}
+
+// questionBankRepository 题库仓储实现
+type questionBankRepository struct {
+	db    DB
+	cache Cache
+}
+
+// NewQuestionBankRepository 创建题库仓储实例
+func NewQuestionBankRepository(db DB, cache Cache) QuestionBankRepository {
+	return &questionBankRepository{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// Create 创建题库题目
+func (r *questionBankRepository) Create(ctx context.Context, item *model.QuestionBankItem) error {
+	if err := r.db.WithContext(ctx).Create(item); err != nil {
+		return fmt.Errorf("create question bank item failed: %w", err)
+	}
+	return nil
+}
+
+// CreateBatch 在同一事务中创建多个题库题目，任一失败时全部回滚
+func (r *questionBankRepository) CreateBatch(ctx context.Context, items []*model.QuestionBankItem) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		for _, item := range items {
+			if err := tx.Create(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("create question bank items failed: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取题库题目
+func (r *questionBankRepository) GetByID(ctx context.Context, id uint) (*model.QuestionBankItem, error) {
+	var item model.QuestionBankItem
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&item); err != nil {
+		return nil, fmt.Errorf("get question bank item by id failed: %w", err)
+	}
+	return &item, nil
+}
+
+// Update 更新题库题目
+func (r *questionBankRepository) Update(ctx context.Context, item *model.QuestionBankItem) error {
+	if err := r.db.WithContext(ctx).Save(item); err != nil {
+		return fmt.Errorf("update question bank item failed: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除题库题目（已复制到作业中的题目不受影响）
+func (r *questionBankRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&model.QuestionBankItem{}, id); err != nil {
+		return fmt.Errorf("delete question bank item failed: %w", err)
+	}
+	return nil
+}
+
+// Search 按条件搜索题库题目
+func (r *questionBankRepository) Search(ctx context.Context, filter *model.BankItemFilter, offset, limit int) ([]*model.QuestionBankItem, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.QuestionBankItem{})
+	if filter.ViewerID != 0 {
+		db = db.Where("owner_id = ? OR scope = ?", filter.ViewerID, model.BankItemScopeSchool)
+	}
+	if filter.OwnerID != 0 {
+		db = db.Where("owner_id = ?", filter.OwnerID)
+	}
+	if filter.Keyword != "" {
+		db = db.Where("content LIKE ?", "%"+filter.Keyword+"%")
+	}
+	if filter.Type != "" {
+		db = db.Where("type = ?", filter.Type)
+	}
+	if filter.KnowledgePoint != "" {
+		db = db.Where("knowledge_point LIKE ?", "%"+filter.KnowledgePoint+"%")
+	}
+	if filter.Difficulty != "" {
+		db = db.Where("difficulty = ?", filter.Difficulty)
+	}
+	if filter.Chapter != "" {
+		db = db.Where("chapter LIKE ?", "%"+filter.Chapter+"%")
+	}
+	if filter.Scope != "" {
+		db = db.Where("scope = ?", filter.Scope)
+	}
+
+	// 获取总数
+	var total int64
+	if err := db.Count(&total); err != nil {
+		return nil, 0, fmt.Errorf("count question bank items failed: %w", err)
+	}
+
+	// 获取列表
+	var items []*model.QuestionBankItem
+	err := db.Preload("Owner").
+		Order("updated_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&items)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("search question bank items failed: %w", err)
+	}
+
+	return items, total, nil
+}
+
+// IncrementUsage 增加题目被使用次数
+func (r *questionBankRepository) IncrementUsage(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Exec(
+		"UPDATE question_bank_items SET usage_count = usage_count + 1 WHERE id IN ?", ids,
+	); err != nil {
+		return fmt.Errorf("increment question bank usage failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"ai-course/internal/model"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// RegradeRepository 复核申请仓储接口
+type RegradeRepository interface {
+	// 基础操作
+	Create(ctx context.Context, request *model.RegradeRequest) error
+	GetByID(ctx context.Context, id uint) (*model.RegradeRequest, error)
+	GetDetailByID(ctx context.Context, id uint) (*model.RegradeRequest, error)
+
+	// 查询操作
+	HasOpenForAnswer(ctx context.Context, answerID uint) (bool, error)
+	GetByStudentID(ctx context.Context, studentID, assignmentID uint) ([]*model.RegradeRequest, error)
+	GetByAssignmentID(ctx context.Context, assignmentID uint, status model.RegradeStatus, offset, limit int) ([]*model.RegradeRequest, int64, error)
+
+	// 处理申请
+	Accept(ctx context.Context, request *model.RegradeRequest, answer *model.Answer, newScore, fullScore int) error
+	Reject(ctx context.Context, request *model.RegradeRequest) error
+}
+
+// regradeRepository 复核申请仓储实现
+type regradeRepository struct {
+	db    DB
+	cache Cache
+}
+
+// NewRegradeRepository 创建复核申请仓储实例
+func NewRegradeRepository(db DB, cache Cache) RegradeRepository {
+	return &regradeRepository{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// Create 创建复核申请
+func (r *regradeRepository) Create(ctx context.Context, request *model.RegradeRequest) error {
+	if err := r.db.WithContext(ctx).Create(request); err != nil {
+		return fmt.Errorf("create regrade request failed: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取复核申请
+func (r *regradeRepository) GetByID(ctx context.Context, id uint) (*model.RegradeRequest, error) {
+	var request model.RegradeRequest
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&request); err != nil {
+		return nil, fmt.Errorf("get regrade request by id failed: %w", err)
+	}
+	return &request, nil
+}
+
+// GetDetailByID 获取复核申请详情（包含学生、题目和答案）
+func (r *regradeRepository) GetDetailByID(ctx context.Context, id uint) (*model.RegradeRequest, error) {
+	var request model.RegradeRequest
+	err := r.db.WithContext(ctx).
+		Preload("Student").
+		Preload("Question").
+		Preload("Answer").
+		Where("id = ?", id).
+		First(&request)
+	if err != nil {
+		return nil, fmt.Errorf("get regrade request detail failed: %w", err)
+	}
+	return &request, nil
+}
+
+// HasOpenForAnswer 检查答案是否已有待处理的复核申请
+func (r *regradeRepository) HasOpenForAnswer(ctx context.Context, answerID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.RegradeRequest{}).
+		Where("answer_id = ? AND status = ?", answerID, model.RegradeStatusOpen).
+		Count(&count)
+	if err != nil {
+		return false, fmt.Errorf("count open regrade requests failed: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetByStudentID 获取学生的复核申请，assignmentID 为 0 时返回全部作业
+func (r *regradeRepository) GetByStudentID(ctx context.Context, studentID, assignmentID uint) ([]*model.RegradeRequest, error) {
+	db := r.db.WithContext(ctx).Where("student_id = ?", studentID)
+	if assignmentID != 0 {
+		db = db.Where("assignment_id = ?", assignmentID)
+	}
+
+	var requests []*model.RegradeRequest
+	if err := db.Order("created_at DESC").Find(&requests); err != nil {
+		return nil, fmt.Errorf("get regrade requests by student failed: %w", err)
+	}
+	return requests, nil
+}
+
+// GetByAssignmentID 分页获取作业的复核申请，status 为空时返回全部状态
+func (r *regradeRepository) GetByAssignmentID(ctx context.Context, assignmentID uint, status model.RegradeStatus, offset, limit int) ([]*model.RegradeRequest, int64, error) {
+	db := r.db.WithContext(ctx).Where("assignment_id = ?", assignmentID)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := db.Model(&model.RegradeRequest{}).Count(&total); err != nil {
+		return nil, 0, fmt.Errorf("count regrade requests failed: %w", err)
+	}
+
+	var requests []*model.RegradeRequest
+	err := db.Preload("Student").
+		Preload("Question").
+		Preload("Answer").
+		Order("created_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&requests)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get regrade requests by assignment failed: %w", err)
+	}
+	return requests, total, nil
+}
+
+// Accept 接受复核申请：在同一事务中调整题目得分、重新计算提交总分，并在申请和成绩变更记录中保留调整前后的分数
+// 答案已被修改或申请已被处理时回滚并返回 ErrVersionConflict。
+// request 的 TeacherResponse、ResolvedBy、ResolvedAt 需已设置，fullScore 为题目满分，得满分时才记为正确
+func (r *regradeRepository) Accept(ctx context.Context, request *model.RegradeRequest, answer *model.Answer, newScore, fullScore int) error {
+	var oldTotal, newTotal int
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		isCorrect := newScore == fullScore
+		rows, err := tx.Model(&model.Answer{}).
+			Where("id = ? AND version = ?", answer.ID, answer.Version).
+			Updates(map[string]interface{}{
+				"score":      newScore,
+				"is_correct": isCorrect,
+				"version":    gorm.Expr("version + 1"),
+			})
+		if err != nil {
+			return fmt.Errorf("update answer score failed: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("answer %d: %w", answer.ID, ErrVersionConflict)
+		}
+
+		// 重新计算总分时保留原有的迟交扣分比例
+		var current model.Submission
+		err = tx.Raw("SELECT score, late_penalty_percent FROM submissions WHERE id = ?", answer.SubmissionID).Scan(&current)
+		if err != nil {
+			return fmt.Errorf("get submission score failed: %w", err)
+		}
+		oldTotal = current.Score
+
+		var rawTotal int
+		err = tx.Raw("SELECT COALESCE(SUM(score), 0) FROM answers WHERE submission_id = ? AND deleted_at IS NULL", answer.SubmissionID).
+			Scan(&rawTotal)
+		if err != nil {
+			return fmt.Errorf("sum answer scores failed: %w", err)
+		}
+		current.ApplyLatePenalty(rawTotal)
+		newTotal = current.Score
+		_, err = tx.Model(&model.Submission{}).
+			Where("id = ?", answer.SubmissionID).
+			Updates(map[string]interface{}{
+				"score":        newTotal,
+				"late_penalty": current.LatePenalty,
+				"version":      gorm.Expr("version + 1"),
+			})
+		if err != nil {
+			return fmt.Errorf("update submission score failed: %w", err)
+		}
+
+		oldScore := answer.Score
+		rows, err = tx.Model(&model.RegradeRequest{}).
+			Where("id = ? AND status = ?", request.ID, model.RegradeStatusOpen).
+			Updates(map[string]interface{}{
+				"status":           model.RegradeStatusAccepted,
+				"teacher_response": request.TeacherResponse,
+				"resolved_by":      request.ResolvedBy,
+				"resolved_at":      request.ResolvedAt,
+				"old_score":        oldScore,
+				"new_score":        newScore,
+				"old_total_score":  oldTotal,
+				"new_total_score":  newTotal,
+			})
+		if err != nil {
+			return fmt.Errorf("update regrade request failed: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("regrade request %d: %w", request.ID, ErrVersionConflict)
+		}
+
+		// 记录题目得分和提交总分的变更
+		note := fmt.Sprintf("regrade request #%d", request.ID)
+		answerID, questionID := answer.ID, answer.QuestionID
+		return createGradeEvents(tx, []*model.GradeEvent{
+			{
+				SubmissionID: answer.SubmissionID,
+				AnswerID:     &answerID,
+				QuestionID:   &questionID,
+				ActorID:      request.ResolvedBy,
+				Source:       model.GradeEventSourceRegrade,
+				OldScore:     oldScore,
+				NewScore:     newScore,
+				OldFeedback:  answer.Feedback,
+				NewFeedback:  answer.Feedback,
+				Note:         note,
+			},
+			{
+				SubmissionID: answer.SubmissionID,
+				ActorID:      request.ResolvedBy,
+				Source:       model.GradeEventSourceRegrade,
+				OldScore:     oldTotal,
+				NewScore:     newTotal,
+				Note:         note,
+			},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	oldScore := answer.Score
+	request.Status = model.RegradeStatusAccepted
+	request.OldScore = &oldScore
+	request.NewScore = &newScore
+	request.OldTotalScore = &oldTotal
+	request.NewTotalScore = &newTotal
+	answer.Score = newScore
+	answer.Version++
+	return nil
+}
+
+// Reject 驳回复核申请，申请已被处理时返回 ErrVersionConflict
+func (r *regradeRepository) Reject(ctx context.Context, request *model.RegradeRequest) error {
+	rows, err := r.db.WithContext(ctx).
+		Model(&model.RegradeRequest{}).
+		Where("id = ? AND status = ?", request.ID, model.RegradeStatusOpen).
+		Updates(map[string]interface{}{
+			"status":           model.RegradeStatusRejected,
+			"teacher_response": request.TeacherResponse,
+			"resolved_by":      request.ResolvedBy,
+			"resolved_at":      request.ResolvedAt,
+		})
+	if err != nil {
+		return fmt.Errorf("reject regrade request failed: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("regrade request %d: %w", request.ID, ErrVersionConflict)
+	}
+	request.Status = model.RegradeStatusRejected
+	return nil
+}
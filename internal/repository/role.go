@@ -13,6 +13,7 @@ type RoleRepository interface {
 	Delete(ctx context.Context, roleId string) error
 	GetById(ctx context.Context, roleId string) (*model.Role, error)
 	GetAll(ctx context.Context) ([]*model.Role, error)
+	CountUsers(ctx context.Context, roleId string) (int64, error)
 }
 
 type roleRepository struct {
@@ -26,7 +27,7 @@ func (r *roleRepository) Create(ctx context.Context, role *model.Role) error {
 
 // Delete implements RoleRepository.
 func (r *roleRepository) Delete(ctx context.Context, roleId string) error {
-	return r.db.WithContext(ctx).Delete(&model.Role{}, roleId).Error
+	return r.db.WithContext(ctx).Where("role_id = ?", roleId).Delete(&model.Role{}).Error
 }
 
 // GetAll implements RoleRepository.
@@ -54,6 +55,16 @@ func (r *roleRepository) Update(ctx context.Context, role *model.Role) error {
 	return r.db.WithContext(ctx).Save(role).Error
 }
 
+// CountUsers implements RoleRepository.
+func (r *roleRepository) CountUsers(ctx context.Context, roleId string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.User{}).Where("role_id = ?", roleId).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func NewRoleRepository(db *gorm.DB) RoleRepository {
 	return &roleRepository{db: db}
 }
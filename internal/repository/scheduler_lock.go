@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"ai-course/internal/model"
+	"context"
+	"fmt"
+	"time"
+)
+
+// SchedulerLockRepository 调度锁仓储接口
+type SchedulerLockRepository interface {
+	// TryAcquire 尝试获取或续期锁：锁不存在、已过期或已由 owner 持有时获取成功，锁在 lease 后过期
+	TryAcquire(ctx context.Context, name, owner string, lease time.Duration, now time.Time) (bool, error)
+}
+
+// schedulerLockRepository 调度锁仓储实现
+type schedulerLockRepository struct {
+	db    DB
+	cache Cache
+}
+
+// NewSchedulerLockRepository 创建调度锁仓储实例
+func NewSchedulerLockRepository(db DB, cache Cache) SchedulerLockRepository {
+	return &schedulerLockRepository{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// TryAcquire 尝试获取或续期锁，通过条件更新保证同一时间只有一个实例持有锁
+func (r *schedulerLockRepository) TryAcquire(ctx context.Context, name, owner string, lease time.Duration, now time.Time) (bool, error) {
+	expiresAt := now.Add(lease)
+	rows, err := r.db.WithContext(ctx).
+		Model(&model.SchedulerLock{}).
+		Where("name = ? AND (owner = ? OR expires_at < ?)", name, owner, now).
+		Updates(map[string]interface{}{
+			"owner":      owner,
+			"expires_at": expiresAt,
+		})
+	if err != nil {
+		return false, fmt.Errorf("acquire scheduler lock failed: %w", err)
+	}
+	if rows > 0 {
+		return true, nil
+	}
+
+	// 锁由其他实例持有
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.SchedulerLock{}).Where("name = ?", name).Count(&count); err != nil {
+		return false, fmt.Errorf("check scheduler lock failed: %w", err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	// 锁不存在时创建，多个实例同时创建时主键冲突的一方获取失败
+	if err := r.db.WithContext(ctx).Create(&model.SchedulerLock{Name: name, Owner: owner, ExpiresAt: expiresAt}); err != nil {
+		if err := r.db.WithContext(ctx).Model(&model.SchedulerLock{}).Where("name = ?", name).Count(&count); err == nil && count > 0 {
+			return false, nil
+		}
+		return false, fmt.Errorf("create scheduler lock failed: %w", err)
+	}
+	return true, nil
+}
@@ -4,6 +4,10 @@ import (
 	"ai-course/internal/model"
 	"context"
 	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SubmissionRepository 提交仓储接口
@@ -15,7 +19,9 @@ type SubmissionRepository interface {
 	Delete(ctx context.Context, id uint) error
 	
 	// 查询操作
+	// GetByAssignmentAndStudent 获取学生最近一次作答
 	GetByAssignmentAndStudent(ctx context.Context, assignmentID, studentID uint) (*model.Submission, error)
+	GetAttempts(ctx context.Context, assignmentID, studentID uint) ([]*model.Submission, error)
 	GetByAssignmentID(ctx context.Context, assignmentID uint, offset, limit int) ([]*model.Submission, int64, error)
 	GetByStudentID(ctx context.Context, studentID uint, offset, limit int) ([]*model.Submission, int64, error)
 	
@@ -28,6 +34,21 @@ type SubmissionRepository interface {
 	CountByAssignmentAndStatus(ctx context.Context, assignmentID uint, status model.SubmissionStatus) (int64, error)
 	GetSubmissionStats(ctx context.Context, assignmentID uint) (map[model.SubmissionStatus]int64, error)
 	GetStatistics(ctx context.Context, assignmentID uint) (*model.SubmissionStatistics, error)
+
+	// 批改操作
+	SaveGrading(ctx context.Context, submission *model.Submission, answers []*model.Answer, events []*model.GradeEvent) error
+	SaveAutoGrading(ctx context.Context, submission *model.Submission, answers []*model.Answer, events []*model.GradeEvent) error
+	// SaveGradingBatch 在同一事务中保存多份提交的成绩，已批改的提交可以重新评分
+	SaveGradingBatch(ctx context.Context, updates []*model.GradingUpdate) error
+	// GetSubmittedWithAnswers 获取作业中已提交和已批改的作答及答案
+	GetSubmittedWithAnswers(ctx context.Context, assignmentID uint) ([]*model.Submission, error)
+	ClaimGradingLock(ctx context.Context, id, graderID uint, now, expiresAt time.Time) (bool, error)
+	ReleaseGradingLock(ctx context.Context, id, graderID uint) (bool, error)
+	// 限时作答和作业关闭时自动交卷
+	GetExpiredDrafts(ctx context.Context, now time.Time, limit int) ([]*model.Submission, error)
+	GetDraftsByAssignment(ctx context.Context, assignmentID uint) ([]*model.Submission, error)
+	AutoSubmit(ctx context.Context, submission *model.Submission) (bool, error)
+	GetGradingCandidates(ctx context.Context, assignmentID, graderID uint, now time.Time, limit int) ([]uint, error)
 }
 
 // submissionRepository 提交仓储实现
@@ -46,7 +67,7 @@ func NewSubmissionRepository(db DB, cache Cache) SubmissionRepository {
 
 // Create 创建提交
 func (r *submissionRepository) Create(ctx context.Context, submission *model.Submission) error {
-	if err := r.db.WithContext(ctx).Create(submission).Error; err != nil {
+	if err := r.db.WithContext(ctx).Create(submission); err != nil {
 		return fmt.Errorf("create submission failed: %w", err)
 	}
 	return nil
@@ -55,16 +76,18 @@ func (r *submissionRepository) Create(ctx context.Context, submission *model.Sub
 // GetByID 根据ID获取提交
 func (r *submissionRepository) GetByID(ctx context.Context, id uint) (*model.Submission, error) {
 	var submission model.Submission
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&submission).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&submission)
 	if err != nil {
 		return nil, fmt.Errorf("get submission by id failed: %w", err)
 	}
 	return &submission, nil
 }
 
-// Update 更新提交
+// Update 更新提交，同时递增版本号，使基于旧版本的批改失效
 func (r *submissionRepository) Update(ctx context.Context, submission *model.Submission) error {
-	if err := r.db.WithContext(ctx).Save(submission).Error; err != nil {
+	submission.Version++
+	if err := r.db.WithContext(ctx).Save(submission); err != nil {
+		submission.Version--
 		return fmt.Errorf("update submission failed: %w", err)
 	}
 	return nil
@@ -72,18 +95,19 @@ func (r *submissionRepository) Update(ctx context.Context, submission *model.Sub
 
 // Delete 删除提交
 func (r *submissionRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&model.Submission{}, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Delete(&model.Submission{}, id); err != nil {
 		return fmt.Errorf("delete submission failed: %w", err)
 	}
 	return nil
 }
 
-// GetByAssignmentAndStudent 根据作业ID和学生ID获取提交
+// GetByAssignmentAndStudent 根据作业ID和学生ID获取提交，多次作答时返回最近一次作答
 func (r *submissionRepository) GetByAssignmentAndStudent(ctx context.Context, assignmentID, studentID uint) (*model.Submission, error) {
 	var submission model.Submission
 	err := r.db.WithContext(ctx).
 		Where("assignment_id = ? AND student_id = ?", assignmentID, studentID).
-		First(&submission).Error
+		Order("attempt DESC").
+		First(&submission)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get submission by assignment and student failed: %w", err)
@@ -92,13 +116,26 @@ func (r *submissionRepository) GetByAssignmentAndStudent(ctx context.Context, as
 	return &submission, nil
 }
 
+// GetAttempts 获取学生在作业上的全部作答，按作答次序排列
+func (r *submissionRepository) GetAttempts(ctx context.Context, assignmentID, studentID uint) ([]*model.Submission, error) {
+	var submissions []*model.Submission
+	err := r.db.WithContext(ctx).
+		Where("assignment_id = ? AND student_id = ?", assignmentID, studentID).
+		Order("attempt ASC").
+		Find(&submissions)
+	if err != nil {
+		return nil, fmt.Errorf("get submission attempts failed: %w", err)
+	}
+	return submissions, nil
+}
+
 // GetByAssignmentID 根据作业ID获取提交列表
 func (r *submissionRepository) GetByAssignmentID(ctx context.Context, assignmentID uint, offset, limit int) ([]*model.Submission, int64, error) {
 	db := r.db.WithContext(ctx).Where("assignment_id = ?", assignmentID)
 	
 	// 获取总数
 	var total int64
-	if err := db.Model(&model.Submission{}).Count(&total).Error; err != nil {
+	if err := db.Model(&model.Submission{}).Count(&total); err != nil {
 		return nil, 0, fmt.Errorf("count submissions failed: %w", err)
 	}
 	
@@ -108,7 +145,7 @@ func (r *submissionRepository) GetByAssignmentID(ctx context.Context, assignment
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(limit).
-		Find(&submissions).Error
+		Find(&submissions)
 	
 	if err != nil {
 		return nil, 0, fmt.Errorf("get submissions by assignment id failed: %w", err)
@@ -123,7 +160,7 @@ func (r *submissionRepository) GetByStudentID(ctx context.Context, studentID uin
 	
 	// 获取总数
 	var total int64
-	if err := db.Model(&model.Submission{}).Count(&total).Error; err != nil {
+	if err := db.Model(&model.Submission{}).Count(&total); err != nil {
 		return nil, 0, fmt.Errorf("count submissions failed: %w", err)
 	}
 	
@@ -134,7 +171,7 @@ func (r *submissionRepository) GetByStudentID(ctx context.Context, studentID uin
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(limit).
-		Find(&submissions).Error
+		Find(&submissions)
 	
 	if err != nil {
 		return nil, 0, fmt.Errorf("get submissions by student id failed: %w", err)
@@ -149,13 +186,13 @@ func (r *submissionRepository) GetDetailByID(ctx context.Context, id uint) (*mod
 	err := r.db.WithContext(ctx).
 		Preload("Assignment").
 		Preload("Assignment.Questions", func(db DB) DB {
-			return db.Order("\"order\" ASC")
+			return db.Order("`order` ASC")
 		}).
 		Preload("Student").
 		Preload("Answers").
 		Preload("Answers.Question").
 		Where("id = ?", id).
-		First(&submission).Error
+		First(&submission)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get submission detail failed: %w", err)
@@ -170,7 +207,7 @@ func (r *submissionRepository) CountByAssignmentAndStatus(ctx context.Context, a
 	err := r.db.WithContext(ctx).
 		Model(&model.Submission{}).
 		Where("assignment_id = ? AND status = ?", assignmentID, status).
-		Count(&count).Error
+		Count(&count)
 	
 	if err != nil {
 		return 0, fmt.Errorf("count submissions by status failed: %w", err)
@@ -207,13 +244,13 @@ func (r *submissionRepository) GetByIDWithDetail(ctx context.Context, id uint) (
 	err := r.db.WithContext(ctx).
 		Preload("Assignment").
 		Preload("Assignment.Questions", func(db DB) DB {
-			return db.Order("\"order\" ASC")
+			return db.Order("`order` ASC")
 		}).
 		Preload("Student").
 		Preload("Answers").
 		Preload("Answers.Question").
 		Where("id = ?", id).
-		First(&submission).Error
+		First(&submission)
 	
 	if err != nil {
 		return nil, fmt.Errorf("get submission detail failed: %w", err)
@@ -239,7 +276,7 @@ func (r *submissionRepository) GetByAssignmentIDWithPagination(ctx context.Conte
 	
 	// 获取总数
 	var total int64
-	if err := db.Model(&model.Submission{}).Count(&total).Error; err != nil {
+	if err := db.Model(&model.Submission{}).Count(&total); err != nil {
 		return nil, 0, fmt.Errorf("count submissions failed: %w", err)
 	}
 	
@@ -254,7 +291,7 @@ func (r *submissionRepository) GetByAssignmentIDWithPagination(ctx context.Conte
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(pageSize).
-		Find(&submissions).Error
+		Find(&submissions)
 	
 	if err != nil {
 		return nil, 0, fmt.Errorf("get submissions by assignment id failed: %w", err)
@@ -281,7 +318,7 @@ func (r *submissionRepository) GetStatistics(ctx context.Context, assignmentID u
 	err := r.db.WithContext(ctx).
 		Model(&model.Submission{}).
 		Where("assignment_id = ?", assignmentID).
-		Count(&stats.TotalSubmissions).Error
+		Count(&stats.TotalSubmissions)
 	if err != nil {
 		return nil, fmt.Errorf("count total submissions failed: %w", err)
 	}
@@ -290,7 +327,7 @@ func (r *submissionRepository) GetStatistics(ctx context.Context, assignmentID u
 	err = r.db.WithContext(ctx).
 		Model(&model.Submission{}).
 		Where("assignment_id = ? AND status = ?", assignmentID, model.SubmissionStatusDraft).
-		Count(&stats.DraftSubmissions).Error
+		Count(&stats.DraftSubmissions)
 	if err != nil {
 		return nil, fmt.Errorf("count draft submissions failed: %w", err)
 	}
@@ -299,7 +336,7 @@ func (r *submissionRepository) GetStatistics(ctx context.Context, assignmentID u
 	err = r.db.WithContext(ctx).
 		Model(&model.Submission{}).
 		Where("assignment_id = ? AND status = ?", assignmentID, model.SubmissionStatusSubmitted).
-		Count(&stats.SubmittedSubmissions).Error
+		Count(&stats.SubmittedSubmissions)
 	if err != nil {
 		return nil, fmt.Errorf("count submitted submissions failed: %w", err)
 	}
@@ -308,10 +345,262 @@ func (r *submissionRepository) GetStatistics(ctx context.Context, assignmentID u
 	err = r.db.WithContext(ctx).
 		Model(&model.Submission{}).
 		Where("assignment_id = ? AND status = ?", assignmentID, model.SubmissionStatusGraded).
-		Count(&stats.GradedSubmissions).Error
+		Count(&stats.GradedSubmissions)
 	if err != nil {
 		return nil, fmt.Errorf("count graded submissions failed: %w", err)
 	}
 	
 	return stats, nil
-}
\ No newline at end of file
+}
+
+// gradingLockFree 提交未被领取、领取已过期或由当前教师领取的条件
+const gradingLockFree = "(grading_locked_by IS NULL OR grading_locked_by = ? OR grading_lock_expires_at < ?)"
+
+// SaveGrading 在同一事务中保存答案得分、提交批改结果和成绩变更记录
+// 每条记录按读取时的版本号条件更新，任一记录已被修改或提交被其他教师领取时回滚并返回 ErrVersionConflict。
+// 提交的 GradedBy、GradedAt 需已设置，保存成功后释放批改锁并递增内存中的版本号
+func (r *submissionRepository) SaveGrading(ctx context.Context, submission *model.Submission, answers []*model.Answer, events []*model.GradeEvent) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		return saveGrading(tx, submission, answers, events, []model.SubmissionStatus{model.SubmissionStatusSubmitted})
+	})
+	if err != nil {
+		return err
+	}
+	gradingSaved(submission, answers)
+	return nil
+}
+
+// SaveGradingBatch 在同一事务中保存多份提交的成绩，版本号检查和批改锁条件与 SaveGrading 相同，任一提交冲突时整体回滚
+func (r *submissionRepository) SaveGradingBatch(ctx context.Context, updates []*model.GradingUpdate) error {
+	statuses := []model.SubmissionStatus{model.SubmissionStatusSubmitted, model.SubmissionStatusGraded}
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		for _, update := range updates {
+			if err := saveGrading(tx, update.Submission, update.Answers, update.Events, statuses); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, update := range updates {
+		gradingSaved(update.Submission, update.Answers)
+	}
+	return nil
+}
+
+// saveGrading 在事务中按版本号更新答案和提交，提交的状态须在 statuses 之中
+// 答案的批改时间与提交相同，已批改的答案不再生成 AI 建议
+func saveGrading(tx DB, submission *model.Submission, answers []*model.Answer, events []*model.GradeEvent, statuses []model.SubmissionStatus) error {
+	for _, answer := range answers {
+		rows, err := tx.Model(&model.Answer{}).
+			Where("id = ? AND version = ?", answer.ID, answer.Version).
+			Updates(map[string]interface{}{
+				"score":       answer.Score,
+				"feedback":    answer.Feedback,
+				"is_correct":  answer.IsCorrect,
+				"ai_accepted": answer.AIAccepted,
+				"graded_at":   submission.GradedAt,
+				"version":     gorm.Expr("version + 1"),
+			})
+		if err != nil {
+			return fmt.Errorf("update answer grade failed: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("answer %d: %w", answer.ID, ErrVersionConflict)
+		}
+	}
+
+	rows, err := tx.Model(&model.Submission{}).
+		Where("id = ? AND version = ? AND status IN ?", submission.ID, submission.Version, statuses).
+		Where(gradingLockFree, submission.GradedBy, *submission.GradedAt).
+		Updates(map[string]interface{}{
+			"score":                   submission.Score,
+			"late_penalty":            submission.LatePenalty,
+			"status":                  model.SubmissionStatusGraded,
+			"graded_at":               submission.GradedAt,
+			"graded_by":               submission.GradedBy,
+			"feedback":                submission.Feedback,
+			"grading_locked_by":       nil,
+			"grading_lock_expires_at": nil,
+			"version":                 gorm.Expr("version + 1"),
+		})
+	if err != nil {
+		return fmt.Errorf("update submission grade failed: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("submission %d: %w", submission.ID, ErrVersionConflict)
+	}
+	return createGradeEvents(tx, events)
+}
+
+// gradingSaved 保存成功后同步内存中的状态和版本号
+func gradingSaved(submission *model.Submission, answers []*model.Answer) {
+	for _, answer := range answers {
+		answer.GradedAt = submission.GradedAt
+		answer.Version++
+	}
+	submission.Status = model.SubmissionStatusGraded
+	submission.GradingLockedBy = nil
+	submission.GradingLockExpiresAt = nil
+	submission.Version++
+}
+
+// GetSubmittedWithAnswers 获取作业中已提交和已批改的作答及答案
+func (r *submissionRepository) GetSubmittedWithAnswers(ctx context.Context, assignmentID uint) ([]*model.Submission, error) {
+	var submissions []*model.Submission
+	err := r.db.WithContext(ctx).
+		Preload("Answers").
+		Where("assignment_id = ? AND status IN ?", assignmentID, []model.SubmissionStatus{model.SubmissionStatusSubmitted, model.SubmissionStatusGraded}).
+		Order("student_id ASC, attempt ASC").
+		Find(&submissions)
+	if err != nil {
+		return nil, fmt.Errorf("get submitted attempts with answers failed: %w", err)
+	}
+	return submissions, nil
+}
+
+// SaveAutoGrading 在同一事务中保存自动判分的答案、提交总分和成绩变更记录
+// 提交状态为已批改（全部答案均已自动判分）时同时保存状态和批改时间
+func (r *submissionRepository) SaveAutoGrading(ctx context.Context, submission *model.Submission, answers []*model.Answer, events []*model.GradeEvent) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		for _, answer := range answers {
+			answer.Version++
+			if err := tx.Save(answer); err != nil {
+				return fmt.Errorf("update answer failed: %w", err)
+			}
+		}
+
+		updates := map[string]interface{}{
+			"score":        submission.Score,
+			"late_penalty": submission.LatePenalty,
+			"version":      gorm.Expr("version + 1"),
+		}
+		if submission.Status == model.SubmissionStatusGraded {
+			updates["status"] = submission.Status
+			updates["graded_at"] = submission.GradedAt
+		}
+		_, err := tx.Model(&model.Submission{}).
+			Where("id = ?", submission.ID).
+			Updates(updates)
+		if err != nil {
+			return fmt.Errorf("update submission score failed: %w", err)
+		}
+		return createGradeEvents(tx, events)
+	})
+	if err != nil {
+		for _, answer := range answers {
+			answer.Version--
+		}
+		return fmt.Errorf("save auto grading failed: %w", err)
+	}
+
+	submission.Version++
+	return nil
+}
+
+// ClaimGradingLock 领取提交的批改锁，提交已被其他教师领取且未过期时返回 false
+// 当前教师再次领取会延长过期时间
+func (r *submissionRepository) ClaimGradingLock(ctx context.Context, id, graderID uint, now, expiresAt time.Time) (bool, error) {
+	rows, err := r.db.WithContext(ctx).
+		Model(&model.Submission{}).
+		Where("id = ? AND status = ?", id, model.SubmissionStatusSubmitted).
+		Where(gradingLockFree, graderID, now).
+		Updates(map[string]interface{}{
+			"grading_locked_by":       graderID,
+			"grading_lock_expires_at": expiresAt,
+		})
+	if err != nil {
+		return false, fmt.Errorf("claim grading lock failed: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// ReleaseGradingLock 释放当前教师持有的批改锁，未持有时返回 false
+func (r *submissionRepository) ReleaseGradingLock(ctx context.Context, id, graderID uint) (bool, error) {
+	rows, err := r.db.WithContext(ctx).
+		Model(&model.Submission{}).
+		Where("id = ? AND grading_locked_by = ?", id, graderID).
+		Updates(map[string]interface{}{
+			"grading_locked_by":       nil,
+			"grading_lock_expires_at": nil,
+		})
+	if err != nil {
+		return false, fmt.Errorf("release grading lock failed: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// GetGradingCandidates 按提交时间顺序获取可由当前教师领取的待批改提交ID，优先返回其已领取的提交
+func (r *submissionRepository) GetGradingCandidates(ctx context.Context, assignmentID, graderID uint, now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Model(&model.Submission{}).
+		Select("id").
+		Where("assignment_id = ? AND status = ?", assignmentID, model.SubmissionStatusSubmitted).
+		Where(gradingLockFree, graderID, now).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "grading_locked_by = ? DESC, submitted_at ASC, id ASC",
+			Vars:               []interface{}{graderID},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Scan(&ids)
+	if err != nil {
+		return nil, fmt.Errorf("get grading candidates failed: %w", err)
+	}
+	return ids, nil
+}
+
+// GetExpiredDrafts 获取限时作答已到时间但仍未提交的草稿
+func (r *submissionRepository) GetExpiredDrafts(ctx context.Context, now time.Time, limit int) ([]*model.Submission, error) {
+	var submissions []*model.Submission
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at < ?", model.SubmissionStatusDraft, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&submissions)
+	if err != nil {
+		return nil, fmt.Errorf("get expired drafts failed: %w", err)
+	}
+	return submissions, nil
+}
+
+// GetDraftsByAssignment 获取作业中仍未提交的草稿
+func (r *submissionRepository) GetDraftsByAssignment(ctx context.Context, assignmentID uint) ([]*model.Submission, error) {
+	var submissions []*model.Submission
+	err := r.db.WithContext(ctx).
+		Where("assignment_id = ? AND status = ?", assignmentID, model.SubmissionStatusDraft).
+		Find(&submissions)
+	if err != nil {
+		return nil, fmt.Errorf("get drafts by assignment failed: %w", err)
+	}
+	return submissions, nil
+}
+
+// AutoSubmit 将超时或作业关闭时未提交的草稿标记为自动交卷，草稿已被学生提交时返回 false
+// 提交的 SubmittedAt、IsLate、LatePenaltyPercent 需已设置
+func (r *submissionRepository) AutoSubmit(ctx context.Context, submission *model.Submission) (bool, error) {
+	rows, err := r.db.WithContext(ctx).
+		Model(&model.Submission{}).
+		Where("id = ? AND status = ?", submission.ID, model.SubmissionStatusDraft).
+		Updates(map[string]interface{}{
+			"status":               model.SubmissionStatusSubmitted,
+			"submitted_at":         submission.SubmittedAt,
+			"auto_submitted":       true,
+			"is_late":              submission.IsLate,
+			"late_penalty_percent": submission.LatePenaltyPercent,
+			"version":              gorm.Expr("version + 1"),
+		})
+	if err != nil {
+		return false, fmt.Errorf("auto submit failed: %w", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+	submission.Status = model.SubmissionStatusSubmitted
+	submission.AutoSubmitted = true
+	submission.Version++
+	return true, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"ai-course/internal/model"
+	"context"
+	"fmt"
+)
+
+// TemplateRepository 作业模板仓储接口
+type TemplateRepository interface {
+	// Create 创建模板，附件随模板一起写入
+	Create(ctx context.Context, template *model.AssignmentTemplate) error
+	// GetByID 根据ID获取模板（包含附件）
+	GetByID(ctx context.Context, id uint) (*model.AssignmentTemplate, error)
+	// GetByOwnerID 获取教师的模板列表
+	GetByOwnerID(ctx context.Context, ownerID uint, offset, limit int) ([]*model.AssignmentTemplate, int64, error)
+	// Delete 删除模板及其附件记录
+	Delete(ctx context.Context, id uint) error
+}
+
+// templateRepository 作业模板仓储实现
+type templateRepository struct {
+	db    DB
+	cache Cache
+}
+
+// NewTemplateRepository 创建作业模板仓储实例
+func NewTemplateRepository(db DB, cache Cache) TemplateRepository {
+	return &templateRepository{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// Create 创建模板
+func (r *templateRepository) Create(ctx context.Context, template *model.AssignmentTemplate) error {
+	if err := r.db.WithContext(ctx).Create(template); err != nil {
+		return fmt.Errorf("create assignment template failed: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取模板
+func (r *templateRepository) GetByID(ctx context.Context, id uint) (*model.AssignmentTemplate, error) {
+	var template model.AssignmentTemplate
+	err := r.db.WithContext(ctx).
+		Preload("Attachments").
+		Where("id = ?", id).
+		First(&template)
+	if err != nil {
+		return nil, fmt.Errorf("get assignment template by id failed: %w", err)
+	}
+	return &template, nil
+}
+
+// GetByOwnerID 获取教师的模板列表
+func (r *templateRepository) GetByOwnerID(ctx context.Context, ownerID uint, offset, limit int) ([]*model.AssignmentTemplate, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.AssignmentTemplate{}).Where("owner_id = ?", ownerID)
+
+	var total int64
+	if err := db.Count(&total); err != nil {
+		return nil, 0, fmt.Errorf("count assignment templates failed: %w", err)
+	}
+
+	var templates []*model.AssignmentTemplate
+	err := db.Preload("Attachments").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&templates)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get assignment templates failed: %w", err)
+	}
+	return templates, total, nil
+}
+
+// Delete 删除模板，附件记录物理删除，不再计入磁盘文件的引用
+func (r *templateRepository) Delete(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		if err := tx.Exec("DELETE FROM template_attachments WHERE template_id = ?", id); err != nil {
+			return err
+		}
+		return tx.Delete(&model.AssignmentTemplate{}, id)
+	})
+	if err != nil {
+		return fmt.Errorf("delete assignment template failed: %w", err)
+	}
+	return nil
+}
@@ -21,6 +21,12 @@ type UserRepository interface {
 	FindByID(ctx context.Context, id uint) (*model.User, error)
 	// List 获取用户列表
 	List(ctx context.Context) ([]*model.User, error)
+	// FindByCodes 根据学号批量查找用户
+	FindByCodes(ctx context.Context, codes []string) ([]*model.User, error)
+	// FindDeletedCodes 返回已被删除用户占用的学号
+	FindDeletedCodes(ctx context.Context, codes []string) ([]string, error)
+	// SaveRoster 在同一事务中新建或更新用户，并加入班级
+	SaveRoster(ctx context.Context, entries []*model.RosterEntry) error
 }
 
 // userRepository 用户仓储实现
@@ -57,7 +63,7 @@ func (r *userRepository) FindByStudentID(ctx context.Context, studentID string)
 	}
 
 	// 从数据库获取
-	if err := r.db.WithContext(ctx).Where("student_id = ?", studentID).First(&user); err != nil {
+	if err := r.db.WithContext(ctx).Where("code = ?", studentID).First(&user); err != nil {
 		return nil, err
 	}
 
@@ -78,8 +84,8 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 
 	// 删除缓存
 	if r.cache != nil {
-		cacheKey := fmt.Sprintf("user:student_id:%s", user.Code)
-		r.cache.Delete(ctx, cacheKey)
+		r.cache.Delete(ctx, fmt.Sprintf("user:student_id:%s", user.Code))
+		r.cache.Delete(ctx, fmt.Sprintf("user:id:%d", user.ID))
 	}
 
 	return nil
@@ -141,3 +147,110 @@ func (r *userRepository) List(ctx context.Context) ([]*model.User, error) {
 	}
 	return users, nil
 }
+
+// FindByCodes 根据学号批量查找用户
+func (r *userRepository) FindByCodes(ctx context.Context, codes []string) ([]*model.User, error) {
+	var users []*model.User
+	if len(codes) == 0 {
+		return users, nil
+	}
+	if err := r.db.WithContext(ctx).Where("code IN ?", codes).Find(&users); err != nil {
+		return nil, fmt.Errorf("find users by codes failed: %w", err)
+	}
+	return users, nil
+}
+
+// FindDeletedCodes 返回已被删除用户占用的学号
+// 学号唯一索引包含软删除的记录，这些学号无法再新建用户
+func (r *userRepository) FindDeletedCodes(ctx context.Context, codes []string) ([]string, error) {
+	var deleted []string
+	if len(codes) == 0 {
+		return deleted, nil
+	}
+	err := r.db.WithContext(ctx).
+		Raw("SELECT code FROM users WHERE code IN ? AND deleted_at IS NOT NULL", codes).
+		Scan(&deleted)
+	if err != nil {
+		return nil, fmt.Errorf("find deleted user codes failed: %w", err)
+	}
+	return deleted, nil
+}
+
+// SaveRoster 在同一事务中新建或更新用户，并将用户加入班级
+// 学生已退出班级时重新加入，已在班级中的学生和已是任课教师的用户不重复添加
+func (r *userRepository) SaveRoster(ctx context.Context, entries []*model.RosterEntry) error {
+	now := time.Now()
+	err := r.db.WithContext(ctx).Transaction(func(tx DB) error {
+		for _, entry := range entries {
+			if entry.Save {
+				if entry.User.ID == 0 {
+					if err := tx.Create(entry.User); err != nil {
+						return fmt.Errorf("create user %s failed: %w", entry.User.Code, err)
+					}
+				} else if err := tx.Save(entry.User); err != nil {
+					return fmt.Errorf("update user %s failed: %w", entry.User.Code, err)
+				}
+			}
+			if entry.ClassID == 0 {
+				continue
+			}
+
+			if entry.AsTeacher {
+				var count int64
+				err := tx.Model(&model.ClassTeacher{}).
+					Where("class_id = ? AND teacher_id = ?", entry.ClassID, entry.User.ID).
+					Count(&count)
+				if err != nil {
+					return fmt.Errorf("check class teacher failed: %w", err)
+				}
+				if count == 0 {
+					if err := tx.Create(&model.ClassTeacher{ClassID: entry.ClassID, TeacherID: entry.User.ID}); err != nil {
+						return fmt.Errorf("add class teacher failed: %w", err)
+					}
+				}
+				continue
+			}
+
+			var enrollments []*model.ClassEnrollment
+			err := tx.Where("class_id = ? AND student_id = ?", entry.ClassID, entry.User.ID).Find(&enrollments)
+			if err != nil {
+				return fmt.Errorf("get enrollment failed: %w", err)
+			}
+			if len(enrollments) == 0 {
+				err = tx.Create(&model.ClassEnrollment{
+					ClassID:   entry.ClassID,
+					StudentID: entry.User.ID,
+					Status:    model.EnrollmentStatusActive,
+					JoinedAt:  now,
+				})
+				if err != nil {
+					return fmt.Errorf("create enrollment failed: %w", err)
+				}
+				continue
+			}
+			if enrollment := enrollments[0]; !enrollment.IsActive() {
+				enrollment.Status = model.EnrollmentStatusActive
+				enrollment.JoinedAt = now
+				enrollment.LeftAt = nil
+				if err := tx.Save(enrollment); err != nil {
+					return fmt.Errorf("update enrollment failed: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 删除缓存
+	if r.cache != nil {
+		for _, entry := range entries {
+			if entry.Save {
+				r.cache.Delete(ctx, fmt.Sprintf("user:student_id:%s", entry.User.Code))
+				r.cache.Delete(ctx, fmt.Sprintf("user:id:%d", entry.User.ID))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"ai-course/internal/repository"
+	"context"
+	"errors"
+)
+
+var (
+	ErrAccessDenied       = errors.New("无权访问该资源")
+	ErrAssignmentNotFound = errors.New("作业不存在")
+	ErrSubmissionNotFound = errors.New("提交记录不存在")
+	ErrAttachmentNotFound = errors.New("附件不存在")
+	ErrLessonPlanNotFound = errors.New("教案不存在")
+)
+
+// AccessService 资源访问控制服务接口
+// 管理员可访问全部资源；教师只能访问自己任课（含协同授课）班级的资源；
+// 学生只能访问自己的提交以及所在班级已发布的作业和附件
+type AccessService interface {
+	// IsAdmin 检查用户是否为管理员
+	IsAdmin(ctx context.Context, userID uint) bool
+	// IsClassTeacher 检查用户是否可以管理班级（管理员或任课教师）
+	IsClassTeacher(ctx context.Context, classID, userID uint) bool
+	// CheckClassManage 检查用户是否可以管理班级
+	CheckClassManage(ctx context.Context, classID, userID uint) error
+	// CheckAssignmentManage 检查用户是否可以管理作业
+	CheckAssignmentManage(ctx context.Context, assignmentID, userID uint) error
+	// CheckAssignmentView 检查用户是否可以查看作业
+	CheckAssignmentView(ctx context.Context, assignmentID, userID uint) error
+	// CheckSubmissionView 检查用户是否可以查看提交
+	CheckSubmissionView(ctx context.Context, submissionID, userID uint) error
+	// CheckAttachmentManage 检查用户是否可以管理附件
+	CheckAttachmentManage(ctx context.Context, attachmentID, userID uint) error
+	// CheckAttachmentView 检查用户是否可以查看和下载附件
+	CheckAttachmentView(ctx context.Context, attachmentID, userID uint) error
+	// CheckLessonPlanManage 检查用户是否可以管理教案
+	CheckLessonPlanManage(ctx context.Context, planID, userID uint) error
+	// CheckLessonPlanView 检查用户是否可以查看教案
+	CheckLessonPlanView(ctx context.Context, planID, userID uint) error
+}
+
+// accessService 资源访问控制服务实现
+type accessService struct {
+	userRepo       repository.UserRepository
+	classRepo      repository.ClassRepository
+	enrollmentRepo repository.EnrollmentRepository
+	assignmentRepo repository.AssignmentRepository
+	submissionRepo repository.SubmissionRepository
+	attachmentRepo repository.AttachmentRepository
+	lessonPlanRepo repository.LessonPlanRepository
+}
+
+// NewAccessService 创建资源访问控制服务实例
+func NewAccessService(
+	userRepo repository.UserRepository,
+	classRepo repository.ClassRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	assignmentRepo repository.AssignmentRepository,
+	submissionRepo repository.SubmissionRepository,
+	attachmentRepo repository.AttachmentRepository,
+	lessonPlanRepo repository.LessonPlanRepository,
+) AccessService {
+	return &accessService{
+		userRepo:       userRepo,
+		classRepo:      classRepo,
+		enrollmentRepo: enrollmentRepo,
+		assignmentRepo: assignmentRepo,
+		submissionRepo: submissionRepo,
+		attachmentRepo: attachmentRepo,
+		lessonPlanRepo: lessonPlanRepo,
+	}
+}
+
+// IsClassTeacher 检查用户是否可以管理班级（管理员或任课教师）
+func (s *accessService) IsClassTeacher(ctx context.Context, classID, userID uint) bool {
+	if s.IsAdmin(ctx, userID) {
+		return true
+	}
+	ok, err := s.classRepo.IsTeacher(ctx, classID, userID)
+	return err == nil && ok
+}
+
+// CheckClassManage 检查用户是否可以管理班级
+func (s *accessService) CheckClassManage(ctx context.Context, classID, userID uint) error {
+	if _, err := s.classRepo.FindByID(ctx, classID); err != nil {
+		return ErrClassNotFound
+	}
+	if !s.IsClassTeacher(ctx, classID, userID) {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// CheckAssignmentManage 检查用户是否可以管理作业
+func (s *accessService) CheckAssignmentManage(ctx context.Context, assignmentID, userID uint) error {
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return ErrAssignmentNotFound
+	}
+	if !s.IsClassTeacher(ctx, assignment.ClassID, userID) {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// CheckAssignmentView 检查用户是否可以查看作业
+func (s *accessService) CheckAssignmentView(ctx context.Context, assignmentID, userID uint) error {
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return ErrAssignmentNotFound
+	}
+	return s.checkAssignmentMember(ctx, assignment, userID)
+}
+
+// CheckSubmissionView 检查用户是否可以查看提交
+func (s *accessService) CheckSubmissionView(ctx context.Context, submissionID, userID uint) error {
+	submission, err := s.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return ErrSubmissionNotFound
+	}
+	if submission.StudentID == userID {
+		return nil
+	}
+	return s.CheckAssignmentManage(ctx, submission.AssignmentID, userID)
+}
+
+// CheckAttachmentManage 检查用户是否可以管理附件
+func (s *accessService) CheckAttachmentManage(ctx context.Context, attachmentID, userID uint) error {
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return ErrAttachmentNotFound
+	}
+	return s.CheckAssignmentManage(ctx, attachment.AssignmentID, userID)
+}
+
+// CheckAttachmentView 检查用户是否可以查看和下载附件
+func (s *accessService) CheckAttachmentView(ctx context.Context, attachmentID, userID uint) error {
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return ErrAttachmentNotFound
+	}
+	assignment, err := s.assignmentRepo.GetByID(ctx, attachment.AssignmentID)
+	if err != nil {
+		return ErrAssignmentNotFound
+	}
+	return s.checkAssignmentMember(ctx, assignment, userID)
+}
+
+// CheckLessonPlanManage 检查用户是否可以管理教案
+func (s *accessService) CheckLessonPlanManage(ctx context.Context, planID, userID uint) error {
+	plan, err := s.lessonPlanRepo.GetByID(ctx, planID)
+	if err != nil {
+		return ErrLessonPlanNotFound
+	}
+	if !s.IsClassTeacher(ctx, plan.ClassID, userID) {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// CheckLessonPlanView 检查用户是否可以查看教案
+// 任课教师可以查看任意状态的教案，学生只能查看所在班级中已发布的教案
+func (s *accessService) CheckLessonPlanView(ctx context.Context, planID, userID uint) error {
+	plan, err := s.lessonPlanRepo.GetByID(ctx, planID)
+	if err != nil {
+		return ErrLessonPlanNotFound
+	}
+	if s.IsClassTeacher(ctx, plan.ClassID, userID) {
+		return nil
+	}
+	if !plan.IsPublished() {
+		return ErrAccessDenied
+	}
+
+	enrolled, err := s.enrollmentRepo.IsActiveMember(ctx, plan.ClassID, userID)
+	if err != nil {
+		return err
+	}
+	if !enrolled {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// checkAssignmentMember 检查用户是否为作业所属班级的成员
+// 任课教师可以访问任意状态的作业，学生只能访问所在班级中已发布的作业
+func (s *accessService) checkAssignmentMember(ctx context.Context, assignment *model.Assignment, userID uint) error {
+	if s.IsClassTeacher(ctx, assignment.ClassID, userID) {
+		return nil
+	}
+	if assignment.Status == "draft" {
+		return ErrAccessDenied
+	}
+
+	enrolled, err := s.enrollmentRepo.IsActiveMember(ctx, assignment.ClassID, userID)
+	if err != nil {
+		return err
+	}
+	if !enrolled {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// IsAdmin 检查用户是否为管理员
+func (s *accessService) IsAdmin(ctx context.Context, userID uint) bool {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	return err == nil && user.RoleId == model.RoleAdmin
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"ai-course/internal/config"
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/pkg/llm"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+const (
+	AIProviderOpenAI = "openai" // OpenAI 兼容接口
+	AIProviderLocal  = "local"  // 本地规则（确定性，无需联网）
+
+	localGraderModel = "local-keyword"
+)
+
+// AIGradeRequest AI 批改请求
+type AIGradeRequest struct {
+	Question    string // 题目内容
+	Reference   string // 参考答案
+	Explanation string // 题目解析
+	Answer      string // 学生答案
+	MaxScore    int    // 题目分值
+}
+
+// AIGradeResult AI 批改结果
+type AIGradeResult struct {
+	Score      int     // 建议得分
+	Feedback   string  // 建议评语
+	Confidence float64 // 置信度（0-1）
+	Model      string  // 模型名称
+}
+
+// AIGrader AI 批改接口
+type AIGrader interface {
+	// Grade 为主观题答案给出建议得分和评语
+	Grade(ctx context.Context, req *AIGradeRequest) (*AIGradeResult, error)
+}
+
+// NewAIGrader 根据配置创建 AI 批改器，未配置或配置不完整时使用本地规则批改
+func NewAIGrader(cfg *config.Config) AIGrader {
+	if client := newLLMClient(cfg); client != nil {
+		return NewLLMGrader(client)
+	}
+	return NewLocalGrader()
+}
+
+// newLLMClient 根据配置创建大模型客户端，未启用 OpenAI 兼容接口时返回 nil
+func newLLMClient(cfg *config.Config) llm.Client {
+	if cfg == nil || strings.ToLower(cfg.AI.Provider) != AIProviderOpenAI || cfg.AI.BaseURL == "" {
+		return nil
+	}
+	return llm.NewOpenAIClient(
+		cfg.AI.BaseURL,
+		cfg.AI.APIKey,
+		cfg.AI.Model,
+		cfg.AI.Temperature,
+		time.Duration(cfg.AI.Timeout)*time.Second,
+	)
+}
+
+// llmGrader 基于大模型的批改实现
+type llmGrader struct {
+	client llm.Client
+}
+
+// NewLLMGrader 创建基于大模型的批改器
+func NewLLMGrader(client llm.Client) AIGrader {
+	return &llmGrader{client: client}
+}
+
+// llmGradeOutput 大模型批改输出格式
+type llmGradeOutput struct {
+	Score      float64 `json:"score"`
+	Feedback   string  `json:"feedback"`
+	Confidence float64 `json:"confidence"`
+}
+
+const gradePrompt = `你是一名严谨的阅卷老师。请根据题目、参考答案和解析，对学生答案评分。
+要求：
+1. 得分为 0 到 %d 之间的整数；
+2. 评语使用中文，指出答案的优点和不足，不超过 200 字；
+3. confidence 为 0 到 1 之间的小数，表示你对评分的把握程度；
+4. 只输出 JSON，格式为 {"score": 整数, "feedback": "评语", "confidence": 小数}。`
+
+// Grade 为主观题答案给出建议得分和评语
+func (g *llmGrader) Grade(ctx context.Context, req *AIGradeRequest) (*AIGradeResult, error) {
+	var user strings.Builder
+	fmt.Fprintf(&user, "【题目】\n%s\n\n", req.Question)
+	if req.Reference != "" {
+		fmt.Fprintf(&user, "【参考答案】\n%s\n\n", req.Reference)
+	}
+	if req.Explanation != "" {
+		fmt.Fprintf(&user, "【解析】\n%s\n\n", req.Explanation)
+	}
+	fmt.Fprintf(&user, "【满分】%d\n\n【学生答案】\n%s", req.MaxScore, req.Answer)
+
+	content, err := g.client.Chat(ctx, []llm.Message{
+		{Role: llm.RoleSystem, Content: fmt.Sprintf(gradePrompt, req.MaxScore)},
+		{Role: llm.RoleUser, Content: user.String()},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var output llmGradeOutput
+	if err := json.Unmarshal([]byte(llm.ExtractJSON(content)), &output); err != nil {
+		return nil, fmt.Errorf("parse grading result failed: %w", err)
+	}
+
+	return &AIGradeResult{
+		Score:      clampInt(int(math.Round(output.Score)), 0, req.MaxScore),
+		Feedback:   strings.TrimSpace(output.Feedback),
+		Confidence: math.Max(0, math.Min(1, output.Confidence)),
+		Model:      g.client.Model(),
+	}, nil
+}
+
+// localGrader 本地规则批改实现
+// 按学生答案对参考答案关键词的覆盖率给分，结果确定，适合开发测试和无法访问大模型的环境
+type localGrader struct{}
+
+// NewLocalGrader 创建本地规则批改器
+func NewLocalGrader() AIGrader {
+	return &localGrader{}
+}
+
+// Grade 为主观题答案给出建议得分和评语
+func (g *localGrader) Grade(ctx context.Context, req *AIGradeRequest) (*AIGradeResult, error) {
+	if strings.TrimSpace(req.Answer) == "" {
+		return &AIGradeResult{
+			Score:      0,
+			Feedback:   "未作答。",
+			Confidence: 1,
+			Model:      localGraderModel,
+		}, nil
+	}
+
+	// 以参考答案为评分依据，没有参考答案时退而使用解析
+	reference := req.Reference
+	if strings.TrimSpace(reference) == "" {
+		reference = req.Explanation
+	}
+
+	keywords := extractKeywords(reference)
+	if len(keywords) == 0 {
+		return &AIGradeResult{
+			Score:      0,
+			Feedback:   "题目缺少参考答案，无法给出建议，请人工批改。",
+			Confidence: 0,
+			Model:      localGraderModel,
+		}, nil
+	}
+
+	answerKeywords := make(map[string]bool)
+	for _, keyword := range extractKeywords(req.Answer) {
+		answerKeywords[keyword] = true
+	}
+
+	hit := 0
+	for _, keyword := range keywords {
+		if answerKeywords[keyword] {
+			hit++
+		}
+	}
+
+	coverage := float64(hit) / float64(len(keywords))
+	score := clampInt(int(math.Round(coverage*float64(req.MaxScore))), 0, req.MaxScore)
+
+	// 找出学生答案基本没有涉及的参考要点
+	var missing []string
+	for _, point := range splitPoints(reference) {
+		if keywordCoverage(extractKeywords(point), answerKeywords) < 0.5 {
+			missing = append(missing, point)
+		}
+	}
+
+	feedback := fmt.Sprintf("答案覆盖了参考要点的 %.0f%%。", coverage*100)
+	if len(missing) > 0 {
+		if len(missing) > 3 {
+			missing = missing[:3]
+		}
+		feedback += fmt.Sprintf("未涉及的要点：%s。", strings.Join(missing, "；"))
+	}
+
+	return &AIGradeResult{
+		Score:      score,
+		Feedback:   feedback,
+		Confidence: 0.5, // 关键词匹配无法理解语义，置信度固定为中等
+		Model:      localGraderModel,
+	}, nil
+}
+
+// extractKeywords 提取文本关键词（去重并保持顺序）
+// 英文和数字按单词切分，中文按相邻两字切分
+func extractKeywords(text string) []string {
+	seen := make(map[string]bool)
+	var keywords []string
+	add := func(keyword string) {
+		if keyword != "" && !seen[keyword] {
+			seen[keyword] = true
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		if len(word) > 1 {
+			add(strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			add(string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			add(string(han[i : i+2]))
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+
+	return keywords
+}
+
+// splitPoints 将参考答案按标点切分为要点
+func splitPoints(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return strings.ContainsRune("，。；！？,.;!?\n、", r)
+	})
+
+	points := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			points = append(points, field)
+		}
+	}
+	return points
+}
+
+// keywordCoverage 计算关键词在答案中的覆盖率
+func keywordCoverage(keywords []string, answerKeywords map[string]bool) float64 {
+	if len(keywords) == 0 {
+		return 1
+	}
+	hit := 0
+	for _, keyword := range keywords {
+		if answerKeywords[keyword] {
+			hit++
+		}
+	}
+	return float64(hit) / float64(len(keywords))
+}
+
+// clampInt 将整数限制在区间内
+func clampInt(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// suggestAnswerGrade 为主观题答案生成 AI 批改建议并写入答案草稿字段（不修改正式得分）
+func suggestAnswerGrade(ctx context.Context, grader AIGrader, question *model.Question, answer *model.Answer) error {
+	result, err := grader.Grade(ctx, &AIGradeRequest{
+		Question:    question.Content,
+		Reference:   question.Reference,
+		Explanation: question.Explanation,
+		Answer:      answer.Content,
+		MaxScore:    question.Score,
+	})
+	if err != nil {
+		logger.Logger.Warn("AI grading failed",
+			zap.Error(err),
+			zap.Uint("answer_id", answer.ID),
+			zap.Uint("question_id", question.ID),
+		)
+		return err
+	}
+
+	now := time.Now()
+	answer.AIScore = &result.Score
+	answer.AIFeedback = result.Feedback
+	answer.AIConfidence = &result.Confidence
+	answer.AIModel = result.Model
+	answer.AISuggestedAt = &now
+	return nil
+}
@@ -5,8 +5,17 @@ import (
 	"ai-course/internal/repository"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLatePolicyInvalid    = errors.New("late policy invalid")
+	ErrRandomizationInvalid = errors.New("randomization settings invalid")
+	ErrScheduleInvalid      = errors.New("assignment schedule invalid")
 )
 
 // AssignmentService 作业服务接口
@@ -16,7 +25,8 @@ type AssignmentService interface {
 	UpdateAssignment(ctx context.Context, id uint, req *model.UpdateAssignmentRequest, teacherID uint) (*model.Assignment, error)
 	DeleteAssignment(ctx context.Context, id uint, teacherID uint) error
 	GetAssignment(ctx context.Context, id uint) (*model.Assignment, error)
-	GetAssignmentDetail(ctx context.Context, id uint) (*model.AssignmentDetailResponse, error)
+	// GetAssignmentDetail 获取作业详情，非任课教师（学生）看到的题目不包含答案和解析，学生按公布时机在作答详情中查看答案
+	GetAssignmentDetail(ctx context.Context, id uint, userID uint) (*model.AssignmentDetailResponse, error)
 	
 	// 作业列表
 	GetTeacherAssignments(ctx context.Context, teacherID uint, page, pageSize int) ([]*model.AssignmentListResponse, int64, error)
@@ -35,6 +45,8 @@ type assignmentService struct {
 	assignmentRepo repository.AssignmentRepository
 	questionRepo   repository.QuestionRepository
 	classRepo      repository.ClassRepository
+	submissionRepo repository.SubmissionRepository
+	access         AccessService
 }
 
 // NewAssignmentService 创建作业服务实例
@@ -42,11 +54,15 @@ func NewAssignmentService(
 	assignmentRepo repository.AssignmentRepository,
 	questionRepo repository.QuestionRepository,
 	classRepo repository.ClassRepository,
+	submissionRepo repository.SubmissionRepository,
+	access AccessService,
 ) AssignmentService {
 	return &assignmentService{
 		assignmentRepo: assignmentRepo,
 		questionRepo:   questionRepo,
 		classRepo:      classRepo,
+		submissionRepo: submissionRepo,
+		access:         access,
 	}
 }
 
@@ -58,10 +74,19 @@ func (s *assignmentService) CreateAssignment(ctx context.Context, req *model.Cre
 		return nil, fmt.Errorf("class not found: %w", err)
 	}
 	
-	if class.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, class.ID, teacherID) {
 		return nil, fmt.Errorf("teacher has no permission to create assignment for this class")
 	}
 	
+	latePolicy, err := marshalLatePolicy(req.LatePolicy, req.Deadline)
+	if err != nil {
+		return nil, err
+	}
+	randomization, err := marshalRandomization(req.Randomization)
+	if err != nil {
+		return nil, err
+	}
+	
 	// 创建作业
 	assignment := &model.Assignment{
 		Title:       req.Title,
@@ -71,7 +96,30 @@ func (s *assignmentService) CreateAssignment(ctx context.Context, req *model.Cre
 		Deadline:    req.Deadline,
 		TotalScore:  req.TotalScore,
 		Status:      req.Status,
+		LatePolicy:  latePolicy,
+		Randomization: randomization,
+		AnswerReveal: model.AnswerRevealAfterPublished,
+		PublishAt:   req.PublishAt,
+		CloseAt:     req.CloseAt,
+		AutoSubmitOnClose: req.AutoSubmitOnClose,
+	}
+	if req.AnswerReveal != "" {
+		assignment.AnswerReveal = req.AnswerReveal
 	}
+	if err := checkSchedule(assignment); err != nil {
+		return nil, err
+	}
+	
+	// 默认只能作答一次，多次作答时默认取最高分
+	assignment.MaxAttempts = 1
+	assignment.AttemptScoring = model.AttemptScoringHighest
+	if req.MaxAttempts != nil {
+		assignment.MaxAttempts = *req.MaxAttempts
+	}
+	if req.AttemptScoring != "" {
+		assignment.AttemptScoring = req.AttemptScoring
+	}
+	assignment.TimeLimitMinutes = req.TimeLimitMinutes
 	
 	if req.Status == "published" {
 		now := time.Now()
@@ -84,25 +132,10 @@ func (s *assignmentService) CreateAssignment(ctx context.Context, req *model.Cre
 	
 	// 创建题目
 	if len(req.Questions) > 0 {
-		for i, questionReq := range req.Questions {
-			question := &model.Question{
-				AssignmentID:  assignment.ID,
-				Type:          questionReq.Type,
-				Content:       questionReq.Content,
-				Score:         questionReq.Score,
-				Order:         questionReq.Order,
-				CorrectAnswer: questionReq.CorrectAnswer,
-				Reference:     questionReq.Reference,
-				Explanation:   questionReq.Explanation,
-			}
-			
-			// 处理选择题选项
-			if questionReq.Type == model.QuestionTypeChoice && len(questionReq.Options) > 0 {
-				optionsJSON, err := json.Marshal(questionReq.Options)
-				if err != nil {
-					return nil, fmt.Errorf("marshal question options failed: %w", err)
-				}
-				question.Options = string(optionsJSON)
+		for i := range req.Questions {
+			question, err := newQuestionFromRequest(&req.Questions[i], assignment.ID)
+			if err != nil {
+				return nil, fmt.Errorf("create question %d failed: %w", i+1, err)
 			}
 			
 			if err := s.questionRepo.Create(ctx, question); err != nil {
@@ -122,7 +155,7 @@ func (s *assignmentService) UpdateAssignment(ctx context.Context, id uint, req *
 		return nil, fmt.Errorf("assignment not found: %w", err)
 	}
 	
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		return nil, fmt.Errorf("teacher has no permission to update this assignment")
 	}
 	
@@ -147,6 +180,54 @@ func (s *assignmentService) UpdateAssignment(ctx context.Context, id uint, req *
 	if req.TotalScore > 0 {
 		assignment.TotalScore = req.TotalScore
 	}
+	if req.LatePolicy != nil {
+		latePolicy, err := marshalLatePolicy(req.LatePolicy, assignment.Deadline)
+		if err != nil {
+			return nil, err
+		}
+		assignment.LatePolicy = latePolicy
+	} else if !req.Deadline.IsZero() && assignment.LatePolicy != "" {
+		// 只修改截止时间时，原有的最晚提交时间仍需晚于新的截止时间
+		latePolicy, err := assignment.GetLatePolicy()
+		if err != nil {
+			return nil, fmt.Errorf("parse late policy failed: %w", err)
+		}
+		if _, err := marshalLatePolicy(latePolicy, assignment.Deadline); err != nil {
+			return nil, err
+		}
+	}
+	if req.MaxAttempts != nil {
+		assignment.MaxAttempts = *req.MaxAttempts
+	}
+	if req.AttemptScoring != "" {
+		assignment.AttemptScoring = req.AttemptScoring
+	}
+	if req.TimeLimitMinutes != nil {
+		assignment.TimeLimitMinutes = *req.TimeLimitMinutes
+	}
+	if req.Randomization != nil {
+		randomization, err := marshalRandomization(req.Randomization)
+		if err != nil {
+			return nil, err
+		}
+		assignment.Randomization = randomization
+	}
+	if req.AnswerReveal != "" {
+		assignment.AnswerReveal = req.AnswerReveal
+	}
+	if req.ClearSchedule {
+		assignment.PublishAt = nil
+		assignment.CloseAt = nil
+	}
+	if req.PublishAt != nil {
+		assignment.PublishAt = req.PublishAt
+	}
+	if req.CloseAt != nil {
+		assignment.CloseAt = req.CloseAt
+	}
+	if req.AutoSubmitOnClose != nil {
+		assignment.AutoSubmitOnClose = *req.AutoSubmitOnClose
+	}
 	if req.Status != "" {
 		assignment.Status = req.Status
 		if req.Status == "published" && assignment.PublishedAt == nil {
@@ -154,6 +235,9 @@ func (s *assignmentService) UpdateAssignment(ctx context.Context, id uint, req *
 			assignment.PublishedAt = &now
 		}
 	}
+	if err := checkSchedule(assignment); err != nil {
+		return nil, err
+	}
 	
 	if err := s.assignmentRepo.Update(ctx, assignment); err != nil {
 		return nil, fmt.Errorf("update assignment failed: %w", err)
@@ -162,6 +246,60 @@ func (s *assignmentService) UpdateAssignment(ctx context.Context, id uint, req *
 	return assignment, nil
 }
 
+// marshalLatePolicy 校验迟交策略并序列化，最晚提交时间必须晚于截止时间
+func marshalLatePolicy(policy *model.LatePolicy, deadline time.Time) (string, error) {
+	if policy == nil {
+		return "", nil
+	}
+	if policy.Mode == "" {
+		policy.Mode = model.LatePolicyDisallow
+	}
+	if policy.Mode == model.LatePolicyAllow && policy.Cutoff != nil && !policy.Cutoff.After(deadline) {
+		return "", fmt.Errorf("%w: cutoff must be after deadline", ErrLatePolicyInvalid)
+	}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return "", fmt.Errorf("marshal late policy failed: %w", err)
+	}
+	return string(data), nil
+}
+
+// checkSchedule 校验定时发布和自动关闭时间，已发布的作业不再需要定时发布
+func checkSchedule(assignment *model.Assignment) error {
+	if assignment.Status != "draft" {
+		assignment.PublishAt = nil
+	}
+	if assignment.CloseAt == nil {
+		return nil
+	}
+	if assignment.CloseAt.Before(assignment.Deadline) {
+		return fmt.Errorf("%w: close time must not be before deadline", ErrScheduleInvalid)
+	}
+	if assignment.PublishAt != nil && !assignment.CloseAt.After(*assignment.PublishAt) {
+		return fmt.Errorf("%w: close time must be after publish time", ErrScheduleInvalid)
+	}
+	return nil
+}
+
+// marshalRandomization 校验随机组卷设置并序列化，同一题目池只能设置一次，未启用任何随机时不保存
+func marshalRandomization(settings *model.RandomizationSettings) (string, error) {
+	if settings == nil || !settings.IsEnabled() {
+		return "", nil
+	}
+	pools := make(map[string]bool, len(settings.Pools))
+	for _, draw := range settings.Pools {
+		if pools[draw.Pool] {
+			return "", fmt.Errorf("%w: duplicate pool %s", ErrRandomizationInvalid, draw.Pool)
+		}
+		pools[draw.Pool] = true
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return "", fmt.Errorf("marshal randomization settings failed: %w", err)
+	}
+	return string(data), nil
+}
+
 // DeleteAssignment 删除作业
 func (s *assignmentService) DeleteAssignment(ctx context.Context, id uint, teacherID uint) error {
 	// 获取作业并验证权限
@@ -170,7 +308,7 @@ func (s *assignmentService) DeleteAssignment(ctx context.Context, id uint, teach
 		return fmt.Errorf("assignment not found: %w", err)
 	}
 	
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		return fmt.Errorf("teacher has no permission to delete this assignment")
 	}
 	
@@ -193,29 +331,35 @@ func (s *assignmentService) GetAssignment(ctx context.Context, id uint) (*model.
 }
 
 // GetAssignmentDetail 获取作业详情
-func (s *assignmentService) GetAssignmentDetail(ctx context.Context, id uint) (*model.AssignmentDetailResponse, error) {
+func (s *assignmentService) GetAssignmentDetail(ctx context.Context, id uint, userID uint) (*model.AssignmentDetailResponse, error) {
 	assignment, err := s.assignmentRepo.GetDetailByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("get assignment detail failed: %w", err)
 	}
 	
-	// 转换题目格式
-	questions := make([]model.QuestionDetailResponse, len(assignment.Questions))
-	for i, q := range assignment.Questions {
-		questionDetail := model.QuestionDetailResponse{
-			Question: q,
+	// 转换题目格式，学生只能看到自己试卷中的题目（随机组卷时为抽中的题目和打乱后的选项），不包含答案
+	var questions []model.QuestionDetailResponse
+	withheld := false
+	if s.access.IsClassTeacher(ctx, assignment.ClassID, userID) {
+		questions = make([]model.QuestionDetailResponse, len(assignment.Questions))
+		for i, q := range assignment.Questions {
+			questions[i] = newQuestionDetail(q)
 		}
-		
-		// 处理选择题选项
-		if q.Type == model.QuestionTypeChoice && q.Options != "" {
-			var options []model.QuestionOption
-			if err := json.Unmarshal([]byte(q.Options), &options); err == nil {
-				questionDetail.OptionList = options
-			}
+	} else {
+		submission, err := s.submissionRepo.GetByAssignmentAndStudent(ctx, id, userID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("get submission failed: %w", err)
+		}
+		layoutSource, err := studentLayoutSource(assignment, submission, userID)
+		if err != nil {
+			return nil, err
+		}
+		questions, withheld, err = studentQuestionDetails(assignment, assignment.Questions, layoutSource, false)
+		if err != nil {
+			return nil, err
 		}
-		
-		questions[i] = questionDetail
 	}
+	assignment.Questions = nil
 	
 	// 获取统计信息
 	stats, err := s.assignmentRepo.GetSubmissionStats(ctx, id)
@@ -226,6 +370,7 @@ func (s *assignmentService) GetAssignmentDetail(ctx context.Context, id uint) (*
 	return &model.AssignmentDetailResponse{
 		Assignment:  *assignment,
 		Questions:   questions,
+		QuestionsWithheld: withheld,
 		Attachments: assignment.Attachments,
 		Statistics:  *stats,
 	}, nil
@@ -268,9 +413,29 @@ func (s *assignmentService) GetTeacherAssignments(ctx context.Context, teacherID
 
 // GetStudentAssignments 获取学生作业列表
 func (s *assignmentService) GetStudentAssignments(ctx context.Context, studentID uint, page, pageSize int) ([]*model.StudentAssignmentResponse, int64, error) {
-	// TODO: 实现学生作业列表获取逻辑
-	// 这里需要根据学生所在班级获取相关作业，并包含提交状态
-	return []*model.StudentAssignmentResponse{}, 0, nil
+	offset := (page - 1) * pageSize
+	assignments, total, err := s.assignmentRepo.GetByStudentID(ctx, studentID, offset, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get student assignments failed: %w", err)
+	}
+	
+	result := make([]*model.StudentAssignmentResponse, len(assignments))
+	for i, assignment := range assignments {
+		// 附带学生的提交状态（未提交时为空）
+		submission, err := s.submissionRepo.GetByAssignmentAndStudent(ctx, assignment.ID, studentID)
+		if err != nil {
+			submission = nil
+		}
+		
+		result[i] = &model.StudentAssignmentResponse{
+			Assignment:  *assignment,
+			Submission:  submission,
+			Questions:   []model.QuestionDetailResponse{},
+			Attachments: []model.Attachment{},
+		}
+	}
+	
+	return result, total, nil
 }
 
 // PublishAssignment 发布作业
@@ -280,7 +445,7 @@ func (s *assignmentService) PublishAssignment(ctx context.Context, id uint, teac
 		return fmt.Errorf("assignment not found: %w", err)
 	}
 	
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		return fmt.Errorf("teacher has no permission to publish this assignment")
 	}
 	
@@ -291,6 +456,7 @@ func (s *assignmentService) PublishAssignment(ctx context.Context, id uint, teac
 	assignment.Status = "published"
 	now := time.Now()
 	assignment.PublishedAt = &now
+	assignment.PublishAt = nil // 手动发布后取消定时发布
 	
 	return s.assignmentRepo.Update(ctx, assignment)
 }
@@ -302,7 +468,7 @@ func (s *assignmentService) UnpublishAssignment(ctx context.Context, id uint, te
 		return fmt.Errorf("assignment not found: %w", err)
 	}
 	
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		return fmt.Errorf("teacher has no permission to unpublish this assignment")
 	}
 	
@@ -326,7 +492,7 @@ func (s *assignmentService) GetAssignmentStatistics(ctx context.Context, id uint
 		return nil, fmt.Errorf("assignment not found: %w", err)
 	}
 	
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		return nil, fmt.Errorf("teacher has no permission to view statistics for this assignment")
 	}
 	
@@ -5,6 +5,8 @@ import (
 	"ai-course/internal/model"
 	"ai-course/internal/repository"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -35,6 +37,7 @@ type AttachmentService interface {
 type attachmentService struct {
 	attachmentRepo repository.AttachmentRepository
 	assignmentRepo repository.AssignmentRepository
+	access         AccessService
 	uploadPath     string
 }
 
@@ -42,6 +45,7 @@ type attachmentService struct {
 func NewAttachmentService(
 	attachmentRepo repository.AttachmentRepository,
 	assignmentRepo repository.AssignmentRepository,
+	access AccessService,
 ) AttachmentService {
 	// 创建上传目录
 	uploadPath := "./uploads/attachments"
@@ -52,6 +56,7 @@ func NewAttachmentService(
 	return &attachmentService{
 		attachmentRepo: attachmentRepo,
 		assignmentRepo: assignmentRepo,
+		access:         access,
 		uploadPath:     uploadPath,
 	}
 }
@@ -68,8 +73,8 @@ func (s *attachmentService) UploadFile(ctx context.Context, file *multipart.File
 		return nil, errors.New("assignment not found")
 	}
 
-	// 验证用户权限（只有作业创建者可以上传附件）
-	if assignment.TeacherID != uploaderID {
+	// 验证用户权限（只有班级任课教师可以上传附件）
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, uploaderID) {
 		logger.Logger.Warn("User has no permission to upload file to assignment",
 			zap.Uint("assignment_id", assignmentID),
 			zap.Uint("uploader_id", uploaderID),
@@ -88,12 +93,7 @@ func (s *attachmentService) UploadFile(ctx context.Context, file *multipart.File
 		return nil, errors.New("file size exceeds limit (10MB)")
 	}
 
-	// 生成唯一文件名
-	ext := filepath.Ext(file.Filename)
-	fileName := fmt.Sprintf("%d_%d_%d%s", assignmentID, uploaderID, time.Now().Unix(), ext)
-	filePath := filepath.Join(s.uploadPath, fileName)
-
-	// 保存文件
+	// 按文件内容保存，内容相同的附件共用同一文件
 	src, err := file.Open()
 	if err != nil {
 		logger.Logger.Error("Failed to open uploaded file",
@@ -104,25 +104,15 @@ func (s *attachmentService) UploadFile(ctx context.Context, file *multipart.File
 	}
 	defer src.Close()
 
-	dst, err := os.Create(filePath)
+	filePath, contentHash, tmpPath, created, err := s.storeFile(src, filepath.Ext(file.Filename))
 	if err != nil {
-		logger.Logger.Error("Failed to create file",
-			zap.Error(err),
-			zap.String("filepath", filePath),
-		)
-		return nil, fmt.Errorf("failed to create file: %w", err)
-	}
-	defer dst.Close()
-
-	if _, err := io.Copy(dst, src); err != nil {
 		logger.Logger.Error("Failed to save file",
 			zap.Error(err),
-			zap.String("filepath", filePath),
+			zap.String("filename", file.Filename),
 		)
-		// 清理失败的文件
-		os.Remove(filePath)
 		return nil, fmt.Errorf("failed to save file: %w", err)
 	}
+	defer os.Remove(tmpPath)
 
 	// 创建附件记录
 	attachment := &model.Attachment{
@@ -132,6 +122,7 @@ func (s *attachmentService) UploadFile(ctx context.Context, file *multipart.File
 		FilePath:     filePath,
 		FileSize:     file.Size,
 		ContentType:  file.Header.Get("Content-Type"),
+		ContentHash:  contentHash,
 	}
 
 	err = s.attachmentRepo.Create(ctx, attachment)
@@ -140,11 +131,22 @@ func (s *attachmentService) UploadFile(ctx context.Context, file *multipart.File
 			zap.Error(err),
 			zap.String("filename", file.Filename),
 		)
-		// 清理文件
-		os.Remove(filePath)
+		// 清理本次新建的文件
+		if created {
+			removeUnreferencedFile(ctx, s.attachmentRepo, filePath)
+		}
 		return nil, fmt.Errorf("failed to create attachment record: %w", err)
 	}
 
+	// 写入记录前文件可能已被并发删除的最后一个引用清理，此时用临时副本恢复
+	if err := restoreFile(tmpPath, filePath); err != nil {
+		logger.Logger.Error("Failed to restore attachment file",
+			zap.Error(err),
+			zap.String("filepath", filePath),
+		)
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
 	logger.Logger.Info("File uploaded successfully",
 		zap.Uint("attachment_id", attachment.ID),
 		zap.String("filename", file.Filename),
@@ -216,8 +218,8 @@ func (s *attachmentService) DeleteFile(ctx context.Context, id, userID uint) err
 		return errors.New("attachment not found")
 	}
 
-	// 验证权限（只有上传者可以删除）
-	if attachment.UploaderID != userID {
+	// 验证权限（上传者或班级任课教师可以删除）
+	if attachment.UploaderID != userID && s.access.CheckAssignmentManage(ctx, attachment.AssignmentID, userID) != nil {
 		logger.Logger.Warn("User has no permission to delete attachment",
 			zap.Uint("attachment_id", id),
 			zap.Uint("user_id", userID),
@@ -236,14 +238,8 @@ func (s *attachmentService) DeleteFile(ctx context.Context, id, userID uint) err
 		return err
 	}
 
-	// 删除文件
-	if err := os.Remove(attachment.FilePath); err != nil {
-		logger.Logger.Warn("Failed to delete file from disk",
-			zap.Error(err),
-			zap.String("filepath", attachment.FilePath),
-		)
-		// 不返回错误，因为数据库记录已删除
-	}
+	// 没有其他附件引用时删除文件
+	removeUnreferencedFile(ctx, s.attachmentRepo, attachment.FilePath)
 
 	logger.Logger.Info("Attachment deleted successfully",
 		zap.Uint("attachment_id", id),
@@ -253,6 +249,94 @@ func (s *attachmentService) DeleteFile(ctx context.Context, id, userID uint) err
 	return nil
 }
 
+// storeFile 以文件内容的 SHA-256 命名保存文件，同名文件已存在时直接复用
+// 返回文件路径、内容哈希、临时副本路径以及文件是否为本次新建
+// 临时副本与文件内容相同，供写入附件记录后恢复被并发删除的文件，由调用方删除
+func (s *attachmentService) storeFile(src io.Reader, ext string) (string, string, string, bool, error) {
+	tmp, err := os.CreateTemp(s.uploadPath, "upload-*")
+	if err != nil {
+		return "", "", "", false, err
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", "", "", false, err
+	}
+
+	contentHash := hex.EncodeToString(hash.Sum(nil))
+	filePath := filepath.Join(s.uploadPath, contentHash+strings.ToLower(ext))
+	if err := os.Link(tmp.Name(), filePath); err != nil {
+		if os.IsExist(err) {
+			return filePath, contentHash, tmp.Name(), false, nil
+		}
+		os.Remove(tmp.Name())
+		return "", "", "", false, err
+	}
+	return filePath, contentHash, tmp.Name(), true, nil
+}
+
+// restoreFile 文件不存在时从副本重新创建
+func restoreFile(copyPath, filePath string) error {
+	if err := os.Link(copyPath, filePath); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+// removeUnreferencedFile 在没有作业附件或模板附件引用时删除磁盘文件
+// 文件先移到待删除路径再重新统计引用，期间有并发上传写入了引用同一文件的记录时恢复文件；
+// 在此之后写入记录的上传会发现文件不存在并用自己的临时副本恢复
+// 删除失败只记录日志，数据库记录已删除
+func removeUnreferencedFile(ctx context.Context, attachmentRepo repository.AttachmentRepository, filePath string) {
+	count, err := attachmentRepo.CountByFilePath(ctx, filePath)
+	if err != nil {
+		logger.Logger.Warn("Failed to count file references",
+			zap.Error(err),
+			zap.String("filepath", filePath),
+		)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	removing := fmt.Sprintf("%s.%d.removing", filePath, time.Now().UnixNano())
+	if err := os.Rename(filePath, removing); err != nil {
+		if !os.IsNotExist(err) {
+			logger.Logger.Warn("Failed to delete file from disk",
+				zap.Error(err),
+				zap.String("filepath", filePath),
+			)
+		}
+		return
+	}
+	defer func() {
+		if err := os.Remove(removing); err != nil {
+			logger.Logger.Warn("Failed to delete file from disk",
+				zap.Error(err),
+				zap.String("filepath", removing),
+			)
+		}
+	}()
+
+	count, err = attachmentRepo.CountByFilePath(ctx, filePath)
+	if err == nil && count == 0 {
+		return
+	}
+	// 重新统计失败时保守地保留文件
+	if err := restoreFile(removing, filePath); err != nil {
+		logger.Logger.Error("Failed to restore referenced file",
+			zap.Error(err),
+			zap.String("filepath", filePath),
+		)
+	}
+}
+
 // isAllowedFileType 检查文件类型是否允许
 func (s *attachmentService) isAllowedFileType(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
@@ -4,6 +4,7 @@ import (
 	"ai-course/internal/model"
 	"ai-course/internal/pkg/pagination"
 	"ai-course/internal/repository"
+	"ai-course/internal/utils"
 	"context"
 	"errors"
 )
@@ -15,6 +16,9 @@ var (
 	ErrInvalidClassName = errors.New("无效的班级名称")
 )
 
+// classCodeLength 班级邀请码长度
+const classCodeLength = 8
+
 // CreateClassDTO 创建班级的数据传输对象
 type CreateClassDTO struct {
 	Name        string `json:"name" binding:"required"`       // 班级名称
@@ -85,9 +89,16 @@ func (s *classService) Create(ctx context.Context, dto *CreateClassDTO) error {
 		return err
 	}
 
+	// 生成班级邀请码
+	code, err := s.generateClassCode(ctx)
+	if err != nil {
+		return err
+	}
+
 	// 创建班级实体
 	class := &model.Class{
 		ClassName:   dto.Name,
+		Code:        code,
 		Description: dto.Description,
 		TeacherID:   dto.TeacherID,
 	}
@@ -112,8 +123,17 @@ func (s *classService) Update(ctx context.Context, dto *UpdateClassDTO) error {
 		return ErrClassNotFound
 	}
 
+	// 如果班级代码发生变化，检查新代码是否已存在
+	if existing.Code != dto.Code {
+		byCode, err := s.classRepo.FindByCode(ctx, dto.Code)
+		if err == nil && byCode != nil && byCode.ID != dto.ID {
+			return ErrClassCodeExists
+		}
+	}
+
 	// 更新班级信息
 	existing.ClassName = dto.Name
+	existing.Code = dto.Code
 	existing.Description = dto.Description
 	existing.TeacherID = dto.TeacherID
 
@@ -134,7 +154,7 @@ func (s *classService) Get(ctx context.Context, id uint) (*ClassResponse, error)
 	if class == nil {
 		return nil, ErrClassNotFound
 	}
-	return s.toClassResponse(class), nil
+	return toClassResponse(class), nil
 }
 
 // GetByCode 根据班级代码获取班级信息
@@ -146,7 +166,7 @@ func (s *classService) GetByCode(ctx context.Context, code string) (*ClassRespon
 	if class == nil {
 		return nil, ErrClassNotFound
 	}
-	return s.toClassResponse(class), nil
+	return toClassResponse(class), nil
 }
 
 // List 获取班级列表
@@ -163,7 +183,7 @@ func (s *classService) List(ctx context.Context, req *ClassListRequest) (*ClassL
 	// 转换为响应对象
 	classResponses := make([]ClassResponse, len(classes))
 	for i, class := range classes {
-		classResponses[i] = *s.toClassResponse(class)
+		classResponses[i] = *toClassResponse(class)
 	}
 	response.List = classResponses
 
@@ -197,10 +217,25 @@ func (s *classService) validateUpdateDTO(dto *UpdateClassDTO) error {
 	return nil
 }
 
+// generateClassCode 生成未被占用的班级邀请码
+func (s *classService) generateClassCode(ctx context.Context) (string, error) {
+	for i := 0; i < 5; i++ {
+		code, err := utils.GenerateInviteCode(classCodeLength)
+		if err != nil {
+			return "", err
+		}
+		if existing, err := s.classRepo.FindByCode(ctx, code); err != nil || existing == nil {
+			return code, nil
+		}
+	}
+	return "", ErrClassCodeExists
+}
+
 // toClassResponse 将班级实体转换为响应对象
-func (s *classService) toClassResponse(class *model.Class) *ClassResponse {
+func toClassResponse(class *model.Class) *ClassResponse {
 	return &ClassResponse{
 		ID:          class.ID,
+		Code:        class.Code,
 		Name:        class.ClassName,
 		Description: class.Description,
 		TeacherID:   class.TeacherID,
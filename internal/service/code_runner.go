@@ -0,0 +1,123 @@
+package service
+
+import (
+	"ai-course/internal/config"
+	"ai-course/internal/model"
+	"ai-course/internal/pkg/sandbox"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	codeStatusWrongAnswer = "wrong_answer"
+	codeStatusNotRun      = "not_run"
+
+	// 保存到答案中的错误输出长度
+	codeStderrSnippet  = 512
+	codeCompileSnippet = 2048
+)
+
+// CodeRunner 编程题运行接口
+type CodeRunner interface {
+	// Run 编译源代码并依次运行每个用例
+	Run(ctx context.Context, language, source string, cases []sandbox.Case) (*sandbox.Result, error)
+}
+
+// NewCodeRunner 根据配置创建本地沙箱运行器，配置关闭时返回 nil，编程题需人工批改
+func NewCodeRunner(cfg *config.Config) CodeRunner {
+	if cfg != nil && cfg.Sandbox.Disabled {
+		return nil
+	}
+	sandboxCfg := sandbox.Config{}
+	if cfg != nil {
+		sandboxCfg.WorkDir = cfg.Sandbox.WorkDir
+		sandboxCfg.CompileTimeout = time.Duration(cfg.Sandbox.CompileTimeout) * time.Second
+		sandboxCfg.MaxOutput = cfg.Sandbox.MaxOutputKB << 10
+		sandboxCfg.MaxConcurrent = cfg.Sandbox.MaxConcurrent
+	}
+	return sandbox.NewRunner(sandboxCfg)
+}
+
+// gradeCodeAnswer 在沙箱中运行编程题答案，按通过的用例数计分，结果写入答案
+func gradeCodeAnswer(ctx context.Context, runner CodeRunner, question *model.Question, answer *model.Answer) error {
+	options, err := question.GetCodeOptions()
+	if err != nil {
+		return fmt.Errorf("parse code options failed: %w", err)
+	}
+	var testCases []model.CodeTestCase
+	if err := json.Unmarshal([]byte(question.CorrectAnswer), &testCases); err != nil || len(testCases) == 0 {
+		return fmt.Errorf("question has no test cases")
+	}
+
+	result := &model.CodeRunResult{
+		Language: options.Language,
+		Total:    len(testCases),
+		Cases:    make([]model.CodeCaseResult, len(testCases)),
+		RunAt:    time.Now(),
+	}
+	for i, testCase := range testCases {
+		result.Cases[i] = model.CodeCaseResult{Index: i, Hidden: testCase.Hidden, Status: codeStatusNotRun}
+	}
+
+	if strings.TrimSpace(answer.Content) != "" {
+		cases := make([]sandbox.Case, len(testCases))
+		for i, testCase := range testCases {
+			cases[i] = sandbox.Case{
+				Input:         testCase.Input,
+				TimeLimit:     time.Duration(testCase.TimeLimitMs) * time.Millisecond,
+				MemoryLimitMB: testCase.MemoryLimitMB,
+			}
+		}
+
+		runResult, err := runner.Run(ctx, options.Language, answer.Content, cases)
+		if err != nil {
+			return fmt.Errorf("run code failed: %w", err)
+		}
+		if runResult.CompileError != "" {
+			result.CompileError = sandbox.Snippet(runResult.CompileError, codeCompileSnippet)
+			for i := range result.Cases {
+				result.Cases[i].Status = string(sandbox.StatusCompileError)
+			}
+		}
+
+		for i, caseResult := range runResult.Cases {
+			passed := caseResult.Status == sandbox.StatusOK && normalizeOutput(caseResult.Stdout) == normalizeOutput(testCases[i].ExpectedOutput)
+			status := string(caseResult.Status)
+			if caseResult.Status == sandbox.StatusOK && !passed {
+				status = codeStatusWrongAnswer
+			}
+			if passed {
+				result.Passed++
+			}
+			result.Cases[i].Passed = passed
+			result.Cases[i].Status = status
+			result.Cases[i].Stderr = sandbox.Snippet(caseResult.Stderr, codeStderrSnippet)
+			result.Cases[i].RuntimeMs = caseResult.Runtime.Milliseconds()
+		}
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshal code result failed: %w", err)
+	}
+
+	isCorrect, score := scoreByRatio(question.Score, float64(result.Passed)/float64(result.Total))
+	now := time.Now()
+	answer.CodeResult = string(resultJSON)
+	answer.IsCorrect = &isCorrect
+	answer.Score = score
+	answer.GradedAt = &now
+	return nil
+}
+
+// normalizeOutput 标准化程序输出：统一换行符，去掉行尾空白和末尾空行
+func normalizeOutput(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
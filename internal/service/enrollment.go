@@ -0,0 +1,383 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"ai-course/internal/pkg/pagination"
+	"ai-course/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoClassPermission  = errors.New("无权限管理该班级")
+	ErrStudentNotEnrolled = errors.New("学生不在该班级中")
+	ErrAlreadyEnrolled    = errors.New("已加入该班级")
+	ErrNotTeacher         = errors.New("该用户不是教师")
+	ErrAlreadyCoTeacher   = errors.New("该教师已在班级中任课")
+	ErrCoTeacherNotFound  = errors.New("该教师不是班级的协同授课教师")
+)
+
+// AddStudentsDTO 添加班级学生的数据传输对象
+type AddStudentsDTO struct {
+	StudentCodes []string `json:"student_codes" binding:"required,min=1"` // 学号列表
+}
+
+// JoinClassDTO 通过邀请码加入班级的数据传输对象
+type JoinClassDTO struct {
+	Code string `json:"code" binding:"required"` // 班级邀请码
+}
+
+// AddTeacherDTO 添加协同授课教师的数据传输对象
+type AddTeacherDTO struct {
+	TeacherCode string `json:"teacher_code" binding:"required"` // 教师工号
+}
+
+// ClassTeacherResponse 班级协同授课教师响应
+type ClassTeacherResponse struct {
+	TeacherID   uint      `json:"teacher_id"`
+	TeacherCode string    `json:"teacher_code"`
+	Name        string    `json:"name"`
+	AddedAt     time.Time `json:"added_at"`
+}
+
+// AddStudentsResult 添加班级学生结果
+type AddStudentsResult struct {
+	Added           []string `json:"added"`            // 新加入的学号
+	AlreadyEnrolled []string `json:"already_enrolled"` // 已在班级中的学号
+	NotFound        []string `json:"not_found"`        // 不存在的学号
+	NotStudent      []string `json:"not_student"`      // 对应用户不是学生的学号
+}
+
+// ClassStudentListRequest 获取班级学生列表的请求参数
+type ClassStudentListRequest struct {
+	pagination.Params
+	Status string `form:"status" binding:"omitempty,oneof=active left"` // 成员状态过滤
+}
+
+// ClassStudentResponse 班级学生响应
+type ClassStudentResponse struct {
+	EnrollmentID uint       `json:"enrollment_id"`
+	StudentID    uint       `json:"student_id"`
+	StudentCode  string     `json:"student_code"`
+	Name         string     `json:"name"`
+	Status       string     `json:"status"`
+	JoinedAt     time.Time  `json:"joined_at"`
+	LeftAt       *time.Time `json:"left_at,omitempty"`
+}
+
+// EnrollmentService 班级成员服务接口
+type EnrollmentService interface {
+	// AddStudents 教师按学号批量添加学生
+	AddStudents(ctx context.Context, classID, operatorID uint, dto *AddStudentsDTO) (*AddStudentsResult, error)
+	// RemoveStudent 教师将学生移出班级
+	RemoveStudent(ctx context.Context, classID, studentID, operatorID uint) error
+	// ListStudents 获取班级学生列表
+	ListStudents(ctx context.Context, classID, operatorID uint, req *ClassStudentListRequest) (*pagination.Response, error)
+	// JoinByCode 学生通过邀请码加入班级
+	JoinByCode(ctx context.Context, studentID uint, dto *JoinClassDTO) (*ClassResponse, error)
+	// Leave 学生退出班级
+	Leave(ctx context.Context, classID, studentID uint) error
+	// GetStudentClasses 获取学生所在的班级列表
+	GetStudentClasses(ctx context.Context, studentID uint) ([]*ClassResponse, error)
+
+	// AddTeacher 班级创建者添加协同授课教师
+	AddTeacher(ctx context.Context, classID, operatorID uint, dto *AddTeacherDTO) (*ClassTeacherResponse, error)
+	// RemoveTeacher 班级创建者移除协同授课教师
+	RemoveTeacher(ctx context.Context, classID, teacherID, operatorID uint) error
+	// ListTeachers 获取班级协同授课教师列表
+	ListTeachers(ctx context.Context, classID, operatorID uint) ([]*ClassTeacherResponse, error)
+}
+
+// enrollmentService 班级成员服务实现
+type enrollmentService struct {
+	enrollmentRepo repository.EnrollmentRepository
+	classRepo      repository.ClassRepository
+	userRepo       repository.UserRepository
+	access         AccessService
+}
+
+// NewEnrollmentService 创建班级成员服务实例
+func NewEnrollmentService(
+	enrollmentRepo repository.EnrollmentRepository,
+	classRepo repository.ClassRepository,
+	userRepo repository.UserRepository,
+	access AccessService,
+) EnrollmentService {
+	return &enrollmentService{
+		enrollmentRepo: enrollmentRepo,
+		classRepo:      classRepo,
+		userRepo:       userRepo,
+		access:         access,
+	}
+}
+
+// AddStudents 教师按学号批量添加学生
+func (s *enrollmentService) AddStudents(ctx context.Context, classID, operatorID uint, dto *AddStudentsDTO) (*AddStudentsResult, error) {
+	if _, err := s.getManagedClass(ctx, classID, operatorID); err != nil {
+		return nil, err
+	}
+
+	result := &AddStudentsResult{
+		Added:           []string{},
+		AlreadyEnrolled: []string{},
+		NotFound:        []string{},
+		NotStudent:      []string{},
+	}
+
+	for _, code := range dto.StudentCodes {
+		student, err := s.userRepo.FindByStudentID(ctx, code)
+		if err != nil || student == nil {
+			result.NotFound = append(result.NotFound, code)
+			continue
+		}
+		if student.RoleId != model.RoleStudent {
+			result.NotStudent = append(result.NotStudent, code)
+			continue
+		}
+
+		if err := s.enroll(ctx, classID, student.ID); err != nil {
+			if errors.Is(err, ErrAlreadyEnrolled) {
+				result.AlreadyEnrolled = append(result.AlreadyEnrolled, code)
+				continue
+			}
+			return nil, err
+		}
+		result.Added = append(result.Added, code)
+	}
+
+	return result, nil
+}
+
+// RemoveStudent 教师将学生移出班级
+func (s *enrollmentService) RemoveStudent(ctx context.Context, classID, studentID, operatorID uint) error {
+	if _, err := s.getManagedClass(ctx, classID, operatorID); err != nil {
+		return err
+	}
+	return s.leave(ctx, classID, studentID)
+}
+
+// ListStudents 获取班级学生列表
+func (s *enrollmentService) ListStudents(ctx context.Context, classID, operatorID uint, req *ClassStudentListRequest) (*pagination.Response, error) {
+	if _, err := s.getManagedClass(ctx, classID, operatorID); err != nil {
+		return nil, err
+	}
+
+	pagination.ValidateAndSetDefaults(&req.Params)
+	offset := (req.Page - 1) * req.PageSize
+
+	enrollments, total, err := s.enrollmentRepo.GetByClassID(ctx, classID, model.EnrollmentStatus(req.Status), offset, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]ClassStudentResponse, len(enrollments))
+	for i, enrollment := range enrollments {
+		list[i] = ClassStudentResponse{
+			EnrollmentID: enrollment.ID,
+			StudentID:    enrollment.StudentID,
+			StudentCode:  enrollment.Student.Code,
+			Name:         enrollment.Student.Name,
+			Status:       string(enrollment.Status),
+			JoinedAt:     enrollment.JoinedAt,
+			LeftAt:       enrollment.LeftAt,
+		}
+	}
+
+	return &pagination.Response{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		List:     list,
+	}, nil
+}
+
+// JoinByCode 学生通过邀请码加入班级
+func (s *enrollmentService) JoinByCode(ctx context.Context, studentID uint, dto *JoinClassDTO) (*ClassResponse, error) {
+	class, err := s.classRepo.FindByCode(ctx, dto.Code)
+	if err != nil || class == nil {
+		return nil, ErrInvalidClassCode
+	}
+
+	if err := s.enroll(ctx, class.ID, studentID); err != nil {
+		return nil, err
+	}
+
+	return toClassResponse(class), nil
+}
+
+// Leave 学生退出班级
+func (s *enrollmentService) Leave(ctx context.Context, classID, studentID uint) error {
+	return s.leave(ctx, classID, studentID)
+}
+
+// GetStudentClasses 获取学生所在的班级列表
+func (s *enrollmentService) GetStudentClasses(ctx context.Context, studentID uint) ([]*ClassResponse, error) {
+	classIDs, err := s.enrollmentRepo.GetActiveClassIDsByStudentID(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make([]*ClassResponse, 0, len(classIDs))
+	for _, classID := range classIDs {
+		class, err := s.classRepo.FindByID(ctx, classID)
+		if err != nil {
+			continue // 班级已删除
+		}
+		classes = append(classes, toClassResponse(class))
+	}
+
+	return classes, nil
+}
+
+// AddTeacher 班级创建者添加协同授课教师
+func (s *enrollmentService) AddTeacher(ctx context.Context, classID, operatorID uint, dto *AddTeacherDTO) (*ClassTeacherResponse, error) {
+	class, err := s.getOwnedClass(ctx, classID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	teacher, err := s.userRepo.FindByStudentID(ctx, dto.TeacherCode)
+	if err != nil || teacher == nil {
+		return nil, ErrUserNotFound
+	}
+	if teacher.RoleId != model.RoleTeacher {
+		return nil, ErrNotTeacher
+	}
+
+	isTeacher, err := s.classRepo.IsTeacher(ctx, class.ID, teacher.ID)
+	if err != nil {
+		return nil, err
+	}
+	if isTeacher {
+		return nil, ErrAlreadyCoTeacher
+	}
+
+	if err := s.classRepo.AddCoTeacher(ctx, class.ID, teacher.ID); err != nil {
+		return nil, fmt.Errorf("add co-teacher failed: %w", err)
+	}
+
+	return &ClassTeacherResponse{
+		TeacherID:   teacher.ID,
+		TeacherCode: teacher.Code,
+		Name:        teacher.Name,
+		AddedAt:     time.Now(),
+	}, nil
+}
+
+// RemoveTeacher 班级创建者移除协同授课教师
+func (s *enrollmentService) RemoveTeacher(ctx context.Context, classID, teacherID, operatorID uint) error {
+	class, err := s.getOwnedClass(ctx, classID, operatorID)
+	if err != nil {
+		return err
+	}
+
+	// 班级创建者不是协同授课教师，不能通过此接口移除
+	if class.TeacherID == teacherID {
+		return ErrCoTeacherNotFound
+	}
+	isTeacher, err := s.classRepo.IsTeacher(ctx, class.ID, teacherID)
+	if err != nil {
+		return err
+	}
+	if !isTeacher {
+		return ErrCoTeacherNotFound
+	}
+
+	return s.classRepo.RemoveCoTeacher(ctx, class.ID, teacherID)
+}
+
+// ListTeachers 获取班级协同授课教师列表
+func (s *enrollmentService) ListTeachers(ctx context.Context, classID, operatorID uint) ([]*ClassTeacherResponse, error) {
+	if _, err := s.getManagedClass(ctx, classID, operatorID); err != nil {
+		return nil, err
+	}
+
+	teachers, err := s.classRepo.GetCoTeachers(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*ClassTeacherResponse, len(teachers))
+	for i, teacher := range teachers {
+		list[i] = &ClassTeacherResponse{
+			TeacherID:   teacher.TeacherID,
+			TeacherCode: teacher.Teacher.Code,
+			Name:        teacher.Teacher.Name,
+			AddedAt:     teacher.CreatedAt,
+		}
+	}
+	return list, nil
+}
+
+// getOwnedClass 获取班级并校验操作者是否为班级创建者或管理员
+func (s *enrollmentService) getOwnedClass(ctx context.Context, classID, operatorID uint) (*model.Class, error) {
+	class, err := s.classRepo.FindByID(ctx, classID)
+	if err != nil || class == nil {
+		return nil, ErrClassNotFound
+	}
+	if class.TeacherID != operatorID && !s.access.IsAdmin(ctx, operatorID) {
+		return nil, ErrNoClassPermission
+	}
+	return class, nil
+}
+
+// getManagedClass 获取班级并校验操作者是否为任课教师（含协同授课教师和管理员）
+func (s *enrollmentService) getManagedClass(ctx context.Context, classID, operatorID uint) (*model.Class, error) {
+	class, err := s.classRepo.FindByID(ctx, classID)
+	if err != nil || class == nil {
+		return nil, ErrClassNotFound
+	}
+	if !s.access.IsClassTeacher(ctx, class.ID, operatorID) {
+		return nil, ErrNoClassPermission
+	}
+	return class, nil
+}
+
+// enroll 将学生加入班级，已退出的成员会被重新激活
+func (s *enrollmentService) enroll(ctx context.Context, classID, studentID uint) error {
+	existing, err := s.enrollmentRepo.GetByClassAndStudent(ctx, classID, studentID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("check enrollment failed: %w", err)
+	}
+
+	now := time.Now()
+	if existing != nil {
+		if existing.IsActive() {
+			return ErrAlreadyEnrolled
+		}
+		existing.Status = model.EnrollmentStatusActive
+		existing.JoinedAt = now
+		existing.LeftAt = nil
+		return s.enrollmentRepo.Update(ctx, existing)
+	}
+
+	return s.enrollmentRepo.Create(ctx, &model.ClassEnrollment{
+		ClassID:   classID,
+		StudentID: studentID,
+		Status:    model.EnrollmentStatusActive,
+		JoinedAt:  now,
+	})
+}
+
+// leave 将学生标记为已退出班级
+func (s *enrollmentService) leave(ctx context.Context, classID, studentID uint) error {
+	existing, err := s.enrollmentRepo.GetByClassAndStudent(ctx, classID, studentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrStudentNotEnrolled
+		}
+		return err
+	}
+	if !existing.IsActive() {
+		return ErrStudentNotEnrolled
+	}
+
+	now := time.Now()
+	existing.Status = model.EnrollmentStatusLeft
+	existing.LeftAt = &now
+	return s.enrollmentRepo.Update(ctx, existing)
+}
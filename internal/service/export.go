@@ -0,0 +1,427 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"ai-course/internal/pkg/export"
+	"ai-course/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+var (
+	ErrExportFormatInvalid = errors.New("导出格式无效，只支持 csv 和 xlsx")
+	ErrExportColumnInvalid = errors.New("导出列无效")
+)
+
+// exportBatchSize 导出时每批查询的学生数，大班级分批查询并逐批写出
+const exportBatchSize = 200
+
+// ExportOpener 在校验完成、即将写出数据时调用，设置文件名并返回输出目标；
+// 调用之后响应已经开始，导出出错时只能中断输出
+type ExportOpener func(filename string, format export.Format) (io.Writer, error)
+
+// ExportService 成绩导出服务接口
+type ExportService interface {
+	// ExportAssignmentGrades 导出作业成绩，每个在读学生一行
+	ExportAssignmentGrades(ctx context.Context, assignmentID uint, req *model.GradeExportRequest, teacherID uint, open ExportOpener) error
+	// ExportClassGradebook 导出班级成绩册，每个在读学生一行
+	ExportClassGradebook(ctx context.Context, classID uint, req *model.GradeExportRequest, teacherID uint, open ExportOpener) error
+}
+
+// exportService 成绩导出服务实现
+type exportService struct {
+	gradebookRepo  repository.GradebookRepository
+	assignmentRepo repository.AssignmentRepository
+	questionRepo   repository.QuestionRepository
+	classRepo      repository.ClassRepository
+	enrollmentRepo repository.EnrollmentRepository
+	access         AccessService
+}
+
+// NewExportService 创建成绩导出服务实例
+func NewExportService(
+	gradebookRepo repository.GradebookRepository,
+	assignmentRepo repository.AssignmentRepository,
+	questionRepo repository.QuestionRepository,
+	classRepo repository.ClassRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	access AccessService,
+) ExportService {
+	return &exportService{
+		gradebookRepo:  gradebookRepo,
+		assignmentRepo: assignmentRepo,
+		questionRepo:   questionRepo,
+		classRepo:      classRepo,
+		enrollmentRepo: enrollmentRepo,
+		access:         access,
+	}
+}
+
+// ExportAssignmentGrades 导出作业成绩
+// 总分和状态与成绩册一致；各题得分、迟交和评语取计分的那次作答：
+// 取最高分时为得分最高的已批改作答，其余为最近一次已批改作答，没有已批改作答时为最近一次提交
+func (s *exportService) ExportAssignmentGrades(ctx context.Context, assignmentID uint, req *model.GradeExportRequest, teacherID uint, open ExportOpener) error {
+	format, columns, err := parseExportRequest(req, model.AssignmentExportColumns)
+	if err != nil {
+		return err
+	}
+	if err := s.access.CheckAssignmentManage(ctx, assignmentID, teacherID); err != nil {
+		return err
+	}
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return ErrAssignmentNotFound
+	}
+	questions, err := s.questionRepo.GetByAssignmentIDWithOrder(ctx, assignmentID)
+	if err != nil {
+		return fmt.Errorf("get assignment questions failed: %w", err)
+	}
+
+	var header []interface{}
+	for _, column := range columns {
+		switch column {
+		case model.ExportColumnQuestions:
+			for i, question := range questions {
+				header = append(header, fmt.Sprintf("第%d题(%d分)", i+1, question.Score))
+			}
+		case model.ExportColumnTotal:
+			header = append(header, fmt.Sprintf("总分(%d分)", assignment.TotalScore))
+		default:
+			header = append(header, exportColumnLabels[column])
+		}
+	}
+
+	out, err := open(assignment.Title+"-成绩"+format.Extension(), format)
+	if err != nil {
+		return err
+	}
+	w, err := export.NewWriter(out, format, export.Options{SheetName: assignment.Title, BOM: req.BOM})
+	if err != nil {
+		return err
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	assignments := []*model.Assignment{assignment}
+	scoring := assignment.GetAttemptScoring()
+	now := time.Now()
+	err = s.eachStudentBatch(ctx, assignment.ClassID, func(students []*model.StudentGrades, studentIDs []uint) error {
+		if _, err := fillGradeCells(ctx, s.gradebookRepo, assignments, students, studentIDs, now); err != nil {
+			return err
+		}
+		submissions, err := s.gradebookRepo.GetSubmittedAttempts(ctx, []uint{assignmentID}, studentIDs)
+		if err != nil {
+			return err
+		}
+		attempts := make(map[uint][]*model.Submission)
+		for _, submission := range submissions {
+			attempts[submission.StudentID] = append(attempts[submission.StudentID], submission)
+		}
+
+		chosen := make(map[uint]*model.Submission, len(attempts))
+		var submissionIDs []uint
+		for studentID, list := range attempts {
+			if submission := scoringAttempt(scoring, list); submission != nil {
+				chosen[studentID] = submission
+				submissionIDs = append(submissionIDs, submission.ID)
+			}
+		}
+		answers, err := s.gradebookRepo.GetAnswerScores(ctx, submissionIDs)
+		if err != nil {
+			return err
+		}
+		type key struct{ submissionID, questionID uint }
+		answerOf := make(map[key]*model.Answer, len(answers))
+		for _, answer := range answers {
+			answerOf[key{answer.SubmissionID, answer.QuestionID}] = answer
+		}
+
+		for _, student := range students {
+			cell := student.Cells[0]
+			submission := chosen[student.StudentID]
+			row := make([]interface{}, 0, len(header))
+			for _, column := range columns {
+				switch column {
+				case model.ExportColumnCode:
+					row = append(row, student.StudentCode)
+				case model.ExportColumnName:
+					row = append(row, student.StudentName)
+				case model.ExportColumnQuestions:
+					for _, question := range questions {
+						var value interface{}
+						if submission != nil {
+							answer := answerOf[key{submission.ID, question.ID}]
+							if answer != nil && (submission.IsGraded() || answer.GradedAt != nil) {
+								value = answer.Score
+							}
+						}
+						row = append(row, value)
+					}
+				case model.ExportColumnTotal:
+					row = append(row, cellScore(cell))
+				case model.ExportColumnStatus:
+					row = append(row, cell.Status.Label())
+				case model.ExportColumnAttempts:
+					row = append(row, len(attempts[student.StudentID]))
+				case model.ExportColumnLate:
+					var value interface{}
+					if submission != nil {
+						value = yesNo(submission.IsLate)
+					}
+					row = append(row, value)
+				case model.ExportColumnLatePenalty:
+					var value interface{}
+					if submission != nil && submission.LatePenalty > 0 {
+						value = submission.LatePenalty
+					}
+					row = append(row, value)
+				case model.ExportColumnFeedback:
+					var value interface{}
+					if submission != nil {
+						value = submission.Feedback
+					}
+					row = append(row, value)
+				case model.ExportColumnSubmittedAt:
+					var value interface{}
+					if submission != nil && submission.SubmittedAt != nil {
+						value = submission.SubmittedAt.Local().Format("2006-01-02 15:04:05")
+					}
+					row = append(row, value)
+				}
+			}
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// ExportClassGradebook 导出班级成绩册，作业列为得分，免做和待批改等没有得分的作业导出状态名称
+func (s *exportService) ExportClassGradebook(ctx context.Context, classID uint, req *model.GradeExportRequest, teacherID uint, open ExportOpener) error {
+	format, columns, err := parseExportRequest(req, model.GradebookExportColumns)
+	if err != nil {
+		return err
+	}
+	if err := s.access.CheckClassManage(ctx, classID, teacherID); err != nil {
+		return err
+	}
+	class, err := s.classRepo.FindByID(ctx, classID)
+	if err != nil {
+		return ErrClassNotFound
+	}
+	categories, scale, err := loadGradebookSettings(ctx, s.gradebookRepo, classID)
+	if err != nil {
+		return err
+	}
+	assignments, err := s.gradebookRepo.GetClassAssignments(ctx, classID)
+	if err != nil {
+		return err
+	}
+
+	var header []interface{}
+	for _, column := range columns {
+		switch column {
+		case model.ExportColumnAssignments:
+			for _, assignment := range assignments {
+				header = append(header, fmt.Sprintf("%s(%d分)", assignment.Title, assignment.TotalScore))
+			}
+		case model.ExportColumnCategories:
+			for _, category := range categories {
+				header = append(header, fmt.Sprintf("%s(权重%g)", category.Name, category.Weight))
+			}
+		default:
+			header = append(header, exportColumnLabels[column])
+		}
+	}
+
+	out, err := open(class.ClassName+"-成绩册"+format.Extension(), format)
+	if err != nil {
+		return err
+	}
+	w, err := export.NewWriter(out, format, export.Options{SheetName: class.ClassName, BOM: req.BOM})
+	if err != nil {
+		return err
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = s.eachStudentBatch(ctx, classID, func(students []*model.StudentGrades, studentIDs []uint) error {
+		gradebookColumns, err := fillGradeCells(ctx, s.gradebookRepo, assignments, students, studentIDs, now)
+		if err != nil {
+			return err
+		}
+		for _, student := range students {
+			student.Compute(gradebookColumns, categories, scale)
+
+			row := make([]interface{}, 0, len(header))
+			for _, column := range columns {
+				switch column {
+				case model.ExportColumnCode:
+					row = append(row, student.StudentCode)
+				case model.ExportColumnName:
+					row = append(row, student.StudentName)
+				case model.ExportColumnAssignments:
+					for _, cell := range student.Cells {
+						switch {
+						case cell.Score != nil:
+							row = append(row, cellScore(cell))
+						case cell.Status == model.GradeCellNotDue:
+							row = append(row, nil)
+						default:
+							row = append(row, cell.Status.Label())
+						}
+					}
+				case model.ExportColumnCategories:
+					for _, grade := range student.Categories {
+						var value interface{}
+						if grade.Percent != nil {
+							value = *grade.Percent
+						}
+						row = append(row, value)
+					}
+				case model.ExportColumnPercent:
+					var value interface{}
+					if student.Percent != nil {
+						value = *student.Percent
+					}
+					row = append(row, value)
+				case model.ExportColumnLevel:
+					row = append(row, student.Level)
+				}
+			}
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// eachStudentBatch 按加入班级的顺序分批获取在读学生
+func (s *exportService) eachStudentBatch(ctx context.Context, classID uint, fn func(students []*model.StudentGrades, studentIDs []uint) error) error {
+	for offset := 0; ; offset += exportBatchSize {
+		enrollments, _, err := s.enrollmentRepo.GetByClassID(ctx, classID, model.EnrollmentStatusActive, offset, exportBatchSize)
+		if err != nil {
+			return fmt.Errorf("get class students failed: %w", err)
+		}
+		if len(enrollments) == 0 {
+			return nil
+		}
+
+		students := make([]*model.StudentGrades, len(enrollments))
+		studentIDs := make([]uint, len(enrollments))
+		for i, enrollment := range enrollments {
+			studentIDs[i] = enrollment.StudentID
+			students[i] = &model.StudentGrades{
+				StudentID:   enrollment.StudentID,
+				StudentName: enrollment.Student.Name,
+				StudentCode: enrollment.Student.Code,
+			}
+		}
+		if err := fn(students, studentIDs); err != nil {
+			return err
+		}
+		if len(enrollments) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// exportColumnLabels 导出列的表头
+var exportColumnLabels = map[model.GradeExportColumn]string{
+	model.ExportColumnCode:        "学号",
+	model.ExportColumnName:        "姓名",
+	model.ExportColumnStatus:      "状态",
+	model.ExportColumnAttempts:    "作答次数",
+	model.ExportColumnLate:        "迟交",
+	model.ExportColumnLatePenalty: "迟交扣分",
+	model.ExportColumnFeedback:    "评语",
+	model.ExportColumnSubmittedAt: "提交时间",
+	model.ExportColumnPercent:     "总评",
+	model.ExportColumnLevel:       "等级",
+}
+
+// parseExportRequest 解析导出格式并校验导出列，未指定列时导出全部列
+func parseExportRequest(req *model.GradeExportRequest, allowed []model.GradeExportColumn) (export.Format, []model.GradeExportColumn, error) {
+	format, err := export.ParseFormat(req.Format)
+	if err != nil {
+		return "", nil, ErrExportFormatInvalid
+	}
+	if len(req.Columns) == 0 {
+		return format, allowed, nil
+	}
+
+	valid := make(map[model.GradeExportColumn]bool, len(allowed))
+	for _, column := range allowed {
+		valid[column] = true
+	}
+	seen := make(map[model.GradeExportColumn]bool, len(req.Columns))
+	for _, column := range req.Columns {
+		if !valid[column] || seen[column] {
+			return "", nil, fmt.Errorf("%w: %s", ErrExportColumnInvalid, column)
+		}
+		seen[column] = true
+	}
+	return format, req.Columns, nil
+}
+
+// scoringAttempt 选出导出各题得分时使用的作答
+func scoringAttempt(scoring model.AttemptScoring, attempts []*model.Submission) *model.Submission {
+	var chosen, latest *model.Submission
+	for _, attempt := range attempts {
+		if latest == nil || attempt.Attempt > latest.Attempt {
+			latest = attempt
+		}
+		if !attempt.IsGraded() {
+			continue
+		}
+		switch {
+		case chosen == nil:
+			chosen = attempt
+		case scoring == model.AttemptScoringHighest:
+			if attempt.Score > chosen.Score || (attempt.Score == chosen.Score && attempt.Attempt > chosen.Attempt) {
+				chosen = attempt
+			}
+		default:
+			if attempt.Attempt > chosen.Attempt {
+				chosen = attempt
+			}
+		}
+	}
+	if chosen != nil {
+		return chosen
+	}
+	return latest
+}
+
+// cellScore 返回保留两位小数的得分，没有得分时返回 nil
+func cellScore(cell model.GradeCell) interface{} {
+	if cell.Score == nil {
+		return nil
+	}
+	return math.Round(*cell.Score*100) / 100
+}
+
+// yesNo 将布尔值导出为“是”或“否”
+func yesNo(value bool) string {
+	if value {
+		return "是"
+	}
+	return "否"
+}
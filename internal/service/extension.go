@@ -0,0 +1,105 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"ai-course/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrExtensionNotFound        = errors.New("延期记录不存在")
+	ErrExtensionStudentNotFound = errors.New("该学生不在作业所在班级")
+	ErrExtensionDeadlineInvalid = errors.New("延期后的截止时间必须晚于作业截止时间")
+)
+
+// ExtensionService 作业延期服务接口
+type ExtensionService interface {
+	// GrantExtension 教师为班级中的学生设置延期，已有延期时替换
+	GrantExtension(ctx context.Context, assignmentID uint, req *model.GrantExtensionRequest, teacherID uint) (*model.DeadlineExtension, error)
+	// GetExtensions 获取作业的全部延期
+	GetExtensions(ctx context.Context, assignmentID, teacherID uint) ([]*model.DeadlineExtension, error)
+	// RevokeExtension 取消学生的延期
+	RevokeExtension(ctx context.Context, assignmentID, studentID, teacherID uint) error
+}
+
+// extensionService 作业延期服务实现
+type extensionService struct {
+	extensionRepo  repository.ExtensionRepository
+	assignmentRepo repository.AssignmentRepository
+	enrollmentRepo repository.EnrollmentRepository
+	access         AccessService
+}
+
+// NewExtensionService 创建作业延期服务实例
+func NewExtensionService(
+	extensionRepo repository.ExtensionRepository,
+	assignmentRepo repository.AssignmentRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	access AccessService,
+) ExtensionService {
+	return &extensionService{
+		extensionRepo:  extensionRepo,
+		assignmentRepo: assignmentRepo,
+		enrollmentRepo: enrollmentRepo,
+		access:         access,
+	}
+}
+
+// GrantExtension 设置学生的作业延期
+func (s *extensionService) GrantExtension(ctx context.Context, assignmentID uint, req *model.GrantExtensionRequest, teacherID uint) (*model.DeadlineExtension, error) {
+	if err := s.access.CheckAssignmentManage(ctx, assignmentID, teacherID); err != nil {
+		return nil, err
+	}
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	if !req.Deadline.After(assignment.Deadline) {
+		return nil, ErrExtensionDeadlineInvalid
+	}
+
+	isMember, err := s.enrollmentRepo.IsActiveMember(ctx, assignment.ClassID, req.StudentID)
+	if err != nil {
+		return nil, fmt.Errorf("check class member failed: %w", err)
+	}
+	if !isMember {
+		return nil, ErrExtensionStudentNotFound
+	}
+
+	extension := &model.DeadlineExtension{
+		AssignmentID: assignmentID,
+		StudentID:    req.StudentID,
+		Deadline:     req.Deadline,
+		Reason:       req.Reason,
+		GrantedBy:    teacherID,
+	}
+	if err := s.extensionRepo.Upsert(ctx, extension); err != nil {
+		return nil, err
+	}
+	return extension, nil
+}
+
+// GetExtensions 获取作业的全部延期
+func (s *extensionService) GetExtensions(ctx context.Context, assignmentID, teacherID uint) ([]*model.DeadlineExtension, error) {
+	if err := s.access.CheckAssignmentManage(ctx, assignmentID, teacherID); err != nil {
+		return nil, err
+	}
+	return s.extensionRepo.GetByAssignmentID(ctx, assignmentID)
+}
+
+// RevokeExtension 取消学生的延期，已按延期提交的记录不受影响
+func (s *extensionService) RevokeExtension(ctx context.Context, assignmentID, studentID, teacherID uint) error {
+	if err := s.access.CheckAssignmentManage(ctx, assignmentID, teacherID); err != nil {
+		return err
+	}
+	deleted, err := s.extensionRepo.Delete(ctx, assignmentID, studentID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrExtensionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/pkg/export"
+	"ai-course/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrGradeImportHeader   = errors.New("invalid grade sheet header")
+	ErrGradeImportRejected = errors.New("grade sheet has rows that cannot be imported")
+
+	// 表头无效的具体原因，均可用 errors.Is 判断为 ErrGradeImportHeader
+	ErrGradeImportEmpty          = fmt.Errorf("%w: empty file", ErrGradeImportHeader)
+	ErrGradeImportNoCodeColumn   = fmt.Errorf("%w: missing student code column", ErrGradeImportHeader)
+	ErrGradeImportNoScoreColumn  = fmt.Errorf("%w: missing score or feedback column", ErrGradeImportHeader)
+	ErrGradeImportQuestionColumn = fmt.Errorf("%w: invalid question column", ErrGradeImportHeader)
+)
+
+// gradeImportQuestionHeader 题目列的表头，如“第3题(10分)”或“Q3”
+var gradeImportQuestionHeader = regexp.MustCompile(`^(?:第\s*(\d+)\s*题|[qQ]\s*(\d+)\b)`)
+
+// gradeImportColumns 导入文件中各列的位置，-1 表示不存在
+type gradeImportColumns struct {
+	code      int
+	name      int
+	feedback  int
+	questions []gradeImportQuestionColumn
+}
+
+// gradeImportQuestionColumn 题目得分列
+type gradeImportQuestionColumn struct {
+	column int // 列位置
+	index  int // 题目下标，从 0 开始
+}
+
+// ImportGrades 从 CSV 或 XLSX 成绩表导入作业成绩
+// 按学号匹配班级在读学生，按题号匹配作业题目，成绩写入导出时的同一次作答（见 scoringAttempt）；
+// 空白单元格表示不修改。dryRun 时只返回比对报告；否则存在阻止导入的行时拒绝导入，
+// 全部行有效时在同一事务中保存，任一提交已被修改或被其他教师领取时整体回滚
+func (s *gradingService) ImportGrades(ctx context.Context, assignmentID uint, file *multipart.FileHeader, dryRun bool, teacherID uint) (*model.GradeImportReport, error) {
+	if err := s.checkGradingAccess(ctx, assignmentID, teacherID); err != nil {
+		return nil, err
+	}
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	questions, err := s.questionRepo.GetByAssignmentIDWithOrder(ctx, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("get assignment questions failed: %w", err)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open uploaded file failed: %w", err)
+	}
+	defer f.Close()
+	rows, err := export.ReadRows(file.Filename, f)
+	if err != nil {
+		return nil, err
+	}
+
+	// 第一行非空行为表头
+	headerIndex := 0
+	for headerIndex < len(rows) && isBlankRow(rows[headerIndex]) {
+		headerIndex++
+	}
+	if headerIndex == len(rows) {
+		return nil, ErrGradeImportEmpty
+	}
+	columns, err := parseGradeImportHeader(rows[headerIndex], len(questions))
+	if err != nil {
+		return nil, err
+	}
+
+	enrollments, _, err := s.enrollmentRepo.GetByClassID(ctx, assignment.ClassID, model.EnrollmentStatusActive, 0, -1)
+	if err != nil {
+		return nil, fmt.Errorf("get class students failed: %w", err)
+	}
+	students := make(map[string]*model.User, len(enrollments))
+	for _, enrollment := range enrollments {
+		if enrollment.Student.Code != "" {
+			students[enrollment.Student.Code] = &enrollment.Student
+		}
+	}
+	submissions, err := s.submissionRepo.GetSubmittedWithAnswers(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	attempts := make(map[uint][]*model.Submission)
+	for _, submission := range submissions {
+		attempts[submission.StudentID] = append(attempts[submission.StudentID], submission)
+	}
+
+	report := &model.GradeImportReport{AssignmentID: assignmentID, DryRun: dryRun}
+	now := time.Now()
+	seen := make(map[string]int)
+	var updates []*model.GradingUpdate
+	for i := headerIndex + 1; i < len(rows); i++ {
+		if isBlankRow(rows[i]) {
+			continue
+		}
+		row, update := compareImportRow(i+1, rows[i], columns, questions, assignment.GetAttemptScoring(), students, attempts, seen, teacherID, now)
+		report.Rows = append(report.Rows, row)
+		if update != nil {
+			updates = append(updates, update)
+		}
+	}
+	summarizeGradeImport(report)
+
+	if dryRun {
+		return report, nil
+	}
+	for _, row := range report.Rows {
+		if row.Status.Blocking() {
+			return report, ErrGradeImportRejected
+		}
+	}
+	if len(updates) == 0 {
+		return report, nil
+	}
+
+	note := "grade import: " + file.Filename
+	for _, update := range updates {
+		for _, event := range update.Events {
+			event.Note = note
+		}
+	}
+	if err := s.submissionRepo.SaveGradingBatch(ctx, updates); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return report, ErrGradeConflict
+		}
+		return nil, err
+	}
+	report.Applied = true
+
+	logger.Logger.Info("Grades imported",
+		zap.Uint("assignment_id", assignmentID),
+		zap.Uint("teacher_id", teacherID),
+		zap.String("filename", file.Filename),
+		zap.Int("submissions", len(updates)),
+	)
+	return report, nil
+}
+
+// compareImportRow 比对一行成绩与现有成绩，有变化且没有错误时返回需要保存的批改结果
+func compareImportRow(
+	rowNum int,
+	cells []string,
+	columns *gradeImportColumns,
+	questions []*model.Question,
+	scoring model.AttemptScoring,
+	students map[string]*model.User,
+	attempts map[uint][]*model.Submission,
+	seen map[string]int,
+	teacherID uint,
+	now time.Time,
+) (*model.GradeImportRow, *model.GradingUpdate) {
+	cell := func(index int) string {
+		if index < 0 || index >= len(cells) {
+			return ""
+		}
+		return strings.TrimSpace(cells[index])
+	}
+
+	row := &model.GradeImportRow{
+		Row:         rowNum,
+		StudentCode: cell(columns.code),
+		StudentName: cell(columns.name),
+	}
+	if row.StudentCode == "" {
+		row.Status = model.GradeImportInvalid
+		row.Errors = append(row.Errors, "学号为空")
+		return row, nil
+	}
+	if first, ok := seen[row.StudentCode]; ok {
+		row.Status = model.GradeImportInvalid
+		row.Errors = append(row.Errors, fmt.Sprintf("学号与第 %d 行重复", first))
+		return row, nil
+	}
+	seen[row.StudentCode] = rowNum
+
+	student := students[row.StudentCode]
+	if student == nil {
+		row.Status = model.GradeImportUnknownStudent
+		row.Errors = append(row.Errors, "学号不是班级在读学生")
+		return row, nil
+	}
+	row.StudentName = student.Name
+
+	feedback := cell(columns.feedback)
+	hasValues := feedback != ""
+	for _, question := range columns.questions {
+		if cell(question.column) != "" {
+			hasValues = true
+		}
+	}
+
+	submission := scoringAttempt(scoring, attempts[student.ID])
+	if submission == nil {
+		if hasValues {
+			row.Status = model.GradeImportNoSubmission
+			row.Errors = append(row.Errors, "学生没有已提交的作答")
+		} else {
+			row.Status = model.GradeImportUnchanged
+		}
+		return row, nil
+	}
+	row.SubmissionID = submission.ID
+
+	answerOf := make(map[uint]*model.Answer, len(submission.Answers))
+	for i := range submission.Answers {
+		answerOf[submission.Answers[i].QuestionID] = &submission.Answers[i]
+	}
+
+	invalid := false
+	scores := make(map[uint]int)
+	fullScores := make(map[uint]int)
+	for _, column := range columns.questions {
+		value := cell(column.column)
+		if value == "" {
+			continue
+		}
+		question := questions[column.index]
+		order := column.index + 1
+
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || number != math.Trunc(number) {
+			invalid = true
+			row.Errors = append(row.Errors, fmt.Sprintf("第%d题得分“%s”不是整数", order, value))
+			continue
+		}
+		score := int(number)
+		if score < 0 || score > question.Score {
+			row.Errors = append(row.Errors, fmt.Sprintf("第%d题得分 %d 超出范围 0-%d", order, score, question.Score))
+			continue
+		}
+		answer := answerOf[question.ID]
+		if answer == nil {
+			invalid = true
+			row.Errors = append(row.Errors, fmt.Sprintf("学生未作答第%d题", order))
+			continue
+		}
+		scores[question.ID] = score
+		fullScores[question.ID] = question.Score
+		if score != answer.Score {
+			row.Changes = append(row.Changes, model.GradeImportChange{
+				QuestionID:    question.ID,
+				QuestionOrder: order,
+				OldScore:      answer.Score,
+				NewScore:      score,
+			})
+		}
+	}
+	if feedback != "" && feedback != submission.Feedback {
+		oldFeedback := submission.Feedback
+		row.OldFeedback = &oldFeedback
+		row.NewFeedback = &feedback
+	}
+
+	switch {
+	case invalid:
+		row.Status = model.GradeImportInvalid
+		return row, nil
+	case len(row.Errors) > 0:
+		row.Status = model.GradeImportOutOfRange
+		return row, nil
+	case len(row.Changes) == 0 && row.NewFeedback == nil && (submission.IsGraded() || len(scores) == 0):
+		// 未批改的提交只要填写了得分就视为批改，即使得分与自动判分的结果相同
+		row.Status = model.GradeImportUnchanged
+		return row, nil
+	}
+	row.Status = model.GradeImportChanged
+
+	// 按导入的得分重新计算总分，未在表中填写的题目保留原有得分
+	update := &model.GradingUpdate{Submission: submission}
+	rawTotal := 0
+	for i := range submission.Answers {
+		answer := &submission.Answers[i]
+		if score, ok := scores[answer.QuestionID]; ok && score != answer.Score {
+			oldScore := answer.Score
+			answer.Score = score
+			isCorrect := score == fullScores[answer.QuestionID]
+			answer.IsCorrect = &isCorrect
+			update.Answers = append(update.Answers, answer)
+			update.Events = append(update.Events, model.NewAnswerGradeEvent(answer, model.GradeEventSourceBatch, &teacherID, oldScore, answer.Feedback))
+		}
+		rawTotal += answer.Score
+	}
+	oldTotal, oldFeedback := submission.Score, submission.Feedback
+	submission.ApplyLatePenalty(rawTotal)
+	submission.GradedAt = &now
+	submission.GradedBy = teacherID
+	if row.NewFeedback != nil {
+		submission.Feedback = feedback
+	}
+	update.Events = append(update.Events, model.NewSubmissionGradeEvent(submission, model.GradeEventSourceBatch, &teacherID, oldTotal, oldFeedback))
+	return row, update
+}
+
+// parseGradeImportHeader 识别学号、姓名、评语和题目列
+func parseGradeImportHeader(header []string, questionCount int) (*gradeImportColumns, error) {
+	columns := &gradeImportColumns{code: -1, name: -1, feedback: -1}
+	used := make(map[int]bool)
+	for i, title := range header {
+		title = strings.TrimSpace(title)
+		switch strings.ToLower(title) {
+		case "学号", "code", "student_code":
+			columns.code = i
+			continue
+		case "姓名", "name", "student_name":
+			columns.name = i
+			continue
+		case "评语", "feedback":
+			columns.feedback = i
+			continue
+		}
+
+		match := gradeImportQuestionHeader.FindStringSubmatch(title)
+		if match == nil {
+			continue // 总分、状态等其他列忽略
+		}
+		number := match[1]
+		if number == "" {
+			number = match[2]
+		}
+		order, _ := strconv.Atoi(number)
+		if order < 1 || order > questionCount {
+			return nil, fmt.Errorf("%w: question %d exceeds question count %d", ErrGradeImportQuestionColumn, order, questionCount)
+		}
+		if used[order] {
+			return nil, fmt.Errorf("%w: duplicate question %d", ErrGradeImportQuestionColumn, order)
+		}
+		used[order] = true
+		columns.questions = append(columns.questions, gradeImportQuestionColumn{column: i, index: order - 1})
+	}
+
+	if columns.code < 0 {
+		return nil, ErrGradeImportNoCodeColumn
+	}
+	if len(columns.questions) == 0 && columns.feedback < 0 {
+		return nil, ErrGradeImportNoScoreColumn
+	}
+	return columns, nil
+}
+
+// summarizeGradeImport 统计各状态的行数
+func summarizeGradeImport(report *model.GradeImportReport) {
+	summary := &report.Summary
+	summary.Total = len(report.Rows)
+	for _, row := range report.Rows {
+		switch row.Status {
+		case model.GradeImportChanged:
+			summary.Changed++
+		case model.GradeImportUnchanged:
+			summary.Unchanged++
+		case model.GradeImportUnknownStudent:
+			summary.UnknownStudent++
+		case model.GradeImportNoSubmission:
+			summary.NoSubmission++
+		case model.GradeImportOutOfRange:
+			summary.OutOfRange++
+		case model.GradeImportInvalid:
+			summary.Invalid++
+		}
+	}
+}
+
+// isBlankRow 检查一行是否全部为空
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"ai-course/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGradeCategoryInvalid     = errors.New("成绩分类不存在或不属于该班级")
+	ErrGradebookSettingsInvalid = errors.New("成绩册设置无效：分类名称和等级名称不能重复")
+	ErrGradeMarkNotFound        = errors.New("成绩标记不存在")
+)
+
+// GradebookService 成绩册服务接口
+type GradebookService interface {
+	// GetClassGradebook 获取班级成绩册（学生×作业矩阵），包含未发布成绩的作业
+	GetClassGradebook(ctx context.Context, classID uint, teacherID uint) (*model.GradebookResponse, error)
+	// GetStudentSummary 获取学生个人成绩汇总，只包含已发布成绩的作业
+	GetStudentSummary(ctx context.Context, classID uint, studentID uint) (*model.GradeSummaryResponse, error)
+
+	// 成绩册设置
+	GetSettings(ctx context.Context, classID uint, teacherID uint) (*model.GradebookSettingsResponse, error)
+	UpdateSettings(ctx context.Context, classID uint, req *model.UpdateGradebookSettingsRequest, teacherID uint) (*model.GradebookSettingsResponse, error)
+	SetAssignmentCategory(ctx context.Context, assignmentID uint, req *model.SetAssignmentCategoryRequest, teacherID uint) error
+
+	// 免做和缺交标记
+	MarkGrade(ctx context.Context, assignmentID, studentID uint, req *model.MarkGradeRequest, teacherID uint) (*model.GradeMark, error)
+	ClearMark(ctx context.Context, assignmentID, studentID uint, teacherID uint) error
+}
+
+// gradebookService 成绩册服务实现
+type gradebookService struct {
+	gradebookRepo  repository.GradebookRepository
+	assignmentRepo repository.AssignmentRepository
+	enrollmentRepo repository.EnrollmentRepository
+	access         AccessService
+}
+
+// NewGradebookService 创建成绩册服务实例
+func NewGradebookService(
+	gradebookRepo repository.GradebookRepository,
+	assignmentRepo repository.AssignmentRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	access AccessService,
+) GradebookService {
+	return &gradebookService{
+		gradebookRepo:  gradebookRepo,
+		assignmentRepo: assignmentRepo,
+		enrollmentRepo: enrollmentRepo,
+		access:         access,
+	}
+}
+
+// GetClassGradebook 获取班级成绩册，包含全部在读学生
+func (s *gradebookService) GetClassGradebook(ctx context.Context, classID uint, teacherID uint) (*model.GradebookResponse, error) {
+	if err := s.access.CheckClassManage(ctx, classID, teacherID); err != nil {
+		return nil, err
+	}
+
+	categories, scale, err := loadGradebookSettings(ctx, s.gradebookRepo, classID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.gradebookRepo.GetClassAssignments(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	enrollments, _, err := s.enrollmentRepo.GetByClassID(ctx, classID, model.EnrollmentStatusActive, 0, -1)
+	if err != nil {
+		return nil, fmt.Errorf("get class students failed: %w", err)
+	}
+
+	students := make([]*model.StudentGrades, len(enrollments))
+	for i, enrollment := range enrollments {
+		students[i] = &model.StudentGrades{
+			StudentID:   enrollment.StudentID,
+			StudentName: enrollment.Student.Name,
+			StudentCode: enrollment.Student.Code,
+		}
+	}
+
+	columns, err := fillGradeCells(ctx, s.gradebookRepo, assignments, students, nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, student := range students {
+		student.Compute(columns, categories, scale)
+	}
+
+	return &model.GradebookResponse{
+		ClassID:     classID,
+		Categories:  categories,
+		GradeScale:  scale,
+		Assignments: columns,
+		Students:    students,
+	}, nil
+}
+
+// GetStudentSummary 获取学生个人成绩汇总，成绩未发布的作业不显示也不计入总评
+func (s *gradebookService) GetStudentSummary(ctx context.Context, classID uint, studentID uint) (*model.GradeSummaryResponse, error) {
+	isMember, err := s.enrollmentRepo.IsActiveMember(ctx, classID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("check class member failed: %w", err)
+	}
+	if !isMember {
+		return nil, ErrAccessDenied
+	}
+
+	categories, scale, err := loadGradebookSettings(ctx, s.gradebookRepo, classID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.gradebookRepo.GetClassAssignments(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	published := make([]*model.Assignment, 0, len(assignments))
+	for _, assignment := range assignments {
+		if assignment.GradesPublished {
+			published = append(published, assignment)
+		}
+	}
+
+	grades := &model.StudentGrades{StudentID: studentID}
+	columns, err := fillGradeCells(ctx, s.gradebookRepo, published, []*model.StudentGrades{grades}, []uint{studentID}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	grades.Compute(columns, categories, scale)
+
+	return &model.GradeSummaryResponse{
+		ClassID:     classID,
+		GradeScale:  scale,
+		Assignments: columns,
+		Grades:      grades,
+	}, nil
+}
+
+// GetSettings 获取成绩册设置
+func (s *gradebookService) GetSettings(ctx context.Context, classID uint, teacherID uint) (*model.GradebookSettingsResponse, error) {
+	if err := s.access.CheckClassManage(ctx, classID, teacherID); err != nil {
+		return nil, err
+	}
+	categories, scale, err := loadGradebookSettings(ctx, s.gradebookRepo, classID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.GradebookSettingsResponse{ClassID: classID, Categories: categories, GradeScale: scale}, nil
+}
+
+// UpdateSettings 更新成绩分类和等级分数线
+func (s *gradebookService) UpdateSettings(ctx context.Context, classID uint, req *model.UpdateGradebookSettingsRequest, teacherID uint) (*model.GradebookSettingsResponse, error) {
+	if err := s.access.CheckClassManage(ctx, classID, teacherID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.gradebookRepo.GetCategories(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*model.GradeCategory, len(existing))
+	for _, category := range existing {
+		byID[category.ID] = category
+	}
+
+	names := make(map[string]bool, len(req.Categories))
+	categories := make([]*model.GradeCategory, 0, len(req.Categories))
+	for i, item := range req.Categories {
+		if names[item.Name] {
+			return nil, ErrGradebookSettingsInvalid
+		}
+		names[item.Name] = true
+
+		category := &model.GradeCategory{ClassID: classID}
+		if item.ID != 0 {
+			if category = byID[item.ID]; category == nil {
+				return nil, ErrGradeCategoryInvalid
+			}
+			delete(byID, item.ID) // 同一分类只能出现一次
+		}
+		category.Name = item.Name
+		category.Weight = item.Weight
+		category.DropLowest = item.DropLowest
+		category.Order = i
+		categories = append(categories, category)
+	}
+
+	scale, err := normalizeGradeScale(req.GradeScale)
+	if err != nil {
+		return nil, err
+	}
+
+	setting, err := s.gradebookRepo.GetSetting(ctx, classID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		setting = &model.GradebookSetting{ClassID: classID}
+	}
+	setting.GradeScale = ""
+	if len(scale) > 0 {
+		scaleJSON, err := json.Marshal(scale)
+		if err != nil {
+			return nil, fmt.Errorf("marshal grade scale failed: %w", err)
+		}
+		setting.GradeScale = string(scaleJSON)
+	}
+
+	if err := s.gradebookRepo.ReplaceCategories(ctx, classID, categories); err != nil {
+		return nil, err
+	}
+	if err := s.gradebookRepo.SaveSetting(ctx, setting); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(ctx, classID, teacherID)
+}
+
+// SetAssignmentCategory 设置作业的成绩分类，分类必须属于作业所在班级
+func (s *gradebookService) SetAssignmentCategory(ctx context.Context, assignmentID uint, req *model.SetAssignmentCategoryRequest, teacherID uint) error {
+	if err := s.access.CheckAssignmentManage(ctx, assignmentID, teacherID); err != nil {
+		return err
+	}
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return ErrAssignmentNotFound
+	}
+
+	if req.CategoryID != nil {
+		category, err := s.gradebookRepo.GetCategoryByID(ctx, *req.CategoryID)
+		if err != nil || category.ClassID != assignment.ClassID {
+			return ErrGradeCategoryInvalid
+		}
+	}
+	return s.gradebookRepo.SetAssignmentCategory(ctx, assignmentID, req.CategoryID)
+}
+
+// MarkGrade 标记学生作业免做或缺交
+func (s *gradebookService) MarkGrade(ctx context.Context, assignmentID, studentID uint, req *model.MarkGradeRequest, teacherID uint) (*model.GradeMark, error) {
+	if err := s.access.CheckAssignmentManage(ctx, assignmentID, teacherID); err != nil {
+		return nil, err
+	}
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+
+	isMember, err := s.enrollmentRepo.IsActiveMember(ctx, assignment.ClassID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("check class member failed: %w", err)
+	}
+	if !isMember {
+		return nil, ErrStudentNotEnrolled
+	}
+
+	mark := &model.GradeMark{
+		AssignmentID: assignmentID,
+		StudentID:    studentID,
+		Mark:         req.Mark,
+		Note:         req.Note,
+		MarkedBy:     teacherID,
+	}
+	if err := s.gradebookRepo.UpsertMark(ctx, mark); err != nil {
+		return nil, err
+	}
+	return mark, nil
+}
+
+// ClearMark 清除学生作业的免做或缺交标记
+func (s *gradebookService) ClearMark(ctx context.Context, assignmentID, studentID uint, teacherID uint) error {
+	if err := s.access.CheckAssignmentManage(ctx, assignmentID, teacherID); err != nil {
+		return err
+	}
+	deleted, err := s.gradebookRepo.DeleteMark(ctx, assignmentID, studentID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrGradeMarkNotFound
+	}
+	return nil
+}
+
+// loadGradebookSettings 获取班级的成绩分类和等级分数线
+func loadGradebookSettings(ctx context.Context, gradebookRepo repository.GradebookRepository, classID uint) ([]model.GradeCategory, []model.GradeCutoff, error) {
+	list, err := gradebookRepo.GetCategories(ctx, classID)
+	if err != nil {
+		return nil, nil, err
+	}
+	categories := make([]model.GradeCategory, len(list))
+	for i, category := range list {
+		categories[i] = *category
+	}
+
+	setting, err := gradebookRepo.GetSetting(ctx, classID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+		setting = nil
+	}
+	scale, err := setting.GetGradeScale()
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse grade scale failed: %w", err)
+	}
+	return categories, scale, nil
+}
+
+// fillGradeCells 生成作业列并填充每个学生的成绩单元格，studentIDs 不为空时只查询这些学生的数据
+// 有已批改的作答时按作业的计分方式计算得分；免做标记优先，缺交标记在学生有成绩后不再生效；
+// 未提交且已过学生的截止时间（含延期）或作业已关闭时视为缺交
+func fillGradeCells(ctx context.Context, gradebookRepo repository.GradebookRepository, assignments []*model.Assignment, students []*model.StudentGrades, studentIDs []uint, now time.Time) ([]model.GradebookColumn, error) {
+	ids := make([]uint, len(assignments))
+	columns := make([]model.GradebookColumn, len(assignments))
+	for i, assignment := range assignments {
+		ids[i] = assignment.ID
+		columns[i] = model.GradebookColumn{
+			AssignmentID:    assignment.ID,
+			Title:           assignment.Title,
+			CategoryID:      assignment.CategoryID,
+			TotalScore:      assignment.TotalScore,
+			Deadline:        assignment.Deadline,
+			GradesPublished: assignment.GradesPublished,
+		}
+	}
+
+	submissions, err := gradebookRepo.GetSubmittedAttempts(ctx, ids, studentIDs)
+	if err != nil {
+		return nil, err
+	}
+	marks, err := gradebookRepo.GetMarks(ctx, ids, studentIDs)
+	if err != nil {
+		return nil, err
+	}
+	extensions, err := gradebookRepo.GetExtensions(ctx, ids, studentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct{ assignmentID, studentID uint }
+	attempts := make(map[key][]*model.Submission)
+	for _, submission := range submissions {
+		k := key{submission.AssignmentID, submission.StudentID}
+		attempts[k] = append(attempts[k], submission)
+	}
+	markOf := make(map[key]*model.GradeMark, len(marks))
+	for _, mark := range marks {
+		markOf[key{mark.AssignmentID, mark.StudentID}] = mark
+	}
+	deadlineOf := make(map[key]time.Time, len(extensions))
+	for _, extension := range extensions {
+		deadlineOf[key{extension.AssignmentID, extension.StudentID}] = extension.Deadline
+	}
+
+	for _, student := range students {
+		student.Cells = make([]model.GradeCell, len(assignments))
+		for i, assignment := range assignments {
+			k := key{assignment.ID, student.StudentID}
+			cell := model.GradeCell{AssignmentID: assignment.ID}
+
+			var graded []*model.Submission
+			for _, attempt := range attempts[k] {
+				if attempt.IsGraded() {
+					graded = append(graded, attempt)
+				}
+			}
+			mark := markOf[k]
+			if mark != nil {
+				cell.Note = mark.Note
+			}
+
+			deadline := assignment.Deadline
+			if extended, ok := deadlineOf[k]; ok && extended.After(deadline) {
+				deadline = extended
+			}
+
+			switch {
+			case mark != nil && mark.Mark == model.GradeMarkExcused:
+				cell.Status = model.GradeCellExcused
+			case len(graded) > 0:
+				score, _ := model.EffectiveScore(assignment.GetAttemptScoring(), graded)
+				cell.Status = model.GradeCellGraded
+				cell.Score = &score
+			case len(attempts[k]) > 0:
+				cell.Status = model.GradeCellPending
+			case mark != nil && mark.Mark == model.GradeMarkMissing,
+				assignment.Status == "closed", now.After(deadline):
+				zero := 0.0
+				cell.Status = model.GradeCellMissing
+				cell.Score = &zero
+			default:
+				cell.Status = model.GradeCellNotDue
+			}
+			student.Cells[i] = cell
+		}
+	}
+	return columns, nil
+}
+
+// normalizeGradeScale 校验等级名称不重复，并按分数线从高到低排列
+func normalizeGradeScale(scale []model.GradeCutoff) ([]model.GradeCutoff, error) {
+	labels := make(map[string]bool, len(scale))
+	for _, cutoff := range scale {
+		if labels[cutoff.Label] {
+			return nil, ErrGradebookSettingsInvalid
+		}
+		labels[cutoff.Label] = true
+	}
+	sorted := append([]model.GradeCutoff(nil), scale...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Min > sorted[j].Min
+	})
+	return sorted, nil
+}
@@ -6,11 +6,32 @@ import (
 	"ai-course/internal/repository"
 	"context"
 	"errors"
+	"mime/multipart"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+var (
+	ErrGradeConflict      = errors.New("提交已被修改，请刷新后重新批改")
+	ErrGradingLocked      = errors.New("该提交已被其他教师领取批改")
+	ErrGradingQueueEmpty  = errors.New("没有可领取的待批改提交")
+	ErrGradeScoreExceeded = errors.New("grade score exceeds question score")
+)
+
+const (
+	// GradingLockTTL 批改锁有效期，到期未提交批改时其他教师可以重新领取
+	GradingLockTTL = 30 * time.Minute
+	// gradingClaimCandidates 领取下一份提交时每次尝试的候选数量
+	gradingClaimCandidates = 5
+	// aiSuggestBatch 后台每次生成 AI 建议的答案数量上限
+	aiSuggestBatch = 10
+	// aiSuggestionLock 后台生成 AI 建议任务的领导锁名称
+	aiSuggestionLock = "ai_suggestion"
+	// maxAISuggestFails 同一答案 AI 建议生成失败的最多次数
+	maxAISuggestFails = 3
+)
+
 // GradingService 批改服务接口
 type GradingService interface {
 	// GetSubmissionsForGrading 获取待批改的提交列表
@@ -23,6 +44,20 @@ type GradingService interface {
 	PublishGrades(ctx context.Context, assignmentID, teacherID uint) error
 	// GetGradingProgress 获取批改进度
 	GetGradingProgress(ctx context.Context, assignmentID, teacherID uint) (*model.GradingProgress, error)
+	// SuggestGrades 为提交中的主观题重新生成 AI 批改建议
+	SuggestGrades(ctx context.Context, submissionID, teacherID uint) ([]*model.AISuggestionResult, error)
+	// SuggestPendingAnswers 获取领导锁后在后台为已提交的主观题答案生成 AI 批改建议，返回生成的数量
+	SuggestPendingAnswers(ctx context.Context, owner string, lease time.Duration, now time.Time) (int, error)
+	// ClaimSubmission 领取提交的批改锁
+	ClaimSubmission(ctx context.Context, submissionID, teacherID uint) (*model.GradingLock, error)
+	// ReleaseSubmission 释放提交的批改锁
+	ReleaseSubmission(ctx context.Context, submissionID, teacherID uint) error
+	// ClaimNextSubmission 领取作业中下一份待批改的提交
+	ClaimNextSubmission(ctx context.Context, assignmentID, teacherID uint) (*model.SubmissionDetail, *model.GradingLock, error)
+	// GetGradeTimeline 获取提交的成绩变更记录，学生本人需在成绩发布后查看
+	GetGradeTimeline(ctx context.Context, submissionID, userID uint) ([]*model.GradeEvent, error)
+	// ImportGrades 从 CSV 或 XLSX 成绩表导入作业成绩，dryRun 时只返回比对报告
+	ImportGrades(ctx context.Context, assignmentID uint, file *multipart.FileHeader, dryRun bool, teacherID uint) (*model.GradeImportReport, error)
 }
 
 // gradingService 批改服务实现
@@ -31,6 +66,11 @@ type gradingService struct {
 	answerRepo     repository.AnswerRepository
 	assignmentRepo repository.AssignmentRepository
 	questionRepo   repository.QuestionRepository
+	gradeEventRepo repository.GradeEventRepository
+	enrollmentRepo repository.EnrollmentRepository
+	lockRepo       repository.SchedulerLockRepository
+	access         AccessService
+	aiGrader       AIGrader
 }
 
 // NewGradingService 创建批改服务
@@ -39,12 +79,22 @@ func NewGradingService(
 	answerRepo repository.AnswerRepository,
 	assignmentRepo repository.AssignmentRepository,
 	questionRepo repository.QuestionRepository,
+	gradeEventRepo repository.GradeEventRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	lockRepo repository.SchedulerLockRepository,
+	access AccessService,
+	aiGrader AIGrader,
 ) GradingService {
 	return &gradingService{
 		submissionRepo: submissionRepo,
 		answerRepo:     answerRepo,
 		assignmentRepo: assignmentRepo,
 		questionRepo:   questionRepo,
+		gradeEventRepo: gradeEventRepo,
+		enrollmentRepo: enrollmentRepo,
+		lockRepo:       lockRepo,
+		access:         access,
+		aiGrader:       aiGrader,
 	}
 }
 
@@ -60,7 +110,7 @@ func (s *gradingService) GetSubmissionsForGrading(ctx context.Context, assignmen
 		return nil, 0, errors.New("assignment not found")
 	}
 
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		logger.Logger.Warn("Teacher has no permission to grade assignment",
 			zap.Uint("assignment_id", assignmentID),
 			zap.Uint("teacher_id", teacherID),
@@ -84,6 +134,11 @@ func (s *gradingService) GetSubmissionsForGrading(ctx context.Context, assignmen
 
 // GradeSubmission 批改单个提交
 func (s *gradingService) GradeSubmission(ctx context.Context, submissionID uint, req *model.GradeSubmissionRequest, teacherID uint) (*model.SubmissionDetail, error) {
+	return s.gradeSubmission(ctx, submissionID, req, teacherID, model.GradeEventSourceManual)
+}
+
+// gradeSubmission 批改单个提交，source 为成绩变更记录的来源
+func (s *gradingService) gradeSubmission(ctx context.Context, submissionID uint, req *model.GradeSubmissionRequest, teacherID uint, source model.GradeEventSource) (*model.SubmissionDetail, error) {
 	// 获取提交详情
 	submission, err := s.submissionRepo.GetByIDWithDetail(ctx, submissionID)
 	if err != nil {
@@ -94,22 +149,13 @@ func (s *gradingService) GradeSubmission(ctx context.Context, submissionID uint,
 		return nil, errors.New("submission not found")
 	}
 
-	// 验证提交状态
-	if submission.Status != model.SubmissionStatusSubmitted {
-		logger.Logger.Warn("Submission is not submitted yet",
-			zap.Uint("submission_id", submissionID),
-			zap.String("status", string(submission.Status)),
-		)
-		return nil, errors.New("submission is not submitted yet")
-	}
-
 	// 验证教师权限
 	assignment, err := s.assignmentRepo.GetByID(ctx, submission.AssignmentID)
 	if err != nil {
 		return nil, errors.New("assignment not found")
 	}
 
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		logger.Logger.Warn("Teacher has no permission to grade submission",
 			zap.Uint("submission_id", submissionID),
 			zap.Uint("teacher_id", teacherID),
@@ -118,8 +164,27 @@ func (s *gradingService) GradeSubmission(ctx context.Context, submissionID uint,
 		return nil, errors.New("teacher has no permission to grade this submission")
 	}
 
+	// 验证批改锁和版本号
+	now := time.Now()
+	if submission.IsLockedByOther(teacherID, now) {
+		return nil, ErrGradingLocked
+	}
+	if req.Version != nil && *req.Version != submission.Version {
+		return nil, ErrGradeConflict
+	}
+
+	// 验证提交状态
+	if submission.Status != model.SubmissionStatusSubmitted {
+		logger.Logger.Warn("Submission is not submitted yet",
+			zap.Uint("submission_id", submissionID),
+			zap.String("status", string(submission.Status)),
+		)
+		return nil, errors.New("submission is not submitted yet")
+	}
+
 	// 批改答案
-	totalScore := 0
+	gradedAnswers := make([]*model.Answer, 0, len(req.Answers))
+	events := make([]*model.GradeEvent, 0, len(req.Answers)+1)
 	for _, gradeAnswer := range req.Answers {
 		// 查找对应的答案记录
 		var targetAnswer *model.Answer
@@ -137,35 +202,67 @@ func (s *gradingService) GradeSubmission(ctx context.Context, submissionID uint,
 			)
 			continue
 		}
+		if gradeAnswer.Version != nil && *gradeAnswer.Version != targetAnswer.Version {
+			return nil, ErrGradeConflict
+		}
+
+		// 采纳 AI 建议时使用建议得分，教师填写的评语优先
+		if gradeAnswer.AcceptAI {
+			if targetAnswer.AIScore == nil {
+				return nil, errors.New("no ai suggestion to accept for question")
+			}
+			gradeAnswer.Score = *targetAnswer.AIScore
+			if gradeAnswer.Feedback == "" {
+				gradeAnswer.Feedback = targetAnswer.AIFeedback
+			}
+		}
+		// 人工给分和采纳的 AI 建议得分都不能超过题目分值
+		if gradeAnswer.Score > targetAnswer.Question.Score {
+			return nil, ErrGradeScoreExceeded
+		}
+		targetAnswer.AIAccepted = gradeAnswer.AcceptAI
 
 		// 更新答案得分和评语
+		oldScore, oldFeedback := targetAnswer.Score, targetAnswer.Feedback
 		targetAnswer.Score = gradeAnswer.Score
 		targetAnswer.Feedback = gradeAnswer.Feedback
-		isCorrect := gradeAnswer.Score > 0
+		isCorrect := gradeAnswer.Score == targetAnswer.Question.Score
 		targetAnswer.IsCorrect = &isCorrect
 
-		err := s.answerRepo.Update(ctx, targetAnswer)
-		if err != nil {
-			logger.Logger.Error("Failed to update answer score",
-				zap.Error(err),
-				zap.Uint("answer_id", targetAnswer.ID),
-			)
-			return nil, err
+		answerSource := source
+		if gradeAnswer.AcceptAI {
+			answerSource = model.GradeEventSourceAI
 		}
+		gradedAnswers = append(gradedAnswers, targetAnswer)
+		events = append(events, model.NewAnswerGradeEvent(targetAnswer, answerSource, &teacherID, oldScore, oldFeedback))
+	}
 
-		totalScore += gradeAnswer.Score
+	// 总分按全部答案重新计算，未在本次请求中的答案（自动判分、部分得分、编程题用例得分）保留原得分
+	totalScore := 0
+	for _, answer := range submission.Answers {
+		totalScore += answer.Score
 	}
 
-	// 更新提交状态和得分
-	submission.Submission.Score = totalScore
-	submission.Submission.Status = model.SubmissionStatusGraded
-	submission.Submission.GradedAt = &time.Time{}
-	*submission.Submission.GradedAt = time.Now()
+	// 在同一事务中保存答案、提交和成绩变更记录，任一记录已被修改时整体回滚
+	// 迟交的提交按提交时确定的比例扣分
+	oldTotal, oldFeedback := submission.Submission.Score, submission.Submission.Feedback
+	submission.Submission.ApplyLatePenalty(totalScore)
+	submission.Submission.GradedAt = &now
+	submission.Submission.GradedBy = teacherID
 	submission.Submission.Feedback = req.OverallFeedback
+	events = append(events, model.NewSubmissionGradeEvent(&submission.Submission, source, &teacherID, oldTotal, oldFeedback))
 
-	err = s.submissionRepo.Update(ctx, &submission.Submission)
+	err = s.submissionRepo.SaveGrading(ctx, &submission.Submission, gradedAnswers, events)
 	if err != nil {
-		logger.Logger.Error("Failed to update submission after grading",
+		if errors.Is(err, repository.ErrVersionConflict) {
+			logger.Logger.Warn("Grading conflict detected",
+				zap.Error(err),
+				zap.Uint("submission_id", submissionID),
+				zap.Uint("teacher_id", teacherID),
+			)
+			return nil, ErrGradeConflict
+		}
+		logger.Logger.Error("Failed to save grading",
 			zap.Error(err),
 			zap.Uint("submission_id", submissionID),
 		)
@@ -185,7 +282,8 @@ func (s *gradingService) GradeSubmission(ctx context.Context, submissionID uint,
 	logger.Logger.Info("Submission graded successfully",
 		zap.Uint("submission_id", submissionID),
 		zap.Uint("teacher_id", teacherID),
-		zap.Int("total_score", totalScore),
+		zap.Int("total_score", gradedSubmission.Submission.Score),
+		zap.Int("late_penalty", gradedSubmission.Submission.LatePenalty),
 	)
 
 	return gradedSubmission, nil
@@ -203,14 +301,16 @@ func (s *gradingService) BatchGrade(ctx context.Context, req *model.BatchGradeRe
 
 		// 构造单个批改请求
 		gradeReq := &model.GradeSubmissionRequest{
-			Answers:          batchItem.Answers,
-			OverallFeedback:  batchItem.OverallFeedback,
+			Answers:         batchItem.Answers,
+			OverallFeedback: batchItem.OverallFeedback,
+			Version:         batchItem.Version,
 		}
 
-		// 执行单个批改
-		_, err := s.GradeSubmission(ctx, batchItem.SubmissionID, gradeReq, teacherID)
+		// 执行单个批改，每个提交独立提交事务
+		_, err := s.gradeSubmission(ctx, batchItem.SubmissionID, gradeReq, teacherID, model.GradeEventSourceBatch)
 		if err != nil {
 			result.Error = err.Error()
+			result.Conflict = errors.Is(err, ErrGradeConflict) || errors.Is(err, ErrGradingLocked)
 			logger.Logger.Warn("Failed to grade submission in batch",
 				zap.Error(err),
 				zap.Uint("submission_id", batchItem.SubmissionID),
@@ -251,7 +351,7 @@ func (s *gradingService) PublishGrades(ctx context.Context, assignmentID, teache
 		return errors.New("assignment not found")
 	}
 
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		logger.Logger.Warn("Teacher has no permission to publish grades",
 			zap.Uint("assignment_id", assignmentID),
 			zap.Uint("teacher_id", teacherID),
@@ -294,7 +394,7 @@ func (s *gradingService) GetGradingProgress(ctx context.Context, assignmentID, t
 		return nil, errors.New("assignment not found")
 	}
 
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		logger.Logger.Warn("Teacher has no permission to view grading progress",
 			zap.Uint("assignment_id", assignmentID),
 			zap.Uint("teacher_id", teacherID),
@@ -327,4 +427,237 @@ func (s *gradingService) GetGradingProgress(ctx context.Context, assignmentID, t
 	}
 
 	return progress, nil
-}
\ No newline at end of file
+}
+
+// SuggestGrades 为提交中的主观题重新生成 AI 批改建议
+func (s *gradingService) SuggestGrades(ctx context.Context, submissionID, teacherID uint) ([]*model.AISuggestionResult, error) {
+	submission, err := s.submissionRepo.GetByIDWithDetail(ctx, submissionID)
+	if err != nil {
+		logger.Logger.Error("Failed to get submission for ai suggestion",
+			zap.Error(err),
+			zap.Uint("submission_id", submissionID),
+		)
+		return nil, errors.New("submission not found")
+	}
+
+	if !s.access.IsClassTeacher(ctx, submission.Assignment.ClassID, teacherID) {
+		return nil, errors.New("teacher has no permission to grade this submission")
+	}
+
+	results := make([]*model.AISuggestionResult, 0)
+	for i := range submission.Answers {
+		answer := &submission.Answers[i]
+		if !answer.Question.IsSubjective() {
+			continue
+		}
+
+		result := &model.AISuggestionResult{
+			QuestionID: answer.QuestionID,
+			AnswerID:   answer.ID,
+		}
+		if err := suggestAnswerGrade(ctx, s.aiGrader, &answer.Question, answer); err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		if err := s.answerRepo.Update(ctx, answer); err != nil {
+			logger.Logger.Error("Failed to save ai suggestion",
+				zap.Error(err),
+				zap.Uint("answer_id", answer.ID),
+			)
+			return nil, err
+		}
+
+		result.Suggestion = answer.GetAISuggestion()
+		results = append(results, result)
+	}
+
+	logger.Logger.Info("AI suggestions generated",
+		zap.Uint("submission_id", submissionID),
+		zap.Uint("teacher_id", teacherID),
+		zap.Int("count", len(results)),
+	)
+
+	return results, nil
+}
+
+// SuggestPendingAnswers 分批为已提交、尚未批改的主观题答案生成 AI 批改建议
+// 在后台任务中运行，模型响应慢或不可用时不影响学生提交和自动判分；同一答案失败达到上限后不再重试，教师可手动重新生成
+// 多个实例同时运行时只有持有领导锁的实例调用模型，每个答案生成前续期，模型调用耗时超过租期导致锁被其他实例接管时停止
+func (s *gradingService) SuggestPendingAnswers(ctx context.Context, owner string, lease time.Duration, now time.Time) (int, error) {
+	if s.aiGrader == nil {
+		return 0, nil
+	}
+	leader, err := s.lockRepo.TryAcquire(ctx, aiSuggestionLock, owner, lease, now)
+	if err != nil || !leader {
+		return 0, err
+	}
+	answers, err := s.answerRepo.GetPendingAISuggestions(ctx, maxAISuggestFails, aiSuggestBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	suggested := 0
+	for i, answer := range answers {
+		if ctx.Err() != nil {
+			return suggested, ctx.Err()
+		}
+		if i > 0 {
+			if leader, err := s.lockRepo.TryAcquire(ctx, aiSuggestionLock, owner, lease, time.Now()); err != nil || !leader {
+				return suggested, err
+			}
+		}
+		if err := suggestAnswerGrade(ctx, s.aiGrader, &answer.Question, answer); err != nil {
+			answer.AISuggestFails++
+		} else {
+			suggested++
+		}
+		if err := s.answerRepo.SaveAISuggestion(ctx, answer); err != nil {
+			return suggested, err
+		}
+	}
+	return suggested, nil
+}
+
+// ClaimSubmission 领取提交的批改锁，已持有时延长有效期
+func (s *gradingService) ClaimSubmission(ctx context.Context, submissionID, teacherID uint) (*model.GradingLock, error) {
+	submission, err := s.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, ErrSubmissionNotFound
+	}
+	if err := s.checkGradingAccess(ctx, submission.AssignmentID, teacherID); err != nil {
+		return nil, err
+	}
+	if submission.Status != model.SubmissionStatusSubmitted {
+		return nil, errors.New("submission is not submitted yet")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(GradingLockTTL)
+	claimed, err := s.submissionRepo.ClaimGradingLock(ctx, submissionID, teacherID, now, expiresAt)
+	if err != nil {
+		logger.Logger.Error("Failed to claim grading lock",
+			zap.Error(err),
+			zap.Uint("submission_id", submissionID),
+		)
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrGradingLocked
+	}
+
+	logger.Logger.Info("Grading lock claimed",
+		zap.Uint("submission_id", submissionID),
+		zap.Uint("teacher_id", teacherID),
+	)
+
+	return &model.GradingLock{SubmissionID: submissionID, LockedBy: teacherID, ExpiresAt: expiresAt}, nil
+}
+
+// ReleaseSubmission 释放提交的批改锁，提交未被领取时直接返回
+func (s *gradingService) ReleaseSubmission(ctx context.Context, submissionID, teacherID uint) error {
+	submission, err := s.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return ErrSubmissionNotFound
+	}
+	if err := s.checkGradingAccess(ctx, submission.AssignmentID, teacherID); err != nil {
+		return err
+	}
+
+	released, err := s.submissionRepo.ReleaseGradingLock(ctx, submissionID, teacherID)
+	if err != nil {
+		logger.Logger.Error("Failed to release grading lock",
+			zap.Error(err),
+			zap.Uint("submission_id", submissionID),
+		)
+		return err
+	}
+	if !released && submission.IsLockedByOther(teacherID, time.Now()) {
+		return ErrGradingLocked
+	}
+
+	logger.Logger.Info("Grading lock released",
+		zap.Uint("submission_id", submissionID),
+		zap.Uint("teacher_id", teacherID),
+	)
+
+	return nil
+}
+
+// ClaimNextSubmission 按提交时间领取作业中下一份未被其他教师领取的提交，并返回提交详情
+func (s *gradingService) ClaimNextSubmission(ctx context.Context, assignmentID, teacherID uint) (*model.SubmissionDetail, *model.GradingLock, error) {
+	if err := s.checkGradingAccess(ctx, assignmentID, teacherID); err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	candidates, err := s.submissionRepo.GetGradingCandidates(ctx, assignmentID, teacherID, now, gradingClaimCandidates)
+	if err != nil {
+		logger.Logger.Error("Failed to get grading candidates",
+			zap.Error(err),
+			zap.Uint("assignment_id", assignmentID),
+		)
+		return nil, nil, err
+	}
+
+	// 候选提交可能同时被其他教师领取，逐个尝试直到领取成功
+	expiresAt := now.Add(GradingLockTTL)
+	for _, submissionID := range candidates {
+		claimed, err := s.submissionRepo.ClaimGradingLock(ctx, submissionID, teacherID, now, expiresAt)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !claimed {
+			continue
+		}
+
+		detail, err := s.submissionRepo.GetByIDWithDetail(ctx, submissionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		logger.Logger.Info("Next submission claimed for grading",
+			zap.Uint("assignment_id", assignmentID),
+			zap.Uint("submission_id", submissionID),
+			zap.Uint("teacher_id", teacherID),
+		)
+
+		return detail, &model.GradingLock{SubmissionID: submissionID, LockedBy: teacherID, ExpiresAt: expiresAt}, nil
+	}
+
+	return nil, nil, ErrGradingQueueEmpty
+}
+
+// GetGradeTimeline 获取提交的成绩变更记录
+func (s *gradingService) GetGradeTimeline(ctx context.Context, submissionID, userID uint) ([]*model.GradeEvent, error) {
+	submission, err := s.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, ErrSubmissionNotFound
+	}
+
+	if submission.StudentID == userID {
+		assignment, err := s.assignmentRepo.GetByID(ctx, submission.AssignmentID)
+		if err != nil {
+			return nil, ErrAssignmentNotFound
+		}
+		if !assignment.GradesPublished {
+			return nil, ErrGradesNotPublished
+		}
+	} else if err := s.checkGradingAccess(ctx, submission.AssignmentID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.gradeEventRepo.GetBySubmissionID(ctx, submissionID)
+}
+
+// checkGradingAccess 验证教师是否可以批改该作业
+func (s *gradingService) checkGradingAccess(ctx context.Context, assignmentID, teacherID uint) error {
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return ErrAssignmentNotFound
+	}
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
+		return ErrAccessDenied
+	}
+	return nil
+}
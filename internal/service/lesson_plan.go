@@ -0,0 +1,413 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"ai-course/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrLessonPlanPublished    = errors.New("教案已发布")
+	ErrLessonPlanNotPublished = errors.New("教案尚未发布")
+	ErrInvalidPlanAssignment  = errors.New("关联的作业不存在或不属于该班级")
+	ErrInvalidPlanAttachment  = errors.New("关联的附件不存在或不属于该班级")
+)
+
+// LessonPlanService 教案服务接口
+type LessonPlanService interface {
+	// 教案管理
+	CreateLessonPlan(ctx context.Context, req *model.CreateLessonPlanRequest, teacherID uint) (*model.LessonPlan, error)
+	UpdateLessonPlan(ctx context.Context, id uint, req *model.UpdateLessonPlanRequest, teacherID uint) (*model.LessonPlan, error)
+	DeleteLessonPlan(ctx context.Context, id uint, teacherID uint) error
+	GetLessonPlanDetail(ctx context.Context, id uint, userID uint) (*model.LessonPlanDetailResponse, error)
+
+	// 教案列表
+	GetTeacherLessonPlans(ctx context.Context, teacherID uint, page, pageSize int) ([]*model.LessonPlanListResponse, int64, error)
+	GetClassLessonPlans(ctx context.Context, classID uint, userID uint) ([]*model.LessonPlanListResponse, error)
+
+	// 教案状态管理
+	PublishLessonPlan(ctx context.Context, id uint, teacherID uint) error
+	UnpublishLessonPlan(ctx context.Context, id uint, teacherID uint) error
+
+	// CloneLessonPlan 将教案复制到指定班级，新教案为草稿状态
+	CloneLessonPlan(ctx context.Context, id uint, req *model.CloneLessonPlanRequest, teacherID uint) (*model.LessonPlan, error)
+}
+
+// lessonPlanService 教案服务实现
+type lessonPlanService struct {
+	lessonPlanRepo repository.LessonPlanRepository
+	classRepo      repository.ClassRepository
+	enrollmentRepo repository.EnrollmentRepository
+	assignmentRepo repository.AssignmentRepository
+	attachmentRepo repository.AttachmentRepository
+	access         AccessService
+}
+
+// NewLessonPlanService 创建教案服务实例
+func NewLessonPlanService(
+	lessonPlanRepo repository.LessonPlanRepository,
+	classRepo repository.ClassRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	assignmentRepo repository.AssignmentRepository,
+	attachmentRepo repository.AttachmentRepository,
+	access AccessService,
+) LessonPlanService {
+	return &lessonPlanService{
+		lessonPlanRepo: lessonPlanRepo,
+		classRepo:      classRepo,
+		enrollmentRepo: enrollmentRepo,
+		assignmentRepo: assignmentRepo,
+		attachmentRepo: attachmentRepo,
+		access:         access,
+	}
+}
+
+// CreateLessonPlan 创建教案
+func (s *lessonPlanService) CreateLessonPlan(ctx context.Context, req *model.CreateLessonPlanRequest, teacherID uint) (*model.LessonPlan, error) {
+	if err := s.access.CheckClassManage(ctx, req.ClassID, teacherID); err != nil {
+		return nil, err
+	}
+
+	plan := &model.LessonPlan{
+		Title:           req.Title,
+		ClassID:         req.ClassID,
+		TeacherID:       teacherID,
+		Objectives:      req.Objectives,
+		KeyPoints:       req.KeyPoints,
+		DifficultPoints: req.DifficultPoints,
+		Status:          model.LessonPlanStatusDraft,
+		Steps:           buildPlanSteps(req.Steps),
+	}
+
+	var err error
+	if plan.Assignments, err = s.buildPlanAssignments(ctx, req.ClassID, req.Assignments); err != nil {
+		return nil, err
+	}
+	if plan.Attachments, err = s.buildPlanAttachments(ctx, req.ClassID, req.AttachmentIDs); err != nil {
+		return nil, err
+	}
+
+	if err := s.lessonPlanRepo.Create(ctx, plan); err != nil {
+		return nil, fmt.Errorf("create lesson plan failed: %w", err)
+	}
+
+	return plan, nil
+}
+
+// UpdateLessonPlan 更新教案
+func (s *lessonPlanService) UpdateLessonPlan(ctx context.Context, id uint, req *model.UpdateLessonPlanRequest, teacherID uint) (*model.LessonPlan, error) {
+	plan, err := s.getManagedPlan(ctx, id, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != "" {
+		plan.Title = req.Title
+	}
+	if req.Objectives != "" {
+		plan.Objectives = req.Objectives
+	}
+	if req.KeyPoints != "" {
+		plan.KeyPoints = req.KeyPoints
+	}
+	if req.DifficultPoints != "" {
+		plan.DifficultPoints = req.DifficultPoints
+	}
+
+	// 提供的关联内容整体替换
+	if req.Steps != nil {
+		plan.Steps = buildPlanSteps(req.Steps)
+	}
+	if req.Assignments != nil {
+		if plan.Assignments, err = s.buildPlanAssignments(ctx, plan.ClassID, req.Assignments); err != nil {
+			return nil, err
+		}
+	}
+	if req.AttachmentIDs != nil {
+		if plan.Attachments, err = s.buildPlanAttachments(ctx, plan.ClassID, req.AttachmentIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.lessonPlanRepo.Update(ctx, plan); err != nil {
+		return nil, fmt.Errorf("update lesson plan failed: %w", err)
+	}
+
+	return plan, nil
+}
+
+// DeleteLessonPlan 删除教案
+func (s *lessonPlanService) DeleteLessonPlan(ctx context.Context, id uint, teacherID uint) error {
+	if _, err := s.getManagedPlan(ctx, id, teacherID); err != nil {
+		return err
+	}
+
+	if err := s.lessonPlanRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete lesson plan failed: %w", err)
+	}
+	return nil
+}
+
+// GetLessonPlanDetail 获取教案详情
+// 学生只能查看已发布的教案，且只能看到已发布的关联作业
+func (s *lessonPlanService) GetLessonPlanDetail(ctx context.Context, id uint, userID uint) (*model.LessonPlanDetailResponse, error) {
+	if err := s.access.CheckLessonPlanView(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	plan, err := s.lessonPlanRepo.GetDetailByID(ctx, id)
+	if err != nil {
+		return nil, ErrLessonPlanNotFound
+	}
+
+	if !s.access.IsClassTeacher(ctx, plan.ClassID, userID) {
+		visible := make([]model.LessonPlanAssignment, 0, len(plan.Assignments))
+		for _, item := range plan.Assignments {
+			if item.Assignment.ID != 0 && item.Assignment.Status != "draft" {
+				visible = append(visible, item)
+			}
+		}
+		plan.Assignments = visible
+	}
+
+	return &model.LessonPlanDetailResponse{
+		LessonPlan:    *plan,
+		TotalDuration: plan.TotalDuration(),
+	}, nil
+}
+
+// GetTeacherLessonPlans 获取教师教案列表
+func (s *lessonPlanService) GetTeacherLessonPlans(ctx context.Context, teacherID uint, page, pageSize int) ([]*model.LessonPlanListResponse, int64, error) {
+	offset := (page - 1) * pageSize
+	plans, total, err := s.lessonPlanRepo.GetByTeacherID(ctx, teacherID, offset, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get teacher lesson plans failed: %w", err)
+	}
+
+	return toLessonPlanList(plans), total, nil
+}
+
+// GetClassLessonPlans 获取班级教案列表
+// 任课教师可以看到全部教案，班级学生只能看到已发布的教案
+func (s *lessonPlanService) GetClassLessonPlans(ctx context.Context, classID uint, userID uint) ([]*model.LessonPlanListResponse, error) {
+	if _, err := s.classRepo.FindByID(ctx, classID); err != nil {
+		return nil, ErrClassNotFound
+	}
+
+	status := model.LessonPlanStatus("")
+	if !s.access.IsClassTeacher(ctx, classID, userID) {
+		enrolled, err := s.enrollmentRepo.IsActiveMember(ctx, classID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !enrolled {
+			return nil, ErrAccessDenied
+		}
+		status = model.LessonPlanStatusPublished
+	}
+
+	plans, err := s.lessonPlanRepo.GetByClassID(ctx, classID, status)
+	if err != nil {
+		return nil, fmt.Errorf("get class lesson plans failed: %w", err)
+	}
+
+	return toLessonPlanList(plans), nil
+}
+
+// PublishLessonPlan 发布教案
+func (s *lessonPlanService) PublishLessonPlan(ctx context.Context, id uint, teacherID uint) error {
+	plan, err := s.getManagedPlan(ctx, id, teacherID)
+	if err != nil {
+		return err
+	}
+
+	if plan.IsPublished() {
+		return ErrLessonPlanPublished
+	}
+
+	now := time.Now()
+	plan.Status = model.LessonPlanStatusPublished
+	plan.PublishedAt = &now
+
+	return s.lessonPlanRepo.Update(ctx, plan)
+}
+
+// UnpublishLessonPlan 取消发布教案
+func (s *lessonPlanService) UnpublishLessonPlan(ctx context.Context, id uint, teacherID uint) error {
+	plan, err := s.getManagedPlan(ctx, id, teacherID)
+	if err != nil {
+		return err
+	}
+
+	if !plan.IsPublished() {
+		return ErrLessonPlanNotPublished
+	}
+
+	plan.Status = model.LessonPlanStatusDraft
+	plan.PublishedAt = nil
+
+	return s.lessonPlanRepo.Update(ctx, plan)
+}
+
+// CloneLessonPlan 将教案复制到指定班级，新教案为草稿状态
+// 教学环节全部复制；关联的作业和附件属于原班级，只有复制到同一班级时才会保留
+func (s *lessonPlanService) CloneLessonPlan(ctx context.Context, id uint, req *model.CloneLessonPlanRequest, teacherID uint) (*model.LessonPlan, error) {
+	if err := s.access.CheckLessonPlanManage(ctx, id, teacherID); err != nil {
+		return nil, err
+	}
+	if err := s.access.CheckClassManage(ctx, req.ClassID, teacherID); err != nil {
+		return nil, err
+	}
+
+	source, err := s.lessonPlanRepo.GetDetailByID(ctx, id)
+	if err != nil {
+		return nil, ErrLessonPlanNotFound
+	}
+
+	title := req.Title
+	if title == "" {
+		title = source.Title
+	}
+
+	sourceID := source.ID
+	plan := &model.LessonPlan{
+		Title:           title,
+		ClassID:         req.ClassID,
+		TeacherID:       teacherID,
+		Objectives:      source.Objectives,
+		KeyPoints:       source.KeyPoints,
+		DifficultPoints: source.DifficultPoints,
+		Status:          model.LessonPlanStatusDraft,
+		SourcePlanID:    &sourceID,
+	}
+
+	for _, step := range source.Steps {
+		plan.Steps = append(plan.Steps, model.LessonPlanStep{
+			Order:    step.Order,
+			Title:    step.Title,
+			Content:  step.Content,
+			Duration: step.Duration,
+		})
+	}
+
+	if req.ClassID == source.ClassID {
+		for _, item := range source.Assignments {
+			plan.Assignments = append(plan.Assignments, model.LessonPlanAssignment{
+				AssignmentID: item.AssignmentID,
+				Usage:        item.Usage,
+				Order:        item.Order,
+			})
+		}
+		for _, item := range source.Attachments {
+			plan.Attachments = append(plan.Attachments, model.LessonPlanAttachment{
+				AttachmentID: item.AttachmentID,
+			})
+		}
+	}
+
+	if err := s.lessonPlanRepo.Create(ctx, plan); err != nil {
+		return nil, fmt.Errorf("clone lesson plan failed: %w", err)
+	}
+
+	return plan, nil
+}
+
+// getManagedPlan 获取教案并校验管理权限
+func (s *lessonPlanService) getManagedPlan(ctx context.Context, id uint, teacherID uint) (*model.LessonPlan, error) {
+	plan, err := s.lessonPlanRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrLessonPlanNotFound
+	}
+	if !s.access.IsClassTeacher(ctx, plan.ClassID, teacherID) {
+		return nil, ErrAccessDenied
+	}
+	return plan, nil
+}
+
+// buildPlanAssignments 校验并构造教案关联作业，作业必须属于教案所在班级
+func (s *lessonPlanService) buildPlanAssignments(ctx context.Context, classID uint, reqs []model.LessonPlanAssignmentRequest) ([]model.LessonPlanAssignment, error) {
+	items := make([]model.LessonPlanAssignment, 0, len(reqs))
+	seen := make(map[uint]bool, len(reqs))
+	for i, req := range reqs {
+		if seen[req.AssignmentID] {
+			continue
+		}
+		seen[req.AssignmentID] = true
+
+		assignment, err := s.assignmentRepo.GetByID(ctx, req.AssignmentID)
+		if err != nil || assignment.ClassID != classID {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidPlanAssignment, req.AssignmentID)
+		}
+
+		usage := req.Usage
+		if usage == "" {
+			usage = model.LessonPlanUsageHomework
+		}
+		items = append(items, model.LessonPlanAssignment{
+			AssignmentID: req.AssignmentID,
+			Usage:        usage,
+			Order:        i + 1,
+		})
+	}
+	return items, nil
+}
+
+// buildPlanAttachments 校验并构造教案关联附件，附件必须属于教案所在班级的作业
+func (s *lessonPlanService) buildPlanAttachments(ctx context.Context, classID uint, attachmentIDs []uint) ([]model.LessonPlanAttachment, error) {
+	items := make([]model.LessonPlanAttachment, 0, len(attachmentIDs))
+	seen := make(map[uint]bool, len(attachmentIDs))
+	for _, attachmentID := range attachmentIDs {
+		if seen[attachmentID] {
+			continue
+		}
+		seen[attachmentID] = true
+
+		attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidPlanAttachment, attachmentID)
+		}
+		assignment, err := s.assignmentRepo.GetByID(ctx, attachment.AssignmentID)
+		if err != nil || assignment.ClassID != classID {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidPlanAttachment, attachmentID)
+		}
+
+		items = append(items, model.LessonPlanAttachment{AttachmentID: attachmentID})
+	}
+	return items, nil
+}
+
+// buildPlanSteps 构造教学环节，顺序按请求中的先后排列
+func buildPlanSteps(reqs []model.LessonPlanStepRequest) []model.LessonPlanStep {
+	steps := make([]model.LessonPlanStep, len(reqs))
+	for i, req := range reqs {
+		steps[i] = model.LessonPlanStep{
+			Order:    i + 1,
+			Title:    req.Title,
+			Content:  req.Content,
+			Duration: req.Duration,
+		}
+	}
+	return steps
+}
+
+// toLessonPlanList 转换为教案列表响应
+func toLessonPlanList(plans []*model.LessonPlan) []*model.LessonPlanListResponse {
+	result := make([]*model.LessonPlanListResponse, len(plans))
+	for i, plan := range plans {
+		result[i] = &model.LessonPlanListResponse{
+			ID:            plan.ID,
+			Title:         plan.Title,
+			ClassID:       plan.ClassID,
+			ClassName:     plan.Class.ClassName,
+			Status:        plan.Status,
+			StepCount:     len(plan.Steps),
+			TotalDuration: plan.TotalDuration(),
+			CreatedAt:     plan.CreatedAt,
+			PublishedAt:   plan.PublishedAt,
+		}
+	}
+	return result
+}
@@ -2,10 +2,15 @@ package service
 
 import (
 	"ai-course/internal/model"
+	"ai-course/internal/pkg/document"
 	"ai-course/internal/repository"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime/multipart"
+	"os"
+	"strings"
 )
 
 // QuestionService 题目服务接口
@@ -16,27 +21,46 @@ type QuestionService interface {
 	DeleteQuestion(ctx context.Context, id uint, teacherID uint) error
 	GetQuestion(ctx context.Context, id uint) (*model.Question, error)
 	
+	// CreateQuestions 批量创建题目（全部校验通过后一次写入）
+	CreateQuestions(ctx context.Context, reqs []model.CreateQuestionRequest, assignmentID uint, teacherID uint) ([]*model.Question, error)
+	// GenerateQuestions 根据文本、附件或上传文件生成题目草稿（不入库）
+	GenerateQuestions(ctx context.Context, assignmentID uint, teacherID uint, req *model.GenerateQuestionsRequest, file *multipart.FileHeader) (*model.GenerateQuestionsResponse, error)
+	// ImportQuestions 从 QTI、Moodle XML、GIFT 或 Markdown 文件导入题目，返回每道题目的校验结果
+	ImportQuestions(ctx context.Context, assignmentID uint, teacherID uint, file *multipart.FileHeader, opts *model.QuestionImportOptions) (*model.QuestionImportReport, error)
+	// ExportQuestions 将作业的题目导出为 QTI、Moodle XML、GIFT 或 Markdown 文件
+	ExportQuestions(ctx context.Context, assignmentID uint, teacherID uint, format string) (*model.QuestionExportFile, error)
+	
 	// 题目列表
 	GetQuestionsByAssignmentID(ctx context.Context, assignmentID uint) ([]*model.QuestionDetailResponse, error)
 	
 	// 题目验证
-	ValidateAnswer(ctx context.Context, questionID uint, answer string) (bool, int, error)
+	// ValidateAnswer 验证答案，optionKeys 为选项打乱时学生看到的选项顺序（原选项标识），为空表示按原顺序作答
+	ValidateAnswer(ctx context.Context, questionID uint, answer string, optionKeys []string) (bool, int, error)
 }
 
 // questionService 题目服务实现
 type questionService struct {
 	questionRepo   repository.QuestionRepository
 	assignmentRepo repository.AssignmentRepository
+	attachmentRepo repository.AttachmentRepository
+	access         AccessService
+	generator      QuestionGenerator
 }
 
 // NewQuestionService 创建题目服务实例
 func NewQuestionService(
 	questionRepo repository.QuestionRepository,
 	assignmentRepo repository.AssignmentRepository,
+	attachmentRepo repository.AttachmentRepository,
+	access AccessService,
+	generator QuestionGenerator,
 ) QuestionService {
 	return &questionService{
 		questionRepo:   questionRepo,
 		assignmentRepo: assignmentRepo,
+		attachmentRepo: attachmentRepo,
+		access:         access,
+		generator:      generator,
 	}
 }
 
@@ -48,7 +72,7 @@ func (s *questionService) CreateQuestion(ctx context.Context, req *model.CreateQ
 		return nil, fmt.Errorf("assignment not found: %w", err)
 	}
 	
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		return nil, fmt.Errorf("teacher has no permission to add question to this assignment")
 	}
 	
@@ -57,7 +81,24 @@ func (s *questionService) CreateQuestion(ctx context.Context, req *model.CreateQ
 		return nil, fmt.Errorf("cannot add question to published assignment")
 	}
 	
-	// 创建题目
+	if err := validateQuestionDraft(req); err != nil {
+		return nil, fmt.Errorf("question invalid: %w", err)
+	}
+	
+	question, err := newQuestionFromRequest(req, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	
+	if err := s.questionRepo.Create(ctx, question); err != nil {
+		return nil, fmt.Errorf("create question failed: %w", err)
+	}
+	
+	return question, nil
+}
+
+// newQuestionFromRequest 根据创建请求构造题目
+func newQuestionFromRequest(req *model.CreateQuestionRequest, assignmentID uint) (*model.Question, error) {
 	question := &model.Question{
 		AssignmentID:  assignmentID,
 		Type:          req.Type,
@@ -67,6 +108,7 @@ func (s *questionService) CreateQuestion(ctx context.Context, req *model.CreateQ
 		CorrectAnswer: req.CorrectAnswer,
 		Reference:     req.Reference,
 		Explanation:   req.Explanation,
+		Pool:          req.Pool,
 	}
 	
 	// 处理选择题选项
@@ -79,12 +121,14 @@ func (s *questionService) CreateQuestion(ctx context.Context, req *model.CreateQ
 		
 		// 处理选择题答案
 		if req.IsMultiple {
-			// 多选题：将正确选项的keys组合成JSON数组
+			// 多选题：将正确选项的keys按选项顺序组合成JSON数组
+			answerKeys := make(map[string]bool)
+			for _, key := range splitAnswerKeys(req.CorrectAnswer) {
+				answerKeys[key] = true
+			}
 			correctKeys := make([]string, 0)
 			for _, option := range req.Options {
-				// 这里需要根据实际的选择逻辑来判断哪些选项是正确的
-				// 暂时使用简单的逻辑：如果CorrectAnswer包含选项的key，则认为是正确答案
-				if contains(req.CorrectAnswer, option.Key) {
+				if answerKeys[option.Key] {
 					correctKeys = append(correctKeys, option.Key)
 				}
 			}
@@ -96,13 +140,182 @@ func (s *questionService) CreateQuestion(ctx context.Context, req *model.CreateQ
 		}
 	}
 	
-	if err := s.questionRepo.Create(ctx, question); err != nil {
-		return nil, fmt.Errorf("create question failed: %w", err)
+	// 处理连线、排序、数值、完形填空和编程题的结构化设置
+	if err := encodeStructuredQuestion(question, req); err != nil {
+		return nil, err
+	}
+	
+	// 处理计分规则
+	if req.ScoringRule != nil {
+		ruleJSON, err := json.Marshal(req.ScoringRule)
+		if err != nil {
+			return nil, fmt.Errorf("marshal scoring rule failed: %w", err)
+		}
+		question.ScoringRule = string(ruleJSON)
+		
+		// 多空填空题未填写正确答案时，以每个空的第一个可接受答案作为标准答案
+		if req.Type == model.QuestionTypeFillBlank && question.CorrectAnswer == "" && len(req.ScoringRule.Blanks) > 0 {
+			firstAnswers := make([]string, len(req.ScoringRule.Blanks))
+			for i, accepted := range req.ScoringRule.Blanks {
+				firstAnswers[i] = accepted[0]
+			}
+			answersJSON, _ := json.Marshal(firstAnswers)
+			question.CorrectAnswer = string(answersJSON)
+		}
 	}
 	
 	return question, nil
 }
 
+// CreateQuestions 批量创建题目（全部校验通过后一次写入）
+func (s *questionService) CreateQuestions(ctx context.Context, reqs []model.CreateQuestionRequest, assignmentID uint, teacherID uint) ([]*model.Question, error) {
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("assignment not found: %w", err)
+	}
+	
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
+		return nil, fmt.Errorf("teacher has no permission to add question to this assignment")
+	}
+	
+	if assignment.Status == "published" {
+		return nil, fmt.Errorf("cannot add question to published assignment")
+	}
+	
+	questions := make([]*model.Question, 0, len(reqs))
+	for i := range reqs {
+		if err := validateQuestionDraft(&reqs[i]); err != nil {
+			return nil, fmt.Errorf("question %d invalid: %w", i+1, err)
+		}
+		question, err := newQuestionFromRequest(&reqs[i], assignmentID)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	
+	if err := s.questionRepo.CreateBatch(ctx, questions); err != nil {
+		return nil, fmt.Errorf("create questions failed: %w", err)
+	}
+	
+	return questions, nil
+}
+
+// GenerateQuestions 根据文本、附件或上传文件生成题目草稿（不入库）
+// 草稿会按创建题目接口的规则校验，未通过校验的草稿连同原因单独返回
+func (s *questionService) GenerateQuestions(ctx context.Context, assignmentID uint, teacherID uint, req *model.GenerateQuestionsRequest, file *multipart.FileHeader) (*model.GenerateQuestionsResponse, error) {
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
+		return nil, ErrAccessDenied
+	}
+	
+	material, err := s.loadMaterial(ctx, assignmentID, req, file)
+	if err != nil {
+		return nil, err
+	}
+	
+	types := req.Types
+	if len(types) == 0 {
+		types = allQuestionTypes
+	}
+	count := req.Count
+	if count <= 0 {
+		count = defaultGenerateCount
+	}
+	
+	result, err := s.generator.Generate(ctx, &QuestionGenerateRequest{
+		Material: material,
+		Types:    types,
+		Count:    count,
+	})
+	if err != nil {
+		if errors.Is(err, ErrMaterialTooShort) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrGenerateQuestions, err)
+	}
+	
+	// 新题目排在已有题目之后
+	existing, err := s.questionRepo.GetByAssignmentID(ctx, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("get questions by assignment id failed: %w", err)
+	}
+	order := 0
+	for _, q := range existing {
+		if q.Order > order {
+			order = q.Order
+		}
+	}
+	
+	allowed := make(map[model.QuestionType]bool, len(types))
+	for _, t := range types {
+		allowed[t] = true
+	}
+	
+	response := &model.GenerateQuestionsResponse{
+		Model:     result.Model,
+		Questions: make([]model.CreateQuestionRequest, 0, len(result.Questions)),
+	}
+	for _, draft := range result.Questions {
+		if len(response.Questions) >= count {
+			break
+		}
+		
+		draft.Content = strings.TrimSpace(draft.Content)
+		if req.Score > 0 {
+			draft.Score = req.Score
+		} else if draft.Score <= 0 {
+			draft.Score = defaultQuestionScore(draft.Type)
+		}
+		draft.Order = order + len(response.Questions) + 1
+		
+		if !allowed[draft.Type] {
+			response.Rejected = append(response.Rejected, model.RejectedQuestion{Question: draft, Reason: fmt.Sprintf("不需要的题目类型 %s", draft.Type)})
+			continue
+		}
+		if err := validateQuestionDraft(&draft); err != nil {
+			response.Rejected = append(response.Rejected, model.RejectedQuestion{Question: draft, Reason: err.Error()})
+			continue
+		}
+		response.Questions = append(response.Questions, draft)
+	}
+	
+	return response, nil
+}
+
+// loadMaterial 读取出题材料，优先级为上传文件、附件、粘贴文本
+func (s *questionService) loadMaterial(ctx context.Context, assignmentID uint, req *model.GenerateQuestionsRequest, file *multipart.FileHeader) (string, error) {
+	if file != nil {
+		f, err := file.Open()
+		if err != nil {
+			return "", fmt.Errorf("open uploaded file failed: %w", err)
+		}
+		defer f.Close()
+		return document.ExtractText(file.Filename, f)
+	}
+	
+	if req.AttachmentID != 0 {
+		attachment, err := s.attachmentRepo.GetByID(ctx, req.AttachmentID)
+		if err != nil || attachment.AssignmentID != assignmentID {
+			return "", ErrAttachmentNotFound
+		}
+		f, err := os.Open(attachment.FilePath)
+		if err != nil {
+			return "", fmt.Errorf("open attachment file failed: %w", err)
+		}
+		defer f.Close()
+		return document.ExtractText(attachment.OriginalName, f)
+	}
+	
+	if text := strings.TrimSpace(req.Text); text != "" {
+		return text, nil
+	}
+	return "", ErrMaterialRequired
+}
+
 // UpdateQuestion 更新题目
 func (s *questionService) UpdateQuestion(ctx context.Context, id uint, req *model.UpdateQuestionRequest, teacherID uint) (*model.Question, error) {
 	// 获取题目并验证权限
@@ -117,7 +330,7 @@ func (s *questionService) UpdateQuestion(ctx context.Context, id uint, req *mode
 		return nil, fmt.Errorf("assignment not found: %w", err)
 	}
 	
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		return nil, fmt.Errorf("teacher has no permission to update this question")
 	}
 	
@@ -126,6 +339,31 @@ func (s *questionService) UpdateQuestion(ctx context.Context, id uint, req *mode
 		return nil, fmt.Errorf("cannot update question in published assignment")
 	}
 	
+	// 连线、排序、数值、完形填空和编程题以现有设置为基础合并修改
+	var draft *model.CreateQuestionRequest
+	if isStructuredType(question.Type) {
+		draft = &model.CreateQuestionRequest{Type: question.Type}
+		decodeStructuredQuestion(draft, question.Options, question.CorrectAnswer)
+		if len(req.Options) > 0 {
+			draft.Options = req.Options
+		}
+		if req.CorrectAnswer != "" {
+			draft.CorrectAnswer = req.CorrectAnswer
+		}
+		if req.Matching != nil {
+			draft.Matching = req.Matching
+		}
+		if req.Numeric != nil {
+			draft.Numeric = req.Numeric
+		}
+		if len(req.Cloze) > 0 {
+			draft.Cloze = req.Cloze
+		}
+		if req.Code != nil {
+			draft.Code = req.Code
+		}
+	}
+	
 	// 更新字段
 	if req.Content != "" {
 		question.Content = req.Content
@@ -145,9 +383,20 @@ func (s *questionService) UpdateQuestion(ctx context.Context, id uint, req *mode
 	if req.Explanation != "" {
 		question.Explanation = req.Explanation
 	}
+	if req.Pool != nil {
+		question.Pool = *req.Pool
+	}
 	
-	// 处理选择题选项更新
-	if len(req.Options) > 0 {
+	// 处理结构化题型和选择题选项更新
+	if draft != nil {
+		draft.Content = question.Content
+		if err := validateStructuredDraft(draft); err != nil {
+			return nil, fmt.Errorf("question invalid: %w", err)
+		}
+		if err := encodeStructuredQuestion(question, draft); err != nil {
+			return nil, err
+		}
+	} else if len(req.Options) > 0 {
 		optionsJSON, err := json.Marshal(req.Options)
 		if err != nil {
 			return nil, fmt.Errorf("marshal question options failed: %w", err)
@@ -155,6 +404,15 @@ func (s *questionService) UpdateQuestion(ctx context.Context, id uint, req *mode
 		question.Options = string(optionsJSON)
 	}
 	
+	// 更新计分规则
+	if req.ScoringRule != nil {
+		ruleJSON, err := json.Marshal(req.ScoringRule)
+		if err != nil {
+			return nil, fmt.Errorf("marshal scoring rule failed: %w", err)
+		}
+		question.ScoringRule = string(ruleJSON)
+	}
+	
 	if err := s.questionRepo.Update(ctx, question); err != nil {
 		return nil, fmt.Errorf("update question failed: %w", err)
 	}
@@ -176,7 +434,7 @@ func (s *questionService) DeleteQuestion(ctx context.Context, id uint, teacherID
 		return fmt.Errorf("assignment not found: %w", err)
 	}
 	
-	if assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
 		return fmt.Errorf("teacher has no permission to delete this question")
 	}
 	
@@ -208,38 +466,15 @@ func (s *questionService) GetQuestionsByAssignmentID(ctx context.Context, assign
 	
 	result := make([]*model.QuestionDetailResponse, len(questions))
 	for i, q := range questions {
-		questionDetail := &model.QuestionDetailResponse{
-			Question: *q,
-		}
-		
-		// 处理选择题选项
-		if q.Type == model.QuestionTypeChoice && q.Options != "" {
-			var options []model.QuestionOption
-			if err := json.Unmarshal([]byte(q.Options), &options); err == nil {
-				questionDetail.OptionList = options
-				
-				// 判断是否为多选题
-				if q.CorrectAnswer != "" && q.CorrectAnswer[0] == '[' {
-					questionDetail.IsMultiple = true
-					var correctKeys []string
-					if err := json.Unmarshal([]byte(q.CorrectAnswer), &correctKeys); err == nil {
-						questionDetail.CorrectKeys = correctKeys
-					}
-				} else {
-					questionDetail.IsMultiple = false
-					questionDetail.CorrectKeys = []string{q.CorrectAnswer}
-				}
-			}
-		}
-		
-		result[i] = questionDetail
+		questionDetail := newQuestionDetail(*q)
+		result[i] = &questionDetail
 	}
 	
 	return result, nil
 }
 
 // ValidateAnswer 验证答案
-func (s *questionService) ValidateAnswer(ctx context.Context, questionID uint, answer string) (bool, int, error) {
+func (s *questionService) ValidateAnswer(ctx context.Context, questionID uint, answer string, optionKeys []string) (bool, int, error) {
 	question, err := s.questionRepo.GetByID(ctx, questionID)
 	if err != nil {
 		return false, 0, fmt.Errorf("question not found: %w", err)
@@ -248,25 +483,46 @@ func (s *questionService) ValidateAnswer(ctx context.Context, questionID uint, a
 	// 根据题目类型验证答案
 	switch question.Type {
 	case model.QuestionTypeChoice:
-		return s.validateChoiceAnswer(question, answer)
+		return s.validateChoiceAnswer(question, answer, optionKeys)
 	case model.QuestionTypeFillBlank:
 		return s.validateFillBlankAnswer(question, answer)
 	case model.QuestionTypeTrueFalse:
 		return s.validateTrueFalseAnswer(question, answer)
+	case model.QuestionTypeMatching:
+		return s.validateMatchingAnswer(question, answer)
+	case model.QuestionTypeOrdering:
+		return s.validateOrderingAnswer(question, answer)
+	case model.QuestionTypeNumeric:
+		return s.validateNumericAnswer(question, answer)
+	case model.QuestionTypeCloze:
+		return s.validateClozeAnswer(question, answer)
 	case model.QuestionTypeEssay:
 		// 简答题需要人工判分
 		return false, 0, nil
+	case model.QuestionTypeCode:
+		// 编程题在提交判分时由沙箱运行测试用例
+		return false, 0, nil
 	default:
 		return false, 0, fmt.Errorf("unsupported question type: %s", question.Type)
 	}
 }
 
-// validateChoiceAnswer 验证选择题答案
-func (s *questionService) validateChoiceAnswer(question *model.Question, answer string) (bool, int, error) {
+// validateChoiceAnswer 验证选择题答案，多选题按计分规则给部分分
+// 选项打乱时学生答案中的选项标识为学生看到的标识，先转换为原选项标识再判分
+func (s *questionService) validateChoiceAnswer(question *model.Question, answer string, optionKeys []string) (bool, int, error) {
 	if question.CorrectAnswer == "" {
 		return false, 0, fmt.Errorf("question has no correct answer")
 	}
 	
+	var keyMap map[string]string
+	if len(optionKeys) > 0 {
+		var options []model.QuestionOption
+		if err := json.Unmarshal([]byte(question.Options), &options); err != nil {
+			return false, 0, fmt.Errorf("parse question options failed: %w", err)
+		}
+		keyMap = displayKeyMap(options, optionKeys)
+	}
+	
 	// 检查是否为多选题
 	if question.CorrectAnswer[0] == '[' {
 		// 多选题
@@ -275,49 +531,50 @@ func (s *questionService) validateChoiceAnswer(question *model.Question, answer
 			return false, 0, fmt.Errorf("parse correct answer failed: %w", err)
 		}
 		
-		var studentKeys []string
-		if err := json.Unmarshal([]byte(answer), &studentKeys); err != nil {
-			return false, 0, fmt.Errorf("parse student answer failed: %w", err)
-		}
-		
-		// 比较答案
-		if len(correctKeys) != len(studentKeys) {
-			return false, 0, nil
-		}
-		
-		correctMap := make(map[string]bool)
-		for _, key := range correctKeys {
-			correctMap[key] = true
-		}
-		
-		for _, key := range studentKeys {
-			if !correctMap[key] {
-				return false, 0, nil
-			}
+		rule, err := question.GetScoringRule()
+		if err != nil {
+			return false, 0, fmt.Errorf("parse scoring rule failed: %w", err)
 		}
 		
-		return true, question.Score, nil
+		// 学生答案支持 JSON 数组和逗号分隔两种格式
+		studentKeys := canonicalKeys(splitAnswerKeys(answer), keyMap)
+		isCorrect, score := scoreByRatio(question.Score, multiSelectRatio(rule, question.Score, correctKeys, studentKeys))
+		return isCorrect, score, nil
 	} else {
 		// 单选题
-		if answer == question.CorrectAnswer {
+		studentKey := strings.TrimSpace(answer)
+		if canonical, ok := keyMap[studentKey]; ok {
+			studentKey = canonical
+		}
+		if studentKey == question.CorrectAnswer {
 			return true, question.Score, nil
 		}
 		return false, 0, nil
 	}
 }
 
-// validateFillBlankAnswer 验证填空题答案
+// validateFillBlankAnswer 验证填空题答案，支持多个可接受答案、答案标准化、数值误差，多个空分别计分
 func (s *questionService) validateFillBlankAnswer(question *model.Question, answer string) (bool, int, error) {
-	if question.CorrectAnswer == "" {
+	rule, err := question.GetScoringRule()
+	if err != nil {
+		return false, 0, fmt.Errorf("parse scoring rule failed: %w", err)
+	}
+	
+	blanks := fillBlankAccepted(question, rule)
+	if len(blanks) == 0 {
 		return false, 0, fmt.Errorf("question has no correct answer")
 	}
 	
-	// 简单的字符串匹配（可以后续增强为支持多个正确答案、忽略大小写等）
-	if answer == question.CorrectAnswer {
-		return true, question.Score, nil
+	answers := splitBlankAnswers(answer)
+	correct := 0
+	for i, accepted := range blanks {
+		if i < len(answers) && matchBlank(rule, answers[i], accepted) {
+			correct++
+		}
 	}
 	
-	return false, 0, nil
+	isCorrect, score := scoreByRatio(question.Score, float64(correct)/float64(len(blanks)))
+	return isCorrect, score, nil
 }
 
 // validateTrueFalseAnswer 验证判断题答案
@@ -337,12 +594,6 @@ func (s *questionService) validateTrueFalseAnswer(question *model.Question, answ
 	return false, 0, nil
 }
 
-// 辅助函数：检查字符串是否包含子字符串
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || 
-		(len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr)))
-}
-
 // 辅助函数：标准化布尔值表示
 func normalizeBoolean(s string) string {
 	switch s {
@@ -0,0 +1,422 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"ai-course/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"strings"
+)
+
+var (
+	ErrBankItemNotFound    = errors.New("题库题目不存在")
+	ErrQuestionNotFound    = errors.New("题目不存在")
+	ErrAssignmentPublished = errors.New("已发布的作业不能添加题目")
+	ErrInvalidBankItem     = errors.New("题库题目内容无效")
+)
+
+// QuestionBankService 题库服务接口
+type QuestionBankService interface {
+	// 题库管理
+	CreateItem(ctx context.Context, req *model.CreateBankItemRequest, ownerID uint) (*model.QuestionBankItem, error)
+	UpdateItem(ctx context.Context, id uint, req *model.UpdateBankItemRequest, userID uint) (*model.QuestionBankItem, error)
+	DeleteItem(ctx context.Context, id uint, userID uint) error
+	GetItem(ctx context.Context, id uint, userID uint) (*model.QuestionBankItem, error)
+	SearchItems(ctx context.Context, req *model.BankItemSearchRequest, userID uint) ([]*model.QuestionBankItem, int64, error)
+
+	// AddToAssignment 将题库题目复制到作业中，复制出的题目保留来源引用
+	AddToAssignment(ctx context.Context, assignmentID uint, req *model.AddFromBankRequest, teacherID uint) ([]*model.Question, error)
+	// SaveQuestionToBank 将作业中的题目保存到题库
+	SaveQuestionToBank(ctx context.Context, questionID uint, req *model.SaveToBankRequest, teacherID uint) (*model.QuestionBankItem, error)
+	// ImportItems 从 QTI、Moodle XML、GIFT 或 Markdown 文件导入题库题目，返回每道题目的校验结果
+	ImportItems(ctx context.Context, ownerID uint, file *multipart.FileHeader, req *model.BankImportRequest, opts *model.QuestionImportOptions) (*model.QuestionImportReport, error)
+}
+
+// questionBankService 题库服务实现
+type questionBankService struct {
+	bankRepo       repository.QuestionBankRepository
+	questionRepo   repository.QuestionRepository
+	assignmentRepo repository.AssignmentRepository
+	access         AccessService
+}
+
+// NewQuestionBankService 创建题库服务实例
+func NewQuestionBankService(
+	bankRepo repository.QuestionBankRepository,
+	questionRepo repository.QuestionRepository,
+	assignmentRepo repository.AssignmentRepository,
+	access AccessService,
+) QuestionBankService {
+	return &questionBankService{
+		bankRepo:       bankRepo,
+		questionRepo:   questionRepo,
+		assignmentRepo: assignmentRepo,
+		access:         access,
+	}
+}
+
+// CreateItem 创建题库题目
+func (s *questionBankService) CreateItem(ctx context.Context, req *model.CreateBankItemRequest, ownerID uint) (*model.QuestionBankItem, error) {
+	item := &model.QuestionBankItem{
+		OwnerID:        ownerID,
+		Scope:          req.Scope,
+		KnowledgePoint: req.KnowledgePoint,
+		Difficulty:     req.Difficulty,
+		Chapter:        req.Chapter,
+	}
+	if item.Scope == "" {
+		item.Scope = model.BankItemScopePrivate
+	}
+	if item.Difficulty == "" {
+		item.Difficulty = model.DifficultyMedium
+	}
+
+	if err := applyBankItemContent(item, &model.CreateQuestionRequest{
+		Type:          req.Type,
+		Content:       req.Content,
+		Score:         req.Score,
+		Order:         1,
+		Options:       req.Options,
+		CorrectAnswer: req.CorrectAnswer,
+		Reference:     req.Reference,
+		Explanation:   req.Explanation,
+		IsMultiple:    req.IsMultiple,
+		ScoringRule:   req.ScoringRule,
+		Matching:      req.Matching,
+		Numeric:       req.Numeric,
+		Cloze:         req.Cloze,
+		Code:          req.Code,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.bankRepo.Create(ctx, item); err != nil {
+		return nil, fmt.Errorf("create question bank item failed: %w", err)
+	}
+	return item, nil
+}
+
+// UpdateItem 更新题库题目
+// 作业中的题目是独立副本，修改题库不会影响已发布作业；sync_drafts 为 true 时同步更新草稿作业中的副本
+func (s *questionBankService) UpdateItem(ctx context.Context, id uint, req *model.UpdateBankItemRequest, userID uint) (*model.QuestionBankItem, error) {
+	item, err := s.getOwnedItem(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 以现有内容为基础合并修改，再按创建题目的规则重新校验
+	draft := bankItemToRequest(item)
+	if req.Content != "" {
+		draft.Content = req.Content
+	}
+	if req.Score > 0 {
+		draft.Score = req.Score
+	}
+	if len(req.Options) > 0 {
+		draft.Options = req.Options
+	}
+	if req.CorrectAnswer != "" {
+		draft.CorrectAnswer = req.CorrectAnswer
+	}
+	if req.Reference != "" {
+		draft.Reference = req.Reference
+	}
+	if req.Explanation != "" {
+		draft.Explanation = req.Explanation
+	}
+	if req.IsMultiple != nil {
+		draft.IsMultiple = *req.IsMultiple
+	}
+	if req.ScoringRule != nil {
+		draft.ScoringRule = req.ScoringRule
+	}
+	if req.Matching != nil {
+		draft.Matching = req.Matching
+	}
+	if req.Numeric != nil {
+		draft.Numeric = req.Numeric
+	}
+	if len(req.Cloze) > 0 {
+		draft.Cloze = req.Cloze
+	}
+	if req.Code != nil {
+		draft.Code = req.Code
+	}
+	if err := applyBankItemContent(item, draft); err != nil {
+		return nil, err
+	}
+
+	if req.KnowledgePoint != nil {
+		item.KnowledgePoint = *req.KnowledgePoint
+	}
+	if req.Difficulty != "" {
+		item.Difficulty = req.Difficulty
+	}
+	if req.Chapter != nil {
+		item.Chapter = *req.Chapter
+	}
+	if req.Scope != "" {
+		item.Scope = req.Scope
+	}
+
+	if err := s.bankRepo.Update(ctx, item); err != nil {
+		return nil, fmt.Errorf("update question bank item failed: %w", err)
+	}
+
+	if req.SyncDrafts {
+		if err := s.syncDraftCopies(ctx, item); err != nil {
+			return nil, err
+		}
+	}
+
+	return item, nil
+}
+
+// DeleteItem 删除题库题目，已复制到作业中的题目不受影响
+func (s *questionBankService) DeleteItem(ctx context.Context, id uint, userID uint) error {
+	if _, err := s.getOwnedItem(ctx, id, userID); err != nil {
+		return err
+	}
+
+	if err := s.bankRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete question bank item failed: %w", err)
+	}
+	return nil
+}
+
+// GetItem 获取题库题目
+func (s *questionBankService) GetItem(ctx context.Context, id uint, userID uint) (*model.QuestionBankItem, error) {
+	item, err := s.bankRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrBankItemNotFound
+	}
+	if !item.IsVisibleTo(userID) && !s.access.IsAdmin(ctx, userID) {
+		return nil, ErrAccessDenied
+	}
+	return item, nil
+}
+
+// SearchItems 搜索题库题目，只返回用户自己的题目和全校共享的题目
+func (s *questionBankService) SearchItems(ctx context.Context, req *model.BankItemSearchRequest, userID uint) ([]*model.QuestionBankItem, int64, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	filter := &model.BankItemFilter{
+		ViewerID:       userID,
+		Keyword:        req.Keyword,
+		Type:           req.Type,
+		KnowledgePoint: req.KnowledgePoint,
+		Difficulty:     req.Difficulty,
+		Chapter:        req.Chapter,
+		Scope:          req.Scope,
+	}
+	if s.access.IsAdmin(ctx, userID) {
+		filter.ViewerID = 0
+	}
+	if req.Mine {
+		filter.OwnerID = userID
+	}
+
+	items, total, err := s.bankRepo.Search(ctx, filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search question bank failed: %w", err)
+	}
+	return items, total, nil
+}
+
+// AddToAssignment 将题库题目复制到作业中，复制出的题目保留来源引用
+func (s *questionBankService) AddToAssignment(ctx context.Context, assignmentID uint, req *model.AddFromBankRequest, teacherID uint) ([]*model.Question, error) {
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
+		return nil, ErrAccessDenied
+	}
+	if assignment.IsPublished() {
+		return nil, ErrAssignmentPublished
+	}
+
+	// 新题目排在已有题目之后
+	existing, err := s.questionRepo.GetByAssignmentID(ctx, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("get questions by assignment id failed: %w", err)
+	}
+	order := 0
+	for _, q := range existing {
+		if q.Order > order {
+			order = q.Order
+		}
+	}
+
+	isAdmin := s.access.IsAdmin(ctx, teacherID)
+	questions := make([]*model.Question, 0, len(req.ItemIDs))
+	itemIDs := make([]uint, 0, len(req.ItemIDs))
+	for _, id := range req.ItemIDs {
+		item, err := s.bankRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %d", ErrBankItemNotFound, id)
+		}
+		if !item.IsVisibleTo(teacherID) && !isAdmin {
+			return nil, ErrAccessDenied
+		}
+
+		order++
+		question := item.ToQuestion(assignmentID, order)
+		if req.Score > 0 {
+			question.Score = req.Score
+		}
+		questions = append(questions, question)
+		itemIDs = append(itemIDs, item.ID)
+	}
+
+	if err := s.questionRepo.CreateBatch(ctx, questions); err != nil {
+		return nil, fmt.Errorf("create questions from bank failed: %w", err)
+	}
+	if err := s.bankRepo.IncrementUsage(ctx, itemIDs); err != nil {
+		return nil, err
+	}
+
+	return questions, nil
+}
+
+// SaveQuestionToBank 将作业中的题目保存到题库
+func (s *questionBankService) SaveQuestionToBank(ctx context.Context, questionID uint, req *model.SaveToBankRequest, teacherID uint) (*model.QuestionBankItem, error) {
+	question, err := s.questionRepo.GetByID(ctx, questionID)
+	if err != nil {
+		return nil, ErrQuestionNotFound
+	}
+	if err := s.access.CheckAssignmentManage(ctx, question.AssignmentID, teacherID); err != nil {
+		return nil, err
+	}
+
+	item := &model.QuestionBankItem{
+		OwnerID:        teacherID,
+		Scope:          req.Scope,
+		Type:           question.Type,
+		Content:        question.Content,
+		Score:          question.Score,
+		Options:        question.Options,
+		CorrectAnswer:  question.CorrectAnswer,
+		Reference:      question.Reference,
+		Explanation:    question.Explanation,
+		ScoringRule:    question.ScoringRule,
+		KnowledgePoint: req.KnowledgePoint,
+		Difficulty:     req.Difficulty,
+		Chapter:        req.Chapter,
+	}
+	if item.Scope == "" {
+		item.Scope = model.BankItemScopePrivate
+	}
+	if item.Difficulty == "" {
+		item.Difficulty = model.DifficultyMedium
+	}
+
+	if err := s.bankRepo.Create(ctx, item); err != nil {
+		return nil, fmt.Errorf("create question bank item failed: %w", err)
+	}
+	return item, nil
+}
+
+// getOwnedItem 获取题库题目并校验修改权限（创建者或管理员）
+func (s *questionBankService) getOwnedItem(ctx context.Context, id uint, userID uint) (*model.QuestionBankItem, error) {
+	item, err := s.bankRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrBankItemNotFound
+	}
+	if item.OwnerID != userID && !s.access.IsAdmin(ctx, userID) {
+		return nil, ErrAccessDenied
+	}
+	return item, nil
+}
+
+// syncDraftCopies 将题库题目的内容同步到草稿作业中的副本，分值和顺序保持不变
+func (s *questionBankService) syncDraftCopies(ctx context.Context, item *model.QuestionBankItem) error {
+	copies, err := s.questionRepo.GetDraftCopiesByBankItemID(ctx, item.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, question := range copies {
+		question.Type = item.Type
+		question.Content = item.Content
+		question.Options = item.Options
+		question.CorrectAnswer = item.CorrectAnswer
+		question.Reference = item.Reference
+		question.Explanation = item.Explanation
+		question.ScoringRule = item.ScoringRule
+		if err := s.questionRepo.Update(ctx, question); err != nil {
+			return fmt.Errorf("sync question %d failed: %w", question.ID, err)
+		}
+	}
+	return nil
+}
+
+// applyBankItemContent 按创建题目的规则校验并写入题目内容
+func applyBankItemContent(item *model.QuestionBankItem, req *model.CreateQuestionRequest) error {
+	if err := validateQuestionDraft(req); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBankItem, err)
+	}
+
+	question, err := newQuestionFromRequest(req, 0)
+	if err != nil {
+		return err
+	}
+
+	item.Type = question.Type
+	item.Content = question.Content
+	item.Score = question.Score
+	item.Options = question.Options
+	item.CorrectAnswer = question.CorrectAnswer
+	item.Reference = question.Reference
+	item.Explanation = question.Explanation
+	item.ScoringRule = question.ScoringRule
+	return nil
+}
+
+// bankItemToRequest 将题库题目还原为创建题目请求，用于合并修改后重新校验
+func bankItemToRequest(item *model.QuestionBankItem) *model.CreateQuestionRequest {
+	return questionToRequest(item.ToQuestion(0, 1))
+}
+
+// questionToRequest 将已保存的题目还原为创建题目请求，是 newQuestionFromRequest 的逆过程
+func questionToRequest(question *model.Question) *model.CreateQuestionRequest {
+	req := &model.CreateQuestionRequest{
+		Type:          question.Type,
+		Content:       question.Content,
+		Score:         question.Score,
+		Order:         question.Order,
+		CorrectAnswer: question.CorrectAnswer,
+		Reference:     question.Reference,
+		Explanation:   question.Explanation,
+		Pool:          question.Pool,
+	}
+
+	if question.ScoringRule != "" {
+		rule := &model.ScoringRule{}
+		if json.Unmarshal([]byte(question.ScoringRule), rule) == nil {
+			req.ScoringRule = rule
+		}
+	}
+
+	decodeStructuredQuestion(req, question.Options, question.CorrectAnswer)
+
+	if question.Type == model.QuestionTypeChoice && question.Options != "" {
+		_ = json.Unmarshal([]byte(question.Options), &req.Options)
+		// 多选题的正确答案以 JSON 数组存储，还原为逗号分隔的选项
+		var keys []string
+		if question.CorrectAnswer != "" && question.CorrectAnswer[0] == '[' && json.Unmarshal([]byte(question.CorrectAnswer), &keys) == nil {
+			req.IsMultiple = true
+			req.CorrectAnswer = strings.Join(keys, ",")
+		}
+	}
+	return req
+}
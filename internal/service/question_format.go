@@ -0,0 +1,258 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"ai-course/internal/pkg/questionfmt"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+)
+
+// ErrQuestionImportRejected 文件中存在无效的题目或没有可导入的题目，未保存任何题目
+var ErrQuestionImportRejected = errors.New("文件中存在无效的题目，未导入任何题目")
+
+// ImportQuestions 从 QTI、Moodle XML、GIFT 或 Markdown 文件导入题目到作业，新题目排在已有题目之后
+// 每道题目按创建题目的规则校验并记录在报告中；试运行时只返回报告，否则全部有效（或选择跳过无效题目）时保存
+func (s *questionService) ImportQuestions(ctx context.Context, assignmentID uint, teacherID uint, file *multipart.FileHeader, opts *model.QuestionImportOptions) (*model.QuestionImportReport, error) {
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
+		return nil, ErrAccessDenied
+	}
+	if assignment.IsPublished() {
+		return nil, ErrAssignmentPublished
+	}
+
+	format, items, err := readQuestionFile(file, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.questionRepo.GetByAssignmentID(ctx, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("get questions by assignment id failed: %w", err)
+	}
+	order := 0
+	for _, q := range existing {
+		if q.Order > order {
+			order = q.Order
+		}
+	}
+
+	report := newQuestionImportReport(format, items, opts, order)
+	report.AssignmentID = assignmentID
+	if err := checkQuestionImport(report, opts); err != nil || opts.DryRun {
+		return report, err
+	}
+
+	valid := validImportItems(report)
+	questions := make([]*model.Question, 0, len(valid))
+	for _, item := range valid {
+		question, err := newQuestionFromRequest(item.Question, assignmentID)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	if err := s.questionRepo.CreateBatch(ctx, questions); err != nil {
+		return nil, fmt.Errorf("create imported questions failed: %w", err)
+	}
+
+	for i, item := range valid {
+		item.Status = model.QuestionImportImported
+		item.QuestionID = questions[i].ID
+	}
+	report.Applied = true
+	report.Summary.Imported = len(valid)
+	return report, nil
+}
+
+// ExportQuestions 将作业的题目按顺序导出为指定格式的文件，格式无法表示的题目跳过并在结果中列出题号
+func (s *questionService) ExportQuestions(ctx context.Context, assignmentID uint, teacherID uint, format string) (*model.QuestionExportFile, error) {
+	f, err := questionfmt.ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	if !s.access.IsClassTeacher(ctx, assignment.ClassID, teacherID) {
+		return nil, ErrAccessDenied
+	}
+
+	questions, err := s.questionRepo.GetByAssignmentID(ctx, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("get questions by assignment id failed: %w", err)
+	}
+	reqs := make([]*model.CreateQuestionRequest, len(questions))
+	for i, question := range questions {
+		reqs[i] = questionToRequest(question)
+	}
+
+	var buf bytes.Buffer
+	skipped, err := questionfmt.Write(&buf, f, assignment.Title, reqs)
+	if err != nil {
+		return nil, fmt.Errorf("write question file failed: %w", err)
+	}
+
+	result := &model.QuestionExportFile{
+		Filename:    assignment.Title + "-题目" + f.Extension(),
+		ContentType: f.ContentType(),
+		Data:        buf.Bytes(),
+	}
+	for _, i := range skipped {
+		result.Skipped = append(result.Skipped, questions[i].Order)
+	}
+	return result, nil
+}
+
+// ImportItems 从题目文件导入题库，分类信息应用于全部题目；校验和保存规则与导入作业相同
+func (s *questionBankService) ImportItems(ctx context.Context, ownerID uint, file *multipart.FileHeader, req *model.BankImportRequest, opts *model.QuestionImportOptions) (*model.QuestionImportReport, error) {
+	format, items, err := readQuestionFile(file, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newQuestionImportReport(format, items, opts, 0)
+	if err := checkQuestionImport(report, opts); err != nil || opts.DryRun {
+		return report, err
+	}
+
+	valid := validImportItems(report)
+	bankItems := make([]*model.QuestionBankItem, 0, len(valid))
+	for _, item := range valid {
+		bankItem := &model.QuestionBankItem{
+			OwnerID:        ownerID,
+			Scope:          req.Scope,
+			KnowledgePoint: req.KnowledgePoint,
+			Difficulty:     req.Difficulty,
+			Chapter:        req.Chapter,
+		}
+		if bankItem.Scope == "" {
+			bankItem.Scope = model.BankItemScopePrivate
+		}
+		if bankItem.Difficulty == "" {
+			bankItem.Difficulty = model.DifficultyMedium
+		}
+		if err := applyBankItemContent(bankItem, item.Question); err != nil {
+			return nil, err
+		}
+		bankItems = append(bankItems, bankItem)
+	}
+	if err := s.bankRepo.CreateBatch(ctx, bankItems); err != nil {
+		return nil, err
+	}
+
+	for i, item := range valid {
+		item.Status = model.QuestionImportImported
+		item.BankItemID = bankItems[i].ID
+	}
+	report.Applied = true
+	report.Summary.Imported = len(valid)
+	return report, nil
+}
+
+// readQuestionFile 读取上传的题目文件，未指定格式时按文件扩展名和内容识别
+func readQuestionFile(file *multipart.FileHeader, format string) (questionfmt.Format, []questionfmt.Item, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", nil, fmt.Errorf("open uploaded file failed: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, questionfmt.MaxImportSize+1))
+	if err != nil {
+		return "", nil, fmt.Errorf("read uploaded file failed: %w", err)
+	}
+	if len(data) > questionfmt.MaxImportSize {
+		return "", nil, questionfmt.ErrTooLarge
+	}
+
+	var parsed questionfmt.Format
+	if format != "" {
+		parsed, err = questionfmt.ParseFormat(format)
+	} else {
+		parsed, err = questionfmt.Detect(file.Filename, data)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	items, err := questionfmt.Read(parsed, bytes.NewReader(data))
+	if err != nil {
+		return "", nil, err
+	}
+	return parsed, items, nil
+}
+
+// newQuestionImportReport 校验文件中的题目并生成报告，有效题目从 order+1 开始依次编号
+func newQuestionImportReport(format questionfmt.Format, items []questionfmt.Item, opts *model.QuestionImportOptions, order int) *model.QuestionImportReport {
+	report := &model.QuestionImportReport{
+		Format: string(format),
+		DryRun: opts.DryRun,
+		Items:  make([]*model.QuestionImportItem, 0, len(items)),
+	}
+	for _, item := range items {
+		result := &model.QuestionImportItem{
+			Index:    item.Index,
+			Name:     item.Name,
+			Question: item.Question,
+			Status:   model.QuestionImportValid,
+		}
+		report.Items = append(report.Items, result)
+		report.Summary.Total++
+
+		if item.Err != nil {
+			result.Status = model.QuestionImportInvalid
+			result.Error = item.Err.Error()
+			report.Summary.Invalid++
+			continue
+		}
+
+		q := item.Question
+		result.Type = q.Type
+		if q.Score <= 0 {
+			q.Score = opts.Score
+			if q.Score <= 0 {
+				q.Score = defaultQuestionScore(q.Type)
+			}
+		}
+		q.Order = order + report.Summary.Valid + 1
+		if err := validateQuestionDraft(q); err != nil {
+			result.Status = model.QuestionImportInvalid
+			result.Error = err.Error()
+			report.Summary.Invalid++
+			continue
+		}
+		report.Summary.Valid++
+	}
+	return report
+}
+
+// checkQuestionImport 判断是否可以保存：存在无效题目且未选择跳过，或没有有效题目时拒绝导入
+func checkQuestionImport(report *model.QuestionImportReport, opts *model.QuestionImportOptions) error {
+	if opts.DryRun {
+		return nil
+	}
+	if report.Summary.Valid == 0 || (report.Summary.Invalid > 0 && !opts.SkipInvalid) {
+		return ErrQuestionImportRejected
+	}
+	return nil
+}
+
+// validImportItems 返回校验通过的题目
+func validImportItems(report *model.QuestionImportReport) []*model.QuestionImportItem {
+	var items []*model.QuestionImportItem
+	for _, item := range report.Items {
+		if item.Status == model.QuestionImportValid {
+			items = append(items, item)
+		}
+	}
+	return items
+}
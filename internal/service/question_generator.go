@@ -0,0 +1,371 @@
+package service
+
+import (
+	"ai-course/internal/config"
+	"ai-course/internal/model"
+	"ai-course/internal/pkg/llm"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	localGeneratorModel = "local-template"
+
+	defaultGenerateCount = 5
+	maxMaterialRunes     = 12000 // 发送给大模型的材料最大字数
+)
+
+var (
+	ErrMaterialRequired  = errors.New("question material required")
+	ErrMaterialTooShort  = errors.New("question material too short")
+	ErrGenerateQuestions = errors.New("generate questions failed")
+)
+
+// allQuestionTypes 默认生成的题目类型
+var allQuestionTypes = []model.QuestionType{
+	model.QuestionTypeChoice,
+	model.QuestionTypeFillBlank,
+	model.QuestionTypeTrueFalse,
+	model.QuestionTypeEssay,
+}
+
+// QuestionGenerateRequest 题目生成请求
+type QuestionGenerateRequest struct {
+	Material string               // 出题材料
+	Types    []model.QuestionType // 题目类型
+	Count    int                  // 生成数量
+}
+
+// QuestionGenerateResult 题目生成结果
+type QuestionGenerateResult struct {
+	Questions []model.CreateQuestionRequest // 题目草稿（未校验）
+	Model     string                        // 模型名称
+}
+
+// QuestionGenerator 题目生成接口
+type QuestionGenerator interface {
+	// Generate 根据材料生成题目草稿
+	Generate(ctx context.Context, req *QuestionGenerateRequest) (*QuestionGenerateResult, error)
+}
+
+// NewQuestionGenerator 根据配置创建题目生成器，未配置或配置不完整时使用本地模板生成
+func NewQuestionGenerator(cfg *config.Config) QuestionGenerator {
+	if client := newLLMClient(cfg); client != nil {
+		return NewLLMQuestionGenerator(client)
+	}
+	return NewLocalQuestionGenerator()
+}
+
+// llmQuestionGenerator 基于大模型的题目生成实现
+type llmQuestionGenerator struct {
+	client llm.Client
+}
+
+// NewLLMQuestionGenerator 创建基于大模型的题目生成器，测试时可传入 llm.FakeClient 返回预设的模型回复
+func NewLLMQuestionGenerator(client llm.Client) QuestionGenerator {
+	return &llmQuestionGenerator{client: client}
+}
+
+const generatePrompt = `你是一名经验丰富的出题老师。请根据用户提供的教学材料出题。
+要求：
+1. 共出 %d 道题，题目类型只能从以下类型中选择：%s；
+2. 题目类型含义：choice 选择题，fill_blank 填空题，true_false 判断题，essay 简答题；
+3. 选择题提供 4 个选项，key 依次为 A、B、C、D，correct_answer 为正确选项的 key，多选题设置 is_multiple 为 true 且 correct_answer 用英文逗号分隔多个 key；
+4. 填空题 correct_answer 为空白处的标准答案；判断题 correct_answer 为 true 或 false；简答题必须给出 reference 参考答案；
+5. 每道题给出 explanation 解析，题目内容必须能从材料中找到依据；
+6. 只输出 JSON，格式为 {"questions": [{"type": "choice", "content": "题干", "options": [{"key": "A", "value": "选项"}], "is_multiple": false, "correct_answer": "A", "reference": "", "explanation": "解析"}]}。`
+
+// llmGenerateOutput 大模型出题输出格式
+type llmGenerateOutput struct {
+	Questions []model.CreateQuestionRequest `json:"questions"`
+}
+
+// Generate 根据材料生成题目草稿
+func (g *llmQuestionGenerator) Generate(ctx context.Context, req *QuestionGenerateRequest) (*QuestionGenerateResult, error) {
+	material := req.Material
+	if utf8.RuneCountInString(material) > maxMaterialRunes {
+		material = string([]rune(material)[:maxMaterialRunes])
+	}
+
+	types := make([]string, len(req.Types))
+	for i, t := range req.Types {
+		types[i] = string(t)
+	}
+
+	content, err := g.client.Chat(ctx, []llm.Message{
+		{Role: llm.RoleSystem, Content: fmt.Sprintf(generatePrompt, req.Count, strings.Join(types, "、"))},
+		{Role: llm.RoleUser, Content: "【教学材料】\n" + material},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var output llmGenerateOutput
+	if err := json.Unmarshal([]byte(llm.ExtractJSON(content)), &output); err != nil {
+		return nil, fmt.Errorf("parse generated questions failed: %w", err)
+	}
+
+	return &QuestionGenerateResult{
+		Questions: output.Questions,
+		Model:     g.client.Model(),
+	}, nil
+}
+
+// localQuestionGenerator 本地模板题目生成实现
+// 从材料中抽取句子和关键词套用固定模板出题，结果确定，适合开发测试和无法访问大模型的环境
+type localQuestionGenerator struct{}
+
+// NewLocalQuestionGenerator 创建本地模板题目生成器
+func NewLocalQuestionGenerator() QuestionGenerator {
+	return &localQuestionGenerator{}
+}
+
+// materialSentence 材料中的句子及其关键词
+type materialSentence struct {
+	text    string
+	keyword string
+}
+
+// Generate 根据材料生成题目草稿
+func (g *localQuestionGenerator) Generate(ctx context.Context, req *QuestionGenerateRequest) (*QuestionGenerateResult, error) {
+	var sentences []materialSentence
+	for _, text := range splitSentences(req.Material) {
+		if keyword := pickKeyword(text); keyword != "" {
+			sentences = append(sentences, materialSentence{text: text, keyword: keyword})
+		}
+	}
+	if len(sentences) == 0 {
+		return nil, ErrMaterialTooShort
+	}
+
+	questions := make([]model.CreateQuestionRequest, 0, req.Count)
+	for i := 0; i < req.Count && i < len(sentences); i++ {
+		sentence := sentences[i]
+		// 干扰项取自其他句子的关键词
+		distractors := make([]string, 0, 3)
+		for j := 1; j < len(sentences) && len(distractors) < 3; j++ {
+			other := sentences[(i+j)%len(sentences)].keyword
+			if other != sentence.keyword && !containsString(distractors, other) {
+				distractors = append(distractors, other)
+			}
+		}
+
+		blanked := strings.Replace(sentence.text, sentence.keyword, "______", 1)
+		explanation := "依据材料：" + sentence.text
+
+		switch t := req.Types[i%len(req.Types)]; {
+		case t == model.QuestionTypeChoice && len(distractors) == 3:
+			// 正确答案位置随题号轮换
+			values := append([]string{}, distractors...)
+			correct := i % 4
+			values = append(values[:correct], append([]string{sentence.keyword}, values[correct:]...)...)
+			options := make([]model.QuestionOption, len(values))
+			for k, value := range values {
+				options[k] = model.QuestionOption{Key: string(rune('A' + k)), Value: value}
+			}
+			questions = append(questions, model.CreateQuestionRequest{
+				Type:          model.QuestionTypeChoice,
+				Content:       "下列选项中，最适合填入空白处的是：" + blanked,
+				Options:       options,
+				CorrectAnswer: options[correct].Key,
+				Explanation:   explanation,
+			})
+		case t == model.QuestionTypeTrueFalse:
+			// 奇数题替换关键词构造错误陈述
+			content, answer := sentence.text, "true"
+			if i%2 == 1 && len(distractors) > 0 {
+				content, answer = strings.Replace(sentence.text, sentence.keyword, distractors[0], 1), "false"
+			}
+			questions = append(questions, model.CreateQuestionRequest{
+				Type:          model.QuestionTypeTrueFalse,
+				Content:       "判断正误：" + content,
+				CorrectAnswer: answer,
+				Explanation:   explanation,
+			})
+		case t == model.QuestionTypeEssay:
+			reference := sentence.text
+			if i+1 < len(sentences) {
+				reference += sentences[i+1].text
+			}
+			questions = append(questions, model.CreateQuestionRequest{
+				Type:        model.QuestionTypeEssay,
+				Content:     fmt.Sprintf("请结合材料简述“%s”的相关内容。", sentence.keyword),
+				Reference:   reference,
+				Explanation: explanation,
+			})
+		default:
+			// 填空题，干扰项不足时选择题也退化为填空题
+			questions = append(questions, model.CreateQuestionRequest{
+				Type:          model.QuestionTypeFillBlank,
+				Content:       "填空：" + blanked,
+				CorrectAnswer: sentence.keyword,
+				Explanation:   explanation,
+			})
+		}
+	}
+
+	return &QuestionGenerateResult{
+		Questions: questions,
+		Model:     localGeneratorModel,
+	}, nil
+}
+
+// splitSentences 将材料按句末标点和换行切分为句子，过短的句子会被忽略
+func splitSentences(text string) []string {
+	var sentences []string
+	var current []rune
+	flush := func() {
+		sentence := strings.TrimSpace(string(current))
+		if utf8.RuneCountInString(sentence) >= 8 {
+			sentences = append(sentences, sentence)
+		}
+		current = current[:0]
+	}
+
+	for _, r := range text {
+		if r == '\n' || r == '\r' {
+			flush()
+			continue
+		}
+		current = append(current, r)
+		if strings.ContainsRune("。！？!?；;", r) || (r == '.' && len(current) > 1 && unicode.IsLetter(current[len(current)-2])) {
+			flush()
+		}
+	}
+	flush()
+	return sentences
+}
+
+// pickKeyword 选取句子中用于出题的关键词
+// 优先选择最长的英文单词或数字，其次选择最长一段中文开头的两个字（通常是句子主语）
+func pickKeyword(sentence string) string {
+	var word, han, longestWord, longestHan []rune
+	flush := func() {
+		if len(word) > len(longestWord) {
+			longestWord = append([]rune{}, word...)
+		}
+		if len(han) > len(longestHan) {
+			longestHan = append([]rune{}, han...)
+		}
+		word, han = word[:0], han[:0]
+	}
+
+	for _, r := range sentence {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if len(word) > 0 {
+				flush()
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(han) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	if len(longestWord) >= 4 {
+		return string(longestWord)
+	}
+	if len(longestHan) >= 4 {
+		return string(longestHan[:2])
+	}
+	return ""
+}
+
+// containsString 检查字符串切片是否包含指定值
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultQuestionScore 题型默认分值
+func defaultQuestionScore(t model.QuestionType) int {
+	if t == model.QuestionTypeEssay {
+		return 10
+	}
+	return 5
+}
+
+// validateQuestionDraft 校验题目草稿，规则与创建题目接口一致
+func validateQuestionDraft(question *model.CreateQuestionRequest) error {
+	if err := binding.Validator.ValidateStruct(question); err != nil {
+		return err
+	}
+
+	switch question.Type {
+	case model.QuestionTypeChoice:
+		if len(question.Options) < 2 {
+			return errors.New("选择题至少需要两个选项")
+		}
+		keys := make(map[string]bool)
+		for _, option := range question.Options {
+			if option.Key == "" || strings.TrimSpace(option.Value) == "" {
+				return errors.New("选择题选项不完整")
+			}
+			if keys[option.Key] {
+				return fmt.Errorf("选择题选项 %s 重复", option.Key)
+			}
+			keys[option.Key] = true
+		}
+		correctKeys := splitAnswerKeys(question.CorrectAnswer)
+		if len(correctKeys) == 0 {
+			return errors.New("选择题缺少正确答案")
+		}
+		if !question.IsMultiple && len(correctKeys) > 1 {
+			return errors.New("单选题只能有一个正确答案")
+		}
+		for _, key := range correctKeys {
+			if !keys[key] {
+				return fmt.Errorf("正确答案 %s 不在选项中", key)
+			}
+		}
+	case model.QuestionTypeFillBlank:
+		if strings.TrimSpace(question.CorrectAnswer) == "" && (question.ScoringRule == nil || len(question.ScoringRule.Blanks) == 0) {
+			return errors.New("填空题缺少正确答案")
+		}
+	case model.QuestionTypeTrueFalse:
+		if answer := normalizeBoolean(strings.TrimSpace(question.CorrectAnswer)); answer != "true" && answer != "false" {
+			return errors.New("判断题答案必须为 true 或 false")
+		}
+	case model.QuestionTypeEssay:
+		if strings.TrimSpace(question.Reference) == "" {
+			return errors.New("简答题缺少参考答案")
+		}
+	default:
+		return validateStructuredDraft(question)
+	}
+	return nil
+}
+
+// splitAnswerKeys 解析选择题正确答案，支持 "A"、"A,C" 和 JSON 数组格式
+func splitAnswerKeys(answer string) []string {
+	answer = strings.TrimSpace(answer)
+	var keys []string
+	if strings.HasPrefix(answer, "[") {
+		if err := json.Unmarshal([]byte(answer), &keys); err == nil {
+			return keys
+		}
+	}
+	for _, key := range strings.FieldsFunc(answer, func(r rune) bool {
+		return r == ',' || r == '，' || r == ' ' || r == '、'
+	}) {
+		keys = append(keys, strings.TrimSpace(key))
+	}
+	return keys
+}
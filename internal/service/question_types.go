@@ -0,0 +1,366 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// isStructuredType 判断是否为选项和答案以结构化 JSON 存储的题型
+func isStructuredType(t model.QuestionType) bool {
+	switch t {
+	case model.QuestionTypeMatching, model.QuestionTypeOrdering, model.QuestionTypeNumeric, model.QuestionTypeCloze, model.QuestionTypeCode:
+		return true
+	default:
+		return false
+	}
+}
+
+// encodeStructuredQuestion 将连线、排序、数值、完形填空和编程题的结构化设置写入题目的选项和答案字段
+//
+// 存储格式：
+//   - 连线题：Options 为 MatchingOptions，CorrectAnswer 为 {"左列标识":"右列标识"}
+//   - 排序题：Options 为待排序的选项数组，CorrectAnswer 为按正确顺序排列的选项标识数组
+//   - 数值题：Options 为 NumericOptions（单位、误差），CorrectAnswer 为数值
+//   - 完形填空：Options 为 ClozeBlank 数组，CorrectAnswer 为每个空可接受答案的二维数组
+//   - 编程题：Options 为 CodeOptions（语言、初始代码），CorrectAnswer 为全部测试用例
+func encodeStructuredQuestion(question *model.Question, req *model.CreateQuestionRequest) error {
+	var options, answer interface{}
+	switch req.Type {
+	case model.QuestionTypeMatching:
+		if req.Matching == nil {
+			return errors.New("matching settings required")
+		}
+		options, answer = req.Matching.MatchingOptions, req.Matching.Pairs
+	case model.QuestionTypeOrdering:
+		options, answer = req.Options, splitAnswerKeys(req.CorrectAnswer)
+	case model.QuestionTypeNumeric:
+		if req.Numeric == nil || req.Numeric.Value == nil {
+			return errors.New("numeric settings required")
+		}
+		options = req.Numeric.NumericOptions
+		question.CorrectAnswer = strconv.FormatFloat(*req.Numeric.Value, 'f', -1, 64)
+	case model.QuestionTypeCloze:
+		blanks := make([]model.ClozeBlank, len(req.Cloze))
+		accepted := make([][]string, len(req.Cloze))
+		for i, blank := range req.Cloze {
+			blanks[i] = blank.ClozeBlank
+			accepted[i] = blank.Answers
+		}
+		options, answer = blanks, accepted
+	case model.QuestionTypeCode:
+		if req.Code == nil {
+			return errors.New("code settings required")
+		}
+		options, answer = req.Code.CodeOptions, req.Code.TestCases
+	default:
+		return nil
+	}
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("marshal question options failed: %w", err)
+	}
+	question.Options = string(optionsJSON)
+
+	if answer != nil {
+		answerJSON, err := json.Marshal(answer)
+		if err != nil {
+			return fmt.Errorf("marshal correct answer failed: %w", err)
+		}
+		question.CorrectAnswer = string(answerJSON)
+	}
+	return nil
+}
+
+// decodeStructuredQuestion 将已存储的结构化题目还原到创建请求中，是 encodeStructuredQuestion 的逆过程
+func decodeStructuredQuestion(req *model.CreateQuestionRequest, options, correctAnswer string) {
+	switch req.Type {
+	case model.QuestionTypeMatching:
+		spec := &model.MatchingSpec{}
+		_ = json.Unmarshal([]byte(options), &spec.MatchingOptions)
+		_ = json.Unmarshal([]byte(correctAnswer), &spec.Pairs)
+		req.Matching = spec
+	case model.QuestionTypeOrdering:
+		_ = json.Unmarshal([]byte(options), &req.Options)
+		var keys []string
+		if json.Unmarshal([]byte(correctAnswer), &keys) == nil {
+			req.CorrectAnswer = strings.Join(keys, ",")
+		}
+	case model.QuestionTypeNumeric:
+		spec := &model.NumericSpec{}
+		_ = json.Unmarshal([]byte(options), &spec.NumericOptions)
+		if value, err := strconv.ParseFloat(correctAnswer, 64); err == nil {
+			spec.Value = &value
+		}
+		req.Numeric = spec
+	case model.QuestionTypeCloze:
+		var blanks []model.ClozeBlank
+		var accepted [][]string
+		_ = json.Unmarshal([]byte(options), &blanks)
+		_ = json.Unmarshal([]byte(correctAnswer), &accepted)
+		req.Cloze = make([]model.ClozeBlankSpec, len(accepted))
+		for i := range accepted {
+			req.Cloze[i].Answers = accepted[i]
+			if i < len(blanks) {
+				req.Cloze[i].ClozeBlank = blanks[i]
+			}
+		}
+	case model.QuestionTypeCode:
+		spec := &model.CodeSpec{}
+		_ = json.Unmarshal([]byte(options), &spec.CodeOptions)
+		_ = json.Unmarshal([]byte(correctAnswer), &spec.TestCases)
+		req.Code = spec
+	}
+}
+
+// validateStructuredDraft 校验连线、排序、数值、完形填空和编程题的结构化设置
+func validateStructuredDraft(question *model.CreateQuestionRequest) error {
+	switch question.Type {
+	case model.QuestionTypeMatching:
+		if question.Matching == nil {
+			return errors.New("连线题缺少配对设置")
+		}
+		leftKeys, err := optionKeySet(question.Matching.Left)
+		if err != nil {
+			return err
+		}
+		rightKeys, err := optionKeySet(question.Matching.Right)
+		if err != nil {
+			return err
+		}
+		for left, right := range question.Matching.Pairs {
+			if !leftKeys[left] || !rightKeys[right] {
+				return fmt.Errorf("配对 %s-%s 不在选项中", left, right)
+			}
+		}
+		if len(question.Matching.Pairs) != len(leftKeys) {
+			return errors.New("连线题左列每一项都需要配对")
+		}
+	case model.QuestionTypeOrdering:
+		if len(question.Options) < 2 {
+			return errors.New("排序题至少需要两个选项")
+		}
+		keys, err := optionKeySet(question.Options)
+		if err != nil {
+			return err
+		}
+		order := splitAnswerKeys(question.CorrectAnswer)
+		if len(order) != len(keys) {
+			return errors.New("排序题正确顺序必须包含全部选项")
+		}
+		seen := make(map[string]bool)
+		for _, key := range order {
+			if !keys[key] || seen[key] {
+				return fmt.Errorf("排序题正确顺序中的选项 %s 无效", key)
+			}
+			seen[key] = true
+		}
+	case model.QuestionTypeNumeric:
+		if question.Numeric == nil || question.Numeric.Value == nil {
+			return errors.New("数值题缺少正确数值")
+		}
+	case model.QuestionTypeCloze:
+		blanks := strings.Count(question.Content, model.ClozePlaceholder)
+		if blanks == 0 {
+			return fmt.Errorf("完形填空题干中缺少 %s 占位符", model.ClozePlaceholder)
+		}
+		if blanks != len(question.Cloze) {
+			return fmt.Errorf("完形填空题干有 %d 个空，但设置了 %d 个空", blanks, len(question.Cloze))
+		}
+		for i, blank := range question.Cloze {
+			if len(blank.Choices) == 0 {
+				continue
+			}
+			keys, err := optionKeySet(blank.Choices)
+			if err != nil {
+				return fmt.Errorf("第 %d 个空: %w", i+1, err)
+			}
+			for _, answer := range blank.Answers {
+				if !keys[answer] {
+					return fmt.Errorf("第 %d 个空的答案 %s 不在选项中", i+1, answer)
+				}
+			}
+		}
+	case model.QuestionTypeCode:
+		if question.Code == nil || len(question.Code.TestCases) == 0 {
+			return errors.New("编程题缺少测试用例")
+		}
+	}
+	return nil
+}
+
+// optionKeySet 校验选项完整且标识不重复，返回选项标识集合
+func optionKeySet(options []model.QuestionOption) (map[string]bool, error) {
+	keys := make(map[string]bool, len(options))
+	for _, option := range options {
+		if option.Key == "" || strings.TrimSpace(option.Value) == "" {
+			return nil, errors.New("选项不完整")
+		}
+		if keys[option.Key] {
+			return nil, fmt.Errorf("选项 %s 重复", option.Key)
+		}
+		keys[option.Key] = true
+	}
+	return keys, nil
+}
+
+// newQuestionDetail 构造题目详情，解析各题型用于渲染的选项数据
+func newQuestionDetail(q model.Question) model.QuestionDetailResponse {
+	detail := model.QuestionDetailResponse{Question: q}
+	if q.Options == "" {
+		return detail
+	}
+
+	switch q.Type {
+	case model.QuestionTypeChoice:
+		var options []model.QuestionOption
+		if err := json.Unmarshal([]byte(q.Options), &options); err == nil {
+			detail.OptionList = options
+
+			// 判断是否为多选题
+			if q.CorrectAnswer != "" && q.CorrectAnswer[0] == '[' {
+				detail.IsMultiple = true
+				var correctKeys []string
+				if err := json.Unmarshal([]byte(q.CorrectAnswer), &correctKeys); err == nil {
+					detail.CorrectKeys = correctKeys
+				}
+			} else {
+				detail.IsMultiple = false
+				detail.CorrectKeys = []string{q.CorrectAnswer}
+			}
+		}
+	case model.QuestionTypeOrdering:
+		var options []model.QuestionOption
+		if err := json.Unmarshal([]byte(q.Options), &options); err == nil {
+			detail.OptionList = options
+		}
+	case model.QuestionTypeMatching:
+		matching := &model.MatchingOptions{}
+		if err := json.Unmarshal([]byte(q.Options), matching); err == nil {
+			detail.Matching = matching
+		}
+	case model.QuestionTypeNumeric:
+		var numeric model.NumericOptions
+		if err := json.Unmarshal([]byte(q.Options), &numeric); err == nil {
+			detail.Unit = numeric.Unit
+		}
+	case model.QuestionTypeCloze:
+		var blanks []model.ClozeBlank
+		if err := json.Unmarshal([]byte(q.Options), &blanks); err == nil {
+			detail.ClozeBlanks = blanks
+		}
+	case model.QuestionTypeCode:
+		if options, err := q.GetCodeOptions(); err == nil {
+			detail.Code = options
+		}
+		var testCases []model.CodeTestCase
+		if err := json.Unmarshal([]byte(q.CorrectAnswer), &testCases); err == nil {
+			for _, testCase := range testCases {
+				if !testCase.Hidden {
+					detail.SampleCases = append(detail.SampleCases, testCase)
+				}
+			}
+		}
+	}
+	return detail
+}
+
+// validateMatchingAnswer 验证连线题答案，学生答案为 {"左列标识":"右列标识"}，每对配对单独计分
+func (s *questionService) validateMatchingAnswer(question *model.Question, answer string) (bool, int, error) {
+	var pairs map[string]string
+	if err := json.Unmarshal([]byte(question.CorrectAnswer), &pairs); err != nil || len(pairs) == 0 {
+		return false, 0, fmt.Errorf("question has no correct answer")
+	}
+
+	var studentPairs map[string]string
+	if err := json.Unmarshal([]byte(answer), &studentPairs); err != nil {
+		return false, 0, nil
+	}
+
+	correct := 0
+	for left, right := range pairs {
+		if studentPairs[left] == right {
+			correct++
+		}
+	}
+	isCorrect, score := scoreByRatio(question.Score, float64(correct)/float64(len(pairs)))
+	return isCorrect, score, nil
+}
+
+// validateOrderingAnswer 验证排序题答案，学生答案为选项标识数组或逗号分隔，按位置正确的项数计分
+func (s *questionService) validateOrderingAnswer(question *model.Question, answer string) (bool, int, error) {
+	var order []string
+	if err := json.Unmarshal([]byte(question.CorrectAnswer), &order); err != nil || len(order) == 0 {
+		return false, 0, fmt.Errorf("question has no correct answer")
+	}
+
+	studentOrder := splitAnswerKeys(answer)
+	correct := 0
+	for i, key := range order {
+		if i < len(studentOrder) && studentOrder[i] == key {
+			correct++
+		}
+	}
+	isCorrect, score := scoreByRatio(question.Score, float64(correct)/float64(len(order)))
+	return isCorrect, score, nil
+}
+
+// validateNumericAnswer 验证数值题答案，允许学生在数值后附带单位
+func (s *questionService) validateNumericAnswer(question *model.Question, answer string) (bool, int, error) {
+	expected, err := strconv.ParseFloat(question.CorrectAnswer, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("question has no correct answer")
+	}
+
+	var options model.NumericOptions
+	if question.Options != "" {
+		if err := json.Unmarshal([]byte(question.Options), &options); err != nil {
+			return false, 0, fmt.Errorf("parse numeric options failed: %w", err)
+		}
+	}
+
+	actual, ok := parseNumericAnswer(answer, options.Unit)
+	if ok && math.Abs(expected-actual) <= options.Tolerance+1e-9 {
+		return true, question.Score, nil
+	}
+	return false, 0, nil
+}
+
+// parseNumericAnswer 解析数值答案，去掉全角字符、空白和单位
+func parseNumericAnswer(answer, unit string) (float64, bool) {
+	answer = strings.TrimSpace(toHalfWidth(answer))
+	unit = strings.TrimSpace(toHalfWidth(unit))
+	if unit != "" && len(answer) > len(unit) && strings.EqualFold(answer[len(answer)-len(unit):], unit) {
+		answer = strings.TrimSpace(answer[:len(answer)-len(unit)])
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(answer, ",", ""), 64)
+	return value, err == nil
+}
+
+// validateClozeAnswer 验证完形填空答案，学生答案为按空顺序排列的字符串数组，各空单独计分
+func (s *questionService) validateClozeAnswer(question *model.Question, answer string) (bool, int, error) {
+	var accepted [][]string
+	if err := json.Unmarshal([]byte(question.CorrectAnswer), &accepted); err != nil || len(accepted) == 0 {
+		return false, 0, fmt.Errorf("question has no correct answer")
+	}
+
+	rule, err := question.GetScoringRule()
+	if err != nil {
+		return false, 0, fmt.Errorf("parse scoring rule failed: %w", err)
+	}
+
+	answers := splitBlankAnswers(answer)
+	correct := 0
+	for i, candidates := range accepted {
+		if i < len(answers) && matchBlank(rule, answers[i], candidates) {
+			correct++
+		}
+	}
+	isCorrect, score := scoreByRatio(question.Score, float64(correct)/float64(len(accepted)))
+	return isCorrect, score, nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// generateLayout 按作答次序的种子生成试卷布局并写入提交，作业未随机组卷时不处理
+// 同一次作答在提交记录创建前后生成的布局相同，学生开始作答前看到的试卷即为作答时的试卷
+func generateLayout(assignment *model.Assignment, questions []model.Question, submission *model.Submission) error {
+	settings, err := assignment.GetRandomization()
+	if err != nil {
+		return fmt.Errorf("parse randomization settings failed: %w", err)
+	}
+	if !settings.IsEnabled() {
+		return nil
+	}
+
+	submission.Seed = model.AttemptSeed(submission.AssignmentID, submission.StudentID, submission.Attempt)
+	layoutJSON, err := json.Marshal(model.BuildQuestionLayout(submission.Seed, questions, settings))
+	if err != nil {
+		return fmt.Errorf("marshal question layout failed: %w", err)
+	}
+	submission.Layout = string(layoutJSON)
+	return nil
+}
+
+// assignLayout 为新的作答生成试卷布局
+func (s *submissionService) assignLayout(ctx context.Context, assignment *model.Assignment, submission *model.Submission) error {
+	settings, err := assignment.GetRandomization()
+	if err != nil {
+		return fmt.Errorf("parse randomization settings failed: %w", err)
+	}
+	if !settings.IsEnabled() {
+		return nil
+	}
+
+	questions, err := s.questionRepo.GetByAssignmentIDWithOrder(ctx, assignment.ID)
+	if err != nil {
+		return fmt.Errorf("get questions failed: %w", err)
+	}
+	list := make([]model.Question, len(questions))
+	for i, q := range questions {
+		list[i] = *q
+	}
+	return generateLayout(assignment, list, submission)
+}
+
+// studentLayoutSource 返回决定学生试卷布局的作答，尚未作答时按第一次作答的种子生成布局，与开始作答后保存的试卷一致
+func studentLayoutSource(assignment *model.Assignment, submission *model.Submission, studentID uint) (*model.Submission, error) {
+	if submission != nil {
+		return submission, nil
+	}
+	source := &model.Submission{AssignmentID: assignment.ID, StudentID: studentID, Attempt: 1}
+	if err := generateLayout(assignment, assignment.Questions, source); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// studentQuestionDetails 按作答的试卷布局返回学生看到的题目（抽中的题目和打乱后的选项），并按答案公布情况隐藏答案
+// 限时作业在开始作答（开始计时）前不返回题目，此时 withheld 为 true
+func studentQuestionDetails(assignment *model.Assignment, questions []model.Question, source *model.Submission, revealed bool) (details []model.QuestionDetailResponse, withheld bool, err error) {
+	if assignment.IsTimed() && source.StartedAt == nil {
+		return []model.QuestionDetailResponse{}, true, nil
+	}
+	details = make([]model.QuestionDetailResponse, len(questions))
+	for i, q := range questions {
+		details[i] = newQuestionDetail(q)
+	}
+	layout, err := source.GetLayout()
+	if err != nil {
+		return nil, false, fmt.Errorf("parse question layout failed: %w", err)
+	}
+	details = applyLayout(details, layout)
+	for i := range details {
+		details[i].RedactForStudent(revealed)
+	}
+	return details, false, nil
+}
+
+// filterLayoutAnswers 只保留试卷中题目的答案，未抽中的题目不接受作答
+func filterLayoutAnswers(layout *model.QuestionLayout, answers []model.AnswerRequest) []model.AnswerRequest {
+	if layout == nil {
+		return answers
+	}
+	filtered := make([]model.AnswerRequest, 0, len(answers))
+	for _, answer := range answers {
+		if layout.Find(answer.QuestionID) != nil {
+			filtered = append(filtered, answer)
+		}
+	}
+	return filtered
+}
+
+// applyLayout 按试卷布局筛选和排列题目，选择题选项按学生看到的顺序排列
+func applyLayout(details []model.QuestionDetailResponse, layout *model.QuestionLayout) []model.QuestionDetailResponse {
+	if layout == nil {
+		return details
+	}
+	byID := make(map[uint]model.QuestionDetailResponse, len(details))
+	for _, detail := range details {
+		byID[detail.ID] = detail
+	}
+
+	result := make([]model.QuestionDetailResponse, 0, len(layout.Questions))
+	for _, item := range layout.Questions {
+		detail, ok := byID[item.QuestionID]
+		if !ok {
+			continue // 生成布局后被删除的题目
+		}
+		if keyMap := displayKeyMap(detail.OptionList, item.OptionKeys); keyMap != nil {
+			detail.OptionList = displayedOptions(detail.OptionList, keyMap)
+			detail.CorrectKeys = displayKeys(detail.CorrectKeys, keyMap)
+		}
+		result = append(result, detail)
+	}
+	return result
+}
+
+// displayKeyMap 返回学生看到的选项标识到原选项标识的映射
+// 选项未打乱，或生成布局后题目的选项被修改导致布局失效时返回 nil，此时按原顺序展示和判分
+func displayKeyMap(options []model.QuestionOption, optionKeys []string) map[string]string {
+	if len(optionKeys) == 0 || len(optionKeys) != len(options) {
+		return nil
+	}
+	exists := make(map[string]bool, len(options))
+	for _, option := range options {
+		exists[option.Key] = true
+	}
+	keyMap := make(map[string]string, len(options))
+	for i, key := range optionKeys {
+		if !exists[key] {
+			return nil
+		}
+		keyMap[options[i].Key] = key
+	}
+	return keyMap
+}
+
+// displayedOptions 构造学生看到的选项：每个位置保留原位置的选项标识，内容换为打乱后对应的原选项
+func displayedOptions(options []model.QuestionOption, keyMap map[string]string) []model.QuestionOption {
+	values := make(map[string]string, len(options))
+	for _, option := range options {
+		values[option.Key] = option.Value
+	}
+	result := make([]model.QuestionOption, len(options))
+	for i, option := range options {
+		result[i] = model.QuestionOption{Key: option.Key, Value: values[keyMap[option.Key]]}
+	}
+	return result
+}
+
+// displayKeys 将原选项标识转换为学生看到的选项标识
+func displayKeys(canonical []string, keyMap map[string]string) []string {
+	reverse := make(map[string]string, len(keyMap))
+	for display, key := range keyMap {
+		reverse[key] = display
+	}
+	result := make([]string, len(canonical))
+	for i, key := range canonical {
+		if display, ok := reverse[key]; ok {
+			key = display
+		}
+		result[i] = key
+	}
+	return result
+}
+
+// canonicalKeys 将学生按看到的选项标识作答的答案转换为原选项标识
+func canonicalKeys(studentKeys []string, keyMap map[string]string) []string {
+	result := make([]string, len(studentKeys))
+	for i, key := range studentKeys {
+		if canonical, ok := keyMap[key]; ok {
+			key = canonical
+		}
+		result[i] = key
+	}
+	return result
+}
+
+// applyGradingLayout 按学生的试卷排列批改详情中的题目，选择题附上学生看到的选项
+func applyGradingLayout(questions []model.QuestionWithAnswer, layout *model.QuestionLayout) []model.QuestionWithAnswer {
+	if layout == nil {
+		return questions
+	}
+	byID := make(map[uint]model.QuestionWithAnswer, len(questions))
+	for _, question := range questions {
+		byID[question.Question.ID] = question
+	}
+
+	result := make([]model.QuestionWithAnswer, 0, len(layout.Questions))
+	for _, item := range layout.Questions {
+		question, ok := byID[item.QuestionID]
+		if !ok {
+			continue
+		}
+		if len(item.OptionKeys) > 0 {
+			var options []model.QuestionOption
+			if err := json.Unmarshal([]byte(question.Question.Options), &options); err == nil {
+				if keyMap := displayKeyMap(options, item.OptionKeys); keyMap != nil {
+					question.DisplayedOptions = displayedOptions(options, keyMap)
+				}
+			}
+		}
+		result = append(result, question)
+	}
+	return result
+}
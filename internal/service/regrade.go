@@ -0,0 +1,206 @@
+package service
+
+import (
+	"ai-course/internal/config"
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRegradeNotFound      = errors.New("regrade request not found")
+	ErrAnswerNotFound       = errors.New("answer not found")
+	ErrGradesNotPublished   = errors.New("grades not published")
+	ErrRegradeWindowClosed  = errors.New("regrade window closed")
+	ErrRegradeAlreadyOpen   = errors.New("regrade request already open")
+	ErrRegradeResolved      = errors.New("regrade request already resolved")
+	ErrRegradeScoreRequired = errors.New("regrade score required")
+	ErrRegradeScoreExceeded = errors.New("regrade score exceeds question score")
+)
+
+// defaultRegradeWindow 未配置时成绩发布后允许申请复核的时长
+const defaultRegradeWindow = 7 * 24 * time.Hour
+
+// RegradeService 成绩复核服务接口
+type RegradeService interface {
+	// CreateRequest 学生在成绩发布后的复核期限内针对单题提出复核申请
+	CreateRequest(ctx context.Context, req *model.CreateRegradeRequest, studentID uint) (*model.RegradeRequest, error)
+	// GetStudentRequests 获取学生自己的复核申请，assignmentID 为 0 时返回全部作业
+	GetStudentRequests(ctx context.Context, studentID, assignmentID uint) ([]*model.RegradeRequest, error)
+	// GetAssignmentRequests 教师分页获取作业的复核申请
+	GetAssignmentRequests(ctx context.Context, assignmentID, teacherID uint, status model.RegradeStatus, page, pageSize int) ([]*model.RegradeRequest, int64, error)
+	// ResolveRequest 教师接受或驳回复核申请，接受时调整题目得分并重新计算提交总分
+	ResolveRequest(ctx context.Context, id uint, req *model.ResolveRegradeRequest, teacherID uint) (*model.RegradeRequest, error)
+}
+
+// regradeService 成绩复核服务实现
+type regradeService struct {
+	regradeRepo    repository.RegradeRepository
+	submissionRepo repository.SubmissionRepository
+	answerRepo     repository.AnswerRepository
+	assignmentRepo repository.AssignmentRepository
+	questionRepo   repository.QuestionRepository
+	access         AccessService
+	window         time.Duration
+}
+
+// NewRegradeService 创建成绩复核服务实例
+func NewRegradeService(
+	regradeRepo repository.RegradeRepository,
+	submissionRepo repository.SubmissionRepository,
+	answerRepo repository.AnswerRepository,
+	assignmentRepo repository.AssignmentRepository,
+	questionRepo repository.QuestionRepository,
+	access AccessService,
+	cfg *config.Config,
+) RegradeService {
+	window := defaultRegradeWindow
+	if cfg != nil && cfg.Grading.RegradeWindowDays > 0 {
+		window = time.Duration(cfg.Grading.RegradeWindowDays) * 24 * time.Hour
+	}
+	return &regradeService{
+		regradeRepo:    regradeRepo,
+		submissionRepo: submissionRepo,
+		answerRepo:     answerRepo,
+		assignmentRepo: assignmentRepo,
+		questionRepo:   questionRepo,
+		access:         access,
+		window:         window,
+	}
+}
+
+// CreateRequest 学生提交复核申请
+func (s *regradeService) CreateRequest(ctx context.Context, req *model.CreateRegradeRequest, studentID uint) (*model.RegradeRequest, error) {
+	answer, err := s.answerRepo.GetByID(ctx, req.AnswerID)
+	if err != nil {
+		return nil, ErrAnswerNotFound
+	}
+	submission, err := s.submissionRepo.GetByID(ctx, answer.SubmissionID)
+	if err != nil {
+		return nil, ErrSubmissionNotFound
+	}
+	if submission.StudentID != studentID {
+		return nil, ErrAccessDenied
+	}
+
+	assignment, err := s.assignmentRepo.GetByID(ctx, submission.AssignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	if !assignment.GradesPublished || !submission.IsGraded() {
+		return nil, ErrGradesNotPublished
+	}
+	if assignment.GradesPublishedAt != nil && time.Now().After(assignment.GradesPublishedAt.Add(s.window)) {
+		return nil, ErrRegradeWindowClosed
+	}
+
+	open, err := s.regradeRepo.HasOpenForAnswer(ctx, answer.ID)
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, ErrRegradeAlreadyOpen
+	}
+
+	request := &model.RegradeRequest{
+		AssignmentID:  submission.AssignmentID,
+		SubmissionID:  submission.ID,
+		AnswerID:      answer.ID,
+		QuestionID:    answer.QuestionID,
+		StudentID:     studentID,
+		Reason:        req.Reason,
+		Status:        model.RegradeStatusOpen,
+		OriginalScore: answer.Score,
+	}
+	if err := s.regradeRepo.Create(ctx, request); err != nil {
+		return nil, fmt.Errorf("create regrade request failed: %w", err)
+	}
+
+	logger.Logger.Info("Regrade request created",
+		zap.Uint("regrade_request_id", request.ID),
+		zap.Uint("answer_id", answer.ID),
+		zap.Uint("student_id", studentID),
+	)
+
+	return request, nil
+}
+
+// GetStudentRequests 获取学生自己的复核申请
+func (s *regradeService) GetStudentRequests(ctx context.Context, studentID, assignmentID uint) ([]*model.RegradeRequest, error) {
+	return s.regradeRepo.GetByStudentID(ctx, studentID, assignmentID)
+}
+
+// GetAssignmentRequests 教师分页获取作业的复核申请
+func (s *regradeService) GetAssignmentRequests(ctx context.Context, assignmentID, teacherID uint, status model.RegradeStatus, page, pageSize int) ([]*model.RegradeRequest, int64, error) {
+	if err := s.access.CheckAssignmentManage(ctx, assignmentID, teacherID); err != nil {
+		return nil, 0, err
+	}
+	return s.regradeRepo.GetByAssignmentID(ctx, assignmentID, status, (page-1)*pageSize, pageSize)
+}
+
+// ResolveRequest 教师处理复核申请
+func (s *regradeService) ResolveRequest(ctx context.Context, id uint, req *model.ResolveRegradeRequest, teacherID uint) (*model.RegradeRequest, error) {
+	request, err := s.regradeRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrRegradeNotFound
+	}
+	if err := s.access.CheckAssignmentManage(ctx, request.AssignmentID, teacherID); err != nil {
+		return nil, err
+	}
+	if !request.IsOpen() {
+		return nil, ErrRegradeResolved
+	}
+
+	now := time.Now()
+	request.TeacherResponse = req.Response
+	request.ResolvedBy = &teacherID
+	request.ResolvedAt = &now
+
+	if req.Status == model.RegradeStatusRejected {
+		err = s.regradeRepo.Reject(ctx, request)
+	} else {
+		err = s.accept(ctx, request, req)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, ErrGradeConflict
+		}
+		return nil, err
+	}
+
+	logger.Logger.Info("Regrade request resolved",
+		zap.Uint("regrade_request_id", id),
+		zap.String("status", string(request.Status)),
+		zap.Uint("teacher_id", teacherID),
+	)
+
+	return s.regradeRepo.GetDetailByID(ctx, id)
+}
+
+// accept 校验调整后的得分并接受复核申请
+func (s *regradeService) accept(ctx context.Context, request *model.RegradeRequest, req *model.ResolveRegradeRequest) error {
+	if req.NewScore == nil {
+		return ErrRegradeScoreRequired
+	}
+	answer, err := s.answerRepo.GetByID(ctx, request.AnswerID)
+	if err != nil {
+		return ErrAnswerNotFound
+	}
+	if req.Version != nil && *req.Version != answer.Version {
+		return ErrGradeConflict
+	}
+	question, err := s.questionRepo.GetByID(ctx, answer.QuestionID)
+	if err != nil {
+		return ErrQuestionNotFound
+	}
+	if *req.NewScore > question.Score {
+		return ErrRegradeScoreExceeded
+	}
+	return s.regradeRepo.Accept(ctx, request, answer, *req.NewScore, question.Score)
+}
@@ -4,6 +4,17 @@ import (
 	"ai-course/internal/model"
 	"ai-course/internal/repository"
 	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRoleNotFound = errors.New("角色不存在")
+	ErrRoleExists   = errors.New("角色已存在")
+	ErrBuiltinRole  = errors.New("内置角色不能删除")
+	ErrRoleInUse    = errors.New("角色仍有用户使用，不能删除")
 )
 
 type CreateRoleDTO struct {
@@ -16,6 +27,12 @@ type UpdateRoleDTO struct {
 	RoleName string `json:"role_name" binding:"required"`
 }
 
+// AssignRoleDTO 为用户分配角色的数据传输对象
+type AssignRoleDTO struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	RoleId string `json:"role_id" binding:"required"`
+}
+
 type RoleResponse struct {
 	RoleId   string `json:"role_id"`
 	RoleName string `json:"role_name"`
@@ -27,23 +44,48 @@ type RoleService interface {
 	Delete(ctx context.Context, roleId string) error
 	GetById(ctx context.Context, roleId string) (*RoleResponse, error)
 	GetAll(ctx context.Context) ([]*RoleResponse, error)
+	// AssignToUser 为用户分配角色
+	AssignToUser(ctx context.Context, dto *AssignRoleDTO) (*UserResponse, error)
 }
 
 type roleService struct {
 	roleRepo repository.RoleRepository
+	userRepo repository.UserRepository
 }
 
 // Create implements RoleService.
 func (r *roleService) Create(ctx context.Context, dto *CreateRoleDTO) error {
+	if _, err := r.roleRepo.GetById(ctx, dto.RoleId); err == nil {
+		return ErrRoleExists
+	}
+
+	now := time.Now()
 	role := &model.Role{
 		RoleId:   dto.RoleId,
 		RoleName: dto.RoleName,
+		CreateAt: now,
+		UpdateAt: now,
 	}
 	return r.roleRepo.Create(ctx, role)
 }
 
 // Delete implements RoleService.
 func (r *roleService) Delete(ctx context.Context, roleId string) error {
+	if model.IsBuiltinRole(roleId) {
+		return ErrBuiltinRole
+	}
+	if _, err := r.getRole(ctx, roleId); err != nil {
+		return err
+	}
+
+	count, err := r.roleRepo.CountUsers(ctx, roleId)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
 	return r.roleRepo.Delete(ctx, roleId)
 }
 
@@ -62,7 +104,7 @@ func (r *roleService) GetAll(ctx context.Context) ([]*RoleResponse, error) {
 
 // GetById implements RoleService.
 func (r *roleService) GetById(ctx context.Context, roleId string) (*RoleResponse, error) {
-	role, err := r.roleRepo.GetById(ctx, roleId)
+	role, err := r.getRole(ctx, roleId)
 	if err != nil {
 		return nil, err
 	}
@@ -71,13 +113,46 @@ func (r *roleService) GetById(ctx context.Context, roleId string) (*RoleResponse
 
 // Update implements RoleService.
 func (r *roleService) Update(ctx context.Context, dto *UpdateRoleDTO) error {
-	role := &model.Role{
-		RoleId:   dto.RoleId,
-		RoleName: dto.RoleName,
+	role, err := r.getRole(ctx, dto.RoleId)
+	if err != nil {
+		return err
 	}
+
+	role.RoleName = dto.RoleName
+	role.UpdateAt = time.Now()
 	return r.roleRepo.Update(ctx, role)
 }
 
+// AssignToUser implements RoleService.
+func (r *roleService) AssignToUser(ctx context.Context, dto *AssignRoleDTO) (*UserResponse, error) {
+	if _, err := r.getRole(ctx, dto.RoleId); err != nil {
+		return nil, err
+	}
+
+	user, err := r.userRepo.FindByID(ctx, dto.UserID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	user.RoleId = dto.RoleId
+	if err := r.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return toUserResponse(user), nil
+}
+
+// getRole 获取角色，不存在时返回 ErrRoleNotFound
+func (r *roleService) getRole(ctx context.Context, roleId string) (*model.Role, error) {
+	role, err := r.roleRepo.GetById(ctx, roleId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
 func (r *roleService) toRoleResponse(role *model.Role) *RoleResponse {
 	return &RoleResponse{
 		RoleId:   role.RoleId,
@@ -85,6 +160,6 @@ func (r *roleService) toRoleResponse(role *model.Role) *RoleResponse {
 	}
 }
 
-func NewRoleService(roleRepo repository.RoleRepository) RoleService {
-	return &roleService{roleRepo: roleRepo}
+func NewRoleService(roleRepo repository.RoleRepository, userRepo repository.UserRepository) RoleService {
+	return &roleService{roleRepo: roleRepo, userRepo: userRepo}
 }
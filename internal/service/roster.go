@@ -0,0 +1,504 @@
+package service
+
+import (
+	"ai-course/internal/logger"
+	"ai-course/internal/model"
+	"ai-course/internal/pkg/export"
+	"ai-course/internal/repository"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"mime/multipart"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrRosterImportHeader   = errors.New("用户表表头无效")
+	ErrRosterImportRejected = errors.New("用户表中存在无法导入的行，未保存任何用户")
+)
+
+const (
+	// initialPasswordLength 初始密码长度
+	initialPasswordLength = 10
+	// initialPasswordAlphabet 初始密码字符集，去掉了 0/O、1/l/I 等容易混淆的字符
+	initialPasswordAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz23456789"
+)
+
+// RosterService 用户批量导入服务接口
+type RosterService interface {
+	// Preview 校验用户表，返回每一行将新建、更新还是无变化，以及无法导入的原因
+	Preview(ctx context.Context, file *multipart.FileHeader) (*model.RosterImportReport, error)
+	// Import 导入用户表，为新建的用户生成初始密码，并通过 open 输出账号表
+	Import(ctx context.Context, file *multipart.FileHeader, format string, bom bool, open ExportOpener) (*model.RosterImportReport, error)
+}
+
+// rosterService 用户批量导入服务实现
+type rosterService struct {
+	userRepo       repository.UserRepository
+	roleRepo       repository.RoleRepository
+	classRepo      repository.ClassRepository
+	enrollmentRepo repository.EnrollmentRepository
+}
+
+// NewRosterService 创建用户批量导入服务实例
+func NewRosterService(
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	classRepo repository.ClassRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+) RosterService {
+	return &rosterService{
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		classRepo:      classRepo,
+		enrollmentRepo: enrollmentRepo,
+	}
+}
+
+// rosterImportColumns 导入文件中各列的位置，-1 表示不存在
+type rosterImportColumns struct {
+	code  int
+	name  int
+	role  int
+	class int
+}
+
+// rosterImport 一次导入的比对结果
+type rosterImport struct {
+	report  *model.RosterImportReport
+	entries []*model.RosterEntry
+	roles   map[string]*model.Role
+}
+
+// Preview 校验用户表
+func (s *rosterService) Preview(ctx context.Context, file *multipart.FileHeader) (*model.RosterImportReport, error) {
+	result, err := s.compare(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	return result.report, nil
+}
+
+// Import 导入用户表
+// 按学号（User.Code）匹配已有用户：已有用户只更新表中填写的姓名和角色，不修改密码，重复导入同一文件不会产生变化；
+// 新用户默认为学生，生成随机初始密码。班级列填写班级邀请码，教师成为协同授课教师，其他角色作为学生加入班级。
+// 存在阻止导入的行时拒绝导入，否则在同一事务中保存，成功后输出包含初始密码的账号表
+func (s *rosterService) Import(ctx context.Context, file *multipart.FileHeader, format string, bom bool, open ExportOpener) (*model.RosterImportReport, error) {
+	sheetFormat, err := export.ParseFormat(format)
+	if err != nil {
+		return nil, ErrExportFormatInvalid
+	}
+
+	result, err := s.compare(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	report := result.report
+	for _, row := range report.Rows {
+		if row.Status.Blocking() {
+			return report, ErrRosterImportRejected
+		}
+	}
+
+	// 生成初始密码，bcrypt 计算较慢，并发处理
+	passwords := make(map[string]string)
+	var created []*model.User
+	for _, entry := range result.entries {
+		if entry.User.ID == 0 {
+			password, err := generateInitialPassword()
+			if err != nil {
+				return nil, err
+			}
+			passwords[entry.User.Code] = password
+			created = append(created, entry.User)
+		}
+	}
+	if err := hashInitialPasswords(created, passwords); err != nil {
+		return nil, err
+	}
+
+	if len(result.entries) > 0 {
+		if err := s.userRepo.SaveRoster(ctx, result.entries); err != nil {
+			return nil, err
+		}
+	}
+	report.Applied = true
+
+	logger.Logger.Info("Roster imported",
+		zap.String("filename", file.Filename),
+		zap.Int("created", report.Summary.Create),
+		zap.Int("updated", report.Summary.Update),
+	)
+
+	// 先在内存中生成账号表，避免写出过程中出错时只输出一部分
+	var buf bytes.Buffer
+	w, err := export.NewWriter(&buf, sheetFormat, export.Options{SheetName: "账号", BOM: bom})
+	if err != nil {
+		return report, err
+	}
+	if err := w.Write([]interface{}{"学号", "姓名", "角色", "班级", "状态", "初始密码"}); err != nil {
+		return report, err
+	}
+	for _, row := range report.Rows {
+		roleName := row.RoleID
+		if role := result.roles[row.RoleID]; role != nil {
+			roleName = role.RoleName
+		}
+		if err := w.Write([]interface{}{row.Code, row.Name, roleName, row.ClassName, row.Status.Label(), passwords[row.Code]}); err != nil {
+			return report, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return report, err
+	}
+
+	out, err := open("账号-"+time.Now().Format("20060102150405")+sheetFormat.Extension(), sheetFormat)
+	if err != nil {
+		return report, err
+	}
+	if _, err := buf.WriteTo(out); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// compare 读取用户表并与现有用户比对
+func (s *rosterService) compare(ctx context.Context, file *multipart.FileHeader) (*rosterImport, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open uploaded file failed: %w", err)
+	}
+	defer f.Close()
+	rows, err := export.ReadRows(file.Filename, f)
+	if err != nil {
+		return nil, err
+	}
+
+	headerIndex := 0
+	for headerIndex < len(rows) && isBlankRow(rows[headerIndex]) {
+		headerIndex++
+	}
+	if headerIndex == len(rows) {
+		return nil, fmt.Errorf("%w: 文件为空", ErrRosterImportHeader)
+	}
+	columns, err := parseRosterImportHeader(rows[headerIndex])
+	if err != nil {
+		return nil, err
+	}
+
+	// 批量查询已有用户和被删除用户占用的学号
+	var codes []string
+	for _, cells := range rows[headerIndex+1:] {
+		if code := rosterCell(cells, columns.code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	users, err := s.userRepo.FindByCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*model.User, len(users))
+	for _, user := range users {
+		existing[user.Code] = user
+	}
+	deletedCodes, err := s.userRepo.FindDeletedCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	deleted := make(map[string]bool, len(deletedCodes))
+	for _, code := range deletedCodes {
+		deleted[code] = true
+	}
+
+	roles, err := s.roleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get roles failed: %w", err)
+	}
+	result := &rosterImport{
+		report: &model.RosterImportReport{},
+		roles:  make(map[string]*model.Role, len(roles)),
+	}
+	for _, role := range roles {
+		result.roles[role.RoleId] = role
+	}
+
+	classes := make(map[string]*model.Class)
+	seen := make(map[string]int)
+	for i := headerIndex + 1; i < len(rows); i++ {
+		if isBlankRow(rows[i]) {
+			continue
+		}
+		row, entry, err := s.compareRow(ctx, i+1, rows[i], columns, existing, deleted, roles, classes, seen)
+		if err != nil {
+			return nil, err
+		}
+		result.report.Rows = append(result.report.Rows, row)
+		if entry != nil {
+			result.entries = append(result.entries, entry)
+		}
+	}
+	summarizeRosterImport(result.report)
+	return result, nil
+}
+
+// compareRow 校验一行并与现有用户比对，需要新建或更新时返回待保存的用户
+func (s *rosterService) compareRow(
+	ctx context.Context,
+	rowNum int,
+	cells []string,
+	columns *rosterImportColumns,
+	existing map[string]*model.User,
+	deleted map[string]bool,
+	roles []*model.Role,
+	classes map[string]*model.Class,
+	seen map[string]int,
+) (*model.RosterImportRow, *model.RosterEntry, error) {
+	row := &model.RosterImportRow{
+		Row:       rowNum,
+		Code:      rosterCell(cells, columns.code),
+		Name:      rosterCell(cells, columns.name),
+		ClassCode: rosterCell(cells, columns.class),
+	}
+	fail := func(status model.RosterImportRowStatus, message string) {
+		if row.Status == "" {
+			row.Status = status
+		}
+		row.Errors = append(row.Errors, message)
+	}
+
+	if row.Code == "" {
+		fail(model.RosterImportInvalid, "学号为空")
+		return row, nil, nil
+	}
+	if first, ok := seen[row.Code]; ok {
+		fail(model.RosterImportDuplicateCode, fmt.Sprintf("学号与第 %d 行重复", first))
+		return row, nil, nil
+	}
+	seen[row.Code] = rowNum
+
+	user := existing[row.Code]
+	if n := utf8.RuneCountInString(row.Code); n < 5 || n > 20 {
+		fail(model.RosterImportInvalid, "学号长度须为 5-20 个字符")
+	} else if user == nil && deleted[row.Code] {
+		fail(model.RosterImportInvalid, "学号属于已删除的用户，无法重新创建")
+	}
+	if row.Name == "" {
+		if user == nil {
+			fail(model.RosterImportInvalid, "新用户的姓名为空")
+		}
+	} else if n := utf8.RuneCountInString(row.Name); n < 2 || n > 50 {
+		fail(model.RosterImportInvalid, "姓名长度须为 2-50 个字符")
+	}
+
+	// 角色可以填写角色ID或角色名称，留空时新用户为学生、已有用户保持不变
+	if value := rosterCell(cells, columns.role); value != "" {
+		for _, role := range roles {
+			if strings.EqualFold(role.RoleId, value) || role.RoleName == value {
+				row.RoleID = role.RoleId
+				break
+			}
+		}
+		if row.RoleID == "" {
+			fail(model.RosterImportUnknownRole, fmt.Sprintf("角色“%s”不存在", value))
+		}
+	} else if user != nil {
+		row.RoleID = user.RoleId
+	} else {
+		row.RoleID = model.RoleStudent
+	}
+
+	var class *model.Class
+	if row.ClassCode != "" {
+		var ok bool
+		if class, ok = classes[row.ClassCode]; !ok {
+			class, _ = s.classRepo.FindByCode(ctx, row.ClassCode)
+			classes[row.ClassCode] = class
+		}
+		if class == nil {
+			fail(model.RosterImportUnknownClass, fmt.Sprintf("班级邀请码“%s”不存在", row.ClassCode))
+		} else {
+			row.ClassID = class.ID
+			row.ClassName = class.ClassName
+			if row.RoleID == model.RoleAdmin {
+				fail(model.RosterImportInvalid, "管理员不能加入班级")
+			}
+		}
+	}
+
+	if row.Status != "" {
+		return row, nil, nil
+	}
+
+	asTeacher := row.RoleID == model.RoleTeacher
+	if user == nil {
+		row.Status = model.RosterImportCreate
+		row.JoinClass = class != nil
+		entry := &model.RosterEntry{
+			User:      &model.User{Code: row.Code, Name: row.Name, RoleId: row.RoleID},
+			Save:      true,
+			AsTeacher: asTeacher,
+		}
+		if row.JoinClass {
+			entry.ClassID = class.ID
+		}
+		return row, entry, nil
+	}
+
+	row.UserID = user.ID
+	updated := *user
+	if row.Name == "" {
+		row.Name = user.Name
+	} else if row.Name != user.Name {
+		row.OldName = user.Name
+		updated.Name = row.Name
+	}
+	if row.RoleID != user.RoleId {
+		row.OldRoleID = user.RoleId
+		updated.RoleId = row.RoleID
+	}
+	if class != nil {
+		member, err := s.isClassMember(ctx, class, user.ID, asTeacher)
+		if err != nil {
+			return nil, nil, err
+		}
+		row.JoinClass = !member
+	}
+
+	save := row.OldName != "" || row.OldRoleID != ""
+	if !save && !row.JoinClass {
+		row.Status = model.RosterImportUnchanged
+		return row, nil, nil
+	}
+	row.Status = model.RosterImportUpdate
+	entry := &model.RosterEntry{User: &updated, Save: save, AsTeacher: asTeacher}
+	if row.JoinClass {
+		entry.ClassID = class.ID
+	}
+	return row, entry, nil
+}
+
+// isClassMember 检查用户是否已是班级的任课教师或在读学生
+func (s *rosterService) isClassMember(ctx context.Context, class *model.Class, userID uint, asTeacher bool) (bool, error) {
+	if asTeacher {
+		if class.TeacherID == userID {
+			return true, nil
+		}
+		return s.classRepo.IsTeacher(ctx, class.ID, userID)
+	}
+	return s.enrollmentRepo.IsActiveMember(ctx, class.ID, userID)
+}
+
+// parseRosterImportHeader 识别学号、姓名、角色和班级列，学号和姓名列必须存在
+func parseRosterImportHeader(header []string) (*rosterImportColumns, error) {
+	columns := &rosterImportColumns{code: -1, name: -1, role: -1, class: -1}
+	for i, title := range header {
+		switch strings.ToLower(strings.TrimSpace(title)) {
+		case "学号", "工号", "账号", "code", "student_code":
+			columns.code = i
+		case "姓名", "name":
+			columns.name = i
+		case "角色", "role", "role_id":
+			columns.role = i
+		case "班级", "班级邀请码", "class", "class_code":
+			columns.class = i
+		}
+	}
+
+	var missing []string
+	if columns.code < 0 {
+		missing = append(missing, "学号")
+	}
+	if columns.name < 0 {
+		missing = append(missing, "姓名")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: 缺少%s列", ErrRosterImportHeader, strings.Join(missing, "、"))
+	}
+	return columns, nil
+}
+
+// summarizeRosterImport 统计各状态的行数
+func summarizeRosterImport(report *model.RosterImportReport) {
+	summary := &report.Summary
+	summary.Total = len(report.Rows)
+	for _, row := range report.Rows {
+		switch row.Status {
+		case model.RosterImportCreate:
+			summary.Create++
+		case model.RosterImportUpdate:
+			summary.Update++
+		case model.RosterImportUnchanged:
+			summary.Unchanged++
+		case model.RosterImportDuplicateCode:
+			summary.DuplicateCode++
+		case model.RosterImportUnknownClass:
+			summary.UnknownClass++
+		case model.RosterImportUnknownRole:
+			summary.UnknownRole++
+		case model.RosterImportInvalid:
+			summary.Invalid++
+		}
+	}
+}
+
+// rosterCell 返回指定列去掉首尾空白的内容，列不存在时返回空
+func rosterCell(cells []string, index int) string {
+	if index < 0 || index >= len(cells) {
+		return ""
+	}
+	return strings.TrimSpace(cells[index])
+}
+
+// generateInitialPassword 使用安全随机数生成初始密码
+func generateInitialPassword() (string, error) {
+	max := big.NewInt(int64(len(initialPasswordAlphabet)))
+	password := make([]byte, initialPasswordLength)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("generate password failed: %w", err)
+		}
+		password[i] = initialPasswordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// hashInitialPasswords 并发计算新用户的密码哈希
+func hashInitialPasswords(users []*model.User, passwords map[string]string) error {
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	jobs := make(chan *model.User)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for user := range jobs {
+				hashed, err := bcrypt.GenerateFromPassword([]byte(passwords[user.Code]), bcrypt.DefaultCost)
+				if err != nil {
+					once.Do(func() { firstErr = err })
+					continue
+				}
+				user.Password = string(hashed)
+			}
+		}()
+	}
+	for _, user := range users {
+		jobs <- user
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"ai-course/internal/logger"
+	"ai-course/internal/repository"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// assignmentSchedulerLock 作业定时发布和关闭任务的领导锁名称
+	assignmentSchedulerLock = "assignment_scheduler"
+	// scheduleBatch 每次调度处理的作业数量上限
+	scheduleBatch = 50
+)
+
+// ScheduleResult 一次调度的执行结果
+type ScheduleResult struct {
+	Leader        bool // 本实例是否持有领导锁，未持有时不执行调度
+	Published     int  // 定时发布的作业数
+	Closed        int  // 自动关闭的作业数
+	AutoSubmitted int  // 关闭时自动交卷的草稿数
+}
+
+// SchedulerService 作业定时发布和关闭服务接口
+type SchedulerService interface {
+	// RunDue 获取领导锁后发布和关闭到期的作业，owner 为本实例的唯一标识，lease 为锁的有效期
+	RunDue(ctx context.Context, owner string, lease time.Duration, now time.Time) (*ScheduleResult, error)
+}
+
+// schedulerService 作业定时发布和关闭服务实现
+type schedulerService struct {
+	assignmentRepo repository.AssignmentRepository
+	lockRepo       repository.SchedulerLockRepository
+	submissionSvc  SubmissionService
+}
+
+// NewSchedulerService 创建作业定时发布和关闭服务实例
+func NewSchedulerService(
+	assignmentRepo repository.AssignmentRepository,
+	lockRepo repository.SchedulerLockRepository,
+	submissionSvc SubmissionService,
+) SchedulerService {
+	return &schedulerService{
+		assignmentRepo: assignmentRepo,
+		lockRepo:       lockRepo,
+		submissionSvc:  submissionSvc,
+	}
+}
+
+// RunDue 获取领导锁后发布和关闭到期的作业
+// 多个实例同时运行时只有持有锁的实例执行，发布和关闭本身也是条件更新，锁过期交接时不会重复执行
+func (s *schedulerService) RunDue(ctx context.Context, owner string, lease time.Duration, now time.Time) (*ScheduleResult, error) {
+	result := &ScheduleResult{}
+	leader, err := s.lockRepo.TryAcquire(ctx, assignmentSchedulerLock, owner, lease, now)
+	if err != nil || !leader {
+		return result, err
+	}
+	result.Leader = true
+
+	if result.Published, err = s.publishDue(ctx, now); err != nil {
+		return result, err
+	}
+	result.Closed, result.AutoSubmitted, err = s.closeDue(ctx, now)
+	return result, err
+}
+
+// publishDue 发布到达定时发布时间的作业
+func (s *schedulerService) publishDue(ctx context.Context, now time.Time) (int, error) {
+	assignments, err := s.assignmentRepo.GetDueForPublish(ctx, now, scheduleBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, assignment := range assignments {
+		ok, err := s.assignmentRepo.PublishScheduled(ctx, assignment, now)
+		if err != nil {
+			return published, err
+		}
+		if ok {
+			published++
+			logger.Logger.Info("Scheduled assignment published", zap.Uint("assignment_id", assignment.ID))
+		}
+	}
+	return published, nil
+}
+
+// closeDue 关闭到达自动关闭时间的作业，按设置将未提交的草稿自动交卷并判分
+func (s *schedulerService) closeDue(ctx context.Context, now time.Time) (int, int, error) {
+	assignments, err := s.assignmentRepo.GetDueForClose(ctx, now, scheduleBatch)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	closed, submitted := 0, 0
+	for _, assignment := range assignments {
+		ok, err := s.assignmentRepo.CloseScheduled(ctx, assignment, now)
+		if err != nil {
+			return closed, submitted, err
+		}
+		if !ok {
+			continue
+		}
+		closed++
+		logger.Logger.Info("Scheduled assignment closed", zap.Uint("assignment_id", assignment.ID))
+
+		if !assignment.AutoSubmitOnClose {
+			continue
+		}
+		// 交卷失败的草稿保留，教师可在批改中处理
+		count, err := s.submissionSvc.SubmitDraftsOnClose(ctx, assignment, *assignment.CloseAt)
+		submitted += count
+		if err != nil {
+			logger.Logger.Error("Failed to submit drafts on close",
+				zap.Error(err),
+				zap.Uint("assignment_id", assignment.ID),
+			)
+		}
+	}
+	return closed, submitted, nil
+}
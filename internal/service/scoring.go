@@ -0,0 +1,141 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// scoreByRatio 按得分比例折算题目得分，完全答对时判为正确
+func scoreByRatio(score int, ratio float64) (bool, int) {
+	if ratio >= 1 {
+		return true, score
+	}
+	if ratio <= 0 {
+		return false, 0
+	}
+	return false, int(math.Round(float64(score) * ratio))
+}
+
+// multiSelectRatio 按计分方式计算多选题得分比例
+func multiSelectRatio(rule *model.ScoringRule, score int, correctKeys, studentKeys []string) float64 {
+	correctSet := make(map[string]bool, len(correctKeys))
+	for _, key := range correctKeys {
+		correctSet[key] = true
+	}
+
+	hits, wrongs := 0, 0
+	picked := make(map[string]bool, len(studentKeys))
+	for _, key := range studentKeys {
+		if picked[key] {
+			continue
+		}
+		picked[key] = true
+		if correctSet[key] {
+			hits++
+		} else {
+			wrongs++
+		}
+	}
+
+	total := float64(len(correctSet))
+	if total == 0 {
+		return 0
+	}
+
+	switch rule.MultiSelectMode {
+	case model.MultiSelectProportional:
+		return float64(hits) / (total + float64(wrongs))
+	case model.MultiSelectPenalty:
+		perKey := float64(score) / total
+		penalty := rule.WrongPenalty
+		if penalty == 0 {
+			penalty = perKey
+		}
+		if score == 0 {
+			return 0
+		}
+		return (float64(hits)*perKey - float64(wrongs)*penalty) / float64(score)
+	case model.MultiSelectAnyWrongZero:
+		if wrongs > 0 {
+			return 0
+		}
+		return float64(hits) / total
+	default:
+		if wrongs == 0 && float64(hits) == total {
+			return 1
+		}
+		return 0
+	}
+}
+
+// fillBlankAccepted 返回填空题每个空可接受的答案
+func fillBlankAccepted(question *model.Question, rule *model.ScoringRule) [][]string {
+	if len(rule.Blanks) > 0 {
+		return rule.Blanks
+	}
+	if question.CorrectAnswer == "" {
+		return nil
+	}
+	return [][]string{{question.CorrectAnswer}}
+}
+
+// splitBlankAnswers 解析学生的填空答案，多个空以 JSON 字符串数组提交
+func splitBlankAnswers(answer string) []string {
+	trimmed := strings.TrimSpace(answer)
+	if strings.HasPrefix(trimmed, "[") {
+		var answers []string
+		if err := json.Unmarshal([]byte(trimmed), &answers); err == nil {
+			return answers
+		}
+	}
+	return []string{answer}
+}
+
+// matchBlank 判断单个空的答案是否与任一可接受答案一致
+func matchBlank(rule *model.ScoringRule, answer string, accepted []string) bool {
+	normalizedAnswer := normalizeBlankAnswer(rule, answer)
+	for _, candidate := range accepted {
+		normalizedCandidate := normalizeBlankAnswer(rule, candidate)
+		if normalizedAnswer == normalizedCandidate {
+			return true
+		}
+		if rule.Tolerance != nil {
+			expected, err1 := strconv.ParseFloat(normalizedCandidate, 64)
+			actual, err2 := strconv.ParseFloat(normalizedAnswer, 64)
+			if err1 == nil && err2 == nil && math.Abs(expected-actual) <= *rule.Tolerance+1e-9 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeBlankAnswer 按计分规则标准化填空答案：全角转半角，默认忽略大小写和多余空白
+func normalizeBlankAnswer(rule *model.ScoringRule, s string) string {
+	s = toHalfWidth(s)
+	if !rule.StrictWhitespace {
+		s = strings.Join(strings.FieldsFunc(s, unicode.IsSpace), " ")
+	}
+	if !rule.CaseSensitive {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// toHalfWidth 将全角字符（含全角空格）转换为对应的半角字符
+func toHalfWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - 0xFEE0
+		default:
+			return r
+		}
+	}, s)
+}
@@ -1,13 +1,28 @@
 package service
 
 import (
+	"ai-course/internal/logger"
 	"ai-course/internal/model"
 	"ai-course/internal/repository"
 	"context"
+	"errors"
 	"fmt"
 	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAttemptInProgress   = errors.New("当前作答尚未提交")
+	ErrAttemptLimitReached = errors.New("已达到最大作答次数")
+	ErrAttemptNotStarted   = errors.New("限时作业需先开始作答")
+	ErrTimeLimitExceeded   = errors.New("作答时间已到")
 )
 
+// expiredSweepBatch 每次扫描自动交卷的草稿数量上限
+const expiredSweepBatch = 100
+
 // SubmissionService 提交服务接口
 type SubmissionService interface {
 	// 提交管理
@@ -16,9 +31,17 @@ type SubmissionService interface {
 	GetSubmission(ctx context.Context, id uint) (*model.Submission, error)
 	GetSubmissionDetail(ctx context.Context, id uint) (*model.Submission, error)
 	
+	// 多次作答
+	// StartAttempt 上一次作答提交（并自动判分）后开始新的作答，限时作业从此时开始计时
+	StartAttempt(ctx context.Context, assignmentID, studentID uint) (*model.Submission, error)
+	// GetStudentAttempts 获取学生的全部作答和按计分方式计算的有效得分
+	GetStudentAttempts(ctx context.Context, assignmentID, studentID uint) (*model.StudentAttemptsResponse, error)
+	
 	// 学生提交列表
 	GetStudentSubmissions(ctx context.Context, studentID uint, page, pageSize int) ([]*model.StudentAssignmentResponse, int64, error)
 	GetStudentSubmissionByAssignment(ctx context.Context, assignmentID, studentID uint) (*model.StudentAssignmentResponse, error)
+	// RedactForStudent 按答案公布时机隐藏提交中学生不应看到的答案和批改信息，需要预加载 Assignment 和 Answers.Question
+	RedactForStudent(ctx context.Context, submission *model.Submission) error
 	
 	// 教师批改列表
 	GetSubmissionsForGrading(ctx context.Context, assignmentID uint, page, pageSize int) ([]*model.SubmissionListResponse, int64, error)
@@ -26,6 +49,12 @@ type SubmissionService interface {
 	
 	// 自动判分
 	AutoGradeSubmission(ctx context.Context, submissionID uint) error
+	
+	// 限时作答
+	// SubmitExpiredAttempts 将已到时间的限时作答草稿自动交卷并判分，返回交卷数量
+	SubmitExpiredAttempts(ctx context.Context, now time.Time) (int, error)
+	// SubmitDraftsOnClose 作业关闭时将未提交的草稿自动交卷并判分，返回交卷数量
+	SubmitDraftsOnClose(ctx context.Context, assignment *model.Assignment, closedAt time.Time) (int, error)
 }
 
 // submissionService 提交服务实现
@@ -34,7 +63,10 @@ type submissionService struct {
 	answerRepo     repository.AnswerRepository
 	assignmentRepo repository.AssignmentRepository
 	questionRepo   repository.QuestionRepository
+	extensionRepo  repository.ExtensionRepository
 	questionSvc    QuestionService
+	access         AccessService
+	codeRunner     CodeRunner
 }
 
 // NewSubmissionService 创建提交服务实例
@@ -43,14 +75,20 @@ func NewSubmissionService(
 	answerRepo repository.AnswerRepository,
 	assignmentRepo repository.AssignmentRepository,
 	questionRepo repository.QuestionRepository,
+	extensionRepo repository.ExtensionRepository,
 	questionSvc QuestionService,
+	access AccessService,
+	codeRunner CodeRunner,
 ) SubmissionService {
 	return &submissionService{
 		submissionRepo: submissionRepo,
 		answerRepo:     answerRepo,
 		assignmentRepo: assignmentRepo,
 		questionRepo:   questionRepo,
+		extensionRepo:  extensionRepo,
 		questionSvc:    questionSvc,
+		access:         access,
+		codeRunner:     codeRunner,
 	}
 }
 
@@ -65,10 +103,19 @@ func (s *submissionService) CreateOrUpdateSubmission(ctx context.Context, req *m
 	if assignment.Status != "published" {
 		return nil, fmt.Errorf("assignment is not published")
 	}
+
+	// 只能提交所在班级的作业
+	if err := s.access.CheckAssignmentView(ctx, assignment.ID, studentID); err != nil {
+		return nil, err
+	}
 	
-	// 检查是否已过截止时间
-	if time.Now().After(assignment.Deadline) && req.Status == model.SubmissionStatusSubmitted {
-		return nil, fmt.Errorf("assignment deadline has passed")
+	// 检查是否已过截止时间，按迟交策略确定是否允许提交及扣分比例
+	var lateness *lateSubmission
+	if req.Status == model.SubmissionStatusSubmitted {
+		lateness, err = s.checkDeadline(ctx, assignment, studentID, time.Now())
+		if err != nil {
+			return nil, err
+		}
 	}
 	
 	// 查找是否已有提交记录
@@ -81,6 +128,19 @@ func (s *submissionService) CreateOrUpdateSubmission(ctx context.Context, req *m
 		existingSubmission = nil
 	}
 	
+	// 已提交或已批改的作答不再修改，再次作答需通过开始新的作答（计入作答次数）
+	if existingSubmission != nil && !existingSubmission.CanBeModified() {
+		return nil, fmt.Errorf("assignment already submitted")
+	}
+	
+	// 限时作答需先开始作答，到时间后不再接受答案
+	if assignment.IsTimed() && (existingSubmission == nil || existingSubmission.StartedAt == nil) {
+		return nil, ErrAttemptNotStarted
+	}
+	if existingSubmission != nil && existingSubmission.IsExpired(time.Now()) {
+		return nil, ErrTimeLimitExceeded
+	}
+	
 	var submission *model.Submission
 	
 	if existingSubmission == nil {
@@ -88,12 +148,17 @@ func (s *submissionService) CreateOrUpdateSubmission(ctx context.Context, req *m
 		submission = &model.Submission{
 			AssignmentID: req.AssignmentID,
 			StudentID:    studentID,
+			Attempt:      1,
 			Status:       req.Status,
 		}
+		if err := s.assignLayout(ctx, assignment, submission); err != nil {
+			return nil, err
+		}
 		
 		if req.Status == model.SubmissionStatusSubmitted {
 			now := time.Now()
 			submission.SubmittedAt = &now
+			lateness.apply(submission)
 		}
 		
 		if err := s.submissionRepo.Create(ctx, submission); err != nil {
@@ -101,16 +166,13 @@ func (s *submissionService) CreateOrUpdateSubmission(ctx context.Context, req *m
 		}
 	} else {
 		// 更新现有提交
-		if existingSubmission.Status == model.SubmissionStatusSubmitted && req.Status == model.SubmissionStatusDraft {
-			return nil, fmt.Errorf("cannot change submitted assignment back to draft")
-		}
-		
 		submission = existingSubmission
 		submission.Status = req.Status
 		
 		if req.Status == model.SubmissionStatusSubmitted && submission.SubmittedAt == nil {
 			now := time.Now()
 			submission.SubmittedAt = &now
+			lateness.apply(submission)
 		}
 		
 		if err := s.submissionRepo.Update(ctx, submission); err != nil {
@@ -118,8 +180,12 @@ func (s *submissionService) CreateOrUpdateSubmission(ctx context.Context, req *m
 		}
 	}
 	
-	// 处理答案
-	if err := s.processAnswers(ctx, submission.ID, req.Answers); err != nil {
+	// 处理答案，随机组卷时只保存试卷中题目的答案
+	layout, err := submission.GetLayout()
+	if err != nil {
+		return nil, fmt.Errorf("parse question layout failed: %w", err)
+	}
+	if err := s.processAnswers(ctx, submission.ID, filterLayoutAnswers(layout, req.Answers)); err != nil {
 		return nil, fmt.Errorf("process answers failed: %w", err)
 	}
 	
@@ -193,7 +259,8 @@ func (s *submissionService) SubmitAssignment(ctx context.Context, assignmentID u
 		return fmt.Errorf("submission not found: %w", err)
 	}
 	
-	if submission.Status == model.SubmissionStatusSubmitted {
+	// 已提交或已批改的作答不能再次提交，避免覆盖判分和教师的批改
+	if !submission.CanBeModified() {
 		return fmt.Errorf("assignment already submitted")
 	}
 	
@@ -203,14 +270,19 @@ func (s *submissionService) SubmitAssignment(ctx context.Context, assignmentID u
 		return fmt.Errorf("assignment not found: %w", err)
 	}
 	
-	if time.Now().After(assignment.Deadline) {
-		return fmt.Errorf("assignment deadline has passed")
+	now := time.Now()
+	if submission.IsExpired(now) {
+		return ErrTimeLimitExceeded
+	}
+	lateness, err := s.checkDeadline(ctx, assignment, studentID, now)
+	if err != nil {
+		return err
 	}
 	
 	// 更新提交状态
 	submission.Status = model.SubmissionStatusSubmitted
-	now := time.Now()
 	submission.SubmittedAt = &now
+	lateness.apply(submission)
 	
 	if err := s.submissionRepo.Update(ctx, submission); err != nil {
 		return fmt.Errorf("update submission status failed: %w", err)
@@ -225,6 +297,286 @@ func (s *submissionService) SubmitAssignment(ctx context.Context, assignmentID u
 	return nil
 }
 
+// StartAttempt 开始新的作答，新作答为草稿，作答次序在上一次基础上加一
+func (s *submissionService) StartAttempt(ctx context.Context, assignmentID, studentID uint) (*model.Submission, error) {
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	if assignment.Status != "published" {
+		return nil, fmt.Errorf("assignment is not published")
+	}
+	if err := s.access.CheckAssignmentView(ctx, assignment.ID, studentID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if _, err := s.checkDeadline(ctx, assignment, studentID, now); err != nil {
+		return nil, err
+	}
+
+	latest, err := s.submissionRepo.GetByAssignmentAndStudent(ctx, assignmentID, studentID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("get latest attempt failed: %w", err)
+	}
+	used := 0
+	if latest != nil {
+		if !latest.IsSubmitted() {
+			// 改为限时作业前保存的草稿尚未计时，从现在开始计时
+			if assignment.IsTimed() && latest.StartedAt == nil {
+				if err := s.startTimer(ctx, assignment, latest, now); err != nil {
+					return nil, err
+				}
+				if err := s.submissionRepo.Update(ctx, latest); err != nil {
+					return nil, fmt.Errorf("start attempt timer failed: %w", err)
+				}
+				return latest, nil
+			}
+			return nil, ErrAttemptInProgress
+		}
+		used = latest.Attempt
+	}
+	if !assignment.CanStartAttempt(used) {
+		return nil, ErrAttemptLimitReached
+	}
+
+	submission := &model.Submission{
+		AssignmentID: assignmentID,
+		StudentID:    studentID,
+		Attempt:      used + 1,
+		Status:       model.SubmissionStatusDraft,
+	}
+	if err := s.assignLayout(ctx, assignment, submission); err != nil {
+		return nil, err
+	}
+	if assignment.IsTimed() {
+		if err := s.startTimer(ctx, assignment, submission, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.submissionRepo.Create(ctx, submission); err != nil {
+		return nil, fmt.Errorf("create attempt failed: %w", err)
+	}
+	return submission, nil
+}
+
+// GetStudentAttempts 获取学生的全部作答
+func (s *submissionService) GetStudentAttempts(ctx context.Context, assignmentID, studentID uint) (*model.StudentAttemptsResponse, error) {
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+
+	attempts, err := s.submissionRepo.GetAttempts(ctx, assignmentID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.StudentAttemptsResponse{
+		AssignmentID:   assignmentID,
+		MaxAttempts:    assignment.MaxAttempts,
+		AttemptScoring: assignment.GetAttemptScoring(),
+		Attempts:       attempts,
+	}
+
+	var submitted []*model.Submission
+	for _, attempt := range attempts {
+		if attempt.IsSubmitted() {
+			submitted = append(submitted, attempt)
+		}
+	}
+	if score, ok := model.EffectiveScore(result.AttemptScoring, submitted); ok {
+		result.EffectiveScore = &score
+	}
+
+	if n := len(attempts); n > 0 {
+		latest := attempts[n-1]
+		result.AttemptsUsed = latest.Attempt
+		result.CanStartAttempt = latest.IsSubmitted() && assignment.CanStartAttempt(latest.Attempt)
+	} else {
+		result.CanStartAttempt = assignment.CanStartAttempt(0)
+	}
+	return result, nil
+}
+
+// lateSubmission 迟交信息
+type lateSubmission struct {
+	penaltyPercent float64
+}
+
+// apply 将迟交信息记录到提交中，未迟交（nil）时清除迟交标记
+func (l *lateSubmission) apply(submission *model.Submission) {
+	submission.IsLate = l != nil
+	submission.LatePenaltyPercent = 0
+	if l != nil {
+		submission.LatePenaltyPercent = l.penaltyPercent
+	}
+}
+
+// submissionWindow 获取学生生效的截止时间和最晚可提交时间，学生有延期时以延期后的截止时间为准
+// 允许迟交、不限最晚提交时间且作业未设置自动关闭时 closeAt 为 nil
+func (s *submissionService) submissionWindow(ctx context.Context, assignment *model.Assignment, studentID uint) (time.Time, *time.Time, *model.LatePolicy, error) {
+	extension, err := s.extensionRepo.GetByAssignmentAndStudent(ctx, assignment.ID, studentID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil, nil, fmt.Errorf("get deadline extension failed: %w", err)
+	}
+
+	deadline := assignment.Deadline
+	if extension != nil && extension.Deadline.After(deadline) {
+		deadline = extension.Deadline
+	}
+
+	policy, err := assignment.GetLatePolicy()
+	if err != nil {
+		return time.Time{}, nil, nil, fmt.Errorf("parse late policy failed: %w", err)
+	}
+
+	var closeAt *time.Time
+	switch {
+	case policy.Mode != model.LatePolicyAllow:
+		closeAt = &deadline
+	case policy.Cutoff != nil:
+		// 延期同样顺延最晚提交时间
+		cutoff := *policy.Cutoff
+		if cutoff.Before(deadline) {
+			cutoff = deadline
+		}
+		closeAt = &cutoff
+	}
+	// 作业关闭后不再接受提交，包括有延期的学生
+	if assignment.CloseAt != nil && (closeAt == nil || assignment.CloseAt.Before(*closeAt)) {
+		closeAt = assignment.CloseAt
+	}
+	return deadline, closeAt, policy, nil
+}
+
+// checkDeadline 检查学生能否在 now 提交作业
+// 未迟交时返回 nil；迟交且策略允许时返回扣分比例（从生效的截止时间起算），否则返回截止错误
+func (s *submissionService) checkDeadline(ctx context.Context, assignment *model.Assignment, studentID uint, now time.Time) (*lateSubmission, error) {
+	deadline, closeAt, policy, err := s.submissionWindow(ctx, assignment, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if closeAt != nil && now.After(*closeAt) {
+		return nil, fmt.Errorf("assignment deadline has passed")
+	}
+	if !now.After(deadline) {
+		return nil, nil
+	}
+	return &lateSubmission{penaltyPercent: policy.PenaltyPercentFor(now.Sub(deadline))}, nil
+}
+
+// startTimer 开始限时作答计时，作答截止时间不晚于最晚可提交时间
+func (s *submissionService) startTimer(ctx context.Context, assignment *model.Assignment, submission *model.Submission, now time.Time) error {
+	_, closeAt, _, err := s.submissionWindow(ctx, assignment, submission.StudentID)
+	if err != nil {
+		return err
+	}
+	expiresAt := now.Add(assignment.TimeLimit())
+	if closeAt != nil && closeAt.Before(expiresAt) {
+		expiresAt = *closeAt
+	}
+	submission.StartedAt = &now
+	submission.ExpiresAt = &expiresAt
+	return nil
+}
+
+// SubmitExpiredAttempts 将已到时间的限时作答草稿自动交卷，以作答截止时间作为提交时间
+// 学生同时提交时以先完成的为准，已提交的草稿不会重复交卷
+func (s *submissionService) SubmitExpiredAttempts(ctx context.Context, now time.Time) (int, error) {
+	drafts, err := s.submissionRepo.GetExpiredDrafts(ctx, now, expiredSweepBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	submitted := 0
+	assignments := make(map[uint]*model.Assignment)
+	for _, submission := range drafts {
+		assignment, ok := assignments[submission.AssignmentID]
+		if !ok {
+			assignment, err = s.assignmentRepo.GetByID(ctx, submission.AssignmentID)
+			if err != nil {
+				logger.Logger.Warn("Assignment not found for expired attempt",
+					zap.Error(err),
+					zap.Uint("submission_id", submission.ID),
+				)
+				continue
+			}
+			assignments[submission.AssignmentID] = assignment
+		}
+
+		ok, err = s.autoSubmit(ctx, assignment, submission, *submission.ExpiresAt)
+		if err != nil {
+			return submitted, err
+		}
+		if ok {
+			submitted++
+		}
+	}
+	return submitted, nil
+}
+
+// SubmitDraftsOnClose 作业关闭时将未提交的草稿自动交卷并判分
+// 交卷时间为关闭时间，但不晚于限时作答的截止时间和学生的最晚可提交时间
+func (s *submissionService) SubmitDraftsOnClose(ctx context.Context, assignment *model.Assignment, closedAt time.Time) (int, error) {
+	drafts, err := s.submissionRepo.GetDraftsByAssignment(ctx, assignment.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	submitted := 0
+	for _, submission := range drafts {
+		submittedAt := closedAt
+		if submission.ExpiresAt != nil && submission.ExpiresAt.Before(submittedAt) {
+			submittedAt = *submission.ExpiresAt
+		}
+		_, closeAt, _, err := s.submissionWindow(ctx, assignment, submission.StudentID)
+		if err != nil {
+			return submitted, err
+		}
+		if closeAt != nil && closeAt.Before(submittedAt) {
+			submittedAt = *closeAt
+		}
+
+		ok, err := s.autoSubmit(ctx, assignment, submission, submittedAt)
+		if err != nil {
+			return submitted, err
+		}
+		if ok {
+			submitted++
+		}
+	}
+	return submitted, nil
+}
+
+// autoSubmit 按交卷时间记录迟交信息后将草稿自动交卷并判分，草稿已被学生提交时返回 false
+// 交卷时间不晚于最晚可提交时间，延期被取消等情况下仍按时交卷但不扣分
+func (s *submissionService) autoSubmit(ctx context.Context, assignment *model.Assignment, submission *model.Submission, submittedAt time.Time) (bool, error) {
+	lateness, err := s.checkDeadline(ctx, assignment, submission.StudentID, submittedAt)
+	if err != nil {
+		logger.Logger.Warn("Failed to check deadline for auto submission",
+			zap.Error(err),
+			zap.Uint("submission_id", submission.ID),
+		)
+		lateness = nil
+	}
+	submission.SubmittedAt = &submittedAt
+	lateness.apply(submission)
+
+	ok, err := s.submissionRepo.AutoSubmit(ctx, submission)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err := s.AutoGradeSubmission(ctx, submission.ID); err != nil {
+		logger.Logger.Warn("Failed to auto grade auto submission",
+			zap.Error(err),
+			zap.Uint("submission_id", submission.ID),
+		)
+	}
+	return true, nil
+}
+
 // GetSubmission 获取提交
 func (s *submissionService) GetSubmission(ctx context.Context, id uint) (*model.Submission, error) {
 	return s.submissionRepo.GetByID(ctx, id)
@@ -250,18 +602,25 @@ func (s *submissionService) GetStudentSubmissions(ctx context.Context, studentID
 		if err != nil {
 			return nil, 0, fmt.Errorf("get questions failed: %w", err)
 		}
-		
-		// 转换题目格式
-		questionDetails := make([]model.QuestionDetailResponse, len(questions))
+		list := make([]model.Question, len(questions))
 		for j, q := range questions {
-			questionDetails[j] = model.QuestionDetailResponse{
-				Question: *q,
-			}
+			list[j] = *q
+		}
+		
+		revealed, err := s.answersRevealed(ctx, &submission.Assignment, submission)
+		if err != nil {
+			return nil, 0, err
+		}
+		questionDetails, withheld, err := studentQuestionDetails(&submission.Assignment, list, submission, revealed)
+		if err != nil {
+			return nil, 0, err
 		}
 		
 		result[i] = &model.StudentAssignmentResponse{
 			Assignment: submission.Assignment,
 			Submission: submission,
+			AnswersRevealed: revealed,
+			QuestionsWithheld: withheld,
 			Questions:  questionDetails,
 		}
 	}
@@ -283,22 +642,71 @@ func (s *submissionService) GetStudentSubmissionByAssignment(ctx context.Context
 		return nil, fmt.Errorf("get submission failed: %w", err)
 	}
 	
-	// 转换题目格式
-	questionDetails := make([]model.QuestionDetailResponse, len(assignment.Questions))
-	for i, q := range assignment.Questions {
-		questionDetails[i] = model.QuestionDetailResponse{
-			Question: q,
-		}
+	// 学生的延期（如果有）
+	extension, err := s.extensionRepo.GetByAssignmentAndStudent(ctx, assignmentID, studentID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("get deadline extension failed: %w", err)
+	}
+	
+	// 随机组卷时按学生的试卷展示题目，作业中的完整题目（题目池和原选项顺序）不返回给学生
+	layoutSource, err := studentLayoutSource(assignment, submission, studentID)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 学生只能按作业的答案公布时机看到答案和解析
+	revealed, err := s.answersRevealed(ctx, assignment, layoutSource)
+	if err != nil {
+		return nil, err
+	}
+	questionDetails, withheld, err := studentQuestionDetails(assignment, assignment.Questions, layoutSource, revealed)
+	if err != nil {
+		return nil, err
+	}
+	assignment.Questions = nil
+	
+	// 限时作答进行中时返回服务器计算的剩余时间，客户端据此倒计时
+	var remainingSeconds *int64
+	if submission != nil && submission.Status == model.SubmissionStatusDraft && submission.ExpiresAt != nil {
+		remaining := int64(submission.RemainingTime(time.Now()) / time.Second)
+		remainingSeconds = &remaining
 	}
 	
 	return &model.StudentAssignmentResponse{
 		Assignment: *assignment,
 		Submission: submission,
+		Extension:  extension,
+		RemainingSeconds: remainingSeconds,
+		AnswersRevealed: revealed,
+		QuestionsWithheld: withheld,
 		Questions:  questionDetails,
 		Attachments: assignment.Attachments,
 	}, nil
 }
 
+// RedactForStudent 按答案公布时机隐藏提交中学生不应看到的答案和批改信息
+func (s *submissionService) RedactForStudent(ctx context.Context, submission *model.Submission) error {
+	revealed, err := s.answersRevealed(ctx, &submission.Assignment, submission)
+	if err != nil {
+		return err
+	}
+	submission.RedactForStudent(revealed)
+	return nil
+}
+
+// answersRevealed 检查学生能否看到作业的答案和解析，截止后公布时按学生生效的截止时间（含延期）判断
+func (s *submissionService) answersRevealed(ctx context.Context, assignment *model.Assignment, submission *model.Submission) (bool, error) {
+	deadline := assignment.Deadline
+	if assignment.GetAnswerReveal() == model.AnswerRevealAfterDeadline {
+		var err error
+		deadline, _, _, err = s.submissionWindow(ctx, assignment, submission.StudentID)
+		if err != nil {
+			return false, err
+		}
+	}
+	return assignment.AnswersRevealed(submission.IsSubmitted(), deadline, time.Now()), nil
+}
+
 // GetSubmissionsForGrading 获取待批改的提交列表
 func (s *submissionService) GetSubmissionsForGrading(ctx context.Context, assignmentID uint, page, pageSize int) ([]*model.SubmissionListResponse, int64, error) {
 	offset := (page - 1) * pageSize
@@ -333,7 +741,7 @@ func (s *submissionService) GetGradingDetail(ctx context.Context, submissionID u
 	}
 	
 	// 验证教师权限
-	if submission.Assignment.TeacherID != teacherID {
+	if !s.access.IsClassTeacher(ctx, submission.Assignment.ClassID, teacherID) {
 		return nil, fmt.Errorf("teacher has no permission to grade this submission")
 	}
 	
@@ -350,8 +758,18 @@ func (s *submissionService) GetGradingDetail(ctx context.Context, submissionID u
 			Question: question,
 			Answer:   answerMap[question.ID],
 		}
+		if answer := answerMap[question.ID]; answer != nil && question.IsSubjective() {
+			questions[i].AISuggestion = answer.GetAISuggestion()
+		}
 	}
 	
+	// 随机组卷时按学生看到的试卷排列题目和选项
+	layout, err := submission.GetLayout()
+	if err != nil {
+		return nil, fmt.Errorf("parse question layout failed: %w", err)
+	}
+	questions = applyGradingLayout(questions, layout)
+	
 	return &model.GradingDetailResponse{
 		Submission: *submission,
 		Student:    submission.Student,
@@ -373,13 +791,28 @@ func (s *submissionService) AutoGradeSubmission(ctx context.Context, submissionI
 	}
 	
 	totalScore := 0
-	answers := submission.Answers
+	var updatedAnswers []*model.Answer
+	var events []*model.GradeEvent
+	
+	// 选项打乱时按学生看到的选项顺序判分
+	layout, err := submission.GetLayout()
+	if err != nil {
+		return fmt.Errorf("parse question layout failed: %w", err)
+	}
 	
 	// 逐题判分
-	for _, answer := range answers {
+	for i := range submission.Answers {
+		answer := &submission.Answers[i]
+		oldScore, oldFeedback := answer.Score, answer.Feedback
 		if answer.Question.IsObjective() {
 			// 客观题自动判分
-			isCorrect, score, err := s.questionSvc.ValidateAnswer(ctx, answer.QuestionID, answer.Content)
+			var optionKeys []string
+			if layout != nil {
+				if item := layout.Find(answer.QuestionID); item != nil {
+					optionKeys = item.OptionKeys
+				}
+			}
+			isCorrect, score, err := s.questionSvc.ValidateAnswer(ctx, answer.QuestionID, answer.Content, optionKeys)
 			if err != nil {
 				continue // 跳过判分失败的题目
 			}
@@ -390,19 +823,33 @@ func (s *submissionService) AutoGradeSubmission(ctx context.Context, submissionI
 			answer.GradedAt = &now
 			
 			totalScore += score
-			
-			// 更新答案
-			if err := s.answerRepo.Update(ctx, &answer); err != nil {
-				return fmt.Errorf("update answer failed: %w", err)
+			updatedAnswers = append(updatedAnswers, answer)
+			events = append(events, model.NewAnswerGradeEvent(answer, model.GradeEventSourceAuto, nil, oldScore, oldFeedback))
+		} else if answer.Question.IsCode() && s.codeRunner != nil {
+			// 编程题在沙箱中运行测试用例判分，运行失败时留给教师人工批改
+			if err := gradeCodeAnswer(ctx, s.codeRunner, &answer.Question, answer); err != nil {
+				continue
 			}
+			
+			totalScore += answer.Score
+			updatedAnswers = append(updatedAnswers, answer)
+			events = append(events, model.NewAnswerGradeEvent(answer, model.GradeEventSourceAuto, nil, oldScore, oldFeedback))
 		}
-		// 主观题需要教师手动批改，跳过
+		// 主观题的 AI 批改建议由后台任务生成（GradingService.SuggestPendingAnswers），不阻塞提交
 	}
 	
-	// 更新提交总分
-	submission.Score = totalScore
-	if err := s.submissionRepo.Update(ctx, submission); err != nil {
-		return fmt.Errorf("update submission score failed: %w", err)
+	// 在同一事务中保存答案、提交总分和成绩变更记录
+	oldTotal := submission.Score
+	submission.ApplyLatePenalty(totalScore)
+	// 全部答案都已自动判分时无需教师批改，直接标记为已批改，计入统计和成绩册
+	if len(updatedAnswers) == len(submission.Answers) {
+		now := time.Now()
+		submission.Status = model.SubmissionStatusGraded
+		submission.GradedAt = &now
+	}
+	events = append(events, model.NewSubmissionGradeEvent(submission, model.GradeEventSourceAuto, nil, oldTotal, submission.Feedback))
+	if err := s.submissionRepo.SaveAutoGrading(ctx, submission, updatedAnswers, events); err != nil {
+		return fmt.Errorf("save auto grading failed: %w", err)
 	}
 	
 	return nil
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"ai-course/internal/model"
+	"ai-course/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("作业模板不存在")
+)
+
+// TemplateService 作业复制和作业模板服务接口
+type TemplateService interface {
+	// CloneAssignment 将作业连同题目和附件复制到指定班级，新作业为草稿状态，日期按新的开始时间平移
+	CloneAssignment(ctx context.Context, id uint, req *model.CloneAssignmentRequest, teacherID uint) (*model.Assignment, error)
+
+	// 作业模板管理
+	SaveAsTemplate(ctx context.Context, assignmentID uint, req *model.SaveTemplateRequest, teacherID uint) (*model.AssignmentTemplate, error)
+	GetTeacherTemplates(ctx context.Context, teacherID uint, page, pageSize int) ([]*model.TemplateListResponse, int64, error)
+	GetTemplateDetail(ctx context.Context, id uint, userID uint) (*model.TemplateDetailResponse, error)
+	DeleteTemplate(ctx context.Context, id uint, userID uint) error
+
+	// InstantiateTemplate 由模板在指定班级中创建作业，新作业为草稿状态
+	InstantiateTemplate(ctx context.Context, id uint, req *model.CloneAssignmentRequest, teacherID uint) (*model.Assignment, error)
+}
+
+// templateService 作业复制和作业模板服务实现
+type templateService struct {
+	assignmentRepo repository.AssignmentRepository
+	templateRepo   repository.TemplateRepository
+	attachmentRepo repository.AttachmentRepository
+	access         AccessService
+}
+
+// NewTemplateService 创建作业复制和作业模板服务实例
+func NewTemplateService(
+	assignmentRepo repository.AssignmentRepository,
+	templateRepo repository.TemplateRepository,
+	attachmentRepo repository.AttachmentRepository,
+	access AccessService,
+) TemplateService {
+	return &templateService{
+		assignmentRepo: assignmentRepo,
+		templateRepo:   templateRepo,
+		attachmentRepo: attachmentRepo,
+		access:         access,
+	}
+}
+
+// CloneAssignment 将作业复制到指定班级
+// 附件只复制记录，与原作业共用磁盘上的文件
+func (s *templateService) CloneAssignment(ctx context.Context, id uint, req *model.CloneAssignmentRequest, teacherID uint) (*model.Assignment, error) {
+	if err := s.access.CheckAssignmentManage(ctx, id, teacherID); err != nil {
+		return nil, err
+	}
+	if err := s.access.CheckClassManage(ctx, req.ClassID, teacherID); err != nil {
+		return nil, err
+	}
+
+	source, err := s.assignmentRepo.GetDetailByID(ctx, id)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	snapshot, err := model.NewAssignmentSnapshot(source)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot assignment failed: %w", err)
+	}
+
+	files := make([]model.TemplateAttachment, len(source.Attachments))
+	for i, attachment := range source.Attachments {
+		files[i] = model.TemplateAttachment{
+			FileName:     attachment.FileName,
+			OriginalName: attachment.OriginalName,
+			FilePath:     attachment.FilePath,
+			FileSize:     attachment.FileSize,
+			ContentType:  attachment.ContentType,
+			ContentHash:  attachment.ContentHash,
+		}
+	}
+
+	return s.createAssignment(ctx, snapshot, files, req, teacherID)
+}
+
+// SaveAsTemplate 将作业保存为模板，附件与原作业共用磁盘上的文件
+func (s *templateService) SaveAsTemplate(ctx context.Context, assignmentID uint, req *model.SaveTemplateRequest, teacherID uint) (*model.AssignmentTemplate, error) {
+	if err := s.access.CheckAssignmentManage(ctx, assignmentID, teacherID); err != nil {
+		return nil, err
+	}
+
+	source, err := s.assignmentRepo.GetDetailByID(ctx, assignmentID)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	snapshot, err := model.NewAssignmentSnapshot(source)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot assignment failed: %w", err)
+	}
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("marshal assignment snapshot failed: %w", err)
+	}
+
+	title := req.Title
+	if title == "" {
+		title = source.Title
+	}
+
+	sourceID := source.ID
+	template := &model.AssignmentTemplate{
+		OwnerID:            teacherID,
+		Title:              title,
+		Description:        req.Description,
+		SourceAssignmentID: &sourceID,
+		Content:            string(content),
+	}
+	for _, attachment := range source.Attachments {
+		template.Attachments = append(template.Attachments, model.TemplateAttachment{
+			FileName:     attachment.FileName,
+			OriginalName: attachment.OriginalName,
+			FilePath:     attachment.FilePath,
+			FileSize:     attachment.FileSize,
+			ContentType:  attachment.ContentType,
+			ContentHash:  attachment.ContentHash,
+		})
+	}
+
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		return nil, fmt.Errorf("save assignment template failed: %w", err)
+	}
+	return template, nil
+}
+
+// GetTeacherTemplates 获取教师的模板列表
+func (s *templateService) GetTeacherTemplates(ctx context.Context, teacherID uint, page, pageSize int) ([]*model.TemplateListResponse, int64, error) {
+	offset := (page - 1) * pageSize
+	templates, total, err := s.templateRepo.GetByOwnerID(ctx, teacherID, offset, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get teacher templates failed: %w", err)
+	}
+
+	list := make([]*model.TemplateListResponse, 0, len(templates))
+	for _, template := range templates {
+		item := &model.TemplateListResponse{
+			ID:              template.ID,
+			Title:           template.Title,
+			Description:     template.Description,
+			AttachmentCount: len(template.Attachments),
+			CreatedAt:       template.CreatedAt,
+		}
+		if content, err := template.GetContent(); err == nil {
+			item.TotalScore = content.TotalScore
+			item.QuestionCount = len(content.Questions)
+		}
+		list = append(list, item)
+	}
+	return list, total, nil
+}
+
+// GetTemplateDetail 获取模板详情，只有创建者和管理员可以查看
+func (s *templateService) GetTemplateDetail(ctx context.Context, id uint, userID uint) (*model.TemplateDetailResponse, error) {
+	template, err := s.getOwnedTemplate(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := template.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("parse template content failed: %w", err)
+	}
+	return &model.TemplateDetailResponse{AssignmentTemplate: template, Content: content}, nil
+}
+
+// DeleteTemplate 删除模板，附件文件没有其他引用时一并删除
+func (s *templateService) DeleteTemplate(ctx context.Context, id uint, userID uint) error {
+	template, err := s.getOwnedTemplate(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.templateRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete assignment template failed: %w", err)
+	}
+	for _, attachment := range template.Attachments {
+		removeUnreferencedFile(ctx, s.attachmentRepo, attachment.FilePath)
+	}
+	return nil
+}
+
+// InstantiateTemplate 由模板在指定班级中创建作业
+func (s *templateService) InstantiateTemplate(ctx context.Context, id uint, req *model.CloneAssignmentRequest, teacherID uint) (*model.Assignment, error) {
+	template, err := s.getOwnedTemplate(ctx, id, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.CheckClassManage(ctx, req.ClassID, teacherID); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := template.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("parse template content failed: %w", err)
+	}
+	return s.createAssignment(ctx, snapshot, template.Attachments, req, teacherID)
+}
+
+// createAssignment 按快照在目标班级中创建作业，题目和附件随作业一起写入
+func (s *templateService) createAssignment(ctx context.Context, snapshot *model.AssignmentSnapshot, files []model.TemplateAttachment, req *model.CloneAssignmentRequest, teacherID uint) (*model.Assignment, error) {
+	assignment, err := snapshot.NewAssignment(req.ClassID, teacherID, req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("build assignment failed: %w", err)
+	}
+	if req.Title != "" {
+		assignment.Title = req.Title
+	}
+	if err := checkSchedule(assignment); err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		assignment.Attachments = append(assignment.Attachments, model.Attachment{
+			UploaderID:   teacherID,
+			FileName:     file.FileName,
+			OriginalName: file.OriginalName,
+			FilePath:     file.FilePath,
+			FileSize:     file.FileSize,
+			ContentType:  file.ContentType,
+			ContentHash:  file.ContentHash,
+		})
+	}
+
+	if err := s.assignmentRepo.Create(ctx, assignment); err != nil {
+		return nil, fmt.Errorf("create assignment failed: %w", err)
+	}
+	return assignment, nil
+}
+
+// getOwnedTemplate 获取模板并校验是否为创建者或管理员
+func (s *templateService) getOwnedTemplate(ctx context.Context, id uint, userID uint) (*model.AssignmentTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+	if template.OwnerID != userID && !s.access.IsAdmin(ctx, userID) {
+		return nil, ErrAccessDenied
+	}
+	return template, nil
+}
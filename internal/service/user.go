@@ -44,6 +44,7 @@ type UserResponse struct {
 	ID        uint   `json:"id"`
 	StudentID string `json:"student_id"`
 	Name      string `json:"name"`
+	RoleID    string `json:"role_id"`
 }
 
 // LoginResponse 登录响应对象
@@ -111,6 +112,7 @@ func (s *userService) Register(ctx context.Context, dto *CreateUserDTO) error {
 	user := &model.User{
 		Code:     dto.StudentID,
 		Name:     dto.Name,
+		RoleId:   model.RoleStudent, // 新注册用户默认为学生，由管理员分配其他角色
 		Password: string(hashedPassword),
 	}
 
@@ -141,7 +143,7 @@ func (s *userService) Login(ctx context.Context, dto *LoginUserDTO) (*LoginRespo
 
 	// 构造响应
 	return &LoginResponse{
-		User:  s.toUserResponse(user),
+		User:  toUserResponse(user),
 		Token: token,
 	}, nil
 }
@@ -191,7 +193,7 @@ func (s *userService) Get(ctx context.Context, id uint) (*UserResponse, error) {
 	if user == nil {
 		return nil, ErrUserNotFound
 	}
-	return s.toUserResponse(user), nil
+	return toUserResponse(user), nil
 }
 
 // GetByStudentID 根据学号获取用户信息
@@ -203,7 +205,7 @@ func (s *userService) GetByStudentID(ctx context.Context, studentID string) (*Us
 	if user == nil {
 		return nil, ErrUserNotFound
 	}
-	return s.toUserResponse(user), nil
+	return toUserResponse(user), nil
 }
 
 // List 获取用户列表
@@ -219,7 +221,7 @@ func (s *userService) List(ctx context.Context) (*UserListResponse, error) {
 	}
 
 	for i, user := range users {
-		response.List[i] = *s.toUserResponse(user)
+		response.List[i] = *toUserResponse(user)
 	}
 
 	return response, nil
@@ -257,10 +259,11 @@ func (s *userService) validateUpdateDTO(dto *UpdateUserDTO) error {
 }
 
 // toUserResponse 将用户实体转换为响应对象
-func (s *userService) toUserResponse(user *model.User) *UserResponse {
+func toUserResponse(user *model.User) *UserResponse {
 	return &UserResponse{
 		ID:        user.ID,
 		StudentID: user.Code,
 		Name:      user.Name,
+		RoleID:    user.RoleId,
 	}
 }
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// inviteCodeAlphabet 邀请码字符集（去掉了易混淆的 0/O、1/I/L）
+const inviteCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// GenerateInviteCode 生成指定长度的随机邀请码
+func GenerateInviteCode(length int) (string, error) {
+	code := make([]byte, length)
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -32,8 +32,22 @@ func InitializeApplication() (*app.Application, error) {
 		repository.NewSubmissionRepository,
 		repository.NewAnswerRepository,
 		repository.NewAttachmentRepository,
+		repository.NewEnrollmentRepository,
+		repository.NewRoleRepository,
+		repository.NewLessonPlanRepository,
+		repository.NewQuestionBankRepository,
+		repository.NewRegradeRepository,
+		repository.NewGradeEventRepository,
+		repository.NewExtensionRepository,
+		repository.NewSchedulerLockRepository,
+		repository.NewTemplateRepository,
+		repository.NewGradebookRepository,
 
 		// Service 层
+		service.NewAccessService,
+		service.NewAIGrader,
+		service.NewCodeRunner,
+		service.NewQuestionGenerator,
 		service.NewUserService,
 		service.NewClassService,
 		service.NewAssignmentService,
@@ -41,6 +55,17 @@ func InitializeApplication() (*app.Application, error) {
 		service.NewSubmissionService,
 		service.NewGradingService,
 		service.NewAttachmentService,
+		service.NewEnrollmentService,
+		service.NewRoleService,
+		service.NewLessonPlanService,
+		service.NewQuestionBankService,
+		service.NewRegradeService,
+		service.NewExtensionService,
+		service.NewSchedulerService,
+		service.NewTemplateService,
+		service.NewGradebookService,
+		service.NewExportService,
+		service.NewRosterService,
 
 		// Gin 引擎
 		app.NewGinEngine,
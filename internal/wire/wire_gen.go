@@ -27,17 +27,42 @@ func InitializeApplication() (*app.Application, error) {
 	userService := service.NewUserService(userRepository)
 	classRepository := repository.NewClassRepository(repositoryDB, cache)
 	classService := service.NewClassService(classRepository)
+	enrollmentRepository := repository.NewEnrollmentRepository(repositoryDB, cache)
 	assignmentRepository := repository.NewAssignmentRepository(repositoryDB, cache)
-	questionRepository := repository.NewQuestionRepository(repositoryDB, cache)
-	assignmentService := service.NewAssignmentService(assignmentRepository, questionRepository, classRepository)
-	questionService := service.NewQuestionService(questionRepository, assignmentRepository)
 	submissionRepository := repository.NewSubmissionRepository(repositoryDB, cache)
-	answerRepository := repository.NewAnswerRepository(repositoryDB, cache)
-	submissionService := service.NewSubmissionService(submissionRepository, answerRepository, assignmentRepository, questionRepository, questionService)
-	gradingService := service.NewGradingService(submissionRepository, answerRepository, assignmentRepository, questionRepository)
 	attachmentRepository := repository.NewAttachmentRepository(repositoryDB, cache)
-	attachmentService := service.NewAttachmentService(attachmentRepository, assignmentRepository)
-	application := app.NewApplication(engine, configConfig, repositoryDB, userService, classService, assignmentService, questionService, submissionService, gradingService, attachmentService)
+	lessonPlanRepository := repository.NewLessonPlanRepository(repositoryDB, cache)
+	accessService := service.NewAccessService(userRepository, classRepository, enrollmentRepository, assignmentRepository, submissionRepository, attachmentRepository, lessonPlanRepository)
+	questionRepository := repository.NewQuestionRepository(repositoryDB, cache)
+	aiGrader := service.NewAIGrader(configConfig)
+	codeRunner := service.NewCodeRunner(configConfig)
+	assignmentService := service.NewAssignmentService(assignmentRepository, questionRepository, classRepository, submissionRepository, accessService)
+	questionGenerator := service.NewQuestionGenerator(configConfig)
+	questionService := service.NewQuestionService(questionRepository, assignmentRepository, attachmentRepository, accessService, questionGenerator)
+	answerRepository := repository.NewAnswerRepository(repositoryDB, cache)
+	extensionRepository := repository.NewExtensionRepository(repositoryDB, cache)
+	submissionService := service.NewSubmissionService(submissionRepository, answerRepository, assignmentRepository, questionRepository, extensionRepository, questionService, accessService, codeRunner)
+	gradeEventRepository := repository.NewGradeEventRepository(repositoryDB, cache)
+		attachmentService := service.NewAttachmentService(attachmentRepository, assignmentRepository, accessService)
+	enrollmentService := service.NewEnrollmentService(enrollmentRepository, classRepository, userRepository, accessService)
+	roleRepository := repository.NewRoleRepository(db)
+	roleService := service.NewRoleService(roleRepository, userRepository)
+	lessonPlanService := service.NewLessonPlanService(lessonPlanRepository, classRepository, enrollmentRepository, assignmentRepository, attachmentRepository, accessService)
+	questionBankRepository := repository.NewQuestionBankRepository(repositoryDB, cache)
+	questionBankService := service.NewQuestionBankService(questionBankRepository, questionRepository, assignmentRepository, accessService)
+	regradeRepository := repository.NewRegradeRepository(repositoryDB, cache)
+	regradeService := service.NewRegradeService(regradeRepository, submissionRepository, answerRepository, assignmentRepository, questionRepository, accessService, configConfig)
+	extensionService := service.NewExtensionService(extensionRepository, assignmentRepository, enrollmentRepository, accessService)
+	schedulerLockRepository := repository.NewSchedulerLockRepository(repositoryDB, cache)
+	gradingService := service.NewGradingService(submissionRepository, answerRepository, assignmentRepository, questionRepository, gradeEventRepository, enrollmentRepository, schedulerLockRepository, accessService, aiGrader)
+	schedulerService := service.NewSchedulerService(assignmentRepository, schedulerLockRepository, submissionService)
+	templateRepository := repository.NewTemplateRepository(repositoryDB, cache)
+	templateService := service.NewTemplateService(assignmentRepository, templateRepository, attachmentRepository, accessService)
+	gradebookRepository := repository.NewGradebookRepository(repositoryDB, cache)
+	gradebookService := service.NewGradebookService(gradebookRepository, assignmentRepository, enrollmentRepository, accessService)
+	exportService := service.NewExportService(gradebookRepository, assignmentRepository, questionRepository, classRepository, enrollmentRepository, accessService)
+	rosterService := service.NewRosterService(userRepository, roleRepository, classRepository, enrollmentRepository)
+	application := app.NewApplication(engine, configConfig, repositoryDB, userService, classService, assignmentService, questionService, submissionService, gradingService, attachmentService, enrollmentService, roleService, accessService, lessonPlanService, questionBankService, regradeService, extensionService, templateService, gradebookService, exportService, rosterService, schedulerService)
 	return application, nil
 }
 